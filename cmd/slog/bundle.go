@@ -0,0 +1,166 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"github.com/dinhtatuanlinh/source_logging/slogging/parse"
+	"github.com/dinhtatuanlinh/source_logging/slogging/redact"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// bundleManifest describes a bundle for whoever receives it.
+type bundleManifest struct {
+	RequestID     string    `json:"request_id,omitempty"`
+	Where         []string  `json:"where,omitempty"`
+	RedactProfile string    `json:"redact_profile"`
+	CreatedAt     time.Time `json:"created_at"`
+	Events        int       `json:"events"`
+	Services      []string  `json:"services"`
+	First         time.Time `json:"first,omitzero"`
+	Last          time.Time `json:"last,omitzero"`
+}
+
+func runBundle(args []string) error {
+	fs := flag.NewFlagSet("bundle", flag.ContinueOnError)
+	requestID := fs.String("request-id", "", "collect events with this request ID")
+	profile := fs.String("redact-profile", "external", "redaction profile: "+strings.Join(redact.Names(), ", "))
+	out := fs.String("out", "", "zip file to write (default bundle-<request-id>.zip)")
+	keyring := fs.String("keyring", "", "keyring file for pseudonyms that stay stable across bundles (default: a random key per bundle)")
+	var where multiFlag
+	fs.Var(&where, "where", "additional field predicate, as for query (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	p, ok := redact.Profiles[*profile]
+	if !ok {
+		return fmt.Errorf("--redact-profile must be one of %s", strings.Join(redact.Names(), ", "))
+	}
+	if *requestID == "" && len(where) == 0 {
+		return fmt.Errorf("--request-id or --where is required")
+	}
+	var preds []parse.Predicate
+	for _, w := range where {
+		pr, err := parse.ParsePredicate(w)
+		if err != nil {
+			return err
+		}
+		preds = append(preds, pr)
+	}
+	if *out == "" {
+		*out = "bundle-" + firstNonEmptyArg(*requestID, "events") + ".zip"
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	files, err := parse.Discover(paths...)
+	if err != nil {
+		return err
+	}
+
+	var events []slogging.Entry
+	err = parse.ForEach(files, func(_ string, ev slogging.Entry, _ []byte) bool {
+		if *requestID != "" && ev.Str("request_id") != *requestID && ev.Str(slogging.XRequestID) != *requestID {
+			return true
+		}
+		for _, pr := range preds {
+			if !pr.Match(ev) {
+				return true
+			}
+		}
+		events = append(events, ev)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(events, func(i, j int) bool { return parse.EventTime(events[i]).Before(parse.EventTime(events[j])) })
+
+	m := bundleManifest{RequestID: *requestID, Where: where, RedactProfile: p.Name, CreatedAt: time.Now().UTC(), Events: len(events)}
+	services := map[string]bool{}
+	r := redact.New(p)
+	if *keyring != "" {
+		kr, err := slogging.LoadKeyring(*keyring)
+		if err != nil {
+			return err
+		}
+		if r, err = redact.NewKeyed(p, kr); err != nil {
+			return err
+		}
+	}
+	for _, ev := range events {
+		if s := ev.Str("service"); s != "" {
+			services[s] = true
+		}
+		if ts := parse.EventTime(ev); !ts.IsZero() {
+			if m.First.IsZero() {
+				m.First = ts
+			}
+			m.Last = ts
+		}
+		r.Event(ev)
+	}
+	for s := range services {
+		m.Services = append(m.Services, s)
+	}
+	sort.Strings(m.Services)
+	if err := writeBundle(*out, m, events); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "wrote %d events to %s (profile %s)\n", len(events), *out, p.Name)
+	return nil
+}
+
+func writeBundle(path string, m bundleManifest, events []slogging.Entry) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(f)
+	err = func() error {
+		w, err := zw.Create("manifest.json")
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(m); err != nil {
+			return err
+		}
+		w, err = zw.Create("events.jsonl")
+		if err != nil {
+			return err
+		}
+		enc = json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		for _, ev := range events {
+			if err := enc.Encode(ev); err != nil {
+				return err
+			}
+		}
+		return zw.Close()
+	}()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
+
+func firstNonEmptyArg(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"github.com/dinhtatuanlinh/source_logging/slogging/parse"
+	"os"
+	"strings"
+)
+
+func runDecrypt(args []string) error {
+	fs := flag.NewFlagSet("decrypt", flag.ContinueOnError)
+	var keyPaths multiFlag
+	fs.Var(&keyPaths, "key", "file holding a hex X25519 private key, as [key-id=]path (repeatable, one per rotated key)")
+	generate := fs.Bool("generate", false, "write a new private key to --key and print its public key")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if len(keyPaths) == 0 {
+		return fmt.Errorf("--key is required")
+	}
+	if *generate {
+		if len(keyPaths) != 1 {
+			return fmt.Errorf("--generate takes a single --key")
+		}
+		_, path := splitKeyFlag(keyPaths[0])
+		return generateKey(path)
+	}
+	keys := slogging.DecryptionKeys{}
+	for _, kp := range keyPaths {
+		kid, path := splitKeyFlag(kp)
+		priv, err := readPrivateKey(path)
+		if err != nil {
+			return err
+		}
+		keys[kid] = priv
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	files, err := parse.Discover(paths...)
+	if err != nil {
+		return err
+	}
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	failed := 0
+	err = parse.ForEach(files, func(_ string, _ slogging.Entry, line []byte) bool {
+		if !bytes.Contains(line, []byte(slogging.EncryptedPrefix)) {
+			out.Write(line)
+			out.WriteByte('\n')
+			return true
+		}
+		var ev map[string]json.RawMessage
+		if json.Unmarshal(line, &ev) != nil {
+			out.Write(line)
+			out.WriteByte('\n')
+			return true
+		}
+		for k, v := range ev {
+			var s string
+			if json.Unmarshal(v, &s) != nil || !strings.HasPrefix(s, slogging.EncryptedPrefix) {
+				continue
+			}
+			plain, err := keys.Decrypt(k, s)
+			if err != nil {
+				failed++
+				continue
+			}
+			ev[k] = plain
+		}
+		b, _ := json.Marshal(ev)
+		out.Write(b)
+		out.WriteByte('\n')
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d values could not be decrypted with the given keys", failed)
+	}
+	return nil
+}
+
+// splitKeyFlag splits a --key value into its key ID (empty for a bare path)
+// and path.
+func splitKeyFlag(v string) (kid, path string) {
+	if kid, path, ok := strings.Cut(v, "="); ok {
+		return kid, path
+	}
+	return "", v
+}
+
+func readPrivateKey(path string) (*ecdh.PrivateKey, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := hex.DecodeString(strings.TrimSpace(string(b)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	priv, err := ecdh.X25519().NewPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return priv, nil
+}
+
+// generateKey writes a new private key to path (refusing to overwrite) and
+// prints the public key to configure as Options.EncryptionKey.
+func generateKey(path string) error {
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(f, hex.EncodeToString(priv.Bytes())); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Println(hex.EncodeToString(priv.PublicKey().Bytes()))
+	return nil
+}
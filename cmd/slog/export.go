@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/dinhtatuanlinh/source_logging/slogging/export"
+	"github.com/dinhtatuanlinh/source_logging/slogging/parse"
+	"os"
+)
+
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", "parquet", "output format (parquet)")
+	out := fs.String("out", "", "output file (required)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "parquet" {
+		return fmt.Errorf("unsupported format %q", *format)
+	}
+	if *out == "" {
+		return fmt.Errorf("--out is required")
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	files, err := parse.Discover(paths...)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	n, err := export.Parquet(f, files)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(*out)
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "wrote %d rows from %d files to %s\n", n, len(files), *out)
+	return nil
+}
@@ -0,0 +1,66 @@
+// Command slog works with slogging output on local disk, as a stopgap when the
+// central log store is behind or down.
+//
+//	slog query --since 1h --where status=500 --where tenant=acme [paths...]
+//	slog export --format parquet --out logs.parquet [paths...]
+//	slog trail --operator alice --from 2024-05-01 --to 2024-06-01 [paths...]
+//	slog import --parser nginx --out loki://loki:3100 access.log...
+//	slog merge --tolerance 2s api=logs/api/ worker=logs/worker/
+//	slog bundle --request-id 7f3a --redact-profile external [paths...]
+//	slog decrypt --key 2024=old.key --key 2025=compliance.key [paths...]
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{"query", "filter events by time and field predicates", runQuery},
+	{"export", "convert JSON/CBOR log files to Parquet", runExport},
+	{"trail", "report one operator's activity over a time range", runTrail},
+	{"import", "convert legacy plaintext logs into structured events", runImport},
+	{"merge", "interleave several services' logs by timestamp", runMerge},
+	{"bundle", "zip one request's events, redacted for sharing", runBundle},
+	{"decrypt", "restore fields encrypted with Options.EncryptFields", runDecrypt},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			if err := c.run(os.Args[2:]); err != nil {
+				fmt.Fprintln(os.Stderr, "slog "+c.name+":", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	var b strings.Builder
+	b.WriteString("usage: slog <command> [flags]\n\ncommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(&b, "  %-8s %s\n", c.name, c.usage)
+	}
+	fmt.Fprint(os.Stderr, b.String())
+}
+
+// multiFlag collects a repeatable string flag.
+type multiFlag []string
+
+func (m *multiFlag) String() string     { return strings.Join(*m, ",") }
+func (m *multiFlag) Set(v string) error { *m = append(*m, v); return nil }
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"github.com/dinhtatuanlinh/source_logging/slogging/parse"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func runMerge(args []string) error {
+	fs := flag.NewFlagSet("merge", flag.ContinueOnError)
+	by := fs.String("by", "time", "timestamp field (ts is accepted for time)")
+	tolerance := fs.Duration("tolerance", 0, "clock drift to absorb between sources (e.g. 2s)")
+	tag := fs.String("tag", "source", "field added to each event naming its source")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("usage: slog merge [flags] [name=]path...")
+	}
+	if *by == "ts" {
+		*by = "time"
+	}
+
+	var sources []parse.Source
+	for _, arg := range fs.Args() {
+		name, path, ok := strings.Cut(arg, "=")
+		if !ok {
+			path, name = arg, strings.TrimSuffix(filepath.Base(arg), filepath.Ext(arg))
+		}
+		files, err := parse.Discover(path)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, parse.Source{Name: name, Files: files})
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	key, _ := json.Marshal(*tag)
+	return parse.Merge(sources, parse.MergeOptions{TimeField: *by, Tolerance: *tolerance},
+		func(source string, _ slogging.Entry, line []byte) bool {
+			// Append the tag to the raw line so the event's field order is kept.
+			val, _ := json.Marshal(source)
+			line = bytes.TrimSuffix(bytes.TrimSpace(line), []byte("}"))
+			out.Write(line)
+			if len(line) > 1 {
+				out.WriteByte(',')
+			}
+			out.Write(key)
+			out.WriteByte(':')
+			out.Write(val)
+			out.WriteString("}\n")
+			return true
+		})
+}
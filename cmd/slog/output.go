@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/dinhtatuanlinh/source_logging/slogging/parse"
+	"github.com/dinhtatuanlinh/source_logging/slogging/sink"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// openOutput resolves an --out destination:
+//
+//	-, stdout                   standard output
+//	path, file:///path          a local file (truncated)
+//	http(s)://host/path         POST newline-delimited JSON batches
+//	loki://host:3100            Loki push API (lokis:// for https)
+//
+// Network outputs go through a sink.Batcher with retries; Close flushes them.
+func openOutput(spec, service string) (io.WriteCloser, error) {
+	if spec == "" || spec == "-" || spec == "stdout" {
+		return &bufCloser{bufio.NewWriter(os.Stdout), nil}, nil
+	}
+	u, err := url.Parse(spec)
+	if err != nil || u.Scheme == "" {
+		return createFile(spec)
+	}
+	switch u.Scheme {
+	case "file":
+		return createFile(u.Path)
+	case "http", "https":
+		return newBatcher(ndjsonSender(spec)), nil
+	case "loki", "lokis":
+		scheme := "http"
+		if u.Scheme == "lokis" {
+			scheme = "https"
+		}
+		path := u.Path
+		if path == "" || path == "/" {
+			path = "/loki/api/v1/push"
+		}
+		return newBatcher(lokiSender(scheme+"://"+u.Host+path, service)), nil
+	}
+	return nil, fmt.Errorf("unsupported output %q", spec)
+}
+
+func createFile(path string) (io.WriteCloser, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &bufCloser{bufio.NewWriter(f), f}, nil
+}
+
+type bufCloser struct {
+	*bufio.Writer
+	c io.Closer
+}
+
+func (b *bufCloser) Close() error {
+	err := b.Flush()
+	if b.c != nil {
+		if cerr := b.c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// batchCloser surfaces delivery failures from a Batcher at Close.
+type batchCloser struct {
+	*sink.Batcher
+	failed error
+}
+
+func newBatcher(s sink.Sender) *batchCloser {
+	bc := &batchCloser{}
+	bc.Batcher = sink.NewBatcher(s, sink.Options{
+		QueueSize: 100000, // imports write much faster than a live service
+		Attempts:  5,
+		OnError:   func(err error) { bc.failed = err },
+	})
+	return bc
+}
+
+// Write blocks while the queue is full instead of dropping: an import has no
+// latency budget to protect.
+func (b *batchCloser) Write(p []byte) (int, error) {
+	for b.Stats().Queued >= 100000*9/10 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	return b.Batcher.Write(p)
+}
+
+func (b *batchCloser) Close() error {
+	b.Batcher.Close()
+	if st := b.Stats(); st.Dropped > 0 {
+		return fmt.Errorf("%d events not delivered: %v", st.Dropped, b.failed)
+	}
+	return nil
+}
+
+func ndjsonSender(endpoint string) sink.Sender {
+	return sink.SenderFunc(func(ctx context.Context, batch [][]byte) error {
+		return post(ctx, endpoint, "application/x-ndjson", bytes.Join(batch, nil))
+	})
+}
+
+// lokiSender pushes batches as Loki streams labelled by service and level.
+func lokiSender(endpoint, service string) sink.Sender {
+	type stream struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	}
+	return sink.SenderFunc(func(ctx context.Context, batch [][]byte) error {
+		byLevel := map[string]*stream{}
+		var order []string
+		for _, line := range batch {
+			line = bytes.TrimRight(line, "\n")
+			var ev map[string]any
+			if json.Unmarshal(line, &ev) != nil {
+				continue
+			}
+			lvl, _ := ev["level"].(string)
+			ts := parse.EventTime(ev)
+			if ts.IsZero() {
+				ts = time.Now()
+			}
+			s, ok := byLevel[lvl]
+			if !ok {
+				s = &stream{Stream: map[string]string{"service": service, "level": lvl}}
+				byLevel[lvl] = s
+				order = append(order, lvl)
+			}
+			s.Values = append(s.Values, [2]string{strconv.FormatInt(ts.UnixNano(), 10), string(line)})
+		}
+		var body struct {
+			Streams []*stream `json:"streams"`
+		}
+		for _, l := range order {
+			body.Streams = append(body.Streams, byLevel[l])
+		}
+		b, err := json.Marshal(body)
+		if err != nil {
+			return sink.Permanent(err)
+		}
+		return post(ctx, endpoint, "application/json", b)
+	})
+}
+
+func post(ctx context.Context, endpoint, contentType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return sink.Permanent(err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	case resp.StatusCode >= 400:
+		return sink.Permanent(fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg))))
+	}
+	return nil
+}
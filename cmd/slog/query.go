@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"github.com/dinhtatuanlinh/source_logging/slogging/parse"
+	"os"
+	"time"
+)
+
+func runQuery(args []string) error {
+	fs := flag.NewFlagSet("query", flag.ContinueOnError)
+	since := fs.Duration("since", 0, "only events newer than this (e.g. 1h)")
+	limit := fs.Int("limit", 0, "print at most N events (0 = all)")
+	var where multiFlag
+	fs.Var(&where, "where", "field predicate: k=v, k!=v, k>v, k>=v, k<v, k<=v, k~substr (repeatable, ANDed)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	preds := make([]parse.Predicate, 0, len(where))
+	var indexed []string
+	for _, w := range where {
+		p, err := parse.ParsePredicate(w)
+		if err != nil {
+			return err
+		}
+		preds = append(preds, p)
+		if p.Op == parse.OpEq {
+			indexed = append(indexed, p.Field)
+		}
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	files, err := parse.Discover(paths...)
+	if err != nil {
+		return err
+	}
+
+	var keep func(slogging.Entry) bool
+	if *since > 0 {
+		cutoff := time.Now().Add(-*since)
+		files = modifiedSince(files, cutoff)
+		keep = parse.Since(cutoff)
+	}
+	ix, err := parse.BuildIndex(files, indexed, keep)
+	if err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	for i, line := range ix.Query(preds) {
+		if *limit > 0 && i >= *limit {
+			break
+		}
+		out.Write(line)
+		out.WriteByte('\n')
+	}
+	return nil
+}
+
+// modifiedSince drops files last written before cutoff; they cannot hold newer events.
+func modifiedSince(files []string, cutoff time.Time) []string {
+	var out []string
+	for _, f := range files {
+		if fi, err := os.Stat(f); err == nil && fi.ModTime().Before(cutoff) {
+			continue
+		}
+		out = append(out, f)
+	}
+	return out
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/dinhtatuanlinh/source_logging/slogging/parse"
+	"os"
+	"time"
+)
+
+func runTrail(args []string) error {
+	fs := flag.NewFlagSet("trail", flag.ContinueOnError)
+	operator := fs.String("operator", "", "operator ID (required)")
+	from := fs.String("from", "", "start of range, RFC3339 or YYYY-MM-DD (inclusive)")
+	to := fs.String("to", "", "end of range, RFC3339 or YYYY-MM-DD (exclusive)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *operator == "" {
+		return fmt.Errorf("--operator is required")
+	}
+	start, err := parseBound(*from)
+	if err != nil {
+		return fmt.Errorf("--from: %w", err)
+	}
+	end, err := parseBound(*to)
+	if err != nil {
+		return fmt.Errorf("--to: %w", err)
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	files, err := parse.Discover(paths...)
+	if err != nil {
+		return err
+	}
+	if !start.IsZero() {
+		files = modifiedSince(files, start)
+	}
+	t, err := parse.BuildTrail(files, *operator, start, end)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(t)
+}
+
+// parseBound accepts RFC3339 or a bare date (midnight UTC); empty is open-ended.
+func parseBound(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, s)
+}
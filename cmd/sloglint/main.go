@@ -0,0 +1,13 @@
+// Command sloglint runs slogging's analyzers: non-canonical field keys
+// (sloglint) and zerolog events that are never sent (slogunterminated).
+//
+//	go run github.com/dinhtatuanlinh/source_logging/cmd/sloglint ./...
+//	go vet -vettool=$(which sloglint) ./...
+package main
+
+import (
+	"github.com/dinhtatuanlinh/source_logging/slogging/sloglint"
+	"golang.org/x/tools/go/analysis/multichecker"
+)
+
+func main() { multichecker.Main(sloglint.Analyzer, sloglint.Unterminated) }
@@ -0,0 +1,82 @@
+package slogging
+
+import (
+	"github.com/rs/zerolog"
+	"sync/atomic"
+	"time"
+)
+
+// adaptiveWindow is how often the adaptive sampler re-derives its rate.
+const adaptiveWindow = time.Second
+
+// adaptiveSampler keeps info and lower events within Options.SampleBudget
+// events per second. Each window it sets a 1-in-N rate from the volume seen
+// in the previous one, tightening at once on a spike and loosening at most
+// by half per window once it is quiet again. Within a window, events beyond
+// the budget are dropped too, so a spike is capped before the rate catches up.
+// Warn and above, and NoLevel events, are never sampled.
+type adaptiveSampler struct {
+	budget int64
+
+	start atomic.Int64  // current window start, unix nanos
+	seen  atomic.Int64  // sampleable events offered in the current window
+	kept  atomic.Int64  // events let through in the current window
+	rate  atomic.Int64  // current N
+	count atomic.Uint64 // events offered, for the 1-in-N choice
+}
+
+func newAdaptiveSampler(budget int) *adaptiveSampler {
+	s := &adaptiveSampler{budget: int64(budget)}
+	s.start.Store(time.Now().UnixNano())
+	s.rate.Store(1)
+	return s
+}
+
+// Sample implements zerolog.Sampler.
+func (s *adaptiveSampler) Sample(lvl zerolog.Level) bool {
+	if lvl > zerolog.InfoLevel {
+		return true
+	}
+	s.roll(time.Now().UnixNano())
+	s.seen.Add(1)
+	if n := uint64(s.rate.Load()); n > 1 && s.count.Add(1)%n != 0 {
+		return false
+	}
+	return s.kept.Add(1) <= s.budget
+}
+
+// roll starts a new window when the current one has ended and derives the
+// rate for it. Only the caller that wins the swap recomputes.
+func (s *adaptiveSampler) roll(now int64) {
+	start := s.start.Load()
+	if now-start < int64(adaptiveWindow) || !s.start.CompareAndSwap(start, now) {
+		return
+	}
+	seen := s.seen.Swap(0)
+	s.kept.Store(0)
+	// A window several seconds long (the process was idle) is averaged.
+	if secs := (now - start) / int64(adaptiveWindow); secs > 1 {
+		seen /= secs
+	}
+	next := max(1, (seen+s.budget-1)/s.budget)
+	if cur := s.rate.Load(); next < cur {
+		next = max(next, cur/2)
+	}
+	s.rate.Store(next)
+}
+
+// currentRate is the N events are sampled 1 in.
+func (s *adaptiveSampler) currentRate() int {
+	return int(s.rate.Load())
+}
+
+// adaptiveRateHook stamps the adaptive sampler's current rate as sample_rate
+// on the events it applies to.
+type adaptiveRateHook struct{ s *adaptiveSampler }
+
+// Run implements zerolog.Hook.
+func (h adaptiveRateHook) Run(e *zerolog.Event, level zerolog.Level, _ string) {
+	if level != zerolog.NoLevel && level <= zerolog.InfoLevel && !unsampled(e) {
+		e.Int("sample_rate", h.s.currentRate())
+	}
+}
@@ -0,0 +1,50 @@
+package slogging
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAdaptiveSampling(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc", SampleBudget: 10})
+	a := current.Load().adaptive
+	ctx := context.Background()
+	// nextWindow ends the sampler's current window early.
+	nextWindow := func() { a.start.Add(-int64(adaptiveWindow)) }
+	burst := func(msg string, n int) []map[string]any {
+		for range n {
+			From(ctx).Info().Msg(msg)
+		}
+		return c.withMessage(t, msg)
+	}
+
+	// The first spike is capped at the budget within its window; warnings
+	// are never sampled.
+	first := burst("first", 100)
+	for range 5 {
+		From(ctx).Warn().Msg("warn")
+	}
+	if len(first) != 10 || first[0]["sample_rate"] != 1.0 {
+		t.Errorf("first window kept %d events at rate %v, want 10 at 1", len(first), first[0]["sample_rate"])
+	}
+	if evs := c.withMessage(t, "warn"); len(evs) != 5 || evs[0]["sample_rate"] != nil {
+		t.Errorf("warnings = %v, want all 5 without sample_rate", evs)
+	}
+
+	// The next window samples 1 in 10 from the start.
+	nextWindow()
+	second := burst("second", 100)
+	if len(second) != 10 || second[0]["sample_rate"] != 10.0 || GetStats().SampleRate != 10 {
+		t.Errorf("second window kept %d events at rate %v (stats %d), want 10 at 10",
+			len(second), second[0]["sample_rate"], GetStats().SampleRate)
+	}
+
+	// Quiet windows loosen the rate by at most half each.
+	for _, want := range []int{10, 5, 2, 1} {
+		nextWindow()
+		From(ctx).Info().Msg("quiet")
+		if got := GetStats().SampleRate; got != want {
+			t.Errorf("SampleRate = %d, want %d", got, want)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package slogging
+
+import (
+	"github.com/rs/zerolog"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultBufferSize         = 1024
+	defaultDropReportInterval = time.Minute
+)
+
+// Drop policies for the normal lane of async sinks (Options.DropPolicy). The
+// priority lane (warn and above) always blocks.
+const (
+	DropNewest = "drop-newest" // default: discard the event being written
+	DropOldest = "drop-oldest" // discard the oldest queued event to make room
+	DropBlock  = "block"       // wait for room, like the priority lane
+)
+
+// asyncWriter moves sink writes off the logging path onto a background goroutine.
+// It has two lanes: the priority lane (warn and above, plus anything whose level is
+// unknown) blocks the caller when full and is never dropped; the normal lane
+// (trace/debug/info) is subject to backpressure and, when its buffer is full,
+// follows the drop policy. The worker always drains the priority lane first.
+type asyncWriter struct {
+	name     string
+	w        io.Writer
+	normal   chan asyncEntry
+	priority chan asyncEntry
+	policy   string
+
+	pending  atomic.Int64 // queued plus in-flight events
+	peak     atomic.Int64 // highest pending seen
+	dropped  atomic.Uint64
+	reported uint64 // dropped count at the last drop report; owned by the reporter
+	high     int64  // OnHighWatermark threshold
+	onHigh   func(SinkStats)
+	armed    atomic.Bool
+	counters *selfStats
+
+	mu     sync.RWMutex // held for reading by senders, for writing by Close
+	closed bool
+	done   chan struct{}
+}
+
+type asyncEntry struct {
+	level zerolog.Level
+	p     []byte
+}
+
+func newAsyncWriter(name string, w io.Writer, opt Options, st *selfStats) *asyncWriter {
+	size := opt.BufferSize
+	if size <= 0 {
+		size = defaultBufferSize
+	}
+	mark := opt.HighWatermark
+	if mark <= 0 || mark > 1 {
+		mark = 0.8
+	}
+	a := &asyncWriter{
+		name:     name,
+		w:        w,
+		normal:   make(chan asyncEntry, size),
+		priority: make(chan asyncEntry, size),
+		high:     max(1, int64(float64(size)*mark)),
+		onHigh:   opt.OnHighWatermark,
+		policy:   opt.DropPolicy,
+		done:     make(chan struct{}),
+		counters: st,
+	}
+	a.armed.Store(true)
+	go a.run()
+	return a
+}
+
+// isPriority reports whether events at level must never be dropped by
+// backpressure. NoLevel covers writers that don't pass the level (pretty output).
+func isPriority(level zerolog.Level) bool {
+	return level >= zerolog.WarnLevel
+}
+
+func (a *asyncWriter) Write(p []byte) (int, error) {
+	return a.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (a *asyncWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	e := asyncEntry{level: level, p: append([]byte(nil), p...)}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		a.drop()
+		return len(p), nil
+	}
+	if isPriority(level) {
+		a.enqueued()
+		a.priority <- e
+		return len(p), nil
+	}
+	switch a.policy {
+	case DropBlock:
+		a.enqueued()
+		a.normal <- e
+	case DropOldest:
+		for {
+			select {
+			case a.normal <- e:
+				a.enqueued()
+				return len(p), nil
+			default:
+			}
+			select {
+			case <-a.normal: // the worker may have taken it first; then just retry
+				a.pending.Add(-1)
+				a.drop()
+			default:
+			}
+		}
+	default:
+		select {
+		case a.normal <- e:
+			a.enqueued()
+		default:
+			a.drop()
+		}
+	}
+	return len(p), nil
+}
+
+func (a *asyncWriter) drop() {
+	a.dropped.Add(1)
+	a.counters.dropped.Add(1)
+}
+
+// enqueued accounts for a new event and fires the high-watermark callback
+// on the rising edge.
+func (a *asyncWriter) enqueued() {
+	n := a.pending.Add(1)
+	for {
+		peak := a.peak.Load()
+		if n <= peak || a.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	if a.onHigh != nil && n >= a.high && a.armed.CompareAndSwap(true, false) {
+		go a.onHigh(a.stats())
+	}
+}
+
+// stats snapshots the queue for GetSinkStats and watermark callbacks.
+func (a *asyncWriter) stats() SinkStats {
+	return SinkStats{
+		Name:       a.name,
+		QueueDepth: int(a.pending.Load()),
+		Capacity:   cap(a.normal),
+		Peak:       int(a.peak.Load()),
+		Dropped:    a.dropped.Load(),
+	}
+}
+
+func (a *asyncWriter) run() {
+	defer close(a.done)
+	for {
+		// Priority first, so errors are not stuck behind a backlog of info lines.
+		select {
+		case e, ok := <-a.priority:
+			if !ok {
+				a.drain()
+				return
+			}
+			a.write(e)
+			continue
+		default:
+		}
+		select {
+		case e, ok := <-a.priority:
+			if !ok {
+				a.drain()
+				return
+			}
+			a.write(e)
+		case e := <-a.normal:
+			a.write(e)
+		}
+	}
+}
+
+// drain flushes whatever is left in the normal lane after Close.
+func (a *asyncWriter) drain() {
+	for {
+		select {
+		case e := <-a.normal:
+			a.write(e)
+		default:
+			return
+		}
+	}
+}
+
+func (a *asyncWriter) write(e asyncEntry) {
+	if _, err := writeLevel(a.w, e.level, e.p); err != nil {
+		a.counters.writeErrors.Add(1)
+	}
+	if a.pending.Add(-1) <= a.high/2 {
+		a.armed.Store(true)
+	}
+}
+
+// Close stops accepting events, flushes both lanes and waits for the worker.
+// It does not close the wrapped sink.
+func (a *asyncWriter) Close() error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.priority)
+	}
+	a.mu.Unlock()
+	<-a.done
+	return nil
+}
+
+// dropReporter periodically emits one "events_dropped" warning per async sink
+// that dropped events since the previous report. Drops are otherwise only
+// visible through GetSinkStats.
+type dropReporter struct {
+	p    *pipeline
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+func startDropReporter(p *pipeline, every time.Duration) *dropReporter {
+	if every <= 0 {
+		every = defaultDropReportInterval
+	}
+	r := &dropReporter{p: p, stop: make(chan struct{}), done: make(chan struct{})}
+	go r.run(every)
+	return r
+}
+
+func (r *dropReporter) run(every time.Duration) {
+	defer close(r.done)
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-r.stop:
+			r.report(every)
+			return
+		case <-t.C:
+			r.report(every)
+		}
+	}
+}
+
+func (r *dropReporter) report(window time.Duration) {
+	for _, q := range r.p.asyncQueues() {
+		total := q.dropped.Load()
+		n := total - q.reported
+		if n == 0 {
+			continue
+		}
+		q.reported = total
+		selfEventOn(&r.p.self, zerolog.WarnLevel, "events_dropped").
+			Str("sink", q.name).
+			Str("policy", q.policyName()).
+			Uint64("dropped", n).
+			Uint64("dropped_total", total).
+			Dur("window", window).
+			Msgf("async sink %s dropped %d events", q.name, n)
+	}
+}
+
+// Close emits a final report and stops the reporter. It must run before the
+// async queues close, so the report itself still gets written.
+func (r *dropReporter) Close() error {
+	r.once.Do(func() { close(r.stop) })
+	<-r.done
+	return nil
+}
+
+func (a *asyncWriter) policyName() string {
+	if a.policy == "" {
+		return DropNewest
+	}
+	return a.policy
+}
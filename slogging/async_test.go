@@ -0,0 +1,151 @@
+package slogging
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// gatedWriter holds every write until open is closed, then passes it on.
+type gatedWriter struct {
+	w    *eventCapture
+	open chan struct{}
+}
+
+func (g gatedWriter) Write(p []byte) (int, error) {
+	<-g.open
+	return g.w.Write(p)
+}
+
+// initGated installs an async pipeline from opt whose extra sink is stuck
+// until the returned func is called, and returns what reaches it.
+func initGated(t *testing.T, opt Options) (*eventCapture, func()) {
+	t.Helper()
+	c := &eventCapture{}
+	g := gatedWriter{w: c, open: make(chan struct{})}
+	opt.FilePath = filepath.Join(t.TempDir(), "test.log")
+	opt.ExtraWriter = g
+	opt.Async = true
+	Init(opt)
+	t.Cleanup(func() { Close(context.Background()) })
+	return c, func() { close(g.open) }
+}
+
+// extraStats returns the extra sink's queue stats.
+func extraStats(t *testing.T) SinkStats {
+	t.Helper()
+	for _, s := range GetSinkStats() {
+		if s.Name == "extra" {
+			return s
+		}
+	}
+	t.Fatal("no extra sink stats")
+	return SinkStats{}
+}
+
+func TestAsyncNeverDropsWarnings(t *testing.T) {
+	c, open := initGated(t, Options{BufferSize: 4})
+	ctx := context.Background()
+	for range 20 {
+		From(ctx).Info().Msg("info")
+	}
+	for range 4 {
+		From(ctx).Error().Msg("error")
+	}
+	dropped := extraStats(t).Dropped
+	if dropped == 0 {
+		t.Fatal("no info event dropped with the sink stuck")
+	}
+	open()
+	Close(ctx)
+
+	if n := len(c.withMessage(t, "error")); n != 4 {
+		t.Errorf("got %d errors, want all 4", n)
+	}
+	if n := len(c.withMessage(t, "info")); n+int(dropped) != 20 {
+		t.Errorf("got %d infos and %d dropped, want 20 in all", n, dropped)
+	}
+}
+
+func TestAsyncHighWatermark(t *testing.T) {
+	marks := make(chan SinkStats, 16)
+	_, open := initGated(t, Options{
+		BufferSize:    10,
+		HighWatermark: 0.5,
+		OnHighWatermark: func(s SinkStats) {
+			if s.Name == "extra" {
+				marks <- s
+			}
+		},
+	})
+	for range 8 {
+		From(context.Background()).Info().Msg("info")
+	}
+	// Fired once, on the rising edge at 5 of 10.
+	s := <-marks
+	if s.QueueDepth < 5 || s.Capacity != 10 {
+		t.Errorf("watermark stats = %+v, want depth of at least 5 of 10", s)
+	}
+	if s := extraStats(t); s.QueueDepth != 8 || s.Peak != 8 || s.Dropped != 0 {
+		t.Errorf("sink stats = %+v, want 8 queued, peak 8, none dropped", s)
+	}
+	open()
+	Close(context.Background())
+	if n := len(marks); n != 0 {
+		t.Errorf("watermark fired %d more times", n)
+	}
+}
+
+func TestAsyncDropPolicies(t *testing.T) {
+	for _, tc := range []struct {
+		policy string
+		want   func(kept []int) bool
+	}{
+		// The first events: the buffer, and the one the worker may have taken.
+		{DropNewest, func(kept []int) bool { return slices.Equal(kept[:3], []int{0, 1, 2}) && kept[len(kept)-1] < 9 }},
+		// The last events, after the one the worker may have taken.
+		{DropOldest, func(kept []int) bool { return slices.Equal(kept[len(kept)-3:], []int{7, 8, 9}) }},
+	} {
+		t.Run(tc.policy, func(t *testing.T) {
+			c, open := initGated(t, Options{BufferSize: 3, DropPolicy: tc.policy})
+			for i := range 10 {
+				From(context.Background()).Info().Int("n", i).Msg("info")
+			}
+			dropped := extraStats(t).Dropped
+			open()
+			Close(context.Background())
+
+			var kept []int
+			for _, ev := range c.withMessage(t, "info") {
+				kept = append(kept, int(ev["n"].(float64)))
+			}
+			if len(kept)+int(dropped) != 10 || len(kept) < 3 || len(kept) > 4 || !tc.want(kept) {
+				t.Errorf("kept %v with %d dropped", kept, dropped)
+			}
+		})
+	}
+
+	t.Run(DropBlock, func(t *testing.T) {
+		c, open := initGated(t, Options{BufferSize: 3, DropPolicy: DropBlock})
+		done := make(chan struct{})
+		go func() {
+			for i := range 10 {
+				From(context.Background()).Info().Int("n", i).Msg("info")
+			}
+			close(done)
+		}()
+		select {
+		case <-done:
+			t.Fatal("logging did not block on a full buffer")
+		case <-time.After(50 * time.Millisecond):
+		}
+		open()
+		<-done
+		Close(context.Background())
+		if n := len(c.withMessage(t, "info")); n != 10 {
+			t.Errorf("got %d events, want all 10", n)
+		}
+	})
+}
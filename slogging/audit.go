@@ -0,0 +1,201 @@
+package slogging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog"
+	"io"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrAuditNotConfigured is returned by AuditEvent.Write when Options.Audit
+	// names no destination.
+	ErrAuditNotConfigured = errors.New("slogging: audit log not configured")
+	// ErrAuditIncomplete is returned, wrapped with the missing names, when an
+	// audit event lacks a mandatory field.
+	ErrAuditIncomplete = errors.New("slogging: audit event incomplete")
+	// ErrAuditUnavailable is returned, wrapping the cause, when the audit
+	// file or Writer failed to take a record.
+	ErrAuditUnavailable = errors.New("slogging: audit log unavailable")
+)
+
+// AuditOptions configures the audit log (Options.Audit). It is written apart
+// from the application log: its own file and rotation, no sampling, no level
+// filtering, and no fallback to stdout when the file is unavailable.
+type AuditOptions struct {
+	FilePath   string
+	MaxSizeMB  int
+	MaxBackups int
+	MaxAgeDays int
+	Compress   bool
+	// Writer receives audit events too, or alone when FilePath is empty
+	// (e.g. a forwarder to the compliance store). It is not closed.
+	Writer io.Writer
+}
+
+func (a AuditOptions) enabled() bool { return a.FilePath != "" || a.Writer != nil }
+
+// auditLog is the pipeline's audit destination. Records are written
+// synchronously, one at a time, so Write can return what the destinations
+// reported for its record.
+type auditLog struct {
+	logger zerolog.Logger
+	file   *fileSink // nil without FilePath
+	extra  io.Writer // AuditOptions.Writer
+
+	mu  sync.Mutex // held while a record is written
+	err error      // the first destination error of that record
+}
+
+// Write implements io.Writer for a.logger. It returns no error to zerolog,
+// which could only print it; AuditEvent.Write returns a.err instead.
+func (a *auditLog) Write(p []byte) (int, error) {
+	a.err = nil
+	if a.file != nil {
+		a.err = a.file.writeStrict(p)
+	}
+	if a.extra != nil {
+		if _, err := a.extra.Write(p); err != nil && a.err == nil {
+			a.err = err
+		}
+	}
+	return len(p), nil
+}
+
+// newAuditLog builds the audit destination from opt.Audit, reusing the main
+// file options (compression format, retry interval) that Audit doesn't set.
+func newAuditLog(p *pipeline, opt Options) *auditLog {
+	a := &auditLog{extra: opt.Audit.Writer}
+	if opt.Audit.FilePath != "" {
+		fopt := opt
+		fopt.MaxSizeMB = opt.Audit.MaxSizeMB
+		fopt.MaxBackups = opt.Audit.MaxBackups
+		fopt.MaxAgeDays = opt.Audit.MaxAgeDays
+		fopt.Compress = opt.Audit.Compress
+		fopt.DailyDirs = false
+		a.file = newRotatingFile(opt.Audit.FilePath, fopt, nil, p.stats)
+		p.closers = append(p.closers, a.file)
+		p.onInstall = append(p.onInstall, a.file.start)
+	}
+	c := zerolog.New(a).With().Timestamp().
+		Str(FieldService, opt.Service).
+		Str(FieldEnv, opt.Environment).
+		Int(FieldSchemaVersion, SchemaVersion)
+	if opt.Version != "" {
+		c = c.Str(FieldVersion, opt.Version)
+	}
+	a.logger = c.Logger()
+	return a
+}
+
+// AuditEvent is one audit record under construction; see Audit.
+type AuditEvent struct {
+	ctx    context.Context
+	action string
+	target string
+	before any
+	after  any
+	kv     []any
+}
+
+// Audit starts an audit record for the request in ctx:
+//
+//	err := slogging.Audit(ctx).Action("user.delete").Target(id).Before(old).After(nil).Write()
+//
+// Write checks that ctx carries the operator (WithOperatorName), role
+// (WithRole) and ip_address (WithIPAddress) and that Action is set, and
+// rejects the record otherwise. Records are written at info level whatever
+// the configured Level, are never sampled, and go only to Options.Audit.
+func Audit(ctx context.Context) *AuditEvent {
+	return &AuditEvent{ctx: ctx}
+}
+
+// Action names what was done, e.g. "user.delete". Mandatory.
+func (a *AuditEvent) Action(action string) *AuditEvent { a.action = action; return a }
+
+// Target identifies what it was done to.
+func (a *AuditEvent) Target(target string) *AuditEvent { a.target = target; return a }
+
+// Before records the target's state before the action.
+func (a *AuditEvent) Before(v any) *AuditEvent { a.before = v; return a }
+
+// After records the target's state after the action.
+func (a *AuditEvent) After(v any) *AuditEvent { a.after = v; return a }
+
+// Fields adds key/value pairs, as With does.
+func (a *AuditEvent) Fields(kv ...any) *AuditEvent { a.kv = append(a.kv, kv...); return a }
+
+// Write validates and writes the record, returning once the audit file and
+// Writer have taken it, or ErrAuditUnavailable with the cause when either
+// failed. A rejected record is also reported on the application log as an
+// "audit_rejected" error.
+func (a *AuditEvent) Write() error {
+	p := current.Load()
+	if p == nil || p.audit == nil {
+		return ErrAuditNotConfigured
+	}
+	operator := GetOperatorID(a.ctx)
+	role := GetRole(a.ctx)
+	ip := GetIPAddress(a.ctx)
+	var missing []string
+	if a.action == "" {
+		missing = append(missing, FieldAction)
+	}
+	if operator == "" {
+		missing = append(missing, FieldOperatorName)
+	}
+	if role == nil || role == "" {
+		missing = append(missing, FieldRole)
+	}
+	if ip == "" {
+		missing = append(missing, FieldIPAddress)
+	}
+	if len(missing) > 0 {
+		err := fmt.Errorf("%w: missing %s", ErrAuditIncomplete, strings.Join(missing, ", "))
+		selfEventOn(&p.self, zerolog.ErrorLevel, "audit_rejected").
+			Str(FieldAction, a.action).
+			Strs("missing", missing).
+			Msg("audit event rejected")
+		return err
+	}
+
+	// NoLevel gets past the global level and lands in async priority lanes;
+	// the level field is written by hand.
+	e := p.audit.logger.WithLevel(zerolog.NoLevel).
+		Str(zerolog.LevelFieldName, zerolog.InfoLevel.String()).
+		Str(FieldAction, a.action).
+		Str(FieldOperatorName, operator).
+		Interface(FieldRole, role).
+		Str(FieldIPAddress, ip)
+	if a.target != "" {
+		e = e.Str(FieldTarget, a.target)
+	}
+	if c := CorrelationFrom(a.ctx); !c.IsZero() {
+		if c.RequestID != "" {
+			e = e.Str(FieldRequestID, c.RequestID)
+		}
+		if c.TraceID != "" {
+			e = e.Str(FieldTraceID, c.TraceID)
+		}
+	}
+	if a.before != nil {
+		e = e.Interface(FieldBefore, a.before)
+	}
+	if a.after != nil {
+		e = e.Interface(FieldAfter, a.after)
+	}
+	if len(a.kv) > 0 {
+		e = e.Fields(a.kv)
+	}
+	p.audit.mu.Lock()
+	e.Msg("audit")
+	err := p.audit.err
+	p.audit.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrAuditUnavailable, err)
+	}
+	return nil
+}
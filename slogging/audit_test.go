@@ -0,0 +1,93 @@
+package slogging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func auditCtx() context.Context {
+	ctx := WithOperatorName(context.Background(), "alice")
+	ctx = WithRole(ctx, "admin")
+	ctx = WithIPAddress(ctx, "10.0.0.1")
+	return WithRequestID(ctx, "r1")
+}
+
+func TestAuditWrite(t *testing.T) {
+	var out bytes.Buffer
+	initCapture(t, Options{Service: "svc", Level: "error", Audit: AuditOptions{Writer: &out}})
+
+	err := Audit(auditCtx()).Action("user.delete").Target("u42").Before(map[string]any{"name": "bob"}).Write()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ev map[string]any
+	if err := json.Unmarshal(out.Bytes(), &ev); err != nil {
+		t.Fatalf("audit record %q: %v", out.Bytes(), err)
+	}
+	for k, want := range map[string]any{
+		"level": "info", FieldAction: "user.delete", FieldTarget: "u42", FieldOperatorName: "alice",
+		FieldRole: "admin", FieldIPAddress: "10.0.0.1", FieldRequestID: "r1", FieldService: "svc",
+	} {
+		if ev[k] != want {
+			t.Errorf("%s = %v, want %v", k, ev[k], want)
+		}
+	}
+}
+
+func TestAuditRejectsIncomplete(t *testing.T) {
+	var out bytes.Buffer
+	c := initCapture(t, Options{Service: "svc", Audit: AuditOptions{Writer: &out}})
+	err := Audit(WithOperatorName(context.Background(), "alice")).Action("user.delete").Write()
+	if !errors.Is(err, ErrAuditIncomplete) {
+		t.Fatalf("err = %v, want ErrAuditIncomplete", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("rejected record was written: %s", out.Bytes())
+	}
+	var rejected int
+	for _, ev := range c.events(t) {
+		if ev[FieldSloggingEvent] == "audit_rejected" {
+			rejected++
+		}
+	}
+	if rejected != 1 {
+		t.Errorf("got %d audit_rejected events, want 1", rejected)
+	}
+}
+
+type failingWriter struct{ err error }
+
+func (w failingWriter) Write([]byte) (int, error) { return 0, w.err }
+
+func TestAuditReportsWriterError(t *testing.T) {
+	boom := errors.New("forwarder down")
+	initCapture(t, Options{Service: "svc", Audit: AuditOptions{Writer: failingWriter{boom}}})
+	err := Audit(auditCtx()).Action("user.delete").Write()
+	if !errors.Is(err, ErrAuditUnavailable) || !errors.Is(err, boom) {
+		t.Errorf("err = %v, want ErrAuditUnavailable wrapping %v", err, boom)
+	}
+}
+
+func TestAuditReportsFileError(t *testing.T) {
+	dir := t.TempDir()
+	blocker := filepath.Join(dir, "not-a-dir")
+	if err := os.WriteFile(blocker, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	initCapture(t, Options{Service: "svc", Audit: AuditOptions{FilePath: filepath.Join(blocker, "audit.log")}})
+	if err := Audit(auditCtx()).Action("user.delete").Write(); !errors.Is(err, ErrAuditUnavailable) {
+		t.Errorf("err = %v, want ErrAuditUnavailable", err)
+	}
+}
+
+func TestAuditNotConfigured(t *testing.T) {
+	initCapture(t, Options{Service: "svc"})
+	if err := Audit(auditCtx()).Action("user.delete").Write(); !errors.Is(err, ErrAuditNotConfigured) {
+		t.Errorf("err = %v, want ErrAuditNotConfigured", err)
+	}
+}
@@ -0,0 +1,57 @@
+package slogging
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+	"runtime"
+	"sync"
+)
+
+// bareSites remembers call sites already warned about, so a hot path warns
+// once rather than per request.
+var bareSites sync.Map // pc -> struct{}
+
+// checkBareContext emits a "bare_context" warning when Options.WarnBareContext
+// is on and ctx carries no correlation at all: no logger stored by IntoContext
+// or the With* helpers, and none of the IDs they set. skip is the number of
+// frames between the caller of From/New and this function; p is the pipeline
+// they log to.
+func checkBareContext(p *pipeline, ctx context.Context, skip int) {
+	if p == nil || !p.warnBare {
+		return
+	}
+	if ctx != nil && (ctxLogger(ctx) != nil || !CorrelationFrom(ctx).IsZero() ||
+		GetAPIID(ctx) != "" || GetOperatorID(ctx) != "" || hasLegacyIDs(ctx) || hasSpan(p, ctx)) {
+		return
+	}
+	pc, file, line, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return
+	}
+	if _, seen := bareSites.LoadOrStore(pc, struct{}{}); seen {
+		return
+	}
+	fn := "unknown"
+	if f := runtime.FuncForPC(pc); f != nil {
+		fn = f.Name()
+	}
+	selfEventOn(&p.self, zerolog.WarnLevel, "bare_context").
+		Str("call_site", fn+" "+file+":"+itoa(line)).
+		Msg("logger taken from a context without request correlation; is the middleware or a With* helper missing?")
+}
+
+// hasSpan reports whether OTELCorrelation will take IDs from an active span.
+func hasSpan(p *pipeline, ctx context.Context) bool {
+	_, _, ok := spanIDs(p, ctx)
+	return ok
+}
+
+// hasLegacyIDs reports whether the headers New reads were set on ctx.
+func hasLegacyIDs(ctx context.Context) bool {
+	for _, k := range []string{XRequestID, APIID, XOperator} {
+		if s, _ := ctx.Value(k).(string); s != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package slogging
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func logFrom(ctx context.Context) { From(ctx).Info().Msg("from") }
+func logNew(ctx context.Context)  { New(ctx).Info().Msg("new") }
+
+func TestWarnBareContext(t *testing.T) {
+	bareSites.Clear()
+	c := initCapture(t, Options{WarnBareContext: true})
+	bare := context.Background()
+	logFrom(WithRequestID(bare, "r1"))
+	logFrom(context.WithValue(bare, XRequestID, "legacy"))
+	if evs := c.selfEvents(t, "bare_context"); len(evs) != 0 {
+		t.Fatalf("warned about correlated contexts: %v", evs)
+	}
+
+	for range 3 {
+		logFrom(bare)
+		logNew(bare)
+	}
+	evs := c.selfEvents(t, "bare_context")
+	if len(evs) != 2 {
+		t.Fatalf("got %d warnings, want one per call site: %v", len(evs), evs)
+	}
+	for i, fn := range []string{"slogging.logFrom ", "slogging.logNew "} {
+		if site, _ := evs[i]["call_site"].(string); !strings.Contains(site, fn) || !strings.Contains(site, "barectx_test.go:") {
+			t.Errorf("call_site = %q, want %s in barectx_test.go", site, fn)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package slogging
+
+import (
+	"github.com/rs/zerolog"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+const (
+	defaultErrorBurstWindow = time.Minute
+	snapshotCooldown        = 5 * time.Minute
+)
+
+// burstHook counts error-level events and, when ErrorBurstThreshold of them
+// land within one window, emits a single "runtime_snapshot" event describing
+// the process, at most once per snapshotCooldown.
+type burstHook struct {
+	log       *zerolog.Logger
+	threshold int
+	window    time.Duration
+
+	mu    sync.Mutex
+	start time.Time // current window
+	n     int
+	last  time.Time // last snapshot
+}
+
+func newBurstHook(log *zerolog.Logger, threshold int, window time.Duration) *burstHook {
+	if window <= 0 {
+		window = defaultErrorBurstWindow
+	}
+	return &burstHook{log: log, threshold: threshold, window: window}
+}
+
+// Run implements zerolog.Hook.
+func (h *burstHook) Run(_ *zerolog.Event, level zerolog.Level, _ string) {
+	if level < zerolog.ErrorLevel || level == zerolog.NoLevel {
+		return
+	}
+	now := time.Now()
+	h.mu.Lock()
+	if now.Sub(h.start) >= h.window {
+		h.start, h.n = now, 0
+	}
+	h.n++
+	fire := h.n == h.threshold && now.Sub(h.last) >= snapshotCooldown
+	if fire {
+		h.last = now
+	}
+	n := h.n
+	h.mu.Unlock()
+	if fire {
+		// Not inline: the triggering event is still being built, and
+		// ReadMemStats briefly stops the world.
+		go h.snapshot(n)
+	}
+}
+
+func (h *burstHook) snapshot(errors int) {
+	e := selfEventOn(h.log, zerolog.WarnLevel, "runtime_snapshot").
+		Int("errors_in_window", errors).
+		Dur("window", h.window)
+	runtimeFields(e).Msg("error burst: runtime snapshot")
+}
+
+// runtimeFields adds a point-in-time view of the Go runtime to e.
+func runtimeFields(e *zerolog.Event) *zerolog.Event {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	e = e.Int("goroutines", runtime.NumGoroutine()).
+		Uint64("heap_alloc", ms.HeapAlloc).
+		Uint64("heap_inuse", ms.HeapInuse).
+		Uint64("heap_sys", ms.HeapSys).
+		Uint64("heap_objects", ms.HeapObjects).
+		Uint32("num_gc", ms.NumGC).
+		Dur("gc_pause_total", time.Duration(ms.PauseTotalNs)).
+		Float64("gc_cpu_fraction", ms.GCCPUFraction)
+	if ms.NumGC > 0 {
+		e = e.Dur("gc_pause_last", time.Duration(ms.PauseNs[(ms.NumGC+255)%256]))
+	}
+	if n, ok := openFDs(); ok {
+		e = e.Int("open_fds", n)
+	}
+	return e
+}
+
+// openFDs counts this process's open file descriptors where /proc or /dev/fd
+// exposes them.
+func openFDs() (int, bool) {
+	for _, dir := range []string{"/proc/self/fd", "/dev/fd"} {
+		if ents, err := os.ReadDir(dir); err == nil {
+			return len(ents) - 1, true // minus the descriptor ReadDir itself used
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,47 @@
+package slogging
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// selfEvents returns the captured events whose slogging_event is name.
+func (c *eventCapture) selfEvents(t testing.TB, name string) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for _, ev := range c.events(t) {
+		if ev[FieldSloggingEvent] == name {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+func TestErrorBurstSnapshot(t *testing.T) {
+	c := initCapture(t, Options{ErrorBurstThreshold: 3, ErrorBurstWindow: time.Hour})
+	l := From(context.Background())
+	l.Error().Msg("e1")
+	l.Error().Msg("e2")
+	l.Warn().Msg("not counted")
+	time.Sleep(20 * time.Millisecond)
+	if evs := c.selfEvents(t, "runtime_snapshot"); len(evs) != 0 {
+		t.Fatalf("snapshot below the threshold: %v", evs)
+	}
+
+	l.Error().Msg("e3")
+	eventually(t, "runtime_snapshot", func() bool { return len(c.selfEvents(t, "runtime_snapshot")) == 1 })
+	ev := c.selfEvents(t, "runtime_snapshot")[0]
+	if ev["errors_in_window"] != float64(3) || ev["goroutines"] == nil || ev["heap_alloc"] == nil {
+		t.Errorf("snapshot = %v, want the error count and runtime stats", ev)
+	}
+
+	// Another burst inside the cooldown does not snapshot again.
+	for range 6 {
+		l.Error().Msg("more")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := len(c.selfEvents(t, "runtime_snapshot")); n != 1 {
+		t.Errorf("got %d snapshots, want 1 within the cooldown", n)
+	}
+}
@@ -0,0 +1,141 @@
+package slogging
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/rs/zerolog"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultClockSkewInterval = 5 * time.Minute
+	clockSkewWarn            = time.Second // |offset| that gets a warn event
+	ntpTimeout               = 5 * time.Second
+	ntpEpochOffset           = 2208988800 // seconds from 1900-01-01 to 1970-01-01
+)
+
+// clockSkew periodically measures the local clock's offset from an NTP server
+// (Options.ClockSkewServer) with a single SNTP exchange. Every event then
+// carries the latest offset as clock_skew_ms, positive when the local clock is
+// behind, so cross-host timelines can be corrected downstream.
+type clockSkew struct {
+	server string
+	every  time.Duration
+	log    *zerolog.Logger
+
+	ms     atomic.Int64
+	known  atomic.Bool
+	failed bool // a query failure was already reported; owned by run
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+func newClockSkew(server string, every time.Duration, log *zerolog.Logger) *clockSkew {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "123")
+	}
+	if every <= 0 {
+		every = defaultClockSkewInterval
+	}
+	return &clockSkew{server: server, every: every, log: log, stop: make(chan struct{}), done: make(chan struct{})}
+}
+
+// start begins measuring; it runs once the pipeline is installed.
+func (c *clockSkew) start() { go c.run() }
+
+func (c *clockSkew) run() {
+	defer close(c.done)
+	t := time.NewTicker(c.every)
+	defer t.Stop()
+	for {
+		c.measure()
+		select {
+		case <-c.stop:
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (c *clockSkew) measure() {
+	offset, err := sntpOffset(c.server)
+	if err != nil {
+		if !c.failed {
+			c.failed = true
+			selfEventOn(c.log, zerolog.WarnLevel, "clock_skew_unavailable").
+				Str("server", c.server).Err(err).
+				Msg("could not query NTP server; clock_skew_ms is not being updated")
+		}
+		return
+	}
+	c.failed = false
+	c.ms.Store(offset.Milliseconds())
+	c.known.Store(true)
+	if offset >= clockSkewWarn || offset <= -clockSkewWarn {
+		// clock_skew_ms itself is added by the hook, now that it is known.
+		selfEventOn(c.log, zerolog.WarnLevel, "clock_skew").
+			Str("server", c.server).
+			Msg("local clock differs from NTP server")
+	}
+}
+
+// Close stops the measurements.
+func (c *clockSkew) Close() error {
+	c.once.Do(func() { close(c.stop) })
+	<-c.done
+	return nil
+}
+
+// Run implements zerolog.Hook.
+func (c *clockSkew) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	if c.known.Load() {
+		e.Int64(FieldClockSkewMs, c.ms.Load())
+	}
+}
+
+// sntpOffset performs one SNTP (RFC 4330) exchange with server and returns
+// the offset to add to the local clock to match it.
+func sntpOffset(server string) (time.Duration, error) {
+	conn, err := net.DialTimeout("udp", server, ntpTimeout)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(ntpTimeout))
+
+	req := make([]byte, 48)
+	req[0] = 0x23 // LI 0, version 4, mode 3 (client)
+	t1 := time.Now()
+	putNTPTime(req[40:], t1) // echoed back as the originate timestamp
+	if _, err := conn.Write(req); err != nil {
+		return 0, err
+	}
+	resp := make([]byte, 48)
+	n, err := conn.Read(resp)
+	t4 := time.Now()
+	if err != nil {
+		return 0, err
+	}
+	if n < 48 || resp[0]&0x07 != 4 || resp[1] == 0 {
+		return 0, errors.New("invalid or unsynchronized NTP response")
+	}
+	t2 := ntpTime(resp[32:])
+	t3 := ntpTime(resp[40:])
+	return (t2.Sub(t1) + t3.Sub(t4)) / 2, nil
+}
+
+func putNTPTime(b []byte, t time.Time) {
+	binary.BigEndian.PutUint32(b, uint32(t.Unix()+ntpEpochOffset))
+	binary.BigEndian.PutUint32(b[4:], uint32((uint64(t.Nanosecond())<<32)/1e9))
+}
+
+func ntpTime(b []byte) time.Time {
+	sec := int64(binary.BigEndian.Uint32(b)) - ntpEpochOffset
+	frac := uint64(binary.BigEndian.Uint32(b[4:]))
+	return time.Unix(sec, int64((frac*1e9)>>32))
+}
@@ -0,0 +1,61 @@
+package slogging
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// fakeNTP answers SNTP requests with a clock ahead of ours by ahead, or as an
+// unsynchronized server (stratum 0) when ahead is zero.
+func fakeNTP(t *testing.T, ahead time.Duration) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 48)
+		for {
+			_, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			resp := make([]byte, 48)
+			resp[0] = 0x24 // version 4, mode 4 (server)
+			if ahead != 0 {
+				resp[1] = 1
+			}
+			now := time.Now().Add(ahead)
+			putNTPTime(resp[32:], now)
+			putNTPTime(resp[40:], now)
+			conn.WriteTo(resp, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestClockSkew(t *testing.T) {
+	c := initCapture(t, Options{ClockSkewServer: fakeNTP(t, 3*time.Second), ClockSkewInterval: time.Hour})
+	eventually(t, "clock_skew", func() bool { return len(c.selfEvents(t, "clock_skew")) == 1 })
+
+	From(context.Background()).Info().Msg("after")
+	evs := append(c.selfEvents(t, "clock_skew"), c.withMessage(t, "after")...)
+	for _, ev := range evs {
+		if ms, _ := ev[FieldClockSkewMs].(float64); ms < 2900 || ms > 3100 {
+			t.Errorf("%s = %v on %q, want about 3000", FieldClockSkewMs, ev[FieldClockSkewMs], ev["message"])
+		}
+	}
+}
+
+func TestClockSkewUnavailable(t *testing.T) {
+	c := initCapture(t, Options{ClockSkewServer: fakeNTP(t, 0), ClockSkewInterval: time.Hour})
+	eventually(t, "clock_skew_unavailable", func() bool { return len(c.selfEvents(t, "clock_skew_unavailable")) == 1 })
+
+	From(context.Background()).Info().Msg("after")
+	if ev := c.withMessage(t, "after"); len(ev) != 1 || ev[0][FieldClockSkewMs] != nil {
+		t.Errorf("events = %v, want no %s while the offset is unknown", ev, FieldClockSkewMs)
+	}
+}
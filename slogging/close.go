@@ -0,0 +1,81 @@
+package slogging
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"os"
+)
+
+// Close shuts the installed pipeline down for process exit: async queues are
+// flushed, reporters emit their final events, the log file is closed and
+// synced to disk, and ExtraWriter is closed if it is an io.Closer. If ctx ends
+// first Close returns ctx.Err() and the shutdown finishes in the background.
+//
+// Afterwards From and friends write plain JSON to stdout until the next Init,
+// so late events are not lost; loggers already stored in contexts keep the
+// closed pipeline's writers. An InitFromFile watcher is stopped.
+func Close(ctx context.Context) error {
+	return std.Close(ctx)
+}
+
+// Close shuts the instance down as the package-level Close does for Default.
+// Afterwards an Instance other than Default discards events until its next
+// Init.
+func (i *Instance) Close(ctx context.Context) error {
+	i.mu.Lock()
+	if i == std {
+		stopConfigWatch()
+	}
+	p := i.current.Swap(nil)
+	if p == nil {
+		i.mu.Unlock()
+		return nil
+	}
+	if i == std {
+		log.Logger = zerolog.New(os.Stdout).With().Timestamp().Logger()
+	}
+	i.mu.Unlock()
+
+	done := make(chan error, 1)
+	go func() { done <- p.shutdown() }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// shutdown is close plus the steps only a final Close takes.
+func (p *pipeline) shutdown() error {
+	first := p.close()
+	if p.file != nil {
+		if err := syncPath(p.file.lj.currentPath()); err != nil && first == nil {
+			first = err
+		}
+	}
+	if p.extra != nil {
+		if err := p.extra.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	if p.crashDir != "" {
+		markCleanExit(p.crashDir)
+	}
+	return first
+}
+
+// syncPath flushes a closed file's data to disk. fsync applies to the file,
+// not the descriptor, so a fresh one will do.
+func syncPath(path string) error {
+	f, err := openShared(path, os.O_RDONLY, 0)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
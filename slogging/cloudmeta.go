@@ -0,0 +1,168 @@
+package slogging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/rs/zerolog"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cloudMetadataTimeout bounds the startup lookup; off-cloud hosts pay at most this.
+const cloudMetadataTimeout = time.Second
+
+// Metadata endpoints, variables so they can be pointed elsewhere in development.
+var (
+	awsMetadataURL   = "http://169.254.169.254/latest"
+	gcpMetadataURL   = "http://metadata.google.internal/computeMetadata/v1/instance/?recursive=true"
+	azureMetadataURL = "http://169.254.169.254/metadata/instance/compute?api-version=2021-02-01"
+)
+
+// cloudInfo is what Options.CloudMetadata stamps on every event.
+type cloudInfo struct {
+	Provider     string
+	Region       string
+	Zone         string
+	InstanceID   string
+	InstanceType string
+}
+
+var (
+	cloudOnce   sync.Once
+	cloudCached *cloudInfo // nil when no provider answered
+)
+
+// lookupCloud queries the AWS, GCP and Azure metadata services concurrently,
+// once per process, and returns the first answer. Re-Init reuses the result,
+// including a negative one.
+func lookupCloud() *cloudInfo {
+	cloudOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cloudMetadataTimeout)
+		defer cancel()
+		// Metadata services are link-local: never go through a proxy.
+		c := &http.Client{Transport: &http.Transport{Proxy: nil}}
+		found := make(chan *cloudInfo, 3)
+		for _, f := range []func(context.Context, *http.Client) (*cloudInfo, error){awsMetadata, gcpMetadata, azureMetadata} {
+			go func() {
+				ci, err := f(ctx, c)
+				if err != nil {
+					ci = nil
+				}
+				found <- ci
+			}()
+		}
+		for range 3 {
+			if ci := <-found; ci != nil {
+				cloudCached = ci
+				return
+			}
+		}
+	})
+	return cloudCached
+}
+
+// fields adds the non-empty values to c.
+func (ci *cloudInfo) fields(c zerolog.Context) zerolog.Context {
+	for _, f := range [...]struct{ k, v string }{
+		{FieldCloud, ci.Provider},
+		{FieldRegion, ci.Region},
+		{FieldZone, ci.Zone},
+		{FieldInstanceID, ci.InstanceID},
+		{FieldInstanceType, ci.InstanceType},
+	} {
+		if f.v != "" {
+			c = c.Str(f.k, f.v)
+		}
+	}
+	return c
+}
+
+func awsMetadata(ctx context.Context, c *http.Client) (*cloudInfo, error) {
+	// IMDSv2: fetch a session token first.
+	token, err := metadataGet(ctx, c, http.MethodPut, awsMetadataURL+"/api/token",
+		map[string]string{"X-aws-ec2-metadata-token-ttl-seconds": "60"})
+	if err != nil {
+		return nil, err
+	}
+	body, err := metadataGet(ctx, c, http.MethodGet, awsMetadataURL+"/dynamic/instance-identity/document",
+		map[string]string{"X-aws-ec2-metadata-token": string(token)})
+	if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Region           string `json:"region"`
+		AvailabilityZone string `json:"availabilityZone"`
+		InstanceID       string `json:"instanceId"`
+		InstanceType     string `json:"instanceType"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	return &cloudInfo{Provider: "aws", Region: doc.Region, Zone: doc.AvailabilityZone, InstanceID: doc.InstanceID, InstanceType: doc.InstanceType}, nil
+}
+
+func gcpMetadata(ctx context.Context, c *http.Client) (*cloudInfo, error) {
+	body, err := metadataGet(ctx, c, http.MethodGet, gcpMetadataURL, map[string]string{"Metadata-Flavor": "Google"})
+	if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		ID          json.Number `json:"id"`
+		Zone        string      `json:"zone"`        // projects/<n>/zones/us-central1-a
+		MachineType string      `json:"machineType"` // projects/<n>/machineTypes/e2-medium
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	zone := lastPathElem(doc.Zone)
+	region := zone
+	if i := strings.LastIndexByte(zone, '-'); i > 0 {
+		region = zone[:i]
+	}
+	return &cloudInfo{Provider: "gcp", Region: region, Zone: zone, InstanceID: doc.ID.String(), InstanceType: lastPathElem(doc.MachineType)}, nil
+}
+
+func azureMetadata(ctx context.Context, c *http.Client) (*cloudInfo, error) {
+	body, err := metadataGet(ctx, c, http.MethodGet, azureMetadataURL, map[string]string{"Metadata": "true"})
+	if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Location string `json:"location"`
+		Zone     string `json:"zone"`
+		VMID     string `json:"vmId"`
+		VMSize   string `json:"vmSize"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	return &cloudInfo{Provider: "azure", Region: doc.Location, Zone: doc.Zone, InstanceID: doc.VMID, InstanceType: doc.VMSize}, nil
+}
+
+// metadataGet performs one metadata request and returns the body of a 200 response.
+func metadataGet(ctx context.Context, c *http.Client, method, url string, header map[string]string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+}
+
+func lastPathElem(s string) string {
+	return s[strings.LastIndexByte(s, '/')+1:]
+}
@@ -0,0 +1,52 @@
+package slogging
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestCloudMetadata(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/gcp" || r.Header.Get("Metadata-Flavor") != "Google" {
+			http.NotFound(w, r) // not AWS or Azure
+			return
+		}
+		w.Write([]byte(`{"id": 4520031799277581759, "zone": "projects/123/zones/europe-west1-b",
+			"machineType": "projects/123/machineTypes/e2-medium"}`))
+	}))
+	defer srv.Close()
+
+	urls := []*string{&awsMetadataURL, &gcpMetadataURL, &azureMetadataURL}
+	saved := []string{awsMetadataURL, gcpMetadataURL, azureMetadataURL}
+	for i, path := range []string{"/aws", "/gcp", "/azure"} {
+		*urls[i] = srv.URL + path
+	}
+	cloudOnce, cloudCached = sync.Once{}, nil
+	t.Cleanup(func() {
+		for i, u := range urls {
+			*u = saved[i]
+		}
+		cloudOnce, cloudCached = sync.Once{}, nil
+	})
+
+	c := initCapture(t, Options{CloudMetadata: true})
+	From(context.Background()).Info().Msg("tagged")
+	evs := c.withMessage(t, "tagged")
+	if len(evs) != 1 {
+		t.Fatalf("got %d events", len(evs))
+	}
+	for k, want := range map[string]string{
+		FieldCloud:        "gcp",
+		FieldRegion:       "europe-west1",
+		FieldZone:         "europe-west1-b",
+		FieldInstanceID:   "4520031799277581759",
+		FieldInstanceType: "e2-medium",
+	} {
+		if evs[0][k] != want {
+			t.Errorf("%s = %v, want %q", k, evs[0][k], want)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package slogging
+
+import (
+	"context"
+	"fmt"
+	"github.com/rs/zerolog"
+	"sync/atomic"
+)
+
+// ComponentLogger is a named logger whose events carry "component" and whose
+// minimum level can differ from the rest of the service. Make one per package
+// and keep it in a package variable; it follows Init and re-Init:
+//
+//	var clog = slogging.Component("repository")
+//
+//	clog.Debug().Str("query", q).Msg("running query")
+//	clog.From(ctx).Warn().Msg("slow query")
+//
+// Its level is Options.ComponentLevels[name], or SetComponentLevel's, and
+// Options.Level (or SetLevel's) when it has none.
+type ComponentLogger struct {
+	name   string
+	inst   *Instance
+	cached atomic.Pointer[componentLogger]
+}
+
+type componentLogger struct {
+	p *pipeline
+	l zerolog.Logger
+}
+
+// Component returns the logger for the named component.
+func Component(name string) *ComponentLogger {
+	return std.Component(name)
+}
+
+// Component returns the instance's logger for the named component.
+func (i *Instance) Component(name string) *ComponentLogger {
+	return &ComponentLogger{name: name, inst: i}
+}
+
+// Name returns the component name.
+func (c *ComponentLogger) Name() string { return c.name }
+
+// Logger returns the component's logger on the installed pipeline.
+func (c *ComponentLogger) Logger() *zerolog.Logger {
+	p := c.inst.current.Load()
+	if cl := c.cached.Load(); cl != nil && cl.p == p {
+		return &cl.l
+	}
+	cl := &componentLogger{p: p}
+	if p == nil {
+		cl.l = c.inst.global().With().Str(FieldComponent, c.name).Logger()
+	} else {
+		cl.l = p.component(context.Background(), c.name, &p.logger)
+	}
+	c.cached.Store(cl)
+	return &cl.l
+}
+
+// From returns From(ctx) as a logger of this component, at its level or the
+// request's (see WithRequestLevel).
+func (c *ComponentLogger) From(ctx context.Context) *zerolog.Logger {
+	l := c.inst.from(ctx, 1)
+	p := c.inst.current.Load()
+	if p == nil {
+		ll := l.With().Str(FieldComponent, c.name).Logger()
+		return &ll
+	}
+	ll := p.component(ctx, c.name, l)
+	return &ll
+}
+
+func (c *ComponentLogger) Debug() *zerolog.Event { return c.Logger().Debug() }
+func (c *ComponentLogger) Info() *zerolog.Event  { return c.Logger().Info() }
+func (c *ComponentLogger) Warn() *zerolog.Event  { return c.Logger().Warn() }
+func (c *ComponentLogger) Error() *zerolog.Event { return c.Logger().Error() }
+
+// ctxComponentKey names, in the context bound to a component's logger, the
+// component it is gated as, for Snapshot.
+const ctxComponentKey ctxKey = "component"
+
+// component derives a logger of the named component from l, which must come
+// from p.logger so it has p's hooks. A request level on ctx takes precedence
+// over the component's.
+func (p *pipeline) component(ctx context.Context, name string, l *zerolog.Logger) zerolog.Logger {
+	ctx = context.WithValue(ctx, ctxComponentKey, name)
+	ll := l.With().Str(FieldComponent, name).Ctx(ctx).Logger()
+	if lvl, ok := requestLevel(ctx); ok {
+		return p.requestLogger(ctx, &ll, lvl)
+	}
+	if t := debugTailOf(ctx); t != nil {
+		return p.tailLogger(ctx, &ll, t, name)
+	}
+	return ll.Sample(levelGate{p: p, component: name, next: p.sampler})
+}
+
+// levelGate enforces the base, component and request levels on every logger a
+// pipeline hands out, so a component or a request can log debug while the rest
+// of the service stays at info, and instances never share a level: zerolog's
+// global level is left alone. It drops what is below the logger's level before
+// the event is built, and wraps the configured sampler, so gated events are not
+// counted as sampled out.
+type levelGate struct {
+	p         *pipeline
+	component string        // "" outside components
+	request   zerolog.Level // WithRequestLevel's with override, WithDebugTail's with tail
+	override  bool
+	tail      bool // below the level, pass request and up to tailWriter
+	next      zerolog.Sampler
+}
+
+func (g levelGate) Sample(lvl zerolog.Level) bool {
+	if g.override {
+		return lvl >= g.request
+	}
+	if lvl < g.p.levelOf(g.component) {
+		return g.tail && lvl >= g.request
+	}
+	return g.next == nil || g.next.Sample(lvl)
+}
+
+// levelOf returns the minimum level of the named component, or the base
+// level for "" and components without one.
+func (p *pipeline) levelOf(component string) zerolog.Level {
+	if component != "" {
+		if lvl, ok := (*p.comps.Load())[component]; ok {
+			return lvl
+		}
+	}
+	return zerolog.Level(p.base.Load())
+}
+
+// setLevels sets the initial levels from Options, reporting invalid
+// ComponentLevels entries once the pipeline is installed.
+func (p *pipeline) setLevels(base zerolog.Level, components map[string]string) {
+	p.base.Store(int32(base))
+	comps := make(map[string]zerolog.Level, len(components))
+	for name, level := range components {
+		lvl, err := parseLevel(level)
+		if err != nil {
+			p.onInstall = append(p.onInstall, func() {
+				selfEventOn(&p.self, zerolog.WarnLevel, "invalid_option").
+					Str("component_name", name).Err(err).
+					Msg("ignoring invalid ComponentLevels entry")
+			})
+			continue
+		}
+		comps[name] = lvl
+	}
+	p.comps.Store(&comps)
+}
+
+// SetComponentLevel changes the minimum level of the named component at
+// runtime; "" removes its override, so it follows SetLevel again. Like
+// SetLevel, the next Init resets it to Options.ComponentLevels.
+func SetComponentLevel(component, level string) error {
+	return std.SetComponentLevel(component, level)
+}
+
+// SetComponentLevel is the package-level SetComponentLevel for the instance's
+// components.
+func (i *Instance) SetComponentLevel(component, level string) error {
+	p := i.current.Load()
+	if p == nil {
+		return fmt.Errorf("slogging: SetComponentLevel(%q) before Init", component)
+	}
+	var lvl zerolog.Level
+	if level != "" {
+		var err error
+		if lvl, err = parseLevel(level); err != nil {
+			return err
+		}
+	}
+	p.levelMu.Lock()
+	defer p.levelMu.Unlock()
+	old, had := (*p.comps.Load())[component]
+	if (level == "" && !had) || (had && level != "" && lvl == old) {
+		return nil
+	}
+	comps := make(map[string]zerolog.Level, len(*p.comps.Load())+1)
+	for k, v := range *p.comps.Load() {
+		comps[k] = v
+	}
+	if level == "" {
+		delete(comps, component)
+		lvl = zerolog.Level(p.base.Load())
+	} else {
+		comps[component] = lvl
+	}
+	if !had {
+		old = zerolog.Level(p.base.Load())
+	}
+	changeLevel(&p.self, "component_level_changed", func() { p.comps.Store(&comps) }).
+		Str("component_name", component).Str("from", old.String()).Str("to", lvl.String()).
+		Msgf("log level of %s changed to %s", component, lvl)
+	return nil
+}
+
+// ComponentLevels returns the running component level overrides.
+func ComponentLevels() map[string]string {
+	return std.ComponentLevels()
+}
+
+// ComponentLevels returns the instance's running component level overrides.
+func (i *Instance) ComponentLevels() map[string]string {
+	p := i.current.Load()
+	if p == nil {
+		return nil
+	}
+	out := make(map[string]string, len(*p.comps.Load()))
+	for k, v := range *p.comps.Load() {
+		out[k] = v.String()
+	}
+	return out
+}
@@ -0,0 +1,66 @@
+package slogging
+
+import (
+	"context"
+	"testing"
+)
+
+func TestComponentLevels(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc", Level: "info", ComponentLevels: map[string]string{"repo": "debug", "http": "error"}})
+	repo, httpc, other := Component("repo"), Component("http"), Component("cache")
+
+	repo.Debug().Msg("repo debug")
+	httpc.Warn().Msg("http warn")
+	httpc.Error().Msg("http error")
+	other.Debug().Msg("cache debug")
+	other.Info().Msg("cache info")
+	From(context.Background()).Debug().Msg("base debug")
+
+	for msg, want := range map[string]int{
+		"repo debug": 1, "http warn": 0, "http error": 1, "cache debug": 0, "cache info": 1, "base debug": 0,
+	} {
+		evs := c.withMessage(t, msg)
+		if len(evs) != want {
+			t.Errorf("%q: %d events, want %d", msg, len(evs), want)
+		}
+		if len(evs) == 1 && msg != "base debug" && evs[0][FieldComponent] == nil {
+			t.Errorf("%q has no component field", msg)
+		}
+	}
+}
+
+func TestSetComponentLevel(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc", Level: "warn"})
+	repo := Component("repo")
+	if err := SetComponentLevel("repo", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	repo.From(context.Background()).Debug().Msg("on")
+	if got := ComponentLevels()["repo"]; got != "debug" {
+		t.Errorf("ComponentLevels()[repo] = %q", got)
+	}
+	if err := SetComponentLevel("repo", ""); err != nil {
+		t.Fatal(err)
+	}
+	repo.Debug().Msg("off")
+	if err := SetComponentLevel("repo", "loud"); err == nil {
+		t.Error("SetComponentLevel accepted an invalid level")
+	}
+
+	if len(c.withMessage(t, "on")) != 1 || len(c.withMessage(t, "off")) != 0 {
+		t.Errorf("events = %v", c.events(t))
+	}
+}
+
+// TestLevelGateKeepsSampling checks that events the gate lets through still
+// go to the configured sampler, and gated ones are not counted by it.
+func TestLevelGateKeepsSampling(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc", Level: "info", SampleEvery: 2, ComponentLevels: map[string]string{"repo": "debug"}})
+	repo := Component("repo")
+	for range 4 {
+		repo.Debug().Msg("sampled")
+	}
+	if n := len(c.withMessage(t, "sampled")); n != 2 {
+		t.Errorf("%d of 4 debug events passed SampleEvery 2, want 2", n)
+	}
+}
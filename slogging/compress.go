@@ -0,0 +1,262 @@
+package slogging
+
+import (
+	"compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/rs/zerolog"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rotated-file compression formats for Options.CompressFormat.
+const (
+	CompressGzip = "gzip"
+	CompressZstd = "zstd"
+)
+
+const (
+	compressScanEvery = 10 * time.Second // at most one backup scan per interval
+	backupTimeLayout  = "2006-01-02T15-04-05.000"
+	throttleChunk     = 64 << 10
+)
+
+// compressor compresses rotated backups of one log file in a background
+// goroutine, optionally throttled to CompressRateMBps so rotation does not
+// spike CPU and disk on small instances. It replaces lumberjack's own gzip.
+// zstd backups are invisible to lumberjack's retention, so MaxBackups and
+// MaxAgeDays are applied to them here.
+type compressor struct {
+	active     func() string // path of the file being written
+	format     string
+	level      int
+	rate       int // bytes per second, 0 = unthrottled
+	maxBackups int
+	maxAge     time.Duration
+	counters   *selfStats
+
+	kickc chan struct{}
+	stop  chan struct{}
+	done  chan struct{}
+	once  sync.Once
+}
+
+func newCompressor(active func() string, opt Options, st *selfStats) *compressor {
+	c := &compressor{
+		active:     active,
+		counters:   st,
+		format:     opt.CompressFormat,
+		level:      opt.CompressLevel,
+		rate:       opt.CompressRateMBps << 20,
+		maxBackups: opt.MaxBackups,
+		maxAge:     time.Duration(opt.MaxAgeDays) * 24 * time.Hour,
+		kickc:      make(chan struct{}, 1),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	if c.format == "" {
+		c.format = CompressGzip
+	}
+	go c.run()
+	c.kick() // pick up backups left by a previous run
+	return c
+}
+
+// kick asks for a backup scan; cheap enough to call on every write.
+func (c *compressor) kick() {
+	select {
+	case c.kickc <- struct{}{}:
+	default:
+	}
+}
+
+func (c *compressor) run() {
+	defer close(c.done)
+	var last time.Time
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-c.kickc:
+		}
+		if wait := compressScanEvery - time.Since(last); wait > 0 {
+			select {
+			case <-c.stop:
+				return
+			case <-time.After(wait):
+			}
+		}
+		last = time.Now()
+		c.scan()
+	}
+}
+
+// scan compresses every uncompressed backup next to the active file, then
+// enforces retention on the backups this compressor owns.
+func (c *compressor) scan() {
+	active := c.active()
+	dir := filepath.Dir(active)
+	base := filepath.Base(active)
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !isBackupName(name, prefix, ext) {
+			continue
+		}
+		select {
+		case <-c.stop:
+			return
+		default:
+		}
+		src := filepath.Join(dir, name)
+		if err := c.compressFile(src); err != nil {
+			c.counters.writeErrors.Add(1)
+			selfEvent(zerolog.WarnLevel, "compress_failed").Str("path", src).Err(err).Msg("failed to compress rotated log")
+		}
+	}
+	if c.format == CompressZstd {
+		c.retain(dir, prefix, ext+".zst")
+	}
+}
+
+func (c *compressor) suffix() string {
+	if c.format == CompressZstd {
+		return ".zst"
+	}
+	return ".gz"
+}
+
+func (c *compressor) compressFile(src string) error {
+	in, err := openShared(src, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	fi, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	dst := src + c.suffix()
+	tmp := dst + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, fi.Mode())
+	if err != nil {
+		return err
+	}
+	enc, err := c.encoder(out)
+	if err == nil {
+		_, err = io.Copy(enc, c.throttle(in))
+		if cerr := enc.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, dst)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(src)
+}
+
+func (c *compressor) encoder(w io.Writer) (io.WriteCloser, error) {
+	if c.format == CompressZstd {
+		lvl := zstd.SpeedDefault
+		if c.level > 0 {
+			lvl = zstd.EncoderLevelFromZstd(c.level)
+		}
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(lvl), zstd.WithEncoderConcurrency(1))
+	}
+	lvl := gzip.DefaultCompression
+	if c.level != 0 {
+		lvl = c.level
+	}
+	return gzip.NewWriterLevel(w, lvl)
+}
+
+// throttle limits reads from r to c.rate bytes per second.
+func (c *compressor) throttle(r io.Reader) io.Reader {
+	if c.rate <= 0 {
+		return r
+	}
+	return &throttledReader{r: r, rate: c.rate, start: time.Now()}
+}
+
+type throttledReader struct {
+	r     io.Reader
+	rate  int
+	start time.Time
+	n     int
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if len(p) > throttleChunk {
+		p = p[:throttleChunk]
+	}
+	n, err := t.r.Read(p)
+	t.n += n
+	if ahead := time.Duration(float64(t.n)/float64(t.rate)*float64(time.Second)) - time.Since(t.start); ahead > 0 {
+		time.Sleep(ahead)
+	}
+	return n, err
+}
+
+// retain deletes the oldest backups with the given suffix beyond MaxBackups
+// and those older than MaxAgeDays.
+func (c *compressor) retain(dir, prefix, suffix string) {
+	if c.maxBackups <= 0 && c.maxAge <= 0 {
+		return
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	var names []string
+	for _, e := range entries {
+		n := e.Name()
+		if strings.HasPrefix(n, prefix) && strings.HasSuffix(n, suffix) {
+			names = append(names, n)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names))) // newest first: names embed the timestamp
+	cutoff := time.Now().Add(-c.maxAge)
+	for i, n := range names {
+		ts, err := time.Parse(backupTimeLayout, strings.TrimSuffix(strings.TrimPrefix(n, prefix), suffix))
+		tooMany := c.maxBackups > 0 && i >= c.maxBackups
+		tooOld := c.maxAge > 0 && err == nil && ts.Before(cutoff)
+		if tooMany || tooOld {
+			os.Remove(filepath.Join(dir, n))
+		}
+	}
+}
+
+// isBackupName matches lumberjack's "<prefix><timestamp><ext>" backup names.
+func isBackupName(name, prefix, ext string) bool {
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+		return false
+	}
+	_, err := time.Parse(backupTimeLayout, strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext))
+	return err == nil
+}
+
+// Close stops the compressor, abandoning (and cleaning up) any file in progress
+// at the next backup boundary.
+func (c *compressor) Close() error {
+	c.once.Do(func() { close(c.stop) })
+	<-c.done
+	return nil
+}
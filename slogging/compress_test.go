@@ -0,0 +1,72 @@
+package slogging
+
+import (
+	"compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCompressorFormats(t *testing.T) {
+	for _, tc := range []struct {
+		format string
+		decode func(io.Reader) (io.Reader, error)
+	}{
+		{CompressGzip, func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{CompressZstd, func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) }},
+	} {
+		t.Run(tc.format, func(t *testing.T) {
+			dir := t.TempDir()
+			active := filepath.Join(dir, "app.log")
+			backups := []string{
+				"app-2024-01-01T00-00-00.000.log",
+				"app-2024-01-02T00-00-00.000.log",
+				"app-2024-01-03T00-00-00.000.log",
+			}
+			for _, name := range append([]string{"app.log", "other.log"}, backups...) {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(name+"\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			c := &compressor{
+				active:     func() string { return active },
+				format:     tc.format,
+				maxBackups: 2,
+				counters:   &selfStats{},
+				stop:       make(chan struct{}),
+			}
+			c.scan()
+
+			entries, _ := os.ReadDir(dir)
+			var got []string
+			for _, e := range entries {
+				got = append(got, e.Name())
+			}
+			suffix := c.suffix()
+			want := []string{backups[1] + suffix, backups[2] + suffix, "app.log", "other.log"}
+			if tc.format == CompressGzip {
+				// lumberjack applies retention to .gz backups itself.
+				want = append([]string{backups[0] + suffix}, want...)
+			}
+			if !slices.Equal(got, want) {
+				t.Fatalf("files = %q, want %q", got, want)
+			}
+
+			f, err := os.Open(filepath.Join(dir, backups[2]+suffix))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			r, err := tc.decode(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b, err := io.ReadAll(r); err != nil || string(b) != backups[2]+"\n" {
+				t.Errorf("decoded %q, %v, want the backup's content", b, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package slogging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// configDebounce coalesces the burst of events an editor or a ConfigMap
+// update produces into one reload.
+const configDebounce = 100 * time.Millisecond
+
+// fileConfig is the config file format read by InitFromFile. JSON is read as
+// YAML, so the keys are the same in both; durations are strings like "2s".
+type fileConfig struct {
+	Service           string            `yaml:"service"`
+	Env               string            `yaml:"env"`
+	Version           string            `yaml:"version"`
+	Level             string            `yaml:"level"`
+	ComponentLevels   map[string]string `yaml:"component_levels"`
+	Pretty            bool              `yaml:"pretty"`
+	Caller            bool              `yaml:"caller"`
+	Stack             bool              `yaml:"stack"`
+	SampleEvery       int               `yaml:"sample_every"`
+	SampleBudget      int               `yaml:"sample_budget"`
+	File              string            `yaml:"file"`
+	MaxSizeMB         int               `yaml:"max_size_mb"`
+	MaxBackups        int               `yaml:"max_backups"`
+	MaxAgeDays        int               `yaml:"max_age_days"`
+	Compress          bool              `yaml:"compress"`
+	FileLock          bool              `yaml:"file_lock"`
+	CreateLogDir      *logDirConfig     `yaml:"create_log_dir"`
+	AlsoStdout        bool              `yaml:"also_stdout"`
+	SplitStdErr       bool              `yaml:"split_stderr"`
+	StdoutMaxLine     int               `yaml:"stdout_max_line"`
+	StdoutOversize    string            `yaml:"stdout_oversize"`
+	Async             bool              `yaml:"async"`
+	BufferSize        int               `yaml:"buffer_size"`
+	DropPolicy        string            `yaml:"drop_policy"`
+	WriteTimeout      time.Duration     `yaml:"write_timeout"`
+	DedupWindow       time.Duration     `yaml:"dedup_window"`
+	DedupKeyFields    []string          `yaml:"dedup_key_fields"`
+	RateLimit         int               `yaml:"rate_limit"`
+	RateLimitInterval time.Duration     `yaml:"rate_limit_interval"`
+	SyslogAddr        string            `yaml:"syslog_addr"`
+	GELFAddr          string            `yaml:"gelf_addr"`
+	SentryDSN         string            `yaml:"sentry_dsn"`
+}
+
+// logDirConfig is create_log_dir; mode is octal, as in "0750".
+type logDirConfig struct {
+	Mode  string `yaml:"mode"`
+	Owner string `yaml:"owner"`
+	Label string `yaml:"label"`
+}
+
+// loadConfigFile reads and validates path.
+func loadConfigFile(path string) (fileConfig, []byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fileConfig{}, nil, err
+	}
+	var c fileConfig
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&c); err != nil && !errors.Is(err, io.EOF) {
+		return fileConfig{}, nil, fmt.Errorf("slogging: %s: %w", path, err)
+	}
+	if c.Level != "" {
+		lvl, err := parseLevel(c.Level)
+		if err != nil {
+			return fileConfig{}, nil, fmt.Errorf("slogging: %s: invalid level %q", path, c.Level)
+		}
+		c.Level = lvl.String()
+	}
+	for name, level := range c.ComponentLevels {
+		lvl, err := parseLevel(level)
+		if err != nil {
+			return fileConfig{}, nil, fmt.Errorf("slogging: %s: component_levels: %s: invalid level %q", path, name, level)
+		}
+		c.ComponentLevels[name] = lvl.String()
+	}
+	if d := c.CreateLogDir; d != nil && d.Mode != "" {
+		if _, err := parseDirMode(d.Mode); err != nil {
+			return fileConfig{}, nil, fmt.Errorf("slogging: %s: create_log_dir: mode %q: %w", path, d.Mode, err)
+		}
+	}
+	switch c.StdoutOversize {
+	case "", LineTruncate, LineSplit:
+	default:
+		return fileConfig{}, nil, fmt.Errorf("slogging: %s: stdout_oversize %q: want %s or %s", path, c.StdoutOversize, LineTruncate, LineSplit)
+	}
+	switch c.DropPolicy {
+	case "", DropNewest, DropOldest, DropBlock:
+	default:
+		return fileConfig{}, nil, fmt.Errorf("slogging: %s: drop_policy %q: want %s, %s or %s", path, c.DropPolicy, DropNewest, DropOldest, DropBlock)
+	}
+	return c, b, nil
+}
+
+func (c fileConfig) options() Options {
+	var dir *LogDir
+	if d := c.CreateLogDir; d != nil {
+		mode, _ := parseDirMode(d.Mode) // validated by loadConfigFile
+		dir = &LogDir{Mode: mode, Owner: d.Owner, Label: d.Label}
+	}
+	return Options{
+		Service:           c.Service,
+		Environment:       c.Env,
+		Version:           c.Version,
+		Level:             c.Level,
+		ComponentLevels:   c.ComponentLevels,
+		Pretty:            c.Pretty,
+		WithCaller:        c.Caller,
+		WithStack:         c.Stack,
+		SampleEvery:       c.SampleEvery,
+		SampleBudget:      c.SampleBudget,
+		FilePath:          c.File,
+		MaxSizeMB:         c.MaxSizeMB,
+		MaxBackups:        c.MaxBackups,
+		MaxAgeDays:        c.MaxAgeDays,
+		Compress:          c.Compress,
+		FileLock:          c.FileLock,
+		CreateLogDir:      dir,
+		AlsoStdout:        c.AlsoStdout,
+		SplitStdErr:       c.SplitStdErr,
+		StdoutMaxLine:     c.StdoutMaxLine,
+		StdoutOversize:    c.StdoutOversize,
+		Async:             c.Async,
+		BufferSize:        c.BufferSize,
+		DropPolicy:        c.DropPolicy,
+		WriteTimeout:      c.WriteTimeout,
+		DedupWindow:       c.DedupWindow,
+		DedupKeyFields:    c.DedupKeyFields,
+		RateLimit:         c.RateLimit,
+		RateLimitInterval: c.RateLimitInterval,
+		SyslogAddr:        c.SyslogAddr,
+		GELFAddr:          c.GELFAddr,
+		SentryDSN:         c.SentryDSN,
+	}
+}
+
+// configWatch is the running InitFromFile watcher; guarded by std.mu.
+var configWatch *configWatcher
+
+// InitFromFile initializes the global logger from a YAML or JSON config file
+// and keeps watching it:
+//
+//	level: info
+//	sample_every: 10
+//	file: /var/log/billing/app.log
+//	max_size_mb: 100
+//
+// The keys are the snake_case names OptionsFromEnv reads (level, pretty,
+// sample_every, file, write_timeout, ...); unknown keys are an error. When
+// the file changes, new levels are applied in place and any other change
+// re-Inits the pipeline. A reload that fails to read or parse is reported as
+// a "config_reload_failed" error and the running config is kept. Close, or
+// another InitFromFile, stops the watcher.
+func InitFromFile(path string) error {
+	c, raw, err := loadConfigFile(path)
+	if err != nil {
+		return err
+	}
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("slogging: watching %s: %w", path, err)
+	}
+	// The directory, not the file: editors and ConfigMap updates replace it.
+	if err := w.Add(filepath.Dir(path)); err != nil {
+		w.Close()
+		return fmt.Errorf("slogging: watching %s: %w", path, err)
+	}
+	cw := &configWatcher{path: path, cfg: c, raw: raw, w: w, done: make(chan struct{})}
+
+	std.mu.Lock()
+	if configWatch != nil {
+		configWatch.stop()
+	}
+	configWatch = cw
+	std.install(build(std, c.options()))
+	std.mu.Unlock()
+
+	go cw.run()
+	return nil
+}
+
+// stopConfigWatch stops the InitFromFile watcher, if any. Callers must hold
+// std.mu.
+func stopConfigWatch() {
+	if configWatch != nil {
+		configWatch.stop()
+		configWatch = nil
+	}
+}
+
+type configWatcher struct {
+	path string
+	cfg  fileConfig // last applied; only touched by run and under std.mu
+	raw  []byte
+	w    *fsnotify.Watcher
+	once sync.Once
+	done chan struct{}
+}
+
+func (cw *configWatcher) run() {
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-cw.done:
+			return
+		case err, ok := <-cw.w.Errors:
+			if !ok {
+				return
+			}
+			selfEvent(zerolog.ErrorLevel, "config_reload_failed").
+				Str("path", cw.path).Err(err).
+				Msg("config file watch error; keeping the running config")
+		case _, ok := <-cw.w.Events:
+			if !ok {
+				return
+			}
+			debounce = time.After(configDebounce)
+		case <-debounce:
+			debounce = nil
+			cw.reload()
+		}
+	}
+}
+
+// reload applies the file if its content changed since the last load.
+func (cw *configWatcher) reload() {
+	c, raw, err := loadConfigFile(cw.path)
+	if err != nil {
+		selfEvent(zerolog.ErrorLevel, "config_reload_failed").
+			Str("path", cw.path).Err(err).
+			Msg("config file reload failed; keeping the running config")
+		return
+	}
+	if bytes.Equal(raw, cw.raw) {
+		return
+	}
+	std.mu.Lock()
+	defer std.mu.Unlock()
+	select {
+	case <-cw.done: // stopped while reading
+		return
+	default:
+	}
+	old := cw.cfg
+	cw.cfg, cw.raw = c, raw
+	levelsOnly := old
+	levelsOnly.Level, levelsOnly.ComponentLevels = c.Level, c.ComponentLevels
+	if reflect.DeepEqual(levelsOnly, c) {
+		if c.Level != old.Level {
+			SetLevel(firstNonEmpty(c.Level, zerolog.InfoLevel.String()))
+		}
+		for name := range old.ComponentLevels {
+			if _, ok := c.ComponentLevels[name]; !ok {
+				SetComponentLevel(name, "")
+			}
+		}
+		for name, level := range c.ComponentLevels {
+			SetComponentLevel(name, level)
+		}
+		return
+	}
+	std.install(build(std, c.options()))
+	selfEvent(zerolog.InfoLevel, "config_reloaded").
+		Str("path", cw.path).
+		Msg("config file changed; logger reconfigured")
+}
+
+func (cw *configWatcher) stop() {
+	cw.once.Do(func() {
+		close(cw.done)
+		cw.w.Close()
+	})
+}
@@ -0,0 +1,101 @@
+package slogging
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"github.com/rs/zerolog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fileEvents decodes the events in the log file at path whose slogging_event
+// is name.
+func fileEvents(t *testing.T, path, name string) []map[string]any {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var out []map[string]any
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var ev map[string]any
+		if json.Unmarshal(sc.Bytes(), &ev) == nil && ev[FieldSloggingEvent] == name {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+// eventually fails the test unless cond holds within a few seconds.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !cond(); {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestInitFromFileReload(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "app.log")
+	cfgPath := filepath.Join(dir, "log.yaml")
+	write := func(cfg string) {
+		t.Helper()
+		if err := os.WriteFile(cfgPath, []byte("file: "+logPath+"\n"+cfg), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("level: info\ncomponent_levels:\n  db: warn\n")
+	if err := InitFromFile(cfgPath); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { Close(context.Background()) })
+	first := current.Load()
+
+	t.Run("levels in place", func(t *testing.T) {
+		write("level: debug\ncomponent_levels:\n  db: error\n")
+		eventually(t, "the new level", func() bool { return std.level() == zerolog.DebugLevel })
+		if current.Load() != first {
+			t.Error("a level-only change re-ran Init")
+		}
+		if got := ComponentLevels()["db"]; got != "error" {
+			t.Errorf("db level = %q, want error", got)
+		}
+	})
+
+	t.Run("structural change", func(t *testing.T) {
+		write("level: debug\ncaller: true\n")
+		eventually(t, "a new pipeline", func() bool { return current.Load() != first })
+		std.Logger().Info().Str(FieldSloggingEvent, "probe").Msg("probe")
+		if evs := fileEvents(t, logPath, "probe"); len(evs) != 1 || evs[0][zerolog.CallerFieldName] == nil {
+			t.Errorf("events = %v, want one with the caller", evs)
+		}
+		if _, ok := ComponentLevels()["db"]; ok {
+			t.Errorf("component levels = %v after the key was removed", ComponentLevels())
+		}
+		eventually(t, "config_reloaded", func() bool { return len(fileEvents(t, logPath, "config_reloaded")) == 1 })
+	})
+
+	t.Run("bad file", func(t *testing.T) {
+		running := current.Load()
+		for i, cfg := range []string{"level: loud\n", "level: [debug\n", "colour: blue\n"} {
+			write(cfg)
+			eventually(t, "config_reload_failed", func() bool {
+				return len(fileEvents(t, logPath, "config_reload_failed")) == i+1
+			})
+		}
+		if current.Load() != running {
+			t.Error("a bad file replaced the running config")
+		}
+		if lvl := std.level(); lvl != zerolog.DebugLevel {
+			t.Errorf("level = %s after bad files, want debug", lvl)
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package slogging
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+	"time"
+)
+
+// ConsumedMessage describes a message for ConsumerMiddleware. Only Topic is
+// required; transports without partitions leave Partition and Offset zero.
+type ConsumedMessage struct {
+	Topic     string // topic, subject or queue name
+	Partition int
+	Offset    int64
+	// HighWaterMark is the partition's next offset as fetched with the message
+	// (kafka-go Message.HighWaterMark); when set, consumer_lag is logged as
+	// HighWaterMark-Offset-1.
+	HighWaterMark int64
+	// Produced, when set, logs message_age_ms: how long the message waited.
+	Produced time.Time
+	// Header reads message headers for the correlation IDs written by
+	// Correlation.Inject; nil when the transport has none.
+	Header func(key string) string
+	// Value is the message itself, for the handler.
+	Value any
+}
+
+// ConsumerOptions configures ConsumerMiddleware.
+type ConsumerOptions struct {
+	// QueueDepth, when set, is called at processing time and logged as
+	// queue_depth, e.g. a NATS subscription's Pending() or len(ch) of a local
+	// work queue.
+	QueueDepth func(ConsumedMessage) int64
+}
+
+// MessageHandler processes one consumed message.
+type MessageHandler func(ctx context.Context, m ConsumedMessage) error
+
+// ConsumerMiddleware is HTTPMiddleware for message consumers: it restores the
+// producer's correlation IDs from the message headers, times the handler and
+// logs "message processed" with topic, partition, offset, duration_ms and,
+// when known, consumer_lag, queue_depth and message_age_ms, so slow
+// processing can be read against the backlog it caused. A handler error logs
+// at error level.
+//
+//	handle := slogging.ConsumerMiddleware(slogging.ConsumerOptions{}, process)
+//	for {
+//		km, err := r.FetchMessage(ctx)
+//		...
+//		handle(ctx, slogging.ConsumedMessage{
+//			Topic: km.Topic, Partition: km.Partition, Offset: km.Offset,
+//			HighWaterMark: km.HighWaterMark, Produced: km.Time, Value: km,
+//		})
+//	}
+func ConsumerMiddleware(opt ConsumerOptions, next MessageHandler) MessageHandler {
+	return func(ctx context.Context, m ConsumedMessage) error {
+		start := time.Now()
+		if m.Header != nil {
+			ctx = ContextWithCorrelation(ctx, m.Header)
+		}
+		ctx = WithRequestStats(ctx)
+		var depth int64 = -1
+		if opt.QueueDepth != nil {
+			depth = opt.QueueDepth(m)
+		}
+		err := next(ctx, m)
+
+		level := zerolog.InfoLevel
+		if err != nil {
+			level = zerolog.ErrorLevel
+		}
+		e := From(ctx).WithLevel(level)
+		if e == nil {
+			return err
+		}
+		e.Str(FieldTopic, m.Topic).
+			Int(FieldPartition, m.Partition).
+			Int64(FieldOffset, m.Offset)
+		if m.HighWaterMark > 0 {
+			e.Int64(FieldConsumerLag, max(m.HighWaterMark-m.Offset-1, 0))
+		}
+		if depth >= 0 {
+			e.Int64(FieldQueueDepth, depth)
+		}
+		if !m.Produced.IsZero() {
+			e.Float64(FieldMessageAgeMs, float64(start.Sub(m.Produced).Microseconds())/1000)
+		}
+		if err != nil {
+			e.Err(err)
+		}
+		AppendRequestStats(ctx, e).
+			Float64(FieldDurationMs, float64(time.Since(start).Microseconds())/1000).
+			Msg("message processed")
+		return err
+	}
+}
@@ -0,0 +1,61 @@
+package slogging
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConsumerMiddleware(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc"})
+	var seen string
+	handle := ConsumerMiddleware(ConsumerOptions{
+		QueueDepth: func(m ConsumedMessage) int64 { return 12 },
+	}, func(ctx context.Context, m ConsumedMessage) error {
+		seen = GetRequestID(ctx)
+		CacheResult(ctx, "orders", true, 0)
+		if m.Value == "bad" {
+			return errors.New("unparseable")
+		}
+		return nil
+	})
+
+	header := map[string]string{HeaderRequestID: "r1"}
+	err := handle(context.Background(), ConsumedMessage{
+		Topic: "orders", Partition: 2, Offset: 100, HighWaterMark: 150,
+		Produced: time.Now().Add(-time.Second),
+		Header:   func(k string) string { return header[k] },
+	})
+	if err != nil || seen != "r1" {
+		t.Fatalf("err = %v, handler saw request ID %q", err, seen)
+	}
+	if err := handle(context.Background(), ConsumedMessage{Topic: "orders", Value: "bad"}); err == nil {
+		t.Fatal("the handler's error was not returned")
+	}
+
+	evs := c.withMessage(t, "message processed")
+	if len(evs) != 2 {
+		t.Fatalf("got %d events, want 2", len(evs))
+	}
+	ok, failed := evs[0], evs[1]
+	for k, want := range map[string]any{
+		"level": "info", FieldRequestID: "r1", FieldTopic: "orders", FieldPartition: 2.0,
+		FieldOffset: 100.0, FieldConsumerLag: 49.0, FieldQueueDepth: 12.0,
+	} {
+		if ok[k] != want {
+			t.Errorf("%s = %v, want %v", k, ok[k], want)
+		}
+	}
+	if age, _ := ok[FieldMessageAgeMs].(float64); age < 1000 {
+		t.Errorf("%s = %v, want at least 1000", FieldMessageAgeMs, ok[FieldMessageAgeMs])
+	}
+	if ok[FieldCache] == nil || ok[FieldDurationMs] == nil {
+		t.Errorf("event = %v, want the request stats and duration", ok)
+	}
+	// Without a high-water mark or produce time there is no lag or age.
+	if failed["level"] != "error" || failed["error"] != "unparseable" ||
+		failed[FieldConsumerLag] != nil || failed[FieldMessageAgeMs] != nil {
+		t.Errorf("failed event = %v", failed)
+	}
+}
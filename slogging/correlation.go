@@ -0,0 +1,65 @@
+package slogging
+
+import "context"
+
+// Header-style keys used when correlation IDs travel with an outbound side
+// effect (email headers, message attributes, webhook HTTP headers).
+const (
+	HeaderRequestID = XRequestID
+	HeaderTraceID   = "X-Trace-ID"
+)
+
+// Correlation holds the IDs that tie a side effect back to the request that
+// caused it.
+type Correlation struct {
+	RequestID string
+	TraceID   string
+}
+
+// CorrelationFrom reads the IDs set by WithRequestID and WithTraceID.
+func CorrelationFrom(ctx context.Context) Correlation {
+	return Correlation{RequestID: GetRequestID(ctx), TraceID: GetTraceID(ctx)}
+}
+
+// IsZero reports whether no ID is set.
+func (c Correlation) IsZero() bool { return c == Correlation{} }
+
+// Inject writes the non-empty IDs under their header names through set, which
+// fits http.Header.Set, textproto.MIMEHeader.Set or a message-attribute map:
+//
+//	slogging.CorrelationFrom(ctx).Inject(msg.Header.Set)
+//	slogging.CorrelationFrom(ctx).Inject(func(k, v string) { attrs[k] = v })
+func (c Correlation) Inject(set func(key, value string)) {
+	if c.RequestID != "" {
+		set(HeaderRequestID, c.RequestID)
+	}
+	if c.TraceID != "" {
+		set(HeaderTraceID, c.TraceID)
+	}
+}
+
+// Metadata returns the non-empty IDs keyed by their log field names, for
+// embedding in webhook or event payload bodies.
+func (c Correlation) Metadata() map[string]string {
+	m := make(map[string]string, 2)
+	if c.RequestID != "" {
+		m[FieldRequestID] = c.RequestID
+	}
+	if c.TraceID != "" {
+		m[FieldTraceID] = c.TraceID
+	}
+	return m
+}
+
+// ContextWithCorrelation is the receiving side of Inject: it reads the IDs
+// through get (e.g. r.Header.Get) and stores them with WithRequestID and
+// WithTraceID, so the consumer's events carry the originating IDs.
+func ContextWithCorrelation(ctx context.Context, get func(key string) string) context.Context {
+	if v := get(HeaderRequestID); v != "" {
+		ctx = WithRequestID(ctx, v)
+	}
+	if v := get(HeaderTraceID); v != "" {
+		ctx = WithTraceID(ctx, v)
+	}
+	return ctx
+}
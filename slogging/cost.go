@@ -0,0 +1,185 @@
+package slogging
+
+import (
+	"bytes"
+	"github.com/rs/zerolog"
+	"io"
+	"sync"
+	"time"
+)
+
+// maxCostComponents bounds the per-component table; further components are
+// counted under "other" until the next report.
+const maxCostComponents = 256
+
+var componentPrefix = []byte(`"` + FieldComponent + `":"`)
+
+// costMeter counts the bytes written per level, component and sink, and emits
+// one "log_cost" event per interval projecting them to bytes per day
+// (Options.CostReportInterval).
+type costMeter struct {
+	log       *zerolog.Logger
+	costPerGB float64
+
+	mu         sync.Mutex
+	since      time.Time
+	levels     map[string]uint64
+	components map[string]uint64
+	sinks      map[string]uint64
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+func startCostMeter(log *zerolog.Logger, every time.Duration, costPerGB float64) *costMeter {
+	c := &costMeter{
+		log:        log,
+		costPerGB:  costPerGB,
+		since:      time.Now(),
+		levels:     make(map[string]uint64),
+		components: make(map[string]uint64),
+		sinks:      make(map[string]uint64),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	go c.run(every)
+	return c
+}
+
+// events wraps w so every event is counted by level and component. It sits
+// right above the sinks and routes, inside dedup, rate limiting and quotas,
+// so events those drop are not counted.
+func (c *costMeter) events(w io.Writer) io.Writer {
+	return costEvents{w: w, c: c}
+}
+
+// sink wraps a sink so its bytes are counted under name.
+func (c *costMeter) sink(name string, w io.Writer) io.Writer {
+	return costSink{w: w, c: c, name: name}
+}
+
+type costEvents struct {
+	w io.Writer
+	c *costMeter
+}
+
+func (e costEvents) Write(p []byte) (int, error) {
+	return e.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (e costEvents) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	lvl := level.String()
+	if level == zerolog.NoLevel {
+		lvl = "none"
+	}
+	comp := eventComponent(p)
+	n := uint64(len(p))
+	e.c.mu.Lock()
+	e.c.levels[lvl] += n
+	if _, ok := e.c.components[comp]; !ok && len(e.c.components) >= maxCostComponents {
+		comp = "other"
+	}
+	e.c.components[comp] += n
+	e.c.mu.Unlock()
+	return writeLevel(e.w, level, p)
+}
+
+// eventComponent returns the event's component field, or "" without one. It
+// takes the first match rather than decoding the event, which is close enough
+// for attribution.
+func eventComponent(p []byte) string {
+	i := bytes.Index(p, componentPrefix)
+	if i < 0 {
+		return ""
+	}
+	rest := p[i+len(componentPrefix):]
+	j := bytes.IndexByte(rest, '"')
+	if j < 0 {
+		return ""
+	}
+	return string(rest[:j])
+}
+
+type costSink struct {
+	w    io.Writer
+	c    *costMeter
+	name string
+}
+
+func (s costSink) Write(p []byte) (int, error) {
+	return s.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (s costSink) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	s.c.mu.Lock()
+	s.c.sinks[s.name] += uint64(len(p))
+	s.c.mu.Unlock()
+	return writeLevel(s.w, level, p)
+}
+
+func (c *costMeter) run(every time.Duration) {
+	defer close(c.done)
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-c.stop:
+			c.report()
+			return
+		case <-t.C:
+			c.report()
+		}
+	}
+}
+
+// report emits the projection for the window since the previous report and
+// resets the counters. The event is emitted after the lock is released, since
+// it is counted too.
+func (c *costMeter) report() {
+	now := time.Now()
+	c.mu.Lock()
+	window := now.Sub(c.since)
+	levels, components, sinks := c.levels, c.components, c.sinks
+	c.since = now
+	c.levels = make(map[string]uint64)
+	c.components = make(map[string]uint64)
+	c.sinks = make(map[string]uint64)
+	c.mu.Unlock()
+	if window <= 0 || len(levels) == 0 {
+		return
+	}
+	scale := float64(24*time.Hour) / float64(window)
+	perDay := func(m map[string]uint64) *zerolog.Event {
+		d := zerolog.Dict()
+		for k, v := range m {
+			if k == "" {
+				k = "none"
+			}
+			d.Uint64(k, uint64(float64(v)*scale))
+		}
+		return d
+	}
+	var total uint64
+	for _, v := range levels {
+		total += v
+	}
+	daily := uint64(float64(total) * scale)
+	e := selfEventOn(c.log, zerolog.InfoLevel, "log_cost").
+		Dur("window", window).
+		Uint64("bytes_per_day", daily).
+		Dict("by_level", perDay(levels)).
+		Dict("by_component", perDay(components)).
+		Dict("by_sink", perDay(sinks))
+	if c.costPerGB > 0 {
+		e = e.Float64("cost_per_day", float64(daily)/(1<<30)*c.costPerGB)
+	}
+	e.Msg("estimated log volume")
+}
+
+// Close emits a final report and stops the reporter.
+func (c *costMeter) Close() error {
+	c.once.Do(func() { close(c.stop) })
+	<-c.done
+	return nil
+}
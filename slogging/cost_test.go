@@ -0,0 +1,43 @@
+package slogging
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+// TestCostCountsOnlyEmittedEvents checks that events dropped by a tenant
+// quota are not counted: the per-level totals match what the sink received.
+func TestCostCountsOnlyEmittedEvents(t *testing.T) {
+	c := initCapture(t, Options{
+		Service:            "svc",
+		CostReportInterval: time.Hour,
+		TenantQuotas:       map[string]TenantQuota{"acme": {EventsPerSec: 1}},
+	})
+	ctx := IntoContext(context.Background(), FieldTenant, "acme")
+	for range 100 {
+		From(ctx).Info().Msg("over quota")
+	}
+	Close(context.Background())
+
+	var cost map[string]any
+	for _, ev := range c.events(t) {
+		if ev[FieldSloggingEvent] == "log_cost" {
+			cost = ev
+		}
+	}
+	if cost == nil {
+		t.Fatal("no log_cost event")
+	}
+	var levels float64
+	byLevel, _ := cost["by_level"].(map[string]any)
+	for _, v := range byLevel {
+		levels += v.(float64)
+	}
+	bySink, _ := cost["by_sink"].(map[string]any)
+	extra, _ := bySink["extra"].(float64)
+	if extra == 0 || math.Abs(levels-extra) > float64(len(byLevel)) {
+		t.Errorf("by_level totals %.0f bytes/day, the extra sink %.0f: %v", levels, extra, cost)
+	}
+}
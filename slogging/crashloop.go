@@ -0,0 +1,132 @@
+package slogging
+
+import (
+	"encoding/json"
+	"github.com/rs/zerolog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCrashLoopStarts = 3
+	defaultCrashLoopWindow = 10 * time.Minute
+	startsFile             = "starts.json"
+	runningFile            = "running.json"
+	maxStartsKept          = 20
+)
+
+// startRecorded makes re-Init in the same process not count as a restart.
+var startRecorded sync.Once
+
+// startRecord is one entry of starts.json. A start is unclean when the run
+// before it did not end in Close: its running marker was left behind, or it
+// wrote a postmortem.
+type startRecord struct {
+	Time    time.Time `json:"time"`
+	Unclean bool      `json:"unclean"`
+}
+
+// detectCrashLoop records this start in dir and emits "crash_loop_suspected"
+// when at least starts unclean starts fall within window, or a postmortem was
+// written within window. Restarts after a Close, such as deploys, do not
+// count. The latest such postmortem is summarized in the event. It runs
+// before reportPostmortems renames them.
+func detectCrashLoop(l *zerolog.Logger, dir string, starts int, window time.Duration) {
+	startRecorded.Do(func() { checkCrashLoop(l, dir, starts, window) })
+}
+
+func checkCrashLoop(l *zerolog.Logger, dir string, starts int, window time.Duration) {
+	if starts <= 0 {
+		starts = defaultCrashLoopStarts
+	}
+	if window <= 0 {
+		window = defaultCrashLoopWindow
+	}
+	now := time.Now()
+	recent := recordStart(dir, now, window)
+	pm, pmPath := latestPostmortem(dir, now.Add(-window))
+	if len(recent) < starts && pmPath == "" {
+		return
+	}
+
+	e := selfEventOn(l, zerolog.ErrorLevel, "crash_loop_suspected").
+		Int("starts_in_window", len(recent)).
+		Dur("window", window)
+	if len(recent) > 1 {
+		e = e.Time("first_start", recent[0])
+	}
+	if pmPath != "" {
+		e = e.Str("postmortem", pmPath).
+			Str("reason", pm.Reason).
+			Str("crash_message", pm.Message).
+			Time("crashed_at", pm.Time)
+	}
+	e.Msgf("crash loop suspected: %d unclean starts within %s", len(recent), window)
+}
+
+// recordStart appends now to dir/starts.json, marks this run as running, and
+// returns the unclean starts within window, oldest first, this one included
+// when it is unclean. Errors degrade to "just this start".
+func recordStart(dir string, now time.Time, window time.Duration) []time.Time {
+	path := filepath.Join(dir, startsFile)
+	var all []startRecord
+	if b, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(b, &all)
+	}
+	prev := now.Add(-window)
+	if len(all) > 0 {
+		prev = all[len(all)-1].Time
+	}
+	_, err := os.Stat(filepath.Join(dir, runningFile))
+	_, pmPath := latestPostmortem(dir, prev)
+	all = append(all, startRecord{Time: now, Unclean: err == nil || pmPath != ""})
+	if len(all) > maxStartsKept {
+		all = all[len(all)-maxStartsKept:]
+	}
+	writeFileAtomic(dir, startsFile, all)
+	writeFileAtomic(dir, runningFile, now)
+
+	var recent []time.Time
+	for _, r := range all {
+		if r.Unclean && now.Sub(r.Time) <= window {
+			recent = append(recent, r.Time)
+		}
+	}
+	sort.Slice(recent, func(i, j int) bool { return recent[i].Before(recent[j]) })
+	return recent
+}
+
+// markCleanExit removes the running marker, so the next start is not
+// counted towards a crash loop.
+func markCleanExit(dir string) {
+	os.Remove(filepath.Join(dir, runningFile))
+}
+
+// latestPostmortem returns the newest postmortem in dir written after since,
+// whether or not it was already reported.
+func latestPostmortem(dir string, since time.Time) (Postmortem, string) {
+	paths, _ := filepath.Glob(filepath.Join(dir, "postmortem-*.json*"))
+	var best Postmortem
+	var bestPath string
+	for _, path := range paths {
+		if !strings.HasSuffix(path, ".json") && !strings.HasSuffix(path, ".json.reported") {
+			continue
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var pm Postmortem
+		if json.Unmarshal(b, &pm) != nil || pm.Time.Before(since) {
+			continue
+		}
+		if bestPath == "" || pm.Time.After(best.Time) {
+			best, bestPath = pm, path
+		}
+	}
+	return best, bestPath
+}
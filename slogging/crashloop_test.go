@@ -0,0 +1,38 @@
+package slogging
+
+import (
+	"bytes"
+	"github.com/rs/zerolog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCrashLoopIgnoresCleanRestarts(t *testing.T) {
+	dir := t.TempDir()
+	var buf bytes.Buffer
+	l := zerolog.New(&buf)
+	for range 5 {
+		checkCrashLoop(&l, dir, 3, time.Minute)
+		markCleanExit(dir)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("clean restarts reported: %s", buf.String())
+	}
+}
+
+func TestCrashLoopCountsUncleanStarts(t *testing.T) {
+	dir := t.TempDir()
+	var buf bytes.Buffer
+	l := zerolog.New(&buf)
+	checkCrashLoop(&l, dir, 3, time.Minute) // first start: nothing before it
+	for i := range 3 {
+		if i < 2 && buf.Len() != 0 {
+			t.Fatalf("reported after %d unclean starts: %s", i+1, buf.String())
+		}
+		checkCrashLoop(&l, dir, 3, time.Minute) // no markCleanExit: killed
+	}
+	if !strings.Contains(buf.String(), `"crash_loop_suspected"`) || !strings.Contains(buf.String(), `"starts_in_window":3`) {
+		t.Fatalf("no crash loop after 3 unclean starts: %s", buf.String())
+	}
+}
@@ -0,0 +1,103 @@
+package slogging
+
+import (
+	"github.com/natefinch/lumberjack"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// dailyTarget writes FilePath "root/app.log" as "root/YYYY/MM/DD/app.log",
+// switching directories at local midnight. Size rotation inside a day is still
+// lumberjack's; whole day directories older than MaxAgeDays are pruned.
+type dailyTarget struct {
+	root, name string
+	opt        Options
+
+	mu  sync.Mutex
+	day string
+	lj  *lumberjack.Logger
+}
+
+func newDailyTarget(path string, opt Options) *dailyTarget {
+	return &dailyTarget{root: filepath.Dir(path), name: filepath.Base(path), opt: opt}
+}
+
+func (d *dailyTarget) pathFor(t time.Time) string {
+	return filepath.Join(d.root, t.Format("2006"), t.Format("01"), t.Format("02"), d.name)
+}
+
+func (d *dailyTarget) currentPath() string {
+	return d.pathFor(time.Now())
+}
+
+func (d *dailyTarget) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	if day := now.Format("2006-01-02"); day != d.day || d.lj == nil {
+		if d.lj != nil {
+			d.lj.Close()
+		}
+		d.day = day
+		d.lj = newLumberjack(d.pathFor(now), d.opt)
+		// Each day has its own directory, which the compressor watching the
+		// active file would stop scanning at midnight; lumberjack gzips
+		// the day's backups itself.
+		d.lj.Compress = d.opt.Compress
+		if err := ensureLogDir(filepath.Dir(d.lj.Filename), d.opt.CreateLogDir); err != nil {
+			return 0, err
+		}
+		if d.opt.MaxAgeDays > 0 {
+			go pruneDayDirs(d.root, now.AddDate(0, 0, -d.opt.MaxAgeDays))
+		}
+	}
+	return d.lj.Write(p)
+}
+
+func (d *dailyTarget) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.lj == nil {
+		return nil
+	}
+	err := d.lj.Close()
+	d.lj = nil
+	return err
+}
+
+// pruneDayDirs removes root/YYYY/MM/DD directories dated before cutoff, then any
+// month/year directories left empty. Directories not named like dates are kept.
+func pruneDayDirs(root string, cutoff time.Time) {
+	cut := time.Date(cutoff.Year(), cutoff.Month(), cutoff.Day(), 0, 0, 0, 0, time.Local)
+	years, _ := os.ReadDir(root)
+	for _, y := range years {
+		yn, err := strconv.Atoi(y.Name())
+		if !y.IsDir() || err != nil || len(y.Name()) != 4 {
+			continue
+		}
+		ydir := filepath.Join(root, y.Name())
+		months, _ := os.ReadDir(ydir)
+		for _, m := range months {
+			mn, err := strconv.Atoi(m.Name())
+			if !m.IsDir() || err != nil || mn < 1 || mn > 12 {
+				continue
+			}
+			mdir := filepath.Join(ydir, m.Name())
+			days, _ := os.ReadDir(mdir)
+			for _, dd := range days {
+				dn, err := strconv.Atoi(dd.Name())
+				if !dd.IsDir() || err != nil || dn < 1 || dn > 31 {
+					continue
+				}
+				if time.Date(yn, time.Month(mn), dn, 0, 0, 0, 0, time.Local).Before(cut) {
+					os.RemoveAll(filepath.Join(mdir, dd.Name()))
+				}
+			}
+			os.Remove(mdir) // only succeeds when empty
+		}
+		os.Remove(ydir)
+	}
+}
@@ -0,0 +1,56 @@
+package slogging
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDailyDirsCompressesBackups(t *testing.T) {
+	root := t.TempDir()
+	d := newDailyTarget(filepath.Join(root, "app.log"), Options{MaxSizeMB: 1, Compress: true})
+	defer d.Close()
+	line := append(bytes.Repeat([]byte("x"), 1023), '\n')
+	for range 1500 { // 1.5MB: one rotation
+		if _, err := d.Write(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dir := filepath.Dir(d.currentPath())
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		gz, _ := filepath.Glob(filepath.Join(dir, "app-*.log.gz"))
+		if len(gz) > 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			entries, _ := os.ReadDir(dir)
+			t.Fatalf("no compressed backup in %s: %v", dir, entries)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestPruneDayDirs(t *testing.T) {
+	root := t.TempDir()
+	old := filepath.Join(root, "2020", "01", "02")
+	recent := filepath.Join(root, "2020", "03", "04")
+	keep := filepath.Join(root, "archive")
+	for _, dir := range []string{old, recent, keep} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pruneDayDirs(root, time.Date(2020, 2, 1, 0, 0, 0, 0, time.Local))
+
+	if _, err := os.Stat(filepath.Join(root, "2020", "01")); !os.IsNotExist(err) {
+		t.Errorf("old month still present: %v", err)
+	}
+	for _, dir := range []string{recent, keep} {
+		if _, err := os.Stat(dir); err != nil {
+			t.Errorf("%s: %v", dir, err)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package slogging
+
+import (
+	"github.com/rs/zerolog"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// deadlineWriter bounds how long a sink may block the logging path. Writes are
+// handed to a single worker goroutine (preserving order); if the worker does not
+// accept and finish a write within the timeout, the caller moves on. Only an
+// event the worker never accepted is counted as dropped: one it accepted is
+// still written when the sink recovers. While a write the caller gave up on is
+// still running, later writes do not wait for the worker but are dropped at
+// once unless it is free, so a hung sink costs one timeout, not one per event.
+type deadlineWriter struct {
+	w        io.Writer
+	timeout  time.Duration
+	jobs     chan writeJob
+	quit     chan struct{}
+	once     sync.Once
+	stuck    atomic.Bool // a write outlived its caller's timeout and has not returned
+	counters *selfStats
+}
+
+type writeJob struct {
+	level zerolog.Level
+	p     []byte
+	res   chan error // buffered so an abandoned job never blocks the worker
+}
+
+func newDeadlineWriter(w io.Writer, timeout time.Duration, st *selfStats) *deadlineWriter {
+	d := &deadlineWriter{
+		w:        w,
+		timeout:  timeout,
+		counters: st,
+		jobs:     make(chan writeJob),
+		quit:     make(chan struct{}),
+	}
+	go d.run()
+	return d
+}
+
+func (d *deadlineWriter) run() {
+	for {
+		select {
+		case <-d.quit:
+			return
+		case j := <-d.jobs:
+			_, err := writeLevel(d.w, j.level, j.p)
+			d.stuck.Store(false)
+			j.res <- err
+		}
+	}
+}
+
+func (d *deadlineWriter) Write(p []byte) (int, error) {
+	return d.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (d *deadlineWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	// zerolog reuses p once we return, and the worker may outlive this call.
+	j := writeJob{level: level, p: append([]byte(nil), p...), res: make(chan error, 1)}
+	t := time.NewTimer(d.timeout)
+	defer t.Stop()
+
+	var handed bool
+	if d.stuck.Load() {
+		// No point waiting for a worker that is already late.
+		select {
+		case d.jobs <- j:
+			handed = true
+		default:
+		}
+	} else {
+		select {
+		case d.jobs <- j:
+			handed = true
+		case <-t.C:
+		case <-d.quit:
+		}
+	}
+	if !handed {
+		d.counters.dropped.Add(1)
+		return len(p), nil
+	}
+	select {
+	case err := <-j.res:
+		if err != nil {
+			d.counters.writeErrors.Add(1)
+			return 0, err
+		}
+		return len(p), nil
+	case <-t.C:
+		// The worker has the event and writes it if the sink recovers, so
+		// it is not dropped; later callers stop waiting for it meanwhile.
+		d.stuck.Store(true)
+		return len(p), nil
+	}
+}
+
+// Close stops the worker; it does not close the wrapped sink.
+func (d *deadlineWriter) Close() error {
+	d.once.Do(func() { close(d.quit) })
+	return nil
+}
+
+// writeLevel writes through WriteLevel when w supports it, so level-aware sinks
+// further down keep seeing the event level.
+func writeLevel(w io.Writer, level zerolog.Level, p []byte) (int, error) {
+	if lw, ok := w.(zerolog.LevelWriter); ok {
+		return lw.WriteLevel(level, p)
+	}
+	return w.Write(p)
+}
@@ -0,0 +1,91 @@
+package slogging
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingSink holds every write until release is closed.
+type blockingSink struct {
+	release chan struct{}
+	mu      sync.Mutex
+	got     []string
+}
+
+func (s *blockingSink) Write(p []byte) (int, error) {
+	<-s.release
+	s.mu.Lock()
+	s.got = append(s.got, string(p))
+	s.mu.Unlock()
+	return len(p), nil
+}
+
+func (s *blockingSink) written() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.got)
+}
+
+func TestDeadlineWriterHungSink(t *testing.T) {
+	const timeout = 200 * time.Millisecond
+	sink := &blockingSink{release: make(chan struct{})}
+	var st selfStats
+	d := newDeadlineWriter(sink, timeout, &st)
+	defer d.Close()
+
+	// The worker takes "a" and hangs on it: the caller waits out the
+	// timeout, but "a" is not lost.
+	start := time.Now()
+	d.Write([]byte("a"))
+	if el := time.Since(start); el < timeout {
+		t.Errorf("first write returned after %v, want the %v timeout", el, timeout)
+	}
+	if n := st.dropped.Load(); n != 0 {
+		t.Errorf("dropped = %d after the first write, want 0", n)
+	}
+
+	// While the worker is stuck, "b" is dropped without waiting.
+	start = time.Now()
+	d.Write([]byte("b"))
+	if el := time.Since(start); el >= timeout/2 {
+		t.Errorf("write to a stuck worker returned after %v, want at once", el)
+	}
+	if n := st.dropped.Load(); n != 1 {
+		t.Errorf("dropped = %d after the second write, want 1", n)
+	}
+
+	close(sink.release)
+	for deadline := time.Now().Add(5 * time.Second); d.stuck.Load(); {
+		if time.Now().After(deadline) {
+			t.Fatal("worker still stuck after the sink recovered")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	d.Write([]byte("c"))
+
+	if got, want := sink.written(), []string{"a", "c"}; !slices.Equal(got, want) {
+		t.Errorf("written = %q, want %q", got, want)
+	}
+	if n := st.dropped.Load(); n != 1 {
+		t.Errorf("dropped = %d, want 1", n)
+	}
+}
+
+func TestDeadlineWriterError(t *testing.T) {
+	var st selfStats
+	d := newDeadlineWriter(failingWriter{errors.New("disk full")}, time.Second, &st)
+	defer d.Close()
+
+	if _, err := d.Write([]byte("a")); err == nil {
+		t.Error("Write err = nil, want the sink's error")
+	}
+	if n := st.writeErrors.Load(); n != 1 {
+		t.Errorf("writeErrors = %d, want 1", n)
+	}
+	if n := st.dropped.Load(); n != 0 {
+		t.Errorf("dropped = %d, want 0", n)
+	}
+}
@@ -0,0 +1,30 @@
+//go:build !slogging_nodebug
+
+package slogging
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+)
+
+// DebugEnabled is false in builds with -tags slogging_nodebug. Guarding a
+// statement with it lets the compiler drop the statement and the evaluation
+// of its arguments entirely:
+//
+//	if slogging.DebugEnabled {
+//		slogging.Debug(ctx).Str("plan", plan.Explain()).Msg("query plan")
+//	}
+const DebugEnabled = true
+
+// Debug starts a debug event on ctx's logger. Under -tags slogging_nodebug it
+// returns nil, on which every zerolog method is a no-op; the call's arguments
+// are still evaluated, so only statements guarded by DebugEnabled cost
+// nothing.
+func Debug(ctx context.Context) *zerolog.Event {
+	return From(ctx).Debug()
+}
+
+// Trace starts a trace event on ctx's logger; see Debug.
+func Trace(ctx context.Context) *zerolog.Event {
+	return From(ctx).Trace()
+}
@@ -0,0 +1,19 @@
+//go:build slogging_nodebug
+
+package slogging
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+)
+
+// DebugEnabled is false in this build; see the default build's doc.
+const DebugEnabled = false
+
+// Debug returns nil in slogging_nodebug builds; chained calls on it are
+// inlined no-ops, though their arguments are still evaluated unless the
+// statement is guarded by DebugEnabled.
+func Debug(context.Context) *zerolog.Event { return nil }
+
+// Trace returns nil in slogging_nodebug builds; see Debug.
+func Trace(context.Context) *zerolog.Event { return nil }
@@ -0,0 +1,29 @@
+package slogging
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDebugHelpers(t *testing.T) {
+	c := initCapture(t, Options{Level: "trace"})
+	ctx := WithRequestID(context.Background(), "r1")
+	Debug(ctx).Int("n", 1).Msg("debug helper")
+	Trace(ctx).Msg("trace helper")
+
+	evs := append(c.withMessage(t, "debug helper"), c.withMessage(t, "trace helper")...)
+	if !DebugEnabled {
+		if len(evs) != 0 {
+			t.Errorf("events = %v in a slogging_nodebug build", evs)
+		}
+		return
+	}
+	if len(evs) != 2 || evs[0]["level"] != "debug" || evs[1]["level"] != "trace" {
+		t.Fatalf("events = %v, want one debug and one trace", evs)
+	}
+	for _, ev := range evs {
+		if ev[FieldRequestID] != "r1" {
+			t.Errorf("event %v without the context's request_id", ev)
+		}
+	}
+}
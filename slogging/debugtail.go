@@ -0,0 +1,144 @@
+package slogging
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+	"io"
+	"sync"
+)
+
+const (
+	defaultDebugTailSize = 256
+	// maxDebugTailBytes bounds one request's tail whatever its size in
+	// events; the oldest are evicted first.
+	maxDebugTailBytes = 1 << 20
+)
+
+const ctxDebugTailKey ctxKey = "debug_tail"
+
+var debugTailTag = []byte(`{"` + FieldDebugTail + `":true`)
+
+// WithDebugTail keeps the debug events of the request behind ctx in memory
+// instead of dropping them: the last size of them (default 256) are held in
+// a ring, and written, marked "debug_tail": true and in their original
+// order, right before the request logs an error through From(ctx) or a
+// Component's From, or when FlushDebugTail is called. A request that ends
+// well costs building its debug events, not writing them.
+//
+// Events at or above the running level are written as usual.
+func WithDebugTail(ctx context.Context, size int) context.Context {
+	p := current.Load()
+	if p == nil {
+		return ctx
+	}
+	if size <= 0 {
+		size = defaultDebugTailSize
+	}
+	t := &debugTail{size: size}
+	ctx = context.WithValue(ctx, ctxDebugTailKey, t)
+	base := ctxLogger(ctx)
+	if base == nil {
+		base = &p.logger
+	}
+	ll := p.tailLogger(ctx, base, t, "")
+	return ll.WithContext(ctx)
+}
+
+// FlushDebugTail writes the debug events WithDebugTail has held for ctx so
+// far, for requests that fail without logging an error, such as a handler
+// returning an error its caller only counts.
+func FlushDebugTail(ctx context.Context) {
+	t, ok := ctx.Value(ctxDebugTailKey).(*debugTail)
+	p := current.Load()
+	if !ok || p == nil {
+		return
+	}
+	t.flush(p.sink())
+}
+
+func debugTailOf(ctx context.Context) *debugTail {
+	t, _ := ctx.Value(ctxDebugTailKey).(*debugTail)
+	return t
+}
+
+// tailLogger derives l's logger for a request whose debug events go to t.
+func (p *pipeline) tailLogger(ctx context.Context, l *zerolog.Logger, t *debugTail, component string) zerolog.Logger {
+	return l.Output(tailWriter{p: p, t: t, component: component}).With().Ctx(ctx).Logger().
+		Sample(levelGate{p: p, component: component, request: zerolog.DebugLevel, tail: true, next: p.sampler})
+}
+
+// tailWriter diverts the events levelGate let through only for the tail
+// into it, and flushes the tail ahead of an error.
+type tailWriter struct {
+	p         *pipeline
+	t         *debugTail
+	component string
+}
+
+func (w tailWriter) Write(b []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, b)
+}
+
+func (w tailWriter) WriteLevel(level zerolog.Level, b []byte) (int, error) {
+	if level == zerolog.NoLevel {
+		return writeLevel(w.p.sink(), level, b)
+	}
+	if level < w.p.levelOf(w.component) {
+		w.t.add(level, b)
+		return len(b), nil
+	}
+	if level >= zerolog.ErrorLevel {
+		w.t.flush(w.p.sink())
+	}
+	return writeLevel(w.p.sink(), level, b)
+}
+
+// debugTail is one request's held events, oldest first.
+type debugTail struct {
+	size int
+
+	mu     sync.Mutex
+	events []tailEvent
+	bytes  int
+}
+
+type tailEvent struct {
+	level zerolog.Level
+	b     []byte
+}
+
+func (t *debugTail) add(level zerolog.Level, b []byte) {
+	if len(b) > maxDebugTailBytes {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, tailEvent{level, append([]byte(nil), b...)})
+	t.bytes += len(b)
+	for len(t.events) > t.size || t.bytes > maxDebugTailBytes {
+		t.bytes -= len(t.events[0].b)
+		t.events[0] = tailEvent{}
+		t.events = t.events[1:]
+	}
+}
+
+// flush writes the held events to out and empties the tail. JSON events get
+// "debug_tail": true; Pretty ones are written as they are.
+func (t *debugTail) flush(out io.Writer) {
+	t.mu.Lock()
+	events := t.events
+	t.events, t.bytes = nil, 0
+	t.mu.Unlock()
+	var line []byte
+	for _, e := range events {
+		b := e.b
+		if len(b) > 1 && b[0] == '{' {
+			line = append(line[:0], debugTailTag...)
+			if b[1] != '}' {
+				line = append(line, ',')
+			}
+			b = append(line, b[1:]...)
+		}
+		writeLevel(out, e.level, b)
+	}
+}
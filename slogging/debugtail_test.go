@@ -0,0 +1,72 @@
+package slogging
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDebugTailFlushedOnError(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc", Level: "info"})
+	ctx, cancel := context.WithCancel(WithRequestID(context.Background(), "r1"))
+	defer cancel()
+	ctx = WithDebugTail(ctx, 3)
+
+	for _, msg := range []string{"step 1", "step 2", "step 3", "step 4"} {
+		From(ctx).Debug().Msg(msg)
+	}
+	From(ctx).Info().Msg("working")
+	if got := c.withMessage(t, "step 4"); len(got) != 0 {
+		t.Fatalf("debug event written before any error: %v", got)
+	}
+
+	From(ctx).Error().Msg("failed")
+	var order []string
+	for _, ev := range c.events(t) {
+		msg, _ := ev["message"].(string)
+		order = append(order, msg)
+		if tail := ev[FieldDebugTail] == true; tail != (msg != "working" && msg != "failed") {
+			t.Errorf("%q: debug_tail = %v", msg, ev[FieldDebugTail])
+		}
+		if ev[FieldRequestID] != "r1" {
+			t.Errorf("%q: request_id = %v", msg, ev[FieldRequestID])
+		}
+	}
+	want := []string{"working", "step 2", "step 3", "step 4", "failed"}
+	if len(order) != len(want) {
+		t.Fatalf("events = %q, want %q", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("events = %q, want %q", order, want)
+		}
+	}
+}
+
+func TestFlushDebugTail(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc", Level: "info"})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx = WithDebugTail(ctx, 0)
+
+	Component("repo").From(ctx).Debug().Msg("component debug")
+	From(ctx).Debug().Msg("request debug")
+	From(context.Background()).Debug().Msg("other debug")
+	if evs := c.events(t); len(evs) != 0 {
+		t.Fatalf("events written before the flush: %v", evs)
+	}
+
+	FlushDebugTail(ctx)
+	for _, msg := range []string{"component debug", "request debug"} {
+		if got := c.withMessage(t, msg); len(got) != 1 || got[0][FieldDebugTail] != true {
+			t.Errorf("%q: got %v, want one debug_tail event", msg, got)
+		}
+	}
+	if got := c.withMessage(t, "other debug"); len(got) != 0 {
+		t.Errorf("debug event outside the request written: %v", got)
+	}
+
+	FlushDebugTail(ctx) // already emptied
+	if got := c.withMessage(t, "request debug"); len(got) != 1 {
+		t.Errorf("second flush wrote the tail again: %d events", len(got))
+	}
+}
@@ -0,0 +1,197 @@
+package slogging
+
+import (
+	"bytes"
+	"github.com/rs/zerolog"
+	"hash/fnv"
+	"io"
+	"sync"
+	"time"
+)
+
+// maxDedupKeys bounds the dedup table; once full, new distinct events pass
+// through untracked until the next sweep frees entries.
+const maxDedupKeys = 4096
+
+// DedupMessageAndError is a DedupKeyFields value that treats events as
+// duplicates when their level, message and error match, whatever their other
+// fields.
+var DedupMessageAndError = []string{zerolog.MessageFieldName, zerolog.ErrorFieldName}
+
+// dedupWriter drops events identical to one already written within the
+// window. Two events are identical when everything but the per-event stamps
+// (timestamp, Options.Sequence, EventIDs and HighResTime fields) matches, which
+// covers level, message and every field, or, with key fields, when their
+// level and those fields match. Fatal and panic events, and slogging's own
+// events, are never dropped.
+//
+// A background sweep runs once per window and emits one "dedup_suppressed"
+// event, at the original level, for every line that had duplicates dropped.
+type dedupWriter struct {
+	w      io.Writer
+	log    *zerolog.Logger
+	window time.Duration
+	keys   [][]byte // quoted; nil compares whole events
+
+	mu      sync.Mutex
+	entries map[uint64]*dedupEntry
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+type dedupEntry struct {
+	first      time.Time
+	level      zerolog.Level
+	message    string
+	errMsg     string
+	suppressed uint64
+}
+
+var selfMarker = []byte(`"component":"slogging"`)
+
+func newDedupWriter(w io.Writer, log *zerolog.Logger, window time.Duration, keys []string) *dedupWriter {
+	d := &dedupWriter{
+		w:       w,
+		log:     log,
+		window:  window,
+		entries: make(map[uint64]*dedupEntry),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	for _, k := range keys {
+		d.keys = append(d.keys, []byte(`"`+k+`"`))
+	}
+	go d.run()
+	return d
+}
+
+func (d *dedupWriter) Write(p []byte) (int, error) {
+	return d.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (d *dedupWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if level >= zerolog.FatalLevel && level != zerolog.NoLevel || bytes.Contains(p, selfMarker) || bytes.Contains(p, exposureMarker) {
+		return writeLevel(d.w, level, p)
+	}
+	key, ok := d.key(level, p)
+	if !ok {
+		return writeLevel(d.w, level, p)
+	}
+	now := time.Now()
+	d.mu.Lock()
+	if e, ok := d.entries[key]; ok && now.Sub(e.first) < d.window {
+		e.suppressed++
+		d.mu.Unlock()
+		return len(p), nil
+	} else if ok {
+		e.first = now
+	} else if len(d.entries) < maxDedupKeys {
+		msg, _ := jsonField(p, zerolog.MessageFieldName)
+		errMsg, _ := jsonField(p, zerolog.ErrorFieldName)
+		d.entries[key] = &dedupEntry{first: now, level: level, message: msg, errMsg: errMsg}
+	}
+	d.mu.Unlock()
+	return writeLevel(d.w, level, p)
+}
+
+// key hashes the event without its per-event stamps or, with key fields, its
+// level and those fields.
+func (d *dedupWriter) key(level zerolog.Level, p []byte) (uint64, bool) {
+	members, _, ok := scanMembers(p)
+	if !ok {
+		return 0, false
+	}
+	h := fnv.New64a()
+	if d.keys != nil {
+		h.Write([]byte{byte(level)})
+		for _, k := range d.keys {
+			for _, m := range members {
+				if bytes.Equal(p[m.start:m.keyEnd], k) {
+					h.Write(p[m.start:m.end])
+					break
+				}
+			}
+			h.Write([]byte{','})
+		}
+		return h.Sum64(), true
+	}
+	for _, m := range members {
+		if isStamp(p[m.start:m.keyEnd]) {
+			continue
+		}
+		h.Write(p[m.start:m.end])
+		h.Write([]byte{','})
+	}
+	return h.Sum64(), true
+}
+
+// isStamp reports whether key (quoted) names a member that differs on every
+// event by design.
+func isStamp(key []byte) bool {
+	if len(key) < 2 {
+		return false
+	}
+	switch string(key[1 : len(key)-1]) {
+	case zerolog.TimestampFieldName, FieldSeq, FieldEventID, FieldTimeNs, FieldMonoNs:
+		return true
+	}
+	return false
+}
+
+func (d *dedupWriter) run() {
+	defer close(d.done)
+	t := time.NewTicker(d.window)
+	defer t.Stop()
+	for {
+		select {
+		case <-d.stop:
+			d.sweep(true)
+			return
+		case <-t.C:
+			d.sweep(false)
+		}
+	}
+}
+
+// sweep reports suppressed counts and forgets entries whose window is over.
+// Events are emitted after the lock is released, since they come back through
+// WriteLevel.
+func (d *dedupWriter) sweep(all bool) {
+	type report struct {
+		level           zerolog.Level
+		message, errMsg string
+		n               uint64
+	}
+	var reports []report
+	now := time.Now()
+	d.mu.Lock()
+	for k, e := range d.entries {
+		if e.suppressed > 0 {
+			reports = append(reports, report{e.level, e.message, e.errMsg, e.suppressed})
+			e.suppressed = 0
+		}
+		if all || now.Sub(e.first) >= d.window {
+			delete(d.entries, k)
+		}
+	}
+	d.mu.Unlock()
+	for _, r := range reports {
+		e := selfEventOn(d.log, r.level, "dedup_suppressed").
+			Str("dedup_message", r.message)
+		if r.errMsg != "" {
+			e = e.Str("dedup_error", r.errMsg)
+		}
+		e.Uint64("suppressed", r.n).
+			Dur("window", d.window).
+			Msgf("repeated %d times in the last %s", r.n, d.window)
+	}
+}
+
+// Close emits the final summaries and stops the sweep.
+func (d *dedupWriter) Close() error {
+	d.once.Do(func() { close(d.stop) })
+	<-d.done
+	return nil
+}
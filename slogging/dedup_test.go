@@ -0,0 +1,58 @@
+package slogging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/rs/zerolog"
+	"testing"
+	"time"
+)
+
+func TestDedupWindow(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc", DedupWindow: time.Hour, Sequence: true})
+	l := From(context.Background())
+	for range 3 {
+		l.Warn().Str("host", "db1").Msg("slow query") // identical but for time and seq
+	}
+	l.Warn().Str("host", "db2").Msg("slow query")
+	l.Error().Str("host", "db1").Msg("slow query")
+
+	evs := c.withMessage(t, "slow query")
+	if len(evs) != 3 {
+		t.Fatalf("got %d events, want db1 warn, db2 warn and db1 error: %v", len(evs), evs)
+	}
+}
+
+func TestDedupKeyFields(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc", DedupWindow: time.Hour, DedupKeyFields: DedupMessageAndError})
+	l := From(context.Background())
+	boom := errors.New("connection refused")
+	for i := range 3 {
+		l.Error().Int("attempt", i).Err(boom).Msg("dial failed")
+	}
+	l.Error().Err(errors.New("timeout")).Msg("dial failed")
+	if n := len(c.withMessage(t, "dial failed")); n != 2 {
+		t.Errorf("got %d events, want one per error", n)
+	}
+}
+
+func TestDedupSummaries(t *testing.T) {
+	var out, self bytes.Buffer
+	log := zerolog.New(&self)
+	d := newDedupWriter(&out, &log, time.Hour, nil)
+	for range 4 {
+		d.WriteLevel(zerolog.WarnLevel, []byte(`{"level":"warn","error":"refused","time":"`+time.Now().Format(time.RFC3339Nano)+`","message":"dial failed"}`+"\n"))
+	}
+	d.WriteLevel(zerolog.FatalLevel, []byte(`{"level":"fatal","message":"bye"}`+"\n"))
+	d.WriteLevel(zerolog.FatalLevel, []byte(`{"level":"fatal","message":"bye"}`+"\n"))
+	d.Close()
+
+	if n := bytes.Count(out.Bytes(), []byte("\n")); n != 3 {
+		t.Errorf("%d events passed, want 1 plus 2 fatal:\n%s", n, out.Bytes())
+	}
+	want := `"level":"warn","component":"slogging","slogging_event":"dedup_suppressed","dedup_message":"dial failed","dedup_error":"refused","suppressed":3`
+	if !bytes.Contains(self.Bytes(), []byte(want)) {
+		t.Errorf("summary lacks %s:\n%s", want, self.Bytes())
+	}
+}
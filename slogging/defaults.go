@@ -0,0 +1,71 @@
+package slogging
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Standard env vars read by ServiceDefaults.
+const (
+	EnvServiceName = "OTEL_SERVICE_NAME"
+	EnvDeployEnv   = "DEPLOY_ENV"
+	EnvImageTag    = "IMAGE_TAG"
+	EnvLabelsFile  = "K8S_LABELS_FILE" // overrides DefaultLabelsFile
+)
+
+// DefaultLabelsFile is where the Kubernetes downward API volume usually mounts pod labels.
+const DefaultLabelsFile = "/etc/podinfo/labels"
+
+// ServiceDefaults returns Options with Service, Environment and Version derived from
+// the standard deployment conventions, so every binary names itself the same way:
+//
+//	service: OTEL_SERVICE_NAME, label app.kubernetes.io/name, label app, then name
+//	env:     DEPLOY_ENV, label environment, label env
+//	version: IMAGE_TAG, label app.kubernetes.io/version, label version
+//
+// Level defaults to info; everything else is left for the caller to fill in.
+func ServiceDefaults(name string) Options {
+	labels := k8sLabels()
+	return Options{
+		Service:     firstNonEmpty(os.Getenv(EnvServiceName), labels["app.kubernetes.io/name"], labels["app"], name),
+		Environment: firstNonEmpty(os.Getenv(EnvDeployEnv), labels["environment"], labels["env"]),
+		Version:     firstNonEmpty(os.Getenv(EnvImageTag), labels["app.kubernetes.io/version"], labels["version"]),
+		Level:       "info",
+	}
+}
+
+// k8sLabels parses the downward API labels file (key="value" per line).
+// A missing or unreadable file yields no labels.
+func k8sLabels() map[string]string {
+	path := firstNonEmpty(os.Getenv(EnvLabelsFile), DefaultLabelsFile)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	labels := make(map[string]string)
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		k, v, ok := strings.Cut(strings.TrimSpace(sc.Text()), "=")
+		if !ok {
+			continue
+		}
+		if uq, err := strconv.Unquote(v); err == nil {
+			v = uq
+		}
+		labels[k] = v
+	}
+	return labels
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,43 @@
+package slogging
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestServiceDefaults(t *testing.T) {
+	labels := filepath.Join(t.TempDir(), "labels")
+	if err := os.WriteFile(labels, []byte(`app="billing-label"
+app.kubernetes.io/version="1.2.3"
+env="staging"
+malformed line
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvLabelsFile, labels)
+	t.Setenv(EnvServiceName, "")
+	t.Setenv(EnvImageTag, "")
+	t.Setenv(EnvDeployEnv, "prod")
+
+	// Env vars win over labels, labels over the binary's name.
+	opt := ServiceDefaults("billing-bin")
+	if opt.Service != "billing-label" || opt.Environment != "prod" || opt.Version != "1.2.3" || opt.Level != "info" {
+		t.Errorf("ServiceDefaults = %q %q %q %q, want billing-label prod 1.2.3 info",
+			opt.Service, opt.Environment, opt.Version, opt.Level)
+	}
+	t.Setenv(EnvServiceName, "billing")
+	t.Setenv(EnvLabelsFile, filepath.Join(t.TempDir(), "missing"))
+	if opt = ServiceDefaults("billing-bin"); opt.Service != "billing" || opt.Version != "" {
+		t.Errorf("ServiceDefaults without labels = %q %q, want billing and no version", opt.Service, opt.Version)
+	}
+
+	// Every event of a logger built from them carries the three names.
+	c := initCapture(t, opt)
+	From(context.Background()).Info().Msg("started")
+	evs := c.withMessage(t, "started")
+	if len(evs) != 1 || evs[0][FieldService] != "billing" || evs[0][FieldEnv] != "prod" {
+		t.Errorf("events = %v, want service billing in env prod", evs)
+	}
+}
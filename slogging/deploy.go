@@ -0,0 +1,64 @@
+package slogging
+
+import (
+	"encoding/json"
+	"github.com/rs/zerolog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// deployState is the content of Options.DeployStateFile.
+type deployState struct {
+	Version    string    `json:"version"`
+	DeployedAt time.Time `json:"deployed_at"`
+}
+
+// deployChecked makes re-Init in the same process not re-check the state file.
+var deployChecked sync.Once
+
+// DeploymentEvent logs a "deployment" marker for the switch from previous to
+// version, so dashboards can draw deploy lines from logs alone. It bypasses
+// sampling. previous may be empty for a first deploy. Options.DeployStateFile
+// calls it automatically when Options.Version changes between starts.
+func DeploymentEvent(version, previous string) {
+	deploymentEventOn(selfLogger(), version, previous, time.Time{})
+}
+
+func deploymentEventOn(l *zerolog.Logger, version, previous string, previousAt time.Time) {
+	e := selfEventOn(l, zerolog.InfoLevel, "deployment").
+		Str("deploy_version", version).
+		Str("previous_version", previous)
+	if !previousAt.IsZero() {
+		e = e.Time("previous_deployed_at", previousAt)
+	}
+	if previous == "" {
+		e.Msgf("deployed %s", version)
+		return
+	}
+	e.Msgf("deployed %s (was %s)", version, previous)
+}
+
+// detectDeployment compares version with the one recorded in path and, when
+// they differ, emits a deployment event and records the new version.
+func detectDeployment(l *zerolog.Logger, path, version string) {
+	deployChecked.Do(func() {
+		var prev deployState
+		if b, err := os.ReadFile(path); err == nil {
+			if err := json.Unmarshal(b, &prev); err != nil {
+				selfEventOn(l, zerolog.WarnLevel, "deploy_state_invalid").Str("path", path).Err(err).
+					Msg("ignoring unreadable deploy state file")
+			}
+		}
+		if prev.Version == version {
+			return
+		}
+		deploymentEventOn(l, version, prev.Version, prev.DeployedAt)
+		err := writeFileAtomic(filepath.Dir(path), filepath.Base(path), deployState{Version: version, DeployedAt: time.Now().UTC()})
+		if err != nil {
+			selfEventOn(l, zerolog.WarnLevel, "deploy_state_unwritable").Str("path", path).Err(err).
+				Msg("could not record deployed version; the next start will report it again")
+		}
+	})
+}
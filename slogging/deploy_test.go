@@ -0,0 +1,35 @@
+package slogging
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestDeploymentEvent(t *testing.T) {
+	state := filepath.Join(t.TempDir(), "deploy.json")
+	if err := os.WriteFile(state, []byte(`{"version":"1.0","deployed_at":"2024-05-01T10:00:00Z"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { deployChecked = sync.Once{} })
+
+	deployChecked = sync.Once{}
+	c := initCapture(t, Options{Version: "1.1", DeployStateFile: state})
+	evs := c.selfEvents(t, "deployment")
+	if len(evs) != 1 || evs[0]["deploy_version"] != "1.1" || evs[0]["previous_version"] != "1.0" ||
+		evs[0]["previous_deployed_at"] != "2024-05-01T10:00:00Z" {
+		t.Fatalf("deployment events = %v", evs)
+	}
+	if b, _ := os.ReadFile(state); !strings.Contains(string(b), `"version": "1.1"`) {
+		t.Errorf("state file = %s, want the new version recorded", b)
+	}
+
+	// A restart on the same version is not a deployment.
+	deployChecked = sync.Once{}
+	c = initCapture(t, Options{Version: "1.1", DeployStateFile: state})
+	if evs := c.selfEvents(t, "deployment"); len(evs) != 0 {
+		t.Errorf("restart logged %v", evs)
+	}
+}
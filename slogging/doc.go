@@ -0,0 +1,45 @@
+// Package slogging wraps zerolog with service-wide setup (Init), request-scoped
+// context loggers (IntoContext/From) and helpers for the common correlation IDs.
+//
+// # Concurrency model
+//
+// Init, InitOnce and MustInitOnce configure the Default instance and are
+// serialized by its mutex; each NewLogger Instance has a mutex and pipeline of
+// its own and works the same way. Each call builds a
+// complete pipeline off to the side and then publishes it with a single atomic
+// pointer store; the previous pipeline's writers are closed afterwards.
+//
+// Everything on the logging path (From, With, IntoContext, Logger methods) reads
+// the published logger through that atomic pointer and never takes the mutex,
+// so it is safe to log from any goroutine while another goroutine re-Inits.
+// Loggers already handed out (stored in contexts or returned by With) keep the
+// fields, hooks and levels of the pipeline they were created from, but write
+// through the writers of the one that replaced it, so a re-Init closing the
+// old writers loses none of their events. Close takes the same mutex,
+// uninstalls the pipeline and then flushes and closes it. Only Default's
+// pipeline is assigned to log.Logger.
+//
+// The minimum levels live in the pipeline, not in zerolog's global level, which
+// slogging never changes: every logger it hands out carries a sampler that
+// drops what is below its base, component or request level before the event is
+// built. Levels are atomics and the component table is copy-on-write. A debug tail is a mutex-guarded slice per request. Samplers are
+// zerolog samplers and are safe for concurrent use.
+// zerolog's package-level format variables (TimeFieldFormat, CallerMarshalFunc,
+// InterfaceMarshalFunc, ErrorMarshalFunc) are written once, on the first Init
+// or RegisterMarshaler, and never again. The marshaler registry behind them is
+// copy-on-write.
+//
+// # Field values
+//
+// Values that reach the encoder through Interface, Any, Fields, Err or the
+// With/IntoContext helpers never corrupt the stream: NaN and ±Inf floats are
+// quoted, invalid UTF-8 is replaced, and a value that fails to encode (a cycle,
+// NaN inside a map, a MarshalJSON or Error method that errors or panics) is
+// logged as an error string, with a one-time "marshal_failed" self event per
+// type. RawJSON is written verbatim, and Object, Array and Stringer values call
+// user code directly from zerolog, so those remain the caller's responsibility.
+//
+// For compatibility Init also assigns zerolog's log.Logger. That assignment is a
+// plain write: code reading log.Logger directly while re-Init runs is racy, so
+// prefer From/With over the zerolog log package.
+package slogging
@@ -0,0 +1,66 @@
+package slogging
+
+import (
+	"context"
+	"time"
+)
+
+// SinkStats describes one async sink queue (Options.Async).
+type SinkStats struct {
+	Name       string // "file", "stdout", "extra" or "route:<path|field>"
+	QueueDepth int    // events queued or being written
+	Capacity   int    // per-lane buffer size
+	Peak       int    // highest QueueDepth observed
+	Dropped    uint64 // events this sink dropped under backpressure
+}
+
+// GetSinkStats returns the queue state of every async sink of the installed
+// pipeline. It is empty unless Options.Async is set.
+func GetSinkStats() []SinkStats {
+	p := current.Load()
+	if p == nil {
+		return nil
+	}
+	var out []SinkStats
+	for _, q := range p.asyncQueues() {
+		out = append(out, q.stats())
+	}
+	return out
+}
+
+const drainPoll = 5 * time.Millisecond
+
+// Drain blocks until every async sink queue is empty, or ctx ends (returning
+// ctx.Err()). Use it before scaling a worker down; unlike Close it keeps the
+// pipeline running, so events logged meanwhile are drained as well.
+func Drain(ctx context.Context) error {
+	p := current.Load()
+	if p == nil {
+		return nil
+	}
+	t := time.NewTicker(drainPoll)
+	defer t.Stop()
+	for {
+		empty := true
+		for _, q := range p.asyncQueues() {
+			if q.pending.Load() > 0 {
+				empty = false
+				break
+			}
+		}
+		if empty {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+func (p *pipeline) asyncQueues() []*asyncWriter {
+	p.queuesMu.Lock()
+	defer p.queuesMu.Unlock()
+	return append([]*asyncWriter(nil), p.queues...)
+}
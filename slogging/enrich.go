@@ -0,0 +1,29 @@
+package slogging
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+)
+
+// Enricher adds fields to an event at emit time, using the context the event
+// was logged with. Unlike fields baked in by IntoContext, enrichers see the
+// context as it is when the event is written, so they suit lookups that should
+// not happen at context creation (GeoIP, K8s metadata, feature state).
+type Enricher func(ctx context.Context, e *zerolog.Event)
+
+// EnricherChain runs enrichers in order for every event. It implements
+// zerolog.Hook, so it can also be attached to loggers built outside Init.
+type EnricherChain []Enricher
+
+// Enrichers builds an ordered enrichment chain for Options.Enrichers.
+func Enrichers(es ...Enricher) EnricherChain {
+	return EnricherChain(es)
+}
+
+// Run implements zerolog.Hook.
+func (c EnricherChain) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	ctx := e.GetCtx()
+	for _, en := range c {
+		en(ctx, e)
+	}
+}
@@ -0,0 +1,34 @@
+package slogging
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+	"testing"
+)
+
+type tenantKey struct{}
+
+func tenantEnricher(ctx context.Context, e *zerolog.Event) {
+	if v, ok := ctx.Value(tenantKey{}).(string); ok {
+		e.Str("tenant", v)
+	}
+}
+
+func TestEnrichersSeeTheEventContext(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc", Enrichers: Enrichers(tenantEnricher)})
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+
+	From(ctx).Info().Msg("from")
+	New(ctx).Info().Msg("new")
+	Component("db").From(ctx).Info().Msg("component")
+
+	for _, msg := range []string{"from", "new", "component"} {
+		evs := c.withMessage(t, msg)
+		if len(evs) != 1 {
+			t.Fatalf("%s: got %d events, want 1", msg, len(evs))
+		}
+		if evs[0]["tenant"] != "acme" {
+			t.Errorf("%s: tenant = %v, want acme", msg, evs[0]["tenant"])
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package slogging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// File rotation defaults applied by OptionsFromEnv when FILE is set.
+const (
+	envDefaultMaxSizeMB  = 100
+	envDefaultMaxBackups = 5
+	envDefaultMaxAgeDays = 14
+)
+
+// OptionsFromEnv builds Options from environment variables named
+// prefix + "_" + NAME (prefix "LOG" when empty), on top of ServiceDefaults
+// for the binary's name:
+//
+//	SERVICE ENV VERSION        override ServiceDefaults
+//	LEVEL                      default info
+//	COMPONENT_LEVELS           e.g. "repository=debug,cache=warn"
+//	PRETTY CALLER STACK        bools
+//	SAMPLE_EVERY SAMPLE_BUDGET ints
+//	FILE                       enables the file sink, rotated with
+//	MAX_SIZE_MB MAX_BACKUPS MAX_AGE_DAYS (defaults 100, 5, 14), COMPRESS
+//	and FILE_LOCK
+//	CREATE_LOG_DIR             bool; DIR_MODE (octal), DIR_OWNER and
+//	                           DIR_LABEL imply it
+//	ALSO_STDOUT SPLIT_STDERR   bools
+//	STDOUT_MAX_LINE            bytes; STDOUT_OVERSIZE truncate or split
+//	ASYNC BUFFER_SIZE DROP_POLICY WRITE_TIMEOUT
+//	DEDUP_WINDOW               duration
+//	DEDUP_KEY_FIELDS           comma-separated, e.g. "message,error"
+//	RATE_LIMIT RATE_LIMIT_INTERVAL
+//	SYSLOG_ADDR GELF_ADDR SENTRY_DSN
+//	WORKER_SOCKET PARENT_SOCKET WORKER_NAME
+//
+// Bools take strconv.ParseBool values and durations time.ParseDuration ones.
+// A value that does not parse is skipped, keeping the default, and reported
+// as an "invalid_option" warning once Init installs the Options.
+func OptionsFromEnv(prefix string) Options {
+	if prefix == "" {
+		prefix = "LOG"
+	}
+	e := envReader{prefix: strings.TrimSuffix(prefix, "_") + "_"}
+
+	opt := ServiceDefaults(filepath.Base(os.Args[0]))
+	e.str("SERVICE", &opt.Service)
+	e.str("ENV", &opt.Environment)
+	e.str("VERSION", &opt.Version)
+	e.level("LEVEL", &opt.Level)
+	e.levels("COMPONENT_LEVELS", &opt.ComponentLevels)
+	e.bool("PRETTY", &opt.Pretty)
+	e.bool("CALLER", &opt.WithCaller)
+	e.bool("STACK", &opt.WithStack)
+	e.int("SAMPLE_EVERY", &opt.SampleEvery)
+	e.int("SAMPLE_BUDGET", &opt.SampleBudget)
+
+	if e.str("FILE", &opt.FilePath) {
+		opt.MaxSizeMB = envDefaultMaxSizeMB
+		opt.MaxBackups = envDefaultMaxBackups
+		opt.MaxAgeDays = envDefaultMaxAgeDays
+	}
+	e.int("MAX_SIZE_MB", &opt.MaxSizeMB)
+	e.int("MAX_BACKUPS", &opt.MaxBackups)
+	e.int("MAX_AGE_DAYS", &opt.MaxAgeDays)
+	e.bool("COMPRESS", &opt.Compress)
+	e.bool("FILE_LOCK", &opt.FileLock)
+	var dir LogDir
+	var create bool
+	e.bool("CREATE_LOG_DIR", &create)
+	var mode string
+	if e.str("DIR_MODE", &mode) {
+		if m, err := parseDirMode(mode); err != nil {
+			e.fail("DIR_MODE", mode, err)
+		} else {
+			dir.Mode = m
+		}
+	}
+	e.str("DIR_OWNER", &dir.Owner)
+	e.str("DIR_LABEL", &dir.Label)
+	if create || dir != (LogDir{}) {
+		opt.CreateLogDir = &dir
+	}
+	e.bool("ALSO_STDOUT", &opt.AlsoStdout)
+	e.bool("SPLIT_STDERR", &opt.SplitStdErr)
+	e.int("STDOUT_MAX_LINE", &opt.StdoutMaxLine)
+	if e.str("STDOUT_OVERSIZE", &opt.StdoutOversize) {
+		switch opt.StdoutOversize {
+		case LineTruncate, LineSplit:
+		default:
+			e.fail("STDOUT_OVERSIZE", opt.StdoutOversize, fmt.Errorf("want %s or %s", LineTruncate, LineSplit))
+			opt.StdoutOversize = ""
+		}
+	}
+
+	e.bool("ASYNC", &opt.Async)
+	e.int("BUFFER_SIZE", &opt.BufferSize)
+	if e.str("DROP_POLICY", &opt.DropPolicy) {
+		switch opt.DropPolicy {
+		case DropNewest, DropOldest, DropBlock:
+		default:
+			e.fail("DROP_POLICY", opt.DropPolicy, fmt.Errorf("want %s, %s or %s", DropNewest, DropOldest, DropBlock))
+			opt.DropPolicy = ""
+		}
+	}
+	e.duration("WRITE_TIMEOUT", &opt.WriteTimeout)
+	e.duration("DEDUP_WINDOW", &opt.DedupWindow)
+	e.list("DEDUP_KEY_FIELDS", &opt.DedupKeyFields)
+	e.int("RATE_LIMIT", &opt.RateLimit)
+	e.duration("RATE_LIMIT_INTERVAL", &opt.RateLimitInterval)
+
+	e.str("SYSLOG_ADDR", &opt.SyslogAddr)
+	e.str("GELF_ADDR", &opt.GELFAddr)
+	e.str("SENTRY_DSN", &opt.SentryDSN)
+
+	e.str("WORKER_SOCKET", &opt.WorkerSocket)
+	e.str("PARENT_SOCKET", &opt.ParentSocket)
+	e.str("WORKER_NAME", &opt.WorkerName)
+
+	opt.envErrs = e.errs
+	return opt
+}
+
+// envReader reads prefixed variables, collecting parse errors.
+type envReader struct {
+	prefix string
+	errs   []error
+}
+
+// str sets *dst to the variable's value and reports whether it was set.
+func (e *envReader) str(name string, dst *string) bool {
+	v, ok := os.LookupEnv(e.prefix + name)
+	v = strings.TrimSpace(v)
+	if !ok || v == "" {
+		return false
+	}
+	*dst = v
+	return true
+}
+
+func (e *envReader) fail(name, value string, err error) {
+	e.errs = append(e.errs, fmt.Errorf("%s%s=%q: %w", e.prefix, name, value, err))
+}
+
+func (e *envReader) bool(name string, dst *bool) {
+	var s string
+	if !e.str(name, &s) {
+		return
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		e.fail(name, s, err)
+		return
+	}
+	*dst = b
+}
+
+func (e *envReader) int(name string, dst *int) {
+	var s string
+	if !e.str(name, &s) {
+		return
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		e.fail(name, s, err)
+		return
+	}
+	*dst = n
+}
+
+func (e *envReader) duration(name string, dst *time.Duration) {
+	var s string
+	if !e.str(name, &s) {
+		return
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		e.fail(name, s, err)
+		return
+	}
+	*dst = d
+}
+
+func (e *envReader) level(name string, dst *string) {
+	var s string
+	if !e.str(name, &s) {
+		return
+	}
+	lvl, err := parseLevel(s)
+	if err != nil {
+		e.fail(name, s, err)
+		return
+	}
+	*dst = lvl.String()
+}
+
+// list reads comma-separated values, dropping empty ones.
+func (e *envReader) list(name string, dst *[]string) {
+	var s string
+	if !e.str(name, &s) {
+		return
+	}
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	*dst = out
+}
+
+// levels reads comma-separated name=level pairs, skipping invalid ones.
+func (e *envReader) levels(name string, dst *map[string]string) {
+	var s string
+	if !e.str(name, &s) {
+		return
+	}
+	m := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			e.fail(name, s, fmt.Errorf("%q: want name=level", pair))
+			continue
+		}
+		lvl, err := parseLevel(v)
+		if err != nil {
+			e.fail(name, s, err)
+			continue
+		}
+		m[k] = lvl.String()
+	}
+	*dst = m
+}
@@ -0,0 +1,149 @@
+package slogging
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOptionsFromEnvLogDir(t *testing.T) {
+	t.Setenv("APP_DIR_MODE", "0750")
+	t.Setenv("APP_DIR_OWNER", "app:adm")
+	t.Setenv("APP_DIR_LABEL", "system_u:object_r:var_log_t:s0")
+
+	opt := OptionsFromEnv("APP")
+	if len(opt.envErrs) != 0 {
+		t.Fatalf("unexpected errors: %v", opt.envErrs)
+	}
+	want := LogDir{Mode: 0o750, Owner: "app:adm", Label: "system_u:object_r:var_log_t:s0"}
+	if opt.CreateLogDir == nil || *opt.CreateLogDir != want {
+		t.Fatalf("CreateLogDir = %+v, want %+v", opt.CreateLogDir, want)
+	}
+}
+
+func TestOptionsFromEnvCreateLogDir(t *testing.T) {
+	t.Setenv("APP_CREATE_LOG_DIR", "true")
+	opt := OptionsFromEnv("APP_")
+	if opt.CreateLogDir == nil || *opt.CreateLogDir != (LogDir{}) {
+		t.Fatalf("CreateLogDir = %+v, want defaults", opt.CreateLogDir)
+	}
+
+	t.Setenv("APP_CREATE_LOG_DIR", "")
+	if opt := OptionsFromEnv("APP"); opt.CreateLogDir != nil {
+		t.Fatalf("CreateLogDir = %+v without any variable set", opt.CreateLogDir)
+	}
+}
+
+func TestOptionsFromEnvInvalidDirMode(t *testing.T) {
+	t.Setenv("APP_DIR_MODE", "rwx")
+	opt := OptionsFromEnv("APP")
+	if len(opt.envErrs) != 1 || !strings.Contains(opt.envErrs[0].Error(), `APP_DIR_MODE="rwx"`) {
+		t.Fatalf("envErrs = %v, want one APP_DIR_MODE error", opt.envErrs)
+	}
+	if opt.CreateLogDir != nil {
+		t.Fatalf("CreateLogDir = %+v from an invalid mode", opt.CreateLogDir)
+	}
+}
+
+func TestOptionsFromEnvPrefix(t *testing.T) {
+	t.Setenv("SVC_LEVEL", "warn")
+	t.Setenv("LOG_LEVEL", "debug")
+	for _, tc := range []struct{ prefix, want string }{
+		{"SVC", "warn"},
+		{"SVC_", "warn"},
+		{"", "debug"},
+	} {
+		if got := OptionsFromEnv(tc.prefix).Level; got != tc.want {
+			t.Errorf("OptionsFromEnv(%q).Level = %q, want %q", tc.prefix, got, tc.want)
+		}
+	}
+}
+
+func TestOptionsFromEnvFileDefaults(t *testing.T) {
+	opt := OptionsFromEnv("SVC")
+	if opt.MaxSizeMB != 0 || opt.MaxBackups != 0 || opt.MaxAgeDays != 0 {
+		t.Errorf("rotation = %d, %d, %d without FILE, want zeros", opt.MaxSizeMB, opt.MaxBackups, opt.MaxAgeDays)
+	}
+
+	t.Setenv("SVC_FILE", "/var/log/svc/app.log")
+	opt = OptionsFromEnv("SVC")
+	if opt.FilePath != "/var/log/svc/app.log" || opt.MaxSizeMB != 100 || opt.MaxBackups != 5 || opt.MaxAgeDays != 14 {
+		t.Errorf("FILE gave %q rotated at %d, %d, %d, want 100, 5, 14", opt.FilePath, opt.MaxSizeMB, opt.MaxBackups, opt.MaxAgeDays)
+	}
+
+	t.Setenv("SVC_MAX_BACKUPS", "0")
+	if opt := OptionsFromEnv("SVC"); opt.MaxBackups != 0 || opt.MaxSizeMB != 100 {
+		t.Errorf("MAX_BACKUPS=0 gave %d backups of %d MB, want 0 of 100", opt.MaxBackups, opt.MaxSizeMB)
+	}
+}
+
+func TestOptionsFromEnvInvalidValues(t *testing.T) {
+	t.Setenv("SVC_SAMPLE_EVERY", "ten")
+	t.Setenv("SVC_PRETTY", "yes please")
+	t.Setenv("SVC_WRITE_TIMEOUT", "2")
+	t.Setenv("SVC_LEVEL", "loud")
+	t.Setenv("SVC_ASYNC", "true")
+
+	opt := OptionsFromEnv("SVC")
+	if opt.SampleEvery != 0 || opt.Pretty || opt.WriteTimeout != 0 || opt.Level != "info" {
+		t.Errorf("invalid values applied: sample every %d, pretty %v, write timeout %v, level %q",
+			opt.SampleEvery, opt.Pretty, opt.WriteTimeout, opt.Level)
+	}
+	if !opt.Async {
+		t.Error("valid ASYNC skipped along with the invalid values")
+	}
+	var names []string
+	for _, err := range opt.envErrs {
+		name, _, _ := strings.Cut(err.Error(), "=")
+		names = append(names, name)
+	}
+	if want := []string{"SVC_LEVEL", "SVC_PRETTY", "SVC_SAMPLE_EVERY", "SVC_WRITE_TIMEOUT"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("envErrs = %v, want errors for %v", opt.envErrs, want)
+	}
+}
+
+func TestOptionsFromEnvComponentLevels(t *testing.T) {
+	for _, tc := range []struct {
+		value string
+		want  map[string]string
+		errs  int
+	}{
+		{"repository=debug,cache=warn", map[string]string{"repository": "debug", "cache": "warn"}, 0},
+		{" repository = debug , cache=WARN ", map[string]string{"repository": "debug", "cache": "warn"}, 0},
+		{"repository=debug,cache", map[string]string{"repository": "debug"}, 1},
+		{"repository=loud,=info,cache=warn", map[string]string{"cache": "warn"}, 2},
+	} {
+		t.Setenv("SVC_COMPONENT_LEVELS", tc.value)
+		opt := OptionsFromEnv("SVC")
+		if !reflect.DeepEqual(opt.ComponentLevels, tc.want) {
+			t.Errorf("%q: ComponentLevels = %v, want %v", tc.value, opt.ComponentLevels, tc.want)
+		}
+		if len(opt.envErrs) != tc.errs {
+			t.Errorf("%q: envErrs = %v, want %d", tc.value, opt.envErrs, tc.errs)
+		}
+	}
+}
+
+func TestOptionsFromEnvEnums(t *testing.T) {
+	for _, tc := range []struct {
+		name, value string
+		get         func(Options) string
+		want        string
+	}{
+		{"DROP_POLICY", DropOldest, func(o Options) string { return o.DropPolicy }, DropOldest},
+		{"DROP_POLICY", "random", func(o Options) string { return o.DropPolicy }, ""},
+		{"STDOUT_OVERSIZE", LineSplit, func(o Options) string { return o.StdoutOversize }, LineSplit},
+		{"STDOUT_OVERSIZE", "wrap", func(o Options) string { return o.StdoutOversize }, ""},
+	} {
+		t.Run(tc.name+"="+tc.value, func(t *testing.T) {
+			t.Setenv("SVC_"+tc.name, tc.value)
+			opt := OptionsFromEnv("SVC")
+			if got := tc.get(opt); got != tc.want {
+				t.Errorf("%s = %q, want %q", tc.name, got, tc.want)
+			}
+			if wantErr := tc.want == ""; (len(opt.envErrs) == 1) != wantErr {
+				t.Errorf("envErrs = %v", opt.envErrs)
+			}
+		})
+	}
+}
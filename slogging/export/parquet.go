@@ -0,0 +1,108 @@
+// Package export converts slogging files into analytics formats.
+package export
+
+import (
+	"encoding/json"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"github.com/dinhtatuanlinh/source_logging/slogging/parse"
+	"github.com/parquet-go/parquet-go"
+	"io"
+	"time"
+)
+
+// Row is the Parquet schema: one column per standard slogging field, with every
+// other field kept as a JSON object in Fields.
+type Row struct {
+	Time         time.Time `parquet:"time,optional,timestamp(millisecond)"` // null when the event has none
+	Level        string    `parquet:"level,dict"`
+	Message      string    `parquet:"message"`
+	Service      string    `parquet:"service,dict"`
+	Env          string    `parquet:"env,dict"`
+	Version      string    `parquet:"version,dict"`
+	Component    string    `parquet:"component,dict"`
+	RequestID    string    `parquet:"request_id"`
+	TraceID      string    `parquet:"trace_id"`
+	APIID        string    `parquet:"api_id,dict"`
+	OperatorName string    `parquet:"operator_name"`
+	Role         string    `parquet:"role,dict"`
+	IPAddress    string    `parquet:"ip_address"`
+	Error        string    `parquet:"error"`
+	Caller       string    `parquet:"caller"`
+	Fields       string    `parquet:"fields,json"`
+
+	SchemaVersion int `parquet:"event_schema_version"`
+}
+
+// standard maps JSON keys to the Row columns that absorb them.
+var standard = map[string]func(*Row, string){
+	"level":         func(r *Row, v string) { r.Level = v },
+	"message":       func(r *Row, v string) { r.Message = v },
+	"service":       func(r *Row, v string) { r.Service = v },
+	"env":           func(r *Row, v string) { r.Env = v },
+	"version":       func(r *Row, v string) { r.Version = v },
+	"component":     func(r *Row, v string) { r.Component = v },
+	"request_id":    func(r *Row, v string) { r.RequestID = v },
+	"trace_id":      func(r *Row, v string) { r.TraceID = v },
+	"api_id":        func(r *Row, v string) { r.APIID = v },
+	"operator_name": func(r *Row, v string) { r.OperatorName = v },
+	"role":          func(r *Row, v string) { r.Role = v },
+	"ip_address":    func(r *Row, v string) { r.IPAddress = v },
+	"error":         func(r *Row, v string) { r.Error = v },
+	"caller":        func(r *Row, v string) { r.Caller = v },
+}
+
+// RowOf flattens an event into a Row.
+func RowOf(ev slogging.Entry) Row {
+	r := Row{Time: parse.EventTime(ev), SchemaVersion: parse.SchemaOf(ev)}
+	rest := make(map[string]any)
+	for k, v := range ev {
+		if k == "time" || k == slogging.FieldSchemaVersion {
+			continue
+		}
+		if set, ok := standard[k]; ok {
+			set(&r, parse.Stringify(v))
+			continue
+		}
+		rest[k] = v
+	}
+	if len(rest) > 0 {
+		b, _ := json.Marshal(rest)
+		r.Fields = string(b)
+	}
+	return r
+}
+
+const rowBatch = 1024
+
+// Parquet reads JSON or CBOR log files (plain, .gz or .zst) and writes them to
+// w as a single Parquet file. It returns the number of rows written.
+func Parquet(w io.Writer, files []string) (int, error) {
+	pw := parquet.NewGenericWriter[Row](w, parquet.Compression(&parquet.Zstd))
+	batch := make([]Row, 0, rowBatch)
+	total := 0
+	flush := func() error {
+		n, err := pw.Write(batch)
+		total += n
+		batch = batch[:0]
+		return err
+	}
+
+	var werr error
+	err := parse.ForEach(files, func(_ string, ev slogging.Entry, _ []byte) bool {
+		batch = append(batch, RowOf(ev))
+		if len(batch) == rowBatch {
+			werr = flush()
+		}
+		return werr == nil
+	})
+	if err == nil {
+		err = werr
+	}
+	if err == nil && len(batch) > 0 {
+		err = flush()
+	}
+	if cerr := pw.Close(); err == nil {
+		err = cerr
+	}
+	return total, err
+}
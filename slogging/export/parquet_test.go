@@ -0,0 +1,61 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"github.com/parquet-go/parquet-go"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParquetRoundTrip(t *testing.T) {
+	lines := `{"time":"2024-05-01T10:00:00.123Z","level":"info","message":"charged","service":"billing","request_id":"r1","event_schema_version":2,"amount":12.5,"count":3,"ok":true,"tags":["a","b"],"card":{"brand":"visa","last4":"4242"},"note":null}
+{"time":"2024-05-01T10:00:01Z","level":"error","message":"failed","error":"boom","caller":"main.go:12","event_schema_version":2}
+{"level":"warn","message":"old","X-Request-ID":"legacy"}
+`
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte(lines), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	n, err := Parquet(&buf, []string{path})
+	if err != nil || n != 3 {
+		t.Fatalf("Parquet = %d, %v; want 3 rows", n, err)
+	}
+	rows, err := parquet.Read[Row](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("read %d rows, want 3", len(rows))
+	}
+
+	r := rows[0]
+	if !r.Time.Equal(time.Date(2024, 5, 1, 10, 0, 0, 123e6, time.UTC)) || r.Level != "info" || r.Message != "charged" ||
+		r.Service != "billing" || r.RequestID != "r1" || r.SchemaVersion != 2 {
+		t.Errorf("row 0 = %+v", r)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(r.Fields), &fields); err != nil {
+		t.Fatalf("fields %q: %v", r.Fields, err)
+	}
+	want := map[string]any{
+		"amount": 12.5, "count": 3.0, "ok": true, "tags": []any{"a", "b"},
+		"card": map[string]any{"brand": "visa", "last4": "4242"}, "note": nil,
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("fields = %v, want %v", fields, want)
+	}
+
+	if r := rows[1]; r.Error != "boom" || r.Caller != "main.go:12" || r.Fields != "" {
+		t.Errorf("row 1 = %+v", r)
+	}
+	// Files from before versioning are upgraded on the way in.
+	if r := rows[2]; r.RequestID != "legacy" || r.SchemaVersion != slogging.SchemaVersion || !r.Time.IsZero() {
+		t.Errorf("row 2 = %+v", r)
+	}
+}
@@ -0,0 +1,31 @@
+package slogging
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+)
+
+// ExposureEvent is the "event" value of Exposure events.
+const ExposureEvent = "exposure"
+
+// exposureMarker identifies exposure lines to writer stages that must not drop them.
+var exposureMarker = []byte(`"` + FieldEvent + `":"` + ExposureEvent + `"`)
+
+// Exposure logs that ctx's request was exposed to variant of experiment.
+// Experiment analysis needs every exposure, so unlike ordinary info events it
+// bypasses sampling and deduplication and rides the async priority lane,
+// which blocks rather than drops. With Options.ExposureFilePath the events
+// are also written to a dedicated file.
+//
+//	slogging.Exposure(ctx, "checkout-button-color", "green")
+func Exposure(ctx context.Context, experiment, variant string) {
+	l := From(ctx).Sample(nil)
+	// NoLevel is what routes the event to the priority lane; the level field
+	// zerolog then omits is written by hand.
+	l.WithLevel(zerolog.NoLevel).
+		Str(zerolog.LevelFieldName, zerolog.LevelInfoValue).
+		Str(FieldEvent, ExposureEvent).
+		Str(FieldExperiment, experiment).
+		Str(FieldVariant, variant).
+		Msg("experiment exposure")
+}
@@ -0,0 +1,39 @@
+package slogging
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExposureBypassesSamplingAndDedup(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "exposure.log")
+	c := initCapture(t, Options{SampleEvery: 10, DedupWindow: time.Minute, ExposureFilePath: file})
+	ctx := WithRequestID(context.Background(), "r1")
+	for range 5 {
+		Exposure(ctx, "checkout-button-color", "green")
+		From(ctx).Info().Msg("ordinary")
+	}
+
+	evs := c.withMessage(t, "experiment exposure")
+	if len(evs) != 5 {
+		t.Fatalf("got %d exposures, want all 5", len(evs))
+	}
+	if ev := evs[0]; ev["level"] != "info" || ev[FieldEvent] != ExposureEvent ||
+		ev[FieldExperiment] != "checkout-button-color" || ev[FieldVariant] != "green" || ev[FieldRequestID] != "r1" {
+		t.Errorf("exposure = %v", ev)
+	}
+	if n := len(c.withMessage(t, "ordinary")); n >= 5 {
+		t.Errorf("got %d ordinary events, want them sampled or deduplicated", n)
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(b), "experiment exposure"); n != 5 || strings.Contains(string(b), "ordinary") {
+		t.Errorf("exposure file has %d exposures:\n%s", n, b)
+	}
+}
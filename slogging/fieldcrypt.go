@@ -0,0 +1,203 @@
+package slogging
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// EncryptedPrefix starts every value written by Options.EncryptFields. With
+// Options.EncryptionKeyID the key ID and a ':' follow it.
+const EncryptedPrefix = "enc:x25519:"
+
+const fieldCryptInfo = "slogging field encryption v1"
+
+// cryptWriter replaces the values of EncryptFields with envelopes only the
+// holder of the X25519 private key can open. Each value gets an ephemeral key
+// pair; the shared secret keys AES-256-GCM, and the field name is bound in as
+// additional data so an envelope cannot be moved to another field.
+type cryptWriter struct {
+	w      io.Writer
+	pub    *ecdh.PublicKey
+	kid    string
+	fields [][]byte // quoted keys
+}
+
+func newCryptWriter(w io.Writer, pub *ecdh.PublicKey, kid string, fields []string) *cryptWriter {
+	c := &cryptWriter{w: w, pub: pub, kid: kid}
+	for _, f := range fields {
+		c.fields = append(c.fields, []byte(`"`+f+`"`))
+	}
+	return c
+}
+
+func (c *cryptWriter) Write(p []byte) (int, error) {
+	return c.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (c *cryptWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if !c.mentions(p) {
+		return writeLevel(c.w, level, p)
+	}
+	members, tail, ok := scanMembers(p)
+	if !ok {
+		return writeLevel(c.w, level, p)
+	}
+	buf := lineBufs.Get().(*bytes.Buffer)
+	defer lineBufs.Put(buf)
+	buf.Reset()
+	buf.WriteByte('{')
+	for i, m := range members {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key := p[m.start:m.keyEnd]
+		if !containsKey(c.fields, key) {
+			buf.Write(p[m.start:m.end])
+			continue
+		}
+		value := bytes.TrimLeft(p[m.keyEnd:m.end], " :")
+		env, err := sealField(c.pub, c.kid, string(key[1:len(key)-1]), value)
+		if err != nil {
+			env = "!ENCRYPTION FAILED" // never fall back to the plaintext
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.WriteString(strconv.Quote(env))
+	}
+	buf.WriteByte('}')
+	buf.Write(p[tail:])
+	if _, err := writeLevel(c.w, level, buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// mentions is the cheap pre-check: does any encrypted key appear at all?
+func (c *cryptWriter) mentions(p []byte) bool {
+	for _, f := range c.fields {
+		if bytes.Contains(p, f) {
+			return true
+		}
+	}
+	return false
+}
+
+// sealField encrypts the raw JSON value of field for pub, naming kid.
+func sealField(pub *ecdh.PublicKey, kid, field string, value []byte) (string, error) {
+	if pub == nil {
+		return "", errors.New("slogging: no EncryptionKey")
+	}
+	eph, err := pub.Curve().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	shared, err := eph.ECDH(pub)
+	if err != nil {
+		return "", err
+	}
+	aead, err := fieldAEAD(shared, eph.PublicKey().Bytes(), pub.Bytes())
+	if err != nil {
+		return "", err
+	}
+	out := append([]byte(nil), eph.PublicKey().Bytes()...)
+	nonce := make([]byte, aead.NonceSize())
+	rand.Read(nonce)
+	out = append(out, nonce...)
+	out = aead.Seal(out, nonce, value, []byte(field))
+	if kid != "" {
+		return EncryptedPrefix + kid + ":" + base64.RawStdEncoding.EncodeToString(out), nil
+	}
+	return EncryptedPrefix + base64.RawStdEncoding.EncodeToString(out), nil
+}
+
+// EncryptedKeyID returns the key ID an encrypted value names, or "" for
+// values written without Options.EncryptionKeyID.
+func EncryptedKeyID(value string) string {
+	rest, _ := strings.CutPrefix(value, EncryptedPrefix)
+	kid, _, ok := strings.Cut(rest, ":")
+	if !ok {
+		return ""
+	}
+	return kid
+}
+
+// DecryptionKeys are the private keys of an encryption key rotation, by the
+// Options.EncryptionKeyID each was used under.
+type DecryptionKeys map[string]*ecdh.PrivateKey
+
+// Decrypt opens value with the key it names. Values without a key ID, or
+// naming one that is not in k, are tried against every key.
+func (k DecryptionKeys) Decrypt(field, value string) ([]byte, error) {
+	kid := EncryptedKeyID(value)
+	if priv, ok := k[kid]; ok && kid != "" {
+		return DecryptField(priv, field, value)
+	}
+	for _, priv := range k {
+		if b, err := DecryptField(priv, field, value); err == nil {
+			return b, nil
+		}
+	}
+	if kid != "" {
+		return nil, fmt.Errorf("slogging: no decryption key %q", kid)
+	}
+	return nil, errors.New("slogging: no decryption key opens this value")
+}
+
+// DecryptField opens a value written by Options.EncryptFields and returns the
+// original JSON value (a quoted string, number, object...). field is the key
+// the value was logged under.
+func DecryptField(priv *ecdh.PrivateKey, field, value string) ([]byte, error) {
+	rest, ok := strings.CutPrefix(value, EncryptedPrefix)
+	if !ok {
+		return nil, errors.New("slogging: not an encrypted field value")
+	}
+	if _, b64, ok := strings.Cut(rest, ":"); ok {
+		rest = b64
+	}
+	raw, err := base64.RawStdEncoding.DecodeString(rest)
+	if err != nil {
+		return nil, err
+	}
+	const pubLen, nonceLen = 32, 12
+	if len(raw) < pubLen+nonceLen {
+		return nil, errors.New("slogging: encrypted field value too short")
+	}
+	ephPub, err := priv.Curve().NewPublicKey(raw[:pubLen])
+	if err != nil {
+		return nil, err
+	}
+	shared, err := priv.ECDH(ephPub)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := fieldAEAD(shared, raw[:pubLen], priv.PublicKey().Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, raw[pubLen:pubLen+nonceLen], raw[pubLen+nonceLen:], []byte(field))
+}
+
+// fieldAEAD derives the per-value AES-256-GCM key.
+func fieldAEAD(shared, ephPub, recipient []byte) (cipher.AEAD, error) {
+	h := sha256.New()
+	h.Write([]byte(fieldCryptInfo))
+	h.Write(shared)
+	h.Write(ephPub)
+	h.Write(recipient)
+	block, err := aes.NewCipher(h.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,147 @@
+package slogging
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestKey(t *testing.T) *ecdh.PrivateKey {
+	t.Helper()
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv
+}
+
+func TestEncryptFieldsRoundTrip(t *testing.T) {
+	priv := newTestKey(t)
+	c := initCapture(t, Options{Service: "svc", EncryptFields: []string{"card"}, EncryptionKey: priv.PublicKey()})
+	From(nil).Info().Str("card", "4111111111111111").Msg("charged")
+
+	evs := c.withMessage(t, "charged")
+	if len(evs) != 1 {
+		t.Fatalf("got %d events, want 1", len(evs))
+	}
+	env, _ := evs[0]["card"].(string)
+	if !strings.HasPrefix(env, EncryptedPrefix) {
+		t.Fatalf("card = %q, want an envelope", env)
+	}
+	plain, err := DecryptField(priv, "card", env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(plain) != `"4111111111111111"` {
+		t.Errorf("decrypted %s", plain)
+	}
+}
+
+func TestEncryptFieldsWithPretty(t *testing.T) {
+	priv := newTestKey(t)
+	c := initCapture(t, Options{Service: "svc", Pretty: true, EncryptFields: []string{"card"}, EncryptionKey: priv.PublicKey()})
+	From(nil).Info().Str("card", "4111111111111111").Msg("charged")
+
+	c.mu.Lock()
+	out := bytes.Join(c.lines, nil)
+	c.mu.Unlock()
+	if bytes.Contains(out, []byte("4111111111111111")) {
+		t.Fatalf("plaintext in pretty output: %s", out)
+	}
+	if !bytes.Contains(out, []byte(EncryptedPrefix)) {
+		t.Errorf("no envelope in pretty output: %s", out)
+	}
+}
+
+func TestEncryptFieldsWithoutKeyWithholds(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc", EncryptFields: []string{"card"}})
+	From(nil).Info().Str("card", "4111111111111111").Msg("charged")
+
+	evs := c.withMessage(t, "charged")
+	if len(evs) != 1 || evs[0]["card"] == "4111111111111111" {
+		t.Fatalf("card not withheld: %v", evs)
+	}
+	var warned bool
+	for _, ev := range c.events(t) {
+		warned = warned || ev[FieldSloggingEvent] == "invalid_option"
+	}
+	if !warned {
+		t.Error("no invalid_option event for EncryptFields without a key")
+	}
+}
+
+func TestEncryptionKeyRotation(t *testing.T) {
+	old, cur := newTestKey(t), newTestKey(t)
+	var envs []string
+	for _, k := range []struct {
+		id   string
+		priv *ecdh.PrivateKey
+	}{{"2025-01", old}, {"2025-06", cur}} {
+		c := initCapture(t, Options{Service: "svc", EncryptFields: []string{"card"}, EncryptionKey: k.priv.PublicKey(), EncryptionKeyID: k.id})
+		From(nil).Info().Str("card", "4111111111111111").Msg("charged")
+		Close(context.Background())
+		evs := c.withMessage(t, "charged")
+		if len(evs) != 1 {
+			t.Fatalf("got %d events, want 1", len(evs))
+		}
+		env, _ := evs[0]["card"].(string)
+		if got := EncryptedKeyID(env); got != k.id {
+			t.Errorf("key ID = %q, want %q", got, k.id)
+		}
+		envs = append(envs, env)
+	}
+
+	// Both generations open with the rotation's keys.
+	keys := DecryptionKeys{"2025-01": old, "2025-06": cur}
+	for _, env := range envs {
+		if plain, err := keys.Decrypt("card", env); err != nil || string(plain) != `"4111111111111111"` {
+			t.Errorf("Decrypt(%q) = %s, %v", env, plain, err)
+		}
+	}
+	// The value is bound to its field.
+	if _, err := keys.Decrypt("note", envs[1]); err == nil {
+		t.Error("Decrypt opened a value under another field name")
+	}
+	// Without the old key, its values stay closed.
+	if _, err := (DecryptionKeys{"2025-06": cur}).Decrypt("card", envs[0]); err == nil {
+		t.Error("Decrypt opened a value without its key")
+	}
+}
+
+func TestLoadKeyring(t *testing.T) {
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		name, file string
+		ok         bool
+	}{
+		{"valid", `{"active": "k2", "keys": {"k1": "00ff", "k2": "0102"}}`, true},
+		{"no active key", `{"active": "k3", "keys": {"k1": "00ff"}}`, false},
+		{"bad hex", `{"active": "k1", "keys": {"k1": "zz"}}`, false},
+		{"colon in ID", `{"active": "a:b", "keys": {"a:b": "00ff"}}`, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, strings.ReplaceAll(tc.name, " ", "_")+".json")
+			if err := os.WriteFile(path, []byte(tc.file), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			kr, err := LoadKeyring(path)
+			if (err == nil) != tc.ok {
+				t.Fatalf("err = %v, want ok = %v", err, tc.ok)
+			}
+			if !tc.ok {
+				return
+			}
+			if id, key, _ := kr.ActiveKey(); id != "k2" || !bytes.Equal(key, []byte{1, 2}) {
+				t.Errorf("active key = %q %x", id, key)
+			}
+			if key, ok := kr.Key("k1"); !ok || !bytes.Equal(key, []byte{0, 0xff}) {
+				t.Errorf("k1 = %x, %v; want the retired key kept", key, ok)
+			}
+		})
+	}
+}
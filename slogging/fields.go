@@ -0,0 +1,107 @@
+package slogging
+
+// Canonical field keys. Use these instead of string literals so the same
+// concept is always logged under the same name; cmd/sloglint flags literals
+// that duplicate or misspell them.
+const (
+	FieldService          = "service"
+	FieldEnv              = "env"
+	FieldVersion          = "version"
+	FieldCloud            = "cloud"
+	FieldRegion           = "region"
+	FieldZone             = "zone"
+	FieldInstanceID       = "instance_id"
+	FieldInstanceType     = "instance_type"
+	FieldComponent        = "component"
+	FieldRequestID        = "request_id"
+	FieldTraceID          = "trace_id"
+	FieldSpanID           = "span_id"
+	FieldAPIID            = "api_id"
+	FieldOperatorName     = "operator_name"
+	FieldRole             = "role"
+	FieldIPAddress        = "ip_address"
+	FieldTenant           = "tenant"
+	FieldUserID           = "user_id"
+	FieldMethod           = "method"
+	FieldPath             = "path"
+	FieldStatus           = "status"
+	FieldDurationMs       = "duration_ms"
+	FieldBytes            = "bytes"
+	FieldResponseBytes    = "response_bytes"
+	FieldContentType      = "content_type"
+	FieldContentEncoding  = "content_encoding"
+	FieldCompressionRatio = "compression_ratio"
+	FieldUserAgent        = "user_agent"
+	FieldRequestPayload   = "request_payload"
+	FieldResponsePayload  = "response_payload"
+	FieldHeaders          = "headers"
+	FieldQuery            = "query"
+	FieldCache            = "cache"
+	FieldCacheName        = "cache_name"
+	FieldCacheHit         = "cache_hit"
+	FieldDependencies     = "dependencies"
+	FieldBudgetExceeded   = "budget_exceeded"
+	FieldTopic            = "topic"
+	FieldPartition        = "partition"
+	FieldOffset           = "offset"
+	FieldConsumerLag      = "consumer_lag"
+	FieldQueueDepth       = "queue_depth"
+	FieldMessageAgeMs     = "message_age_ms"
+	FieldErrorKind        = "error_kind"
+	FieldErrorChain       = "error_chain"
+	FieldFlagKey          = "flag_key"
+	FieldFlagVariant      = "flag_variant"
+	FieldFlagReason       = "flag_reason"
+	FieldEvent            = "event"
+	FieldExperiment       = "experiment"
+	FieldVariant          = "variant"
+	FieldAction           = "action"
+	FieldTarget           = "target"
+	FieldBefore           = "before"
+	FieldAfter            = "after"
+	FieldSloggingEvent    = "slogging_event"
+	FieldSeq              = "seq"
+	FieldTimeNs           = "time_ns"
+	FieldMonoNs           = "mono_ns"
+	FieldClockSkewMs      = "clock_skew_ms"
+	FieldWorker           = "worker"
+	FieldWorkerPID        = "worker_pid"
+	FieldRateKey          = "rate_key"
+	FieldDebugTail        = "debug_tail"
+	FieldEventID          = "event_id"
+	FieldPart             = "part"
+	FieldParts            = "parts"
+	FieldPartData         = "part_data"
+	FieldTruncated        = "truncated"
+	FieldOriginalBytes    = "original_bytes"
+	FieldURL              = "url"
+	FieldRetry            = "retry"
+	FieldDB               = "db"
+	FieldSQL              = "sql"
+	FieldSQLArgs          = "sql_args"
+	FieldRowsAffected     = "rows_affected"
+	FieldSlowQuery        = "slow_query"
+	FieldParentEventID    = "parent_event_id"
+	FieldRoute            = "route"
+)
+
+// CanonicalFields lists every Field* constant, for tools such as sloglint.
+var CanonicalFields = []string{
+	FieldService, FieldEnv, FieldVersion, FieldComponent,
+	FieldCloud, FieldRegion, FieldZone, FieldInstanceID, FieldInstanceType,
+	FieldRequestID, FieldTraceID, FieldSpanID, FieldAPIID,
+	FieldOperatorName, FieldRole, FieldIPAddress, FieldTenant, FieldUserID,
+	FieldMethod, FieldPath, FieldStatus, FieldDurationMs, FieldBytes, FieldUserAgent,
+	FieldResponseBytes, FieldContentType, FieldContentEncoding, FieldCompressionRatio,
+	FieldHeaders, FieldQuery, FieldRequestPayload, FieldResponsePayload,
+	FieldCache, FieldCacheName, FieldCacheHit, FieldDependencies, FieldBudgetExceeded,
+	FieldTopic, FieldPartition, FieldOffset, FieldConsumerLag, FieldQueueDepth, FieldMessageAgeMs,
+	FieldErrorKind, FieldErrorChain, FieldFlagKey, FieldFlagVariant, FieldFlagReason,
+	FieldEvent, FieldExperiment, FieldVariant,
+	FieldAction, FieldTarget, FieldBefore, FieldAfter,
+	FieldSloggingEvent, FieldSeq, FieldTimeNs, FieldMonoNs, FieldClockSkewMs,
+	FieldWorker, FieldWorkerPID, FieldRateKey, FieldDebugTail,
+	FieldEventID, FieldPart, FieldParts, FieldPartData, FieldTruncated, FieldOriginalBytes,
+	FieldURL, FieldRetry, FieldDB, FieldSQL, FieldSQLArgs, FieldRowsAffected, FieldSlowQuery,
+	FieldParentEventID, FieldRoute,
+}
@@ -0,0 +1,67 @@
+package slogging
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrFileLocked is reported, wrapped with the holder's PID, when
+// Options.FileLock is set and another process holds the log file's lock.
+var ErrFileLocked = errors.New("slogging: log file locked by another process")
+
+// fileWriteRetries is how often a write failing with a transient error (a
+// Windows sharing violation while a reader holds the file during rotation) is
+// retried before the sink degrades.
+const fileWriteRetries = 3
+
+// fileLock is an advisory lock on "<log file>.lock", held while a sink owns
+// the file. The lock file holds the owner's PID, for the error others get.
+type fileLock struct{ f *os.File }
+
+func lockFile(path string) (*fileLock, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFD(f); err != nil {
+		b := make([]byte, 32)
+		n, _ := f.ReadAt(b, 0)
+		f.Close()
+		if errors.Is(err, ErrFileLocked) {
+			if pid := strings.TrimSpace(string(b[:n])); pid != "" {
+				return nil, fmt.Errorf("%w (pid %s, %s)", ErrFileLocked, pid, path)
+			}
+			return nil, fmt.Errorf("%w (%s)", ErrFileLocked, path)
+		}
+		return nil, err
+	}
+	f.Truncate(0)
+	f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	return &fileLock{f: f}, nil
+}
+
+func (l *fileLock) release() error {
+	unlockFD(l.f)
+	return l.f.Close()
+}
+
+// normalizeLogPath makes a configured log path safe to reuse for the life of
+// the process on any OS: "~" expands to the home directory, "/" separators
+// become the OS's, and the path is made absolute, so a later Chdir or a
+// relative Windows path cannot move the file.
+func normalizeLogPath(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, `~\`) {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = home + path[1:]
+		}
+	}
+	path = filepath.FromSlash(path)
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return filepath.Clean(path)
+}
@@ -0,0 +1,30 @@
+//go:build darwin || dragonfly || freebsd || illumos || linux || netbsd || openbsd || solaris
+
+package slogging
+
+import (
+	"errors"
+	"golang.org/x/sys/unix"
+	"os"
+)
+
+func lockFD(f *os.File) error {
+	err := unix.Flock(int(f.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+	if errors.Is(err, unix.EWOULDBLOCK) {
+		return ErrFileLocked
+	}
+	return err
+}
+
+func unlockFD(f *os.File) error {
+	return unix.Flock(int(f.Fd()), unix.LOCK_UN)
+}
+
+// transientFileErr reports whether a failed write is worth retrying at once.
+// Unix renames never fail because a file is open.
+func transientFileErr(error) bool { return false }
+
+// openShared is os.OpenFile; an open file never blocks a rename on Unix.
+func openShared(path string, flag int, perm os.FileMode) (*os.File, error) {
+	return os.OpenFile(path, flag, perm)
+}
@@ -0,0 +1,15 @@
+//go:build !(darwin || dragonfly || freebsd || illumos || linux || netbsd || openbsd || solaris || windows)
+
+package slogging
+
+import "os"
+
+// Without a locking primitive, FileLock never finds the file locked.
+func lockFD(*os.File) error   { return nil }
+func unlockFD(*os.File) error { return nil }
+
+func transientFileErr(error) bool { return false }
+
+func openShared(path string, flag int, perm os.FileMode) (*os.File, error) {
+	return os.OpenFile(path, flag, perm)
+}
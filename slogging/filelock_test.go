@@ -0,0 +1,56 @@
+package slogging
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestOpenSharedAllowsRotation checks that a log file held open by openShared
+// can still be renamed and removed, as lumberjack does when it rotates.
+func TestOpenSharedAllowsRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	w, err := openShared(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if _, err := w.Write([]byte("one\n")); err != nil {
+		t.Fatal(err)
+	}
+	r, err := openShared(path, os.O_RDONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	backup := filepath.Join(dir, "app-1.log")
+	if err := os.Rename(path, backup); err != nil {
+		t.Fatalf("rename while open: %v", err)
+	}
+	if err := os.Remove(backup); err != nil {
+		t.Fatalf("remove while open: %v", err)
+	}
+	if _, err := openShared(path, os.O_RDONLY, 0); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("opening a missing file: %v", err)
+	}
+}
+
+func TestFileLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log.lock")
+	l, err := lockFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lockFile(path); !errors.Is(err, ErrFileLocked) {
+		t.Errorf("second lock: %v, want ErrFileLocked", err)
+	}
+	l.release()
+	l2, err := lockFile(path)
+	if err != nil {
+		t.Fatalf("lock after release: %v", err)
+	}
+	l2.release()
+}
@@ -0,0 +1,78 @@
+//go:build windows
+
+package slogging
+
+import (
+	"errors"
+	"golang.org/x/sys/windows"
+	"os"
+)
+
+func lockFD(f *os.File) error {
+	var ol windows.Overlapped
+	err := windows.LockFileEx(windows.Handle(f.Fd()),
+		windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, &ol)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return ErrFileLocked
+	}
+	return err
+}
+
+func unlockFD(f *os.File) error {
+	var ol windows.Overlapped
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, &ol)
+}
+
+// transientFileErr reports whether a failed write is worth retrying at once:
+// rotation renames the file, which fails while another process (a tailer, an
+// antivirus scan) has it open without FILE_SHARE_DELETE. ACCESS_DENIED is not
+// among them: it is a permission problem that retrying does not fix.
+func transientFileErr(err error) bool {
+	return errors.Is(err, windows.ERROR_SHARING_VIOLATION) ||
+		errors.Is(err, windows.ERROR_LOCK_VIOLATION)
+}
+
+// openShared is os.OpenFile with FILE_SHARE_DELETE, which os.OpenFile leaves
+// out, so our own handles on a log file (probes, tails, compression) never
+// make lumberjack's rotation rename or remove fail. It supports the flags
+// slogging uses: O_RDONLY, O_RDWR, O_WRONLY with O_APPEND, O_CREATE and
+// O_TRUNC.
+func openShared(path string, flag int, perm os.FileMode) (*os.File, error) {
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: path, Err: err}
+	}
+	var access uint32
+	switch flag & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR) {
+	case os.O_RDONLY:
+		access = windows.GENERIC_READ
+	case os.O_WRONLY:
+		access = windows.GENERIC_WRITE
+	case os.O_RDWR:
+		access = windows.GENERIC_READ | windows.GENERIC_WRITE
+	}
+	if flag&os.O_APPEND != 0 {
+		access &^= windows.GENERIC_WRITE
+		access |= windows.FILE_APPEND_DATA
+	}
+	disposition := uint32(windows.OPEN_EXISTING)
+	switch {
+	case flag&os.O_CREATE != 0 && flag&os.O_TRUNC != 0:
+		disposition = windows.CREATE_ALWAYS
+	case flag&os.O_CREATE != 0:
+		disposition = windows.OPEN_ALWAYS
+	case flag&os.O_TRUNC != 0:
+		disposition = windows.TRUNCATE_EXISTING
+	}
+	attrs := uint32(windows.FILE_ATTRIBUTE_NORMAL)
+	if perm&0o200 == 0 {
+		attrs = windows.FILE_ATTRIBUTE_READONLY
+	}
+	h, err := windows.CreateFile(name, access,
+		windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE|windows.FILE_SHARE_DELETE,
+		nil, disposition, attrs, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: path, Err: err}
+	}
+	return os.NewFile(uintptr(h), path), nil
+}
@@ -0,0 +1,22 @@
+//go:build windows
+
+package slogging
+
+import (
+	"fmt"
+	"golang.org/x/sys/windows"
+	"testing"
+)
+
+func TestTransientFileErr(t *testing.T) {
+	for err, want := range map[error]bool{
+		windows.ERROR_SHARING_VIOLATION:                       true,
+		fmt.Errorf("write: %w", windows.ERROR_LOCK_VIOLATION): true,
+		windows.ERROR_ACCESS_DENIED:                           false,
+		windows.ERROR_DISK_FULL:                               false,
+	} {
+		if got := transientFileErr(err); got != want {
+			t.Errorf("transientFileErr(%v) = %v, want %v", err, got, want)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package slogging
+
+import (
+	"errors"
+	"github.com/natefinch/lumberjack"
+	"github.com/rs/zerolog"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultFileRetryInterval = 30 * time.Second
+
+// fileTarget is the rotating file under a fileSink.
+type fileTarget interface {
+	io.WriteCloser
+	currentPath() string // file being written right now
+}
+
+// ljTarget is a plain lumberjack file.
+type ljTarget struct{ *lumberjack.Logger }
+
+func (t ljTarget) currentPath() string { return t.Filename }
+
+// fileSink writes to a rotating file target. When the file cannot be opened or
+// written it degrades to a fallback writer, reports it once, and keeps retrying
+// in the background until the file is usable again.
+type fileSink struct {
+	lj       fileTarget
+	comp     *compressor // nil unless Options.Compress
+	fallback io.Writer
+	retry    time.Duration
+	lockPath string  // "" unless Options.FileLock
+	dir      *LogDir // Options.CreateLogDir
+	counters *selfStats
+
+	degraded atomic.Bool
+	mu       sync.Mutex // guards retrying/stop/closed/lock, and degrading
+	retrying bool
+	stop     chan struct{}
+	closed   bool
+	lock     *fileLock
+}
+
+// newRotatingFile builds a file sink at path using the rotation settings in opt.
+func newRotatingFile(path string, opt Options, fallback io.Writer, st *selfStats) *fileSink {
+	path = normalizeLogPath(path)
+	var t fileTarget
+	if opt.DailyDirs {
+		t = newDailyTarget(path, opt)
+	} else {
+		t = ljTarget{newLumberjack(path, opt)}
+	}
+	s := newFileSink(t, fallback, opt.FileRetryInterval, st)
+	s.dir = opt.CreateLogDir
+	if opt.FileLock {
+		s.lockPath = path + ".lock"
+	}
+	if opt.Compress && !opt.DailyDirs {
+		s.comp = newCompressor(t.currentPath, opt, st)
+	}
+	return s
+}
+
+func newLumberjack(path string, opt Options) *lumberjack.Logger {
+	return &lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    max(1, opt.MaxSizeMB),
+		MaxBackups: max(0, opt.MaxBackups),
+		MaxAge:     max(0, opt.MaxAgeDays),
+		// compression is done by our compressor (format choice, throttling)
+	}
+}
+
+func newFileSink(lj fileTarget, fallback io.Writer, retry time.Duration, st *selfStats) *fileSink {
+	if retry <= 0 {
+		retry = defaultFileRetryInterval
+	}
+	return &fileSink{lj: lj, fallback: fallback, retry: retry, stop: make(chan struct{}), counters: st}
+}
+
+// start probes the file once the pipeline is installed, so a degraded start is
+// reported through the new pipeline rather than the one being replaced.
+func (s *fileSink) start() {
+	if err := s.probe(); err != nil {
+		s.degrade(err)
+	}
+}
+
+// probe checks the file is writable and, with FileLock, takes the lock.
+func (s *fileSink) probe() error {
+	if err := probeFile(s.lj.currentPath(), s.dir); err != nil {
+		return err
+	}
+	if s.lockPath == "" {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lock != nil || s.closed {
+		return nil
+	}
+	l, err := lockFile(s.lockPath)
+	if err != nil {
+		return err
+	}
+	s.lock = l
+	return nil
+}
+
+func (s *fileSink) Write(p []byte) (int, error) {
+	if !s.degraded.Load() {
+		if n, err := s.writeFile(p); err == nil {
+			return n, nil
+		}
+	}
+	if s.fallback == nil {
+		s.counters.dropped.Add(1)
+		return len(p), nil
+	}
+	return s.fallback.Write(p)
+}
+
+// writeStrict writes p to the file or returns why it could not, without
+// falling back, for the audit log, whose records must not vanish silently.
+func (s *fileSink) writeStrict(p []byte) error {
+	if s.degraded.Load() {
+		return errFileDegraded
+	}
+	_, err := s.writeFile(p)
+	return err
+}
+
+var errFileDegraded = errors.New("log file unavailable, retrying in the background")
+
+// writeFile writes p to the file, retrying transient errors, and degrades
+// the sink when it still fails.
+func (s *fileSink) writeFile(p []byte) (int, error) {
+	n, err := s.lj.Write(p)
+	for i := 1; err != nil && i <= fileWriteRetries && transientFileErr(err); i++ {
+		time.Sleep(time.Duration(i) * 10 * time.Millisecond)
+		n, err = s.lj.Write(p)
+	}
+	if err == nil {
+		if s.comp != nil {
+			s.comp.kick()
+		}
+		return n, nil
+	}
+	s.counters.writeErrors.Add(1)
+	err = explainWriteErr(s.lj.currentPath(), err)
+	s.degrade(err)
+	return n, err
+}
+
+// degrade switches to the fallback writer, emits a prominent self-monitoring
+// event and starts the background retry loop (once per degradation).
+// A closed sink stays as it is: Close has already settled its count.
+func (s *fileSink) degrade(err error) {
+	s.mu.Lock()
+	if s.closed || !s.degraded.CompareAndSwap(false, true) {
+		s.mu.Unlock()
+		return
+	}
+	s.counters.degradedSinks.Add(1)
+	if s.retrying {
+		s.mu.Unlock()
+		return
+	}
+	s.retrying = true
+	s.mu.Unlock()
+
+	selfEvent(zerolog.ErrorLevel, "file_sink_degraded").
+		Str("path", s.lj.currentPath()).
+		Dur("retry_every", s.retry).
+		Err(err).
+		Msg("log file unavailable, falling back to stdout")
+	go s.retryLoop()
+}
+
+func (s *fileSink) retryLoop() {
+	t := time.NewTicker(s.retry)
+	defer t.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-t.C:
+		}
+		if s.probe() != nil {
+			continue
+		}
+		s.mu.Lock()
+		s.retrying = false
+		s.mu.Unlock()
+		if s.degraded.CompareAndSwap(true, false) {
+			s.counters.degradedSinks.Add(-1)
+		}
+		selfEvent(zerolog.WarnLevel, "file_sink_recovered").
+			Str("path", s.lj.currentPath()).
+			Msg("log file writable again, resuming file output")
+		return
+	}
+}
+
+func (s *fileSink) Close() error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.stop)
+	}
+	lock := s.lock
+	s.lock = nil
+	s.mu.Unlock()
+	if lock != nil {
+		defer lock.release()
+	}
+	if s.degraded.CompareAndSwap(true, false) {
+		s.counters.degradedSinks.Add(-1)
+	}
+	if s.comp != nil {
+		s.comp.Close()
+	}
+	return s.lj.Close()
+}
+
+// probeFile checks that path (and its directory, created as dir says) can be
+// created and appended to.
+func probeFile(path string, dir *LogDir) error {
+	if err := ensureLogDir(filepath.Dir(path), dir); err != nil {
+		return explainWriteErr(path, err)
+	}
+	f, err := openShared(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return explainWriteErr(path, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,113 @@
+package slogging
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyTarget is a file target whose writes fail while broken is set. Its
+// path is a real one, so the retry loop's probe succeeds.
+type flakyTarget struct {
+	path string
+
+	mu     sync.Mutex
+	broken bool
+	got    []string
+}
+
+func (f *flakyTarget) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.broken {
+		return 0, errors.New("input/output error")
+	}
+	f.got = append(f.got, string(p))
+	return len(p), nil
+}
+
+func (f *flakyTarget) setBroken(b bool) {
+	f.mu.Lock()
+	f.broken = b
+	f.mu.Unlock()
+}
+
+func (f *flakyTarget) written() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.got)
+}
+
+func (f *flakyTarget) Close() error        { return nil }
+func (f *flakyTarget) currentPath() string { return f.path }
+
+func TestFileSinkFallbackAndRecovery(t *testing.T) {
+	target := &flakyTarget{path: filepath.Join(t.TempDir(), "app.log"), broken: true}
+	var fallback bytes.Buffer
+	var st selfStats
+	s := newFileSink(target, &fallback, 10*time.Millisecond, &st)
+	defer s.Close()
+
+	s.Write([]byte("a\n"))
+	s.Write([]byte("b\n"))
+	if got := fallback.String(); got != "a\nb\n" {
+		t.Errorf("fallback got %q, want both events", got)
+	}
+	// Only the first write tried the file; the second went straight to the
+	// fallback.
+	if n := st.writeErrors.Load(); n != 1 {
+		t.Errorf("writeErrors = %d, want 1", n)
+	}
+	if n := st.degradedSinks.Load(); n != 1 {
+		t.Errorf("degradedSinks = %d while degraded, want 1", n)
+	}
+
+	target.setBroken(false)
+	for deadline := time.Now().Add(5 * time.Second); s.degraded.Load(); {
+		if time.Now().After(deadline) {
+			t.Fatal("sink still degraded after the file became writable")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := st.degradedSinks.Load(); n != 0 {
+		t.Errorf("degradedSinks = %d after recovery, want 0", n)
+	}
+	s.Write([]byte("c\n"))
+	if got, want := target.written(), []string{"c\n"}; !slices.Equal(got, want) {
+		t.Errorf("file got %q, want %q", got, want)
+	}
+	if got := fallback.String(); got != "a\nb\n" {
+		t.Errorf("fallback got %q after recovery, want no more events", got)
+	}
+}
+
+func TestFileSinkWithoutFallback(t *testing.T) {
+	target := &flakyTarget{path: filepath.Join(t.TempDir(), "app.log"), broken: true}
+	var st selfStats
+	s := newFileSink(target, nil, time.Hour, &st)
+	defer s.Close()
+
+	s.Write([]byte("a\n"))
+	if n := st.dropped.Load(); n != 1 {
+		t.Errorf("dropped = %d, want 1", n)
+	}
+}
+
+func TestFileSinkDegradeAfterClose(t *testing.T) {
+	target := &flakyTarget{path: filepath.Join(t.TempDir(), "app.log"), broken: true}
+	var st selfStats
+	s := newFileSink(target, nil, 10*time.Millisecond, &st)
+	s.Close()
+
+	s.Write([]byte("a\n"))
+	if n := st.degradedSinks.Load(); n != 0 {
+		t.Errorf("degradedSinks = %d after Close, want 0", n)
+	}
+	if s.degraded.Load() {
+		t.Error("closed sink degraded")
+	}
+}
@@ -0,0 +1,61 @@
+package slogging
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+)
+
+// flagSampling holds the Options.FlagSampleEvery samplers. Keys without their
+// own entry share the "*" sampler, or are all logged when there is none.
+type flagSampling struct {
+	byKey map[string]*countingSampler
+	def   *countingSampler
+}
+
+// newFlagSampling builds the samplers for cfg; they are also returned so the
+// sampling reporter includes them.
+func newFlagSampling(cfg map[string]int) (*flagSampling, []*countingSampler) {
+	f := &flagSampling{byKey: make(map[string]*countingSampler)}
+	var all []*countingSampler
+	for key, n := range cfg {
+		if n <= 1 {
+			if key != "*" {
+				f.byKey[key] = nil // listed: logged every time, not by "*"
+			}
+			continue
+		}
+		s := newCountingSampler("flag:"+key, &zerolog.BasicSampler{N: uint32(n)})
+		all = append(all, s)
+		if key == "*" {
+			f.def = s
+		} else {
+			f.byKey[key] = s
+		}
+	}
+	return f, all
+}
+
+func (f *flagSampling) sample(key string) bool {
+	s, ok := f.byKey[key]
+	if !ok {
+		s = f.def
+	}
+	return s == nil || s.Sample(zerolog.InfoLevel)
+}
+
+// FlagEvaluated is the hook for feature flag SDKs: call it from the SDK's
+// evaluation callback to log which variant ctx's request got and why. The
+// event goes through From(ctx), so it carries the request's correlation
+// fields, and Options.FlagSampleEvery thins out hot flags.
+//
+//	slogging.FlagEvaluated(ctx, "new-checkout", "treatment", "TARGETING_MATCH")
+func FlagEvaluated(ctx context.Context, key, variant, reason string) {
+	if p := current.Load(); p != nil && p.flags != nil && !p.flags.sample(key) {
+		return
+	}
+	From(ctx).Info().
+		Str(FieldFlagKey, key).
+		Str(FieldFlagVariant, variant).
+		Str(FieldFlagReason, reason).
+		Msg("feature flag evaluated")
+}
@@ -0,0 +1,28 @@
+package slogging
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFlagEvaluated(t *testing.T) {
+	c := initCapture(t, Options{FlagSampleEvery: map[string]int{"hot": 5, "*": 2, "always": 1}})
+	ctx := WithRequestID(context.Background(), "r1")
+	for _, key := range []string{"hot", "other", "always"} {
+		for range 10 {
+			FlagEvaluated(ctx, key, "treatment", "TARGETING_MATCH")
+		}
+	}
+
+	counts := map[string]int{}
+	for _, ev := range c.withMessage(t, "feature flag evaluated") {
+		counts[ev[FieldFlagKey].(string)]++
+		if ev[FieldFlagVariant] != "treatment" || ev[FieldFlagReason] != "TARGETING_MATCH" || ev[FieldRequestID] != "r1" {
+			t.Errorf("event = %v", ev)
+		}
+	}
+	if want := map[string]int{"hot": 2, "other": 5, "always": 10}; counts["hot"] != want["hot"] ||
+		counts["other"] != want["other"] || counts["always"] != want["always"] {
+		t.Errorf("events per flag = %v, want %v", counts, want)
+	}
+}
@@ -0,0 +1,215 @@
+package slogging
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"github.com/rs/zerolog"
+	"math"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GELF UDP chunking limits: Graylog's recommended datagram size and the
+// protocol's maximum chunk count.
+const (
+	gelfChunkSize = 8192
+	gelfMaxChunks = 128
+)
+
+// errGELFTooLarge is returned for an event that does not fit in 128 chunks
+// even compressed.
+var errGELFTooLarge = errors.New("slogging: GELF message exceeds 128 chunks")
+
+// gelfWriter sends each event as a GELF 1.1 message. Over UDP, messages larger
+// than one datagram are gzipped and, if still too large, chunked; over TCP
+// they are null-delimited. A failed write drops the connection and the next
+// event redials.
+type gelfWriter struct {
+	host string
+
+	mu     sync.Mutex
+	nc     netConn
+	closed bool
+}
+
+// newGELFWriter prepares a writer for addr; network defaults to "udp". It
+// does not dial until the first event.
+func newGELFWriter(network, addr string) *gelfWriter {
+	if network == "" {
+		network = "udp"
+	}
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return &gelfWriter{host: host, nc: netConn{network: network, addr: addr}}
+}
+
+func (g *gelfWriter) Write(p []byte) (int, error) {
+	return g.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (g *gelfWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	msg, err := gelfMessage(g.host, level, p)
+	if err != nil {
+		return 0, err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return 0, net.ErrClosed
+	}
+	if err := g.send(msg); err != nil {
+		if errors.Is(err, errGELFTooLarge) {
+			return 0, err
+		}
+		g.nc.drop()
+		if err := g.send(msg); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// gelfMessage converts a JSON event to GELF 1.1. message becomes
+// short_message, time the timestamp, the level a syslog severity, and every
+// other field an additional field ("request_id" -> "_request_id"). Objects and
+// arrays are sent as JSON strings and booleans as "true"/"false", since GELF
+// only has strings and numbers.
+func gelfMessage(host string, level zerolog.Level, p []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	var ev map[string]any
+	if err := dec.Decode(&ev); err != nil {
+		return nil, err
+	}
+	out := make(map[string]any, len(ev)+4)
+	out["version"] = "1.1"
+	out["host"] = host
+	out["level"] = syslogSeverity(level)
+	short, _ := ev[zerolog.MessageFieldName].(string)
+	if short == "" {
+		short = "-" // required and non-empty
+	}
+	out["short_message"] = short
+	ts := time.Now()
+	if s, ok := ev[zerolog.TimestampFieldName].(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			ts = t
+		}
+	}
+	if ns, ok := ev[FieldTimeNs].(json.Number); ok {
+		if n, err := ns.Int64(); err == nil {
+			ts = time.Unix(0, n)
+		}
+	}
+	out["timestamp"] = math.Round(float64(ts.UnixMicro())/1e3) / 1e3
+	for k, v := range ev {
+		switch k {
+		case zerolog.MessageFieldName, zerolog.TimestampFieldName, zerolog.LevelFieldName:
+			continue
+		}
+		out["_"+gelfFieldName(k)] = gelfValue(v)
+	}
+	return json.Marshal(out)
+}
+
+// gelfFieldName maps k to the [\w.-] characters GELF allows; "id" is reserved.
+func gelfFieldName(k string) string {
+	if k == "id" {
+		return "id_"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '.', r == '-':
+			return r
+		}
+		return '_'
+	}, k)
+}
+
+func gelfValue(v any) any {
+	switch v := v.(type) {
+	case string, json.Number:
+		return v
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	case nil:
+		return ""
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// send writes msg, dialing first if needed. Callers hold g.mu.
+func (g *gelfWriter) send(msg []byte) error {
+	if !g.nc.datagram() {
+		conn, err := g.nc.get()
+		if err != nil {
+			return err
+		}
+		_, err = conn.Write(append(msg, 0))
+		return err
+	}
+	if len(msg) > gelfChunkSize {
+		var b bytes.Buffer
+		zw := gzip.NewWriter(&b)
+		zw.Write(msg)
+		zw.Close()
+		msg = b.Bytes()
+	}
+	if len(msg) <= gelfChunkSize {
+		conn, err := g.nc.get()
+		if err != nil {
+			return err
+		}
+		_, err = conn.Write(msg)
+		return err
+	}
+	return g.sendChunks(msg)
+}
+
+// sendChunks splits msg into GELF chunks: magic 0x1e 0x0f, an 8-byte message
+// ID, the sequence number and count, then the data.
+func (g *gelfWriter) sendChunks(msg []byte) error {
+	const header = 12
+	data := gelfChunkSize - header
+	n := (len(msg) + data - 1) / data
+	if n > gelfMaxChunks {
+		return errGELFTooLarge
+	}
+	conn, err := g.nc.get()
+	if err != nil {
+		return err
+	}
+	var id [8]byte
+	rand.Read(id[:])
+	buf := make([]byte, 0, gelfChunkSize)
+	for i := 0; i < n; i++ {
+		part := msg[i*data : min((i+1)*data, len(msg))]
+		buf = append(buf[:0], 0x1e, 0x0f)
+		buf = append(buf, id[:]...)
+		buf = append(buf, byte(i), byte(n))
+		buf = append(buf, part...)
+		if _, err := conn.Write(buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *gelfWriter) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.closed = true
+	return g.nc.drop()
+}
@@ -0,0 +1,106 @@
+package slogging
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/rs/zerolog"
+	"io"
+	"net"
+	"slices"
+	"testing"
+	"time"
+)
+
+// readGELF reads datagrams from pc until it has n whole GELF messages,
+// reassembling chunks and decompressing them. It returns the messages and
+// the number of datagrams they took.
+func readGELF(t *testing.T, pc net.PacketConn, n int) ([]map[string]any, int) {
+	t.Helper()
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	chunks := map[string][][]byte{}
+	var out []map[string]any
+	datagrams := 0
+	for buf := make([]byte, 64<<10); len(out) < n; {
+		k, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("got %d messages before %v", len(out), err)
+		}
+		datagrams++
+		msg := bytes.Clone(buf[:k])
+		if msg[0] == 0x1e && msg[1] == 0x0f {
+			id, seq, count := string(msg[2:10]), msg[10], int(msg[11])
+			if chunks[id] == nil {
+				chunks[id] = make([][]byte, count)
+			}
+			chunks[id][seq] = msg[12:]
+			if slices.ContainsFunc(chunks[id], func(c []byte) bool { return c == nil }) {
+				continue
+			}
+			msg = bytes.Join(chunks[id], nil)
+			delete(chunks, id)
+		}
+		if msg[0] == 0x1f && msg[1] == 0x8b {
+			zr, err := gzip.NewReader(bytes.NewReader(msg))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if msg, err = io.ReadAll(zr); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var m map[string]any
+		if err := json.Unmarshal(msg, &m); err != nil {
+			t.Fatalf("bad GELF message %q: %v", msg, err)
+		}
+		out = append(out, m)
+	}
+	return out, datagrams
+}
+
+func TestGELFSink(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	initCapture(t, Options{Service: "billing", GELFAddr: pc.LocalAddr().String()})
+
+	ctx := WithRequestID(context.Background(), "r1")
+	From(ctx).Warn().Bool("retry", true).Dict("card", zerolog.Dict().Str("brand", "visa")).
+		Str("id", "o-7").Int("amount", 7).Msg("charge slow")
+	msgs, _ := readGELF(t, pc, 1)
+	m := msgs[0]
+	for k, want := range map[string]any{
+		"version": "1.1", "short_message": "charge slow", "level": 4.0,
+		"_request_id": "r1", "_service": "billing", "_retry": "true",
+		"_card": `{"brand":"visa"}`, "_id_": "o-7", "_amount": 7.0,
+	} {
+		if m[k] != want {
+			t.Errorf("%s = %#v, want %#v", k, m[k], want)
+		}
+	}
+	if ts, _ := m["timestamp"].(float64); time.Since(time.Unix(int64(ts), 0)) > time.Minute {
+		t.Errorf("timestamp = %v", m["timestamp"])
+	}
+	for _, k := range []string{"_message", "_level", "_time", "message"} {
+		if _, ok := m[k]; ok {
+			t.Errorf("%s sent as a field", k)
+		}
+	}
+
+	// An event over one datagram is gzipped and, still too large, chunked.
+	blob := make([]byte, 30<<10)
+	rand.Read(blob)
+	From(ctx).Info().Str("blob", hex.EncodeToString(blob)).Msg("big")
+	msgs, datagrams := readGELF(t, pc, 1)
+	if msgs[0]["short_message"] != "big" || msgs[0]["_blob"] != hex.EncodeToString(blob) {
+		t.Errorf("chunked message = %.200v", msgs[0])
+	}
+	if datagrams < 2 {
+		t.Errorf("a %d-byte event took %d datagram", 2*len(blob), datagrams)
+	}
+}
@@ -0,0 +1,113 @@
+// Package ginmw provides Gin middlewares that do for Gin what
+// slogging.HTTPMiddleware does for net/http: read the correlation headers,
+// store them in c.Request.Context() with the slogging With* helpers and log
+// one access line per request. It is a separate package so services without
+// Gin do not pull in its dependencies.
+//
+//	r := gin.New()
+//	r.Use(ginmw.Logger(), ginmw.Recovery())
+//	r.GET("/orders/:id", func(c *gin.Context) {
+//		slogging.From(c.Request.Context()).Info().Msg("loading order")
+//	})
+//
+// Put Logger before Recovery, so the access line of a recovered panic shows
+// its 500.
+package ginmw
+
+import (
+	"fmt"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+// Logger prepares the request context and logs one access line per request,
+// like slogging.HTTPMiddleware: it reads X-Request-ID (generating one when
+// missing), X-Trace-ID, x-operator and api_id, echoes the request ID in the
+// response and, when the handlers return, logs the fields of
+// slogging.HTTPMiddleware's access line with route, and the last error added
+// through c.Error. 5xx responses log at error level, 4xx at warn, everything
+// else at info.
+func Logger() gin.HandlerFunc {
+	return LoggerWithOptions(slogging.MiddlewareOptions{})
+}
+
+// LoggerWithOptions is Logger with the options of
+// slogging.NewHTTPMiddleware: headers and query parameters to log, and the
+// debug level or debug tail of a request.
+//
+//	r.Use(ginmw.LoggerWithOptions(slogging.MiddlewareOptions{
+//		LogHeaders: []string{"User-Agent"},
+//		DebugTail:  100,
+//	}), ginmw.Recovery())
+func LoggerWithOptions(opt slogging.MiddlewareOptions) gin.HandlerFunc {
+	a := slogging.NewAccessLog(opt)
+	return func(c *gin.Context) {
+		start := time.Now()
+		ctx := a.Begin(c.Request)
+		c.Header(slogging.HeaderRequestID, slogging.GetRequestID(ctx))
+		c.Request = c.Request.WithContext(ctx)
+		defer func() {
+			rec := recover()
+			status := c.Writer.Status()
+			if rec != nil && !c.Writer.Written() {
+				status = http.StatusInternalServerError
+			}
+			logAccess(a, c, status, start)
+			if rec != nil {
+				panic(rec) // leave recovery policy to Recovery or net/http
+			}
+		}()
+		c.Next()
+	}
+}
+
+// logAccess writes the access line for a finished request.
+func logAccess(a *slogging.AccessLog, c *gin.Context, status int, start time.Time) {
+	e := a.Event(c.Request, status, int64(max(c.Writer.Size(), 0)), c.Writer.Header(), start)
+	if e == nil {
+		return
+	}
+	if route := c.FullPath(); route != "" {
+		e.Str(slogging.FieldRoute, route)
+	}
+	if err := c.Errors.Last(); err != nil {
+		e.Err(err.Err)
+	}
+	e.Msg("request completed")
+}
+
+// Recovery turns a panic in the handlers into an error event, with the panic
+// value as "panic", the error and a stack trace, and a 500 response unless
+// one was already written. http.ErrAbortHandler is passed on, as net/http
+// uses it to abort a response without logging.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			err, ok := rec.(error)
+			if !ok {
+				err = fmt.Errorf("panic: %v", rec)
+			}
+			slogging.From(c.Request.Context()).Error().
+				Str("panic", fmt.Sprint(rec)).
+				Stack().Err(err).
+				Str(slogging.FieldMethod, c.Request.Method).
+				Str(slogging.FieldPath, c.Request.URL.Path).
+				Msg("recovered from panic")
+			if !c.Writer.Written() {
+				c.AbortWithStatus(http.StatusInternalServerError)
+				return
+			}
+			c.Abort()
+		}()
+		c.Next()
+	}
+}
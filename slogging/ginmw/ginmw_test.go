@@ -0,0 +1,158 @@
+package ginmw_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"github.com/dinhtatuanlinh/source_logging/slogging/ginmw"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// lines is an ExtraWriter that keeps every event.
+type lines struct {
+	mu  sync.Mutex
+	buf [][]byte
+}
+
+func (l *lines) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	l.buf = append(l.buf, bytes.Clone(p))
+	l.mu.Unlock()
+	return len(p), nil
+}
+
+// withMessage returns the decoded events whose message is msg.
+func (l *lines) withMessage(t *testing.T, msg string) []map[string]any {
+	t.Helper()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var out []map[string]any
+	for _, b := range l.buf {
+		var ev map[string]any
+		if err := json.Unmarshal(b, &ev); err != nil {
+			t.Fatalf("non-JSON event %q: %v", b, err)
+		}
+		if ev["message"] == msg {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+func capture(t *testing.T, level string) *lines {
+	l := &lines{}
+	slogging.Init(slogging.Options{Service: "svc", Level: level, FilePath: filepath.Join(t.TempDir(), "app.log"), ExtraWriter: l})
+	t.Cleanup(func() { slogging.Close(context.Background()) })
+	return l
+}
+
+func serve(r *gin.Engine, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	l := capture(t, "info")
+	r := gin.New()
+	r.Use(ginmw.LoggerWithOptions(slogging.MiddlewareOptions{LogHeaders: []string{"User-Agent", "Authorization"}}))
+	r.GET("/orders/:id", func(c *gin.Context) {
+		slogging.From(c.Request.Context()).Info().Msg("loading order")
+		c.Error(errors.New("order locked"))
+		c.JSON(http.StatusConflict, gin.H{"id": c.Param("id")})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/7", nil)
+	req.Header.Set(slogging.HeaderRequestID, "r1")
+	req.Header.Set("User-Agent", "curl/8")
+	req.Header.Set("Authorization", "Bearer x")
+	if got := serve(r, req).Header().Get(slogging.HeaderRequestID); got != "r1" {
+		t.Errorf("echoed request ID = %q", got)
+	}
+
+	if evs := l.withMessage(t, "loading order"); len(evs) != 1 || evs[0][slogging.FieldRequestID] != "r1" {
+		t.Fatalf("handler events = %v", evs)
+	}
+	evs := l.withMessage(t, "request completed")
+	if len(evs) != 1 {
+		t.Fatalf("got %d access lines, want 1", len(evs))
+	}
+	ev := evs[0]
+	for k, want := range map[string]any{
+		"level":                     "warn",
+		slogging.FieldRequestID:     "r1",
+		slogging.FieldMethod:        "GET",
+		slogging.FieldPath:          "/orders/7",
+		slogging.FieldRoute:         "/orders/:id",
+		slogging.FieldStatus:        float64(http.StatusConflict),
+		slogging.FieldBytes:         float64(len(`{"id":"7"}`)),
+		slogging.FieldResponseBytes: float64(len(`{"id":"7"}`)),
+		slogging.FieldContentType:   "application/json; charset=utf-8",
+		"error":                     "order locked",
+	} {
+		if ev[k] != want {
+			t.Errorf("%s = %v, want %v", k, ev[k], want)
+		}
+	}
+	headers, _ := ev[slogging.FieldHeaders].(map[string]any)
+	if headers["user-agent"] != "curl/8" || headers["authorization"] != nil {
+		t.Errorf("headers = %v", ev[slogging.FieldHeaders])
+	}
+}
+
+func TestLoggerRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	capture(t, "info")
+	r := gin.New()
+	r.Use(ginmw.Logger())
+	r.GET("/", func(c *gin.Context) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	long := strings.Repeat("a", 200)
+	req.Header.Set(slogging.HeaderRequestID, long)
+	if got := serve(r, req).Header().Get(slogging.HeaderRequestID); got == "" || got == long {
+		t.Errorf("request ID = %q, want a generated one", got)
+	}
+}
+
+func TestLoggerDebugTail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	l := capture(t, "info")
+	r := gin.New()
+	r.Use(ginmw.LoggerWithOptions(slogging.MiddlewareOptions{DebugTail: 10}), ginmw.Recovery())
+	r.GET("/ok", func(c *gin.Context) {
+		slogging.From(c.Request.Context()).Debug().Msg("ok detail")
+	})
+	r.GET("/panic", func(c *gin.Context) {
+		slogging.From(c.Request.Context()).Debug().Msg("panic detail")
+		panic("boom")
+	})
+
+	serve(r, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	if w := serve(r, httptest.NewRequest(http.MethodGet, "/panic", nil)); w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", w.Code)
+	}
+
+	if evs := l.withMessage(t, "ok detail"); len(evs) != 0 {
+		t.Errorf("debug tail of a successful request written: %v", evs)
+	}
+	if evs := l.withMessage(t, "panic detail"); len(evs) != 1 || evs[0][slogging.FieldDebugTail] != true {
+		t.Errorf("debug tail of the failed request = %v", evs)
+	}
+	if evs := l.withMessage(t, "recovered from panic"); len(evs) != 1 || evs[0]["panic"] != "boom" {
+		t.Errorf("recovery events = %v", evs)
+	}
+	access := l.withMessage(t, "request completed")
+	if len(access) != 2 || access[1][slogging.FieldStatus] != float64(http.StatusInternalServerError) || access[1]["level"] != "error" {
+		t.Errorf("access lines = %v", access)
+	}
+}
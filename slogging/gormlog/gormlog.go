@@ -0,0 +1,159 @@
+// Package gormlog is a GORM logger (gorm.io/gorm/logger.Interface) that logs
+// through slogging.From(ctx), so GORM's SQL traces, slow query warnings and
+// errors carry request_id, api_id and the rest of the request's fields, under
+// the field names slogging/sqllog uses:
+//
+//	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+//		Logger: gormlog.New(gormlog.Options{
+//			SlowThreshold:        200 * time.Millisecond,
+//			IgnoreRecordNotFound: true,
+//		}),
+//	})
+//
+// It is a separate package so services without GORM do not pull it in.
+package gormlog
+
+import (
+	"context"
+	"errors"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+	"strings"
+	"time"
+)
+
+// Options configures New.
+type Options struct {
+	// Name, when set, is logged as "db".
+	Name string
+	// LogLevel is GORM's: logger.Silent, Error, Warn (default) or Info.
+	// Info adds a debug event per statement; slogging's level still
+	// applies on top.
+	LogLevel logger.LogLevel
+	// Statements taking SlowThreshold or longer log at warn with
+	// "slow_query": true; 0 turns this off.
+	SlowThreshold time.Duration
+	// IgnoreRecordNotFound does not log gorm.ErrRecordNotFound as an
+	// error; with LogLevel Info the statement is still traced.
+	IgnoreRecordNotFound bool
+	// InlineValues logs statements with the argument values in place of
+	// the placeholders. They are left out by default, as sqllog does, since
+	// they often hold passwords, tokens and personal data.
+	InlineValues bool
+}
+
+// New returns a GORM logger writing through slogging.
+func New(opt Options) logger.Interface {
+	if opt.LogLevel == 0 {
+		opt.LogLevel = logger.Warn
+	}
+	return &gormLogger{opt: opt}
+}
+
+type gormLogger struct {
+	opt Options
+}
+
+// LogMode returns a copy of the logger at level, as db.Debug() asks for.
+func (l *gormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	c := *l
+	c.opt.LogLevel = level
+	return &c
+}
+
+func (l *gormLogger) Info(ctx context.Context, msg string, data ...any) {
+	if l.opt.LogLevel >= logger.Info {
+		l.message(ctx, zerolog.InfoLevel, utils.FileWithLineNum(), msg, data)
+	}
+}
+
+func (l *gormLogger) Warn(ctx context.Context, msg string, data ...any) {
+	if l.opt.LogLevel >= logger.Warn {
+		l.message(ctx, zerolog.WarnLevel, utils.FileWithLineNum(), msg, data)
+	}
+}
+
+func (l *gormLogger) Error(ctx context.Context, msg string, data ...any) {
+	if l.opt.LogLevel >= logger.Error {
+		l.message(ctx, zerolog.ErrorLevel, utils.FileWithLineNum(), msg, data)
+	}
+}
+
+// message logs one of GORM's messages. caller is taken by Info, Warn and
+// Error, which FileWithLineNum expects to be called from.
+func (l *gormLogger) message(ctx context.Context, level zerolog.Level, caller, msg string, data []any) {
+	e := slogging.From(ctx).WithLevel(level)
+	if e == nil {
+		return
+	}
+	l.db(e).Str(zerolog.CallerFieldName, caller).Msgf(msg, data...)
+}
+
+// Trace logs one statement: at error when it failed, at warn when slow, and
+// at debug with LogLevel Info.
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.opt.LogLevel <= logger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	notFound := errors.Is(err, logger.ErrRecordNotFound) && l.opt.IgnoreRecordNotFound
+	slow := l.opt.SlowThreshold > 0 && elapsed >= l.opt.SlowThreshold
+	var level zerolog.Level
+	switch {
+	case err != nil && !notFound && l.opt.LogLevel >= logger.Error:
+		level = zerolog.ErrorLevel
+	case slow && l.opt.LogLevel >= logger.Warn:
+		level = zerolog.WarnLevel
+	case l.opt.LogLevel >= logger.Info:
+		level = zerolog.DebugLevel
+	default:
+		return
+	}
+	e := slogging.From(ctx).WithLevel(level)
+	if e == nil {
+		return
+	}
+	sql, rows := fc()
+	l.db(e).Str(slogging.FieldSQL, sql)
+	if rows >= 0 {
+		e.Int64(slogging.FieldRowsAffected, rows)
+	}
+	if slow {
+		e.Bool(slogging.FieldSlowQuery, true)
+	}
+	if err != nil && !notFound {
+		e.Err(err)
+	}
+	e.Float64(slogging.FieldDurationMs, float64(elapsed.Microseconds())/1000).
+		Str(zerolog.CallerFieldName, utils.FileWithLineNum()).
+		Msg("sql " + statement(sql))
+}
+
+// ParamsFilter implements GORM's optional gorm.ParamsFilter, through which
+// it asks for the statement to log.
+func (l *gormLogger) ParamsFilter(ctx context.Context, sql string, params ...any) (string, []any) {
+	if l.opt.InlineValues {
+		return sql, params
+	}
+	return sql, nil
+}
+
+func (l *gormLogger) db(e *zerolog.Event) *zerolog.Event {
+	if l.opt.Name != "" {
+		e.Str(slogging.FieldDB, l.opt.Name)
+	}
+	return e
+}
+
+// statement returns "query" for statements that read, like sqllog's events,
+// and "exec" for the rest.
+func statement(sql string) string {
+	verb, _, _ := strings.Cut(strings.TrimSpace(sql), " ")
+	switch strings.ToLower(verb) {
+	case "select", "with", "show", "explain":
+		return "query"
+	}
+	return "exec"
+}
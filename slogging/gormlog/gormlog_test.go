@@ -0,0 +1,80 @@
+package gormlog_test
+
+import (
+	"context"
+	"errors"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"github.com/dinhtatuanlinh/source_logging/slogging/gormlog"
+	"github.com/dinhtatuanlinh/source_logging/slogging/sloggingtest"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/utils/tests"
+	"strings"
+	"testing"
+	"time"
+)
+
+type user struct {
+	ID       uint
+	Name     string
+	Password string
+}
+
+// dryRun opens a database that builds statements without running them, and
+// saves one user through it.
+func dryRun(t *testing.T, l logger.Interface, ctx context.Context) {
+	t.Helper()
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{Logger: l, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.WithContext(ctx).Create(&user{Name: "ann", Password: "hunter2"})
+}
+
+func TestTraceLeavesValuesOut(t *testing.T) {
+	m := sloggingtest.NewMock(t)
+	ctx := slogging.WithRequestID(m.Context(context.Background()), "r1")
+	dryRun(t, gormlog.New(gormlog.Options{Name: "main", LogLevel: logger.Info}), ctx)
+
+	m.ExpectDebug().WithMessage("sql exec").
+		WithField(slogging.FieldDB, "main").
+		WithField(slogging.FieldRequestID, "r1").
+		Times(1)
+	for _, e := range m.Events() {
+		sql, _ := e[slogging.FieldSQL].(string)
+		if !strings.Contains(sql, "INSERT INTO") || strings.Contains(sql, "hunter2") {
+			t.Errorf("sql = %q, want the statement without its values", sql)
+		}
+	}
+}
+
+func TestTraceInlineValues(t *testing.T) {
+	m := sloggingtest.NewMock(t)
+	dryRun(t, gormlog.New(gormlog.Options{LogLevel: logger.Info, InlineValues: true}), m.Context(context.Background()))
+
+	events := m.Events()
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	if sql, _ := events[0][slogging.FieldSQL].(string); !strings.Contains(sql, `"hunter2"`) {
+		t.Errorf("sql = %q, want the values inlined", sql)
+	}
+}
+
+func TestTraceLevels(t *testing.T) {
+	m := sloggingtest.NewMock(t)
+	ctx := m.Context(context.Background())
+	l := gormlog.New(gormlog.Options{SlowThreshold: 100 * time.Millisecond, IgnoreRecordNotFound: true})
+	stmt := func() (string, int64) { return "SELECT * FROM users WHERE id = ?", 1 }
+
+	l.Trace(ctx, time.Now(), stmt, nil)                                    // fast, below LogLevel Info
+	l.Trace(ctx, time.Now(), stmt, logger.ErrRecordNotFound)               // ignored
+	l.Trace(ctx, time.Now().Add(-time.Second), stmt, nil)                  // slow
+	l.Trace(ctx, time.Now(), stmt, errors.New("connection reset by peer")) // failed
+
+	m.ExpectWarn().WithMessage("sql query").WithField(slogging.FieldSlowQuery, true).Times(1)
+	m.ExpectError().WithMessage("sql query").WithField("error", "connection reset by peer").Times(1)
+	if n := len(m.Events()); n != 2 {
+		t.Errorf("got %d events, want 2", n)
+	}
+}
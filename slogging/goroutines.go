@@ -0,0 +1,209 @@
+package slogging
+
+import (
+	"bytes"
+	"context"
+	"github.com/rs/zerolog"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Limits that keep a goroutine dump a single reasonably sized event.
+const (
+	maxDumpBytes  = 32 << 20 // raw runtime.Stack buffer
+	maxDumpGroups = 50       // distinct stacks reported, largest groups first
+	maxDumpFrames = 32       // frames per stack
+)
+
+// goroutineGroup is a set of goroutines parked on the same stack.
+type goroutineGroup struct {
+	count   int
+	states  map[string]int
+	frames  []string
+	longest string // longest wait reported by the runtime, e.g. "12 minutes"
+}
+
+func (g *goroutineGroup) MarshalZerologObject(e *zerolog.Event) {
+	states := make([]string, 0, len(g.states))
+	for s := range g.states {
+		states = append(states, s)
+	}
+	sort.Strings(states)
+	e.Int("count", g.count).Strs("states", states)
+	if g.longest != "" {
+		e.Str("longest_wait", g.longest)
+	}
+	e.Strs("frames", g.frames)
+}
+
+type goroutineGroups []*goroutineGroup
+
+func (gs goroutineGroups) MarshalZerologArray(a *zerolog.Array) {
+	for _, g := range gs {
+		a.Object(g)
+	}
+}
+
+// DumpGoroutines logs every goroutine's stack as one structured event
+// ("goroutine_dump"), grouped by identical stack and largest group first,
+// so a stuck pool of workers shows up as one entry with its count. The dump
+// goes through the pipeline like any other event instead of raw stderr.
+// Fields of ctx's logger are attached; reason says why the dump was taken.
+func DumpGoroutines(ctx context.Context, reason string) {
+	groups, total, truncated := goroutineDump()
+	l := selfLogger()
+	if ctx != nil {
+		if cl := ctxLogger(ctx); cl != nil {
+			l = cl
+		}
+	}
+	selfEventOn(l, zerolog.WarnLevel, "goroutine_dump").
+		Str("reason", reason).
+		Int("goroutines", total).
+		Int("stack_groups", len(groups)).
+		Bool("truncated", truncated).
+		Array("stacks", groups).
+		Msgf("goroutine dump: %d goroutines in %d distinct stacks", total, len(groups))
+}
+
+// DumpHandler is an admin endpoint that triggers DumpGoroutines; mount it
+// behind your admin auth. The optional "reason" query parameter is logged.
+func DumpHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reason := r.URL.Query().Get("reason")
+		if reason == "" {
+			reason = "admin request"
+		}
+		DumpGoroutines(r.Context(), reason)
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+// goroutineDump captures and groups all goroutine stacks.
+func goroutineDump() (groups goroutineGroups, total int, truncated bool) {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		if len(buf) >= maxDumpBytes {
+			truncated = true
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	byStack := make(map[string]*goroutineGroup)
+	for _, block := range bytes.Split(buf, []byte("\n\n")) {
+		header, body, _ := strings.Cut(string(block), "\n")
+		if !strings.HasPrefix(header, "goroutine ") {
+			continue
+		}
+		total++
+		state, wait := goroutineState(header)
+		frames := stackFrames(body)
+		key := strings.Join(frames, "\n")
+		g, ok := byStack[key]
+		if !ok {
+			g = &goroutineGroup{states: make(map[string]int), frames: frames}
+			byStack[key] = g
+			groups = append(groups, g)
+		}
+		g.count++
+		g.states[state]++
+		if waitMinutes(wait) > waitMinutes(g.longest) {
+			g.longest = wait
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].count > groups[j].count })
+	if len(groups) > maxDumpGroups {
+		groups, truncated = groups[:maxDumpGroups], true
+	}
+	return groups, total, truncated
+}
+
+// goroutineState parses "goroutine 7 [chan receive, 3 minutes]:".
+func goroutineState(header string) (state, wait string) {
+	_, rest, ok := strings.Cut(header, "[")
+	if !ok {
+		return "unknown", ""
+	}
+	rest, _, _ = strings.Cut(rest, "]")
+	state, wait, _ = strings.Cut(rest, ", ")
+	if strings.Contains(state, "locked to thread") {
+		state, wait = wait, ""
+	}
+	return state, strings.TrimSuffix(wait, ", locked to thread")
+}
+
+func waitMinutes(wait string) int {
+	n, _ := strconv.Atoi(strings.TrimSuffix(wait, " minutes"))
+	return n
+}
+
+// stackFrames reduces runtime.Stack frame pairs to "func file:line", dropping
+// argument values and PC offsets so identical stacks group together.
+func stackFrames(body string) []string {
+	lines := strings.Split(strings.TrimRight(body, "\n"), "\n")
+	var frames []string
+	for i := 0; i < len(lines); i++ {
+		fn := strings.TrimSpace(lines[i])
+		if fn == "" {
+			continue
+		}
+		if j := strings.LastIndexByte(fn, '('); j > 0 && !strings.HasPrefix(fn, "created by ") {
+			fn = fn[:j]
+		}
+		fn, _, _ = strings.Cut(fn, " in goroutine ")
+		loc := ""
+		if i+1 < len(lines) && strings.HasPrefix(lines[i+1], "\t") {
+			i++
+			loc, _, _ = strings.Cut(strings.TrimSpace(lines[i]), " +0x")
+		}
+		if len(frames) == maxDumpFrames {
+			frames = append(frames, "...")
+			break
+		}
+		frames = append(frames, strings.TrimSpace(fn+" "+loc))
+	}
+	return frames
+}
+
+// dumpOnSignal calls DumpGoroutines whenever one of dumpSignals arrives,
+// until Close. Unlike SIGQUIT the process keeps running.
+type dumpOnSignal struct {
+	ch   chan os.Signal
+	done chan struct{}
+}
+
+func startDumpOnSignal() *dumpOnSignal {
+	d := &dumpOnSignal{ch: make(chan os.Signal, 1), done: make(chan struct{})}
+	if len(dumpSignals) == 0 {
+		close(d.done)
+		return d
+	}
+	signal.Notify(d.ch, dumpSignals...)
+	go func() {
+		defer close(d.done)
+		for sig := range d.ch {
+			DumpGoroutines(context.Background(), "signal "+sig.String())
+		}
+	}()
+	return d
+}
+
+func (d *dumpOnSignal) Close() error {
+	if len(dumpSignals) > 0 {
+		signal.Stop(d.ch)
+		close(d.ch)
+	}
+	<-d.done
+	return nil
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package slogging
+
+import "os"
+
+// dumpSignals is empty where SIGUSR1 does not exist; use DumpHandler instead.
+var dumpSignals []os.Signal
@@ -0,0 +1,64 @@
+package slogging
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// parkedWorker blocks until release is closed, so a dump shows several
+// goroutines on one stack.
+func parkedWorker(release chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	<-release
+}
+
+func TestDumpHandlerGroupsStacks(t *testing.T) {
+	c := initCapture(t, Options{})
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go parkedWorker(release, &wg)
+	}
+	defer wg.Wait()
+	defer close(release)
+	eventually(t, "the workers to park", func() bool {
+		groups, _, _ := goroutineDump()
+		for _, g := range groups {
+			if g.states["chan receive"] == 5 {
+				return true
+			}
+		}
+		return false
+	})
+
+	rec := httptest.NewRecorder()
+	DumpHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/debug/dump?reason=stuck", nil))
+	if rec.Code != http.StatusAccepted {
+		t.Errorf("status = %d, want 202", rec.Code)
+	}
+
+	evs := c.selfEvents(t, "goroutine_dump")
+	if len(evs) != 1 {
+		t.Fatalf("got %d dumps, want 1", len(evs))
+	}
+	ev := evs[0]
+	if ev["reason"] != "stuck" || ev["goroutines"].(float64) < 6 {
+		t.Errorf("dump = reason %v, %v goroutines", ev["reason"], ev["goroutines"])
+	}
+	for _, s := range ev["stacks"].([]any) {
+		g := s.(map[string]any)
+		for _, f := range g["frames"].([]any) {
+			if strings.Contains(f.(string), "slogging.parkedWorker ") {
+				if g["count"] != float64(5) || g["states"].([]any)[0] != "chan receive" {
+					t.Errorf("parked group = %v, want 5 in chan receive", g)
+				}
+				return
+			}
+		}
+	}
+	t.Errorf("no group for parkedWorker in %v", ev["stacks"])
+}
@@ -0,0 +1,11 @@
+//go:build unix
+
+package slogging
+
+import (
+	"os"
+	"syscall"
+)
+
+// dumpSignals trigger a goroutine dump when Options.GoroutineDumpSignal is set.
+var dumpSignals = []os.Signal{syscall.SIGUSR1}
@@ -0,0 +1,211 @@
+// Package grpcmw provides gRPC server interceptors that do for gRPC what
+// slogging.HTTPMiddleware does for net/http: read the correlation metadata,
+// store it in the context with the slogging With* helpers and log one line
+// per call. It is a separate package so services without gRPC do not pull in
+// its dependencies.
+//
+//	srv := grpc.NewServer(
+//		grpc.UnaryInterceptor(grpcmw.UnaryServerInterceptor()),
+//		grpc.StreamInterceptor(grpcmw.StreamServerInterceptor()),
+//	)
+//
+// The client interceptors record each outgoing call with
+// slogging.RecordDependency, so the caller's access line lists what it called,
+// and with slogging.TrackRetries for retry-storm detection:
+//
+//	conn, err := grpc.NewClient(target,
+//		grpc.WithUnaryInterceptor(grpcmw.UnaryClientInterceptor()),
+//		grpc.WithStreamInterceptor(grpcmw.StreamClientInterceptor()),
+//	)
+package grpcmw
+
+import (
+	"context"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"strings"
+	"time"
+)
+
+// Metadata keys read from incoming calls. gRPC lower-cases metadata keys, so
+// these are the HTTP header names slogging uses, lower-cased.
+var (
+	MDRequestID = strings.ToLower(slogging.HeaderRequestID)
+	MDTraceID   = strings.ToLower(slogging.HeaderTraceID)
+	MDOperator  = strings.ToLower(slogging.XOperator)
+	MDAPIID     = strings.ToLower(slogging.APIID)
+)
+
+// UnaryServerInterceptor prepares the context of each unary call and logs its
+// method, status code and duration when the handler returns.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		ctx = withMetadata(ctx)
+		resp, err := handler(ctx, req)
+		logCall(ctx, info.FullMethod, err, start)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor is UnaryServerInterceptor for streaming calls; the
+// line is logged when the stream handler returns.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx := withMetadata(ss.Context())
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		logCall(ctx, info.FullMethod, err, start)
+		return err
+	}
+}
+
+// withMetadata stores the incoming correlation metadata in ctx, generating a
+// request ID when the caller sent none, and starts the call's request stats.
+func withMetadata(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = slogging.WithRequestID(ctx, slogging.RequestIDFromHeader(first(md, MDRequestID)))
+	if v := first(md, MDTraceID); v != "" {
+		ctx = slogging.WithTraceID(ctx, v)
+	}
+	if v := first(md, MDOperator); v != "" {
+		ctx = slogging.WithOperatorName(ctx, v)
+	}
+	if v := first(md, MDAPIID); v != "" {
+		ctx = slogging.WithAPIID(ctx, v)
+	}
+	return slogging.WithRequestStats(ctx)
+}
+
+// logCall writes the completion line. Codes that indicate a server fault log
+// at error level, other non-OK codes at warn.
+func logCall(ctx context.Context, method string, err error, start time.Time) {
+	code := status.Code(err)
+	level := zerolog.InfoLevel
+	switch {
+	case code == codes.OK:
+	case serverFault(code):
+		level = zerolog.ErrorLevel
+	default:
+		level = zerolog.WarnLevel
+	}
+	e := slogging.From(ctx).WithLevel(level).
+		Str(slogging.FieldMethod, method).
+		Str("grpc_code", code.String()).
+		Float64(slogging.FieldDurationMs, float64(time.Since(start).Microseconds())/1000)
+	if err != nil {
+		e = e.Err(err)
+	}
+	slogging.AppendRequestStats(ctx, e).Msg("call completed")
+}
+
+// serverFault reports codes that indicate a fault on the serving side rather
+// than in the request.
+func serverFault(code codes.Code) bool {
+	switch code {
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable, codes.DeadlineExceeded, codes.Unimplemented:
+		return true
+	}
+	return false
+}
+
+// UnaryClientInterceptor records each call as a dependency of the current
+// request (see slogging.RecordDependency); server-fault codes count as errors.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return NewUnaryClientInterceptor(ClientOptions{})
+}
+
+// ClientOptions configures NewUnaryClientInterceptor.
+type ClientOptions struct {
+	// Payloads logs the request and response messages, as protojson, of a
+	// sample of calls per host.
+	Payloads slogging.PayloadSampling
+}
+
+// NewUnaryClientInterceptor is UnaryClientInterceptor with options.
+func NewUnaryClientInterceptor(opt ClientOptions) grpc.UnaryClientInterceptor {
+	sample := opt.Payloads.Enabled()
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		recordCall(ctx, cc.Target(), method, err, start)
+		if sample {
+			if host := hostOf(cc.Target()); opt.Payloads.Sample(host) {
+				var resp []byte
+				if err == nil {
+					resp = protoJSON(reply)
+				}
+				opt.Payloads.LogPayload(ctx, host, method, protoJSON(req), resp)
+			}
+		}
+		return err
+	}
+}
+
+// protoJSON renders a message for PayloadSampling; nil if m is not one.
+func protoJSON(m any) []byte {
+	pm, ok := m.(proto.Message)
+	if !ok {
+		return nil
+	}
+	b, err := protojson.Marshal(pm)
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// StreamClientInterceptor is UnaryClientInterceptor for streaming calls; the
+// dependency is recorded when the stream is established, so latency_ms is the
+// setup time.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := time.Now()
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		recordCall(ctx, cc.Target(), method, err, start)
+		return cs, err
+	}
+}
+
+// recordCall maps a target such as "dns:///payments:443" and a method such as
+// "/billing.Payments/Charge" to host "payments:443" and service
+// "billing.Payments".
+func recordCall(ctx context.Context, target, method string, err error, start time.Time) {
+	target = hostOf(target)
+	service := strings.TrimPrefix(method, "/")
+	if i := strings.LastIndex(service, "/"); i >= 0 {
+		service = service[:i]
+	}
+	failed := err != nil && serverFault(status.Code(err))
+	slogging.RecordDependency(ctx, target, service, time.Since(start), failed)
+	slogging.TrackRetries(ctx, target, method, failed)
+}
+
+// hostOf strips the resolver scheme from a dial target.
+func hostOf(target string) string {
+	if i := strings.LastIndex(target, "/"); i >= 0 {
+		return target[i+1:]
+	}
+	return target
+}
+
+func first(md metadata.MD, key string) string {
+	if v := md.Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// serverStream overrides Context so handlers see the enriched context.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context { return s.ctx }
@@ -0,0 +1,127 @@
+package grpcmw_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"github.com/dinhtatuanlinh/source_logging/slogging/grpcmw"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// lines is an ExtraWriter that keeps every event.
+type lines struct {
+	mu  sync.Mutex
+	buf [][]byte
+}
+
+func (l *lines) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	l.buf = append(l.buf, bytes.Clone(p))
+	l.mu.Unlock()
+	return len(p), nil
+}
+
+// calls returns the decoded "call completed" events.
+func (l *lines) calls(t *testing.T) []map[string]any {
+	t.Helper()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var out []map[string]any
+	for _, b := range l.buf {
+		var ev map[string]any
+		if err := json.Unmarshal(b, &ev); err != nil {
+			t.Fatalf("non-JSON event %q: %v", b, err)
+		}
+		if ev["message"] == "call completed" {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+func capture(t *testing.T) *lines {
+	l := &lines{}
+	slogging.Init(slogging.Options{Service: "svc", FilePath: filepath.Join(t.TempDir(), "app.log"), ExtraWriter: l})
+	t.Cleanup(func() { slogging.Close(context.Background()) })
+	return l
+}
+
+func incoming(kv ...string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(kv...))
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	l := capture(t)
+	icpt := grpcmw.UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/billing.Payments/Charge"}
+
+	var seen string
+	ctx := incoming(grpcmw.MDRequestID, "req-1", grpcmw.MDTraceID, "trace-1")
+	_, err := icpt(ctx, nil, info, func(ctx context.Context, _ any) (any, error) {
+		seen = slogging.GetRequestID(ctx)
+		return nil, status.Error(codes.Internal, "db down")
+	})
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("err = %v", err)
+	}
+	if seen != "req-1" {
+		t.Errorf("handler saw request ID %q, want req-1", seen)
+	}
+
+	long := strings.Repeat("x", 129)
+	icpt(incoming(grpcmw.MDRequestID, long), nil, info, func(ctx context.Context, _ any) (any, error) {
+		seen = slogging.GetRequestID(ctx)
+		return nil, status.Error(codes.NotFound, "no such order")
+	})
+	if seen == long || seen == "" {
+		t.Errorf("over-long request ID was kept or dropped: %q", seen)
+	}
+
+	evs := l.calls(t)
+	if len(evs) != 2 {
+		t.Fatalf("got %d call lines, want 2", len(evs))
+	}
+	for i, want := range []map[string]any{
+		{"level": "error", "grpc_code": "Internal", slogging.FieldRequestID: "req-1", slogging.FieldTraceID: "trace-1", slogging.FieldMethod: info.FullMethod},
+		{"level": "warn", "grpc_code": "NotFound", slogging.FieldRequestID: seen},
+	} {
+		for k, v := range want {
+			if evs[i][k] != v {
+				t.Errorf("call %d: %s = %v, want %v", i, k, evs[i][k], v)
+			}
+		}
+	}
+}
+
+type stream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s stream) Context() context.Context { return s.ctx }
+
+func TestStreamServerInterceptor(t *testing.T) {
+	l := capture(t)
+	icpt := grpcmw.StreamServerInterceptor()
+	info := &grpc.StreamServerInfo{FullMethod: "/billing.Payments/Watch"}
+	var seen string
+	err := icpt(nil, stream{ctx: incoming(grpcmw.MDRequestID, "req-2")}, info, func(_ any, ss grpc.ServerStream) error {
+		seen = slogging.GetRequestID(ss.Context())
+		return nil
+	})
+	if err != nil || seen != "req-2" {
+		t.Fatalf("err = %v, handler saw request ID %q", err, seen)
+	}
+	evs := l.calls(t)
+	if len(evs) != 1 || evs[0]["level"] != "info" || evs[0]["grpc_code"] != "OK" || evs[0][slogging.FieldRequestID] != "req-2" {
+		t.Errorf("call lines = %v", evs)
+	}
+}
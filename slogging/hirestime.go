@@ -0,0 +1,22 @@
+package slogging
+
+import (
+	"github.com/rs/zerolog"
+	"time"
+)
+
+// processStart anchors mono_ns. time.Since uses the monotonic clock reading it
+// carries, so wall-clock steps (NTP, manual changes) do not affect deltas.
+var processStart = time.Now()
+
+// hiResHook adds the Options.HighResTime fields: time_ns, the wall clock in
+// Unix nanoseconds, and mono_ns, monotonic nanoseconds since process start.
+// The RFC3339 "time" field is left as is so existing consumers keep working.
+type hiResHook struct{}
+
+// Run implements zerolog.Hook.
+func (hiResHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	now := time.Now()
+	e.Int64(FieldTimeNs, now.UnixNano()).
+		Int64(FieldMonoNs, int64(now.Sub(processStart)))
+}
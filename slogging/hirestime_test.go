@@ -0,0 +1,54 @@
+package slogging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestHighResTime(t *testing.T) {
+	c := initCapture(t, Options{HighResTime: true})
+	before := time.Now().UnixNano()
+	From(context.Background()).Info().Msg("first")
+	From(context.Background()).Info().Msg("second")
+	after := time.Now().UnixNano()
+
+	c.mu.Lock()
+	lines := c.lines
+	c.mu.Unlock()
+	var wall, mono []int64
+	for _, l := range lines {
+		var ev map[string]any
+		dec := json.NewDecoder(bytes.NewReader(l))
+		dec.UseNumber() // nanoseconds do not fit a float64
+		if err := dec.Decode(&ev); err != nil {
+			t.Fatal(err)
+		}
+		if ev["message"] != "first" && ev["message"] != "second" {
+			continue
+		}
+		if ev["time"] == nil {
+			t.Error("time field missing")
+		}
+		ns, err1 := strconv.ParseInt(string(ev[FieldTimeNs].(json.Number)), 10, 64)
+		mn, err2 := strconv.ParseInt(string(ev[FieldMonoNs].(json.Number)), 10, 64)
+		if err1 != nil || err2 != nil {
+			t.Fatalf("time_ns = %v, mono_ns = %v", ev[FieldTimeNs], ev[FieldMonoNs])
+		}
+		wall, mono = append(wall, ns), append(mono, mn)
+	}
+	if len(wall) != 2 {
+		t.Fatalf("got %d events, want 2", len(wall))
+	}
+	for _, ns := range wall {
+		if ns < before || ns > after {
+			t.Errorf("time_ns = %d, want within [%d, %d]", ns, before, after)
+		}
+	}
+	if mono[0] <= 0 || mono[1] < mono[0] {
+		t.Errorf("mono_ns = %v, want positive and non-decreasing", mono)
+	}
+}
@@ -0,0 +1,173 @@
+package slogging
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"sync"
+	"sync/atomic"
+)
+
+// Instance is a configured logger. Default is the one Init configures and the
+// package-level functions (From, With, IntoContext, SetLevel, Component,
+// GetStats, Pressure, WithRequestStats, ...) use; NewLogger makes independent
+// ones, for a second stream such as an audit log next to the application log,
+// or for tests that must not share state:
+//
+//	audit, err := slogging.NewLogger(slogging.Options{Service: "billing", FilePath: "/var/log/audit.log"})
+//	...
+//	defer audit.Close(ctx)
+//	audit.From(ctx).Info().Str("action", "refund").Msg("refund approved")
+//
+// Each Instance has its own sinks, level, component levels, self-monitoring
+// counters and request stats, and zerolog's global level is left alone, so
+// instances never filter or count each other's events. The context helpers
+// (WithRequestID, WithRequestLevel, ...) are shared: an Instance's From adds
+// the correlation IDs and request level they put on ctx.
+type Instance struct {
+	mu       sync.Mutex // serializes Init, InitOnce and Close
+	current  atomic.Pointer[pipeline]
+	stats    selfStats
+	pressure pressureMarks
+}
+
+// Default returns the Instance Init configures.
+func Default() *Instance { return std }
+
+// NewLogger builds an independent Instance from opt. Unlike Init it rejects a
+// Level it cannot parse instead of falling back to info.
+func NewLogger(opt Options) (*Instance, error) {
+	if opt.Level != "" {
+		lvl, err := parseLevel(opt.Level)
+		if err != nil {
+			return nil, err
+		}
+		opt.Level = lvl.String()
+	}
+	i := &Instance{}
+	i.Init(opt)
+	return i, nil
+}
+
+// Init configures the instance from opt, replacing and closing the writers of
+// its previous configuration, as the package-level Init does for Default.
+func (i *Instance) Init(opt Options) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i == std {
+		stopConfigWatch() // an explicit Init takes over from InitFromFile
+	}
+	i.install(build(i, opt))
+}
+
+// disabled is what a closed Instance other than Default logs to.
+var disabled = zerolog.Nop()
+
+// global returns i's installed logger. Before Init, and after Close, Default
+// falls back to zerolog's log.Logger and other instances discard events.
+func (i *Instance) global() *zerolog.Logger {
+	if p := i.current.Load(); p != nil {
+		return &p.logger
+	}
+	if i == std {
+		return &log.Logger
+	}
+	return &disabled
+}
+
+// Logger returns the instance's logger.
+func (i *Instance) Logger() *zerolog.Logger {
+	return i.global()
+}
+
+// With returns a child logger with more fields.
+func (i *Instance) With(kv ...any) zerolog.Logger {
+	return withFields(i.global().With(), kv...).Logger()
+}
+
+// instanceKey stores the logger of an Instance other than Default in a
+// context, apart from the one zerolog keeps for Default, so one request can
+// carry both.
+type instanceKey struct{ i *Instance }
+
+// stored returns the logger IntoContext stored in ctx for i, or nil.
+func (i *Instance) stored(ctx context.Context) *zerolog.Logger {
+	if i == std {
+		return ctxLogger(ctx)
+	}
+	l, _ := ctx.Value(instanceKey{i}).(*zerolog.Logger)
+	return l
+}
+
+// store returns ctx carrying l as i's logger.
+func (i *Instance) store(ctx context.Context, l zerolog.Logger) context.Context {
+	if i == std {
+		return l.WithContext(ctx) // ✅ store under zerolog's key
+	}
+	return context.WithValue(ctx, instanceKey{i}, &l)
+}
+
+// IntoContext stores the instance's logger, with kv added, in ctx. With
+// OTELCorrelation, the active span's trace_id is stored too unless ctx
+// already has one.
+func (i *Instance) IntoContext(ctx context.Context, kv ...any) context.Context {
+	if tid, _, ok := spanIDs(i.current.Load(), ctx); ok && GetTraceID(ctx) == "" {
+		ctx = context.WithValue(ctx, ctxTraceIDKey, tid)
+		kv = append([]any{FieldTraceID, tid}, kv...)
+	}
+	base := i.stored(ctx)
+	if base == nil {
+		base = i.global()
+	}
+	return i.store(ctx, withFields(base.With(), kv...).Logger())
+}
+
+// From returns the instance's logger for ctx: the one IntoContext stored, or
+// the instance's own.
+func (i *Instance) From(ctx context.Context) *zerolog.Logger {
+	return i.from(ctx, 1)
+}
+
+// from is From; skip is the number of frames between the caller of the
+// exported function and from, for the bare-context warning.
+func (i *Instance) from(ctx context.Context, skip int) *zerolog.Logger {
+	p := i.current.Load()
+	if ctx == nil {
+		checkBareContext(p, ctx, skip+1)
+		return i.global()
+	}
+	if l := i.stored(ctx); l != nil { // ← zerolog-native context lookup for Default
+		return withEventCtx(p, i.scoped(p, l, ctx), ctx)
+	}
+	// (optional) compat path if you still have old code that used your custom key:
+	if l, ok := ctx.Value(ctxLoggerKey).(*zerolog.Logger); ok && l != nil && i == std {
+		return withEventCtx(p, l, ctx)
+	}
+	checkBareContext(p, ctx, skip+1)
+	return withEventCtx(p, i.scoped(p, i.global(), ctx), ctx)
+}
+
+// scoped adds to l, for an Instance other than Default, what the With*
+// helpers and WithRequestLevel put on ctx, which Default's loggers already
+// carry: the correlation IDs and the request level.
+func (i *Instance) scoped(p *pipeline, l *zerolog.Logger, ctx context.Context) *zerolog.Logger {
+	if i == std || p == nil {
+		return l
+	}
+	c := l.With()
+	for _, id := range [...][2]string{
+		{FieldRequestID, GetRequestID(ctx)},
+		{FieldTraceID, GetTraceID(ctx)},
+		{FieldAPIID, GetAPIID(ctx)},
+		{FieldOperatorName, GetOperatorID(ctx)},
+	} {
+		if id[1] != "" {
+			c = c.Str(id[0], id[1])
+		}
+	}
+	ll := c.Logger()
+	if lvl, ok := requestLevel(ctx); ok {
+		ll = p.requestLogger(ctx, &ll, lvl)
+	}
+	return &ll
+}
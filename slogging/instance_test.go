@@ -0,0 +1,179 @@
+package slogging
+
+import (
+	"context"
+	"errors"
+	"github.com/rs/zerolog"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestInstanceLogsBelowInitLevel(t *testing.T) {
+	global := zerolog.GlobalLevel()
+	def := initCapture(t, Options{Service: "svc", Level: "warn"})
+	inst := &eventCapture{}
+	audit, err := NewLogger(Options{Service: "audit", Level: "debug", FilePath: filepath.Join(t.TempDir(), "audit.log"), ExtraWriter: inst})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := WithRequestID(context.Background(), "r1")
+	audit.From(ctx).Debug().Msg("instance debug")
+	From(ctx).Debug().Msg("default debug")
+	From(ctx).Warn().Msg("default warn")
+
+	if evs := inst.withMessage(t, "instance debug"); len(evs) != 1 || evs[0][FieldRequestID] != "r1" || evs[0][FieldService] != "audit" {
+		t.Errorf("instance events = %v", inst.events(t))
+	}
+	if len(def.withMessage(t, "default debug")) != 0 || len(def.withMessage(t, "default warn")) != 1 {
+		t.Errorf("default events = %v", def.events(t))
+	}
+	if len(def.withMessage(t, "instance debug")) != 0 || len(inst.withMessage(t, "default warn")) != 0 {
+		t.Error("events crossed between the loggers")
+	}
+
+	if err := audit.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if lvl := zerolog.GlobalLevel(); lvl != global {
+		t.Errorf("zerolog's global level changed from %s to %s", global, lvl)
+	}
+}
+
+func TestInstanceSurvivesReInit(t *testing.T) {
+	initCapture(t, Options{Service: "svc", Level: "error"})
+	inst := &eventCapture{}
+	audit, err := NewLogger(Options{Service: "audit", Level: "info", FilePath: filepath.Join(t.TempDir(), "audit.log"), ExtraWriter: inst})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer audit.Close(context.Background())
+
+	initCapture(t, Options{Service: "svc", Level: "error"})
+	if err := SetLevel("error"); err != nil {
+		t.Fatal(err)
+	}
+	audit.Logger().Info().Msg("after re-Init")
+	if len(inst.withMessage(t, "after re-Init")) != 1 {
+		t.Errorf("instance events = %v", inst.events(t))
+	}
+}
+
+func TestNewLoggerRejectsBadLevel(t *testing.T) {
+	if _, err := NewLogger(Options{Level: "loud"}); err == nil {
+		t.Error(`NewLogger accepted level "loud"`)
+	}
+}
+
+// newInstance returns an Instance logging to a capture, closed at the end of
+// the test.
+func newInstance(t *testing.T, opt Options) (*Instance, *eventCapture) {
+	t.Helper()
+	c := &eventCapture{}
+	opt.FilePath = filepath.Join(t.TempDir(), "test.log")
+	opt.ExtraWriter = c
+	i, err := NewLogger(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { i.Close(context.Background()) })
+	return i, c
+}
+
+// TestInstancesAreIndependent runs instances side by side, as parallel tests
+// would: levels, component levels and stats of one never reach the others.
+func TestInstancesAreIndependent(t *testing.T) {
+	for _, level := range []string{"debug", "info", "warn", "error"} {
+		t.Run(level, func(t *testing.T) {
+			t.Parallel()
+			i, c := newInstance(t, Options{
+				Service:         level,
+				Level:           level,
+				ComponentLevels: map[string]string{"repo": level},
+				Routes:          []Route{{MinLevel: "error", Writer: failingWriter{errors.New("boom")}}},
+			})
+			lvl, _ := zerolog.ParseLevel(level)
+			ctx := i.IntoContext(WithRequestID(context.Background(), "r-"+level))
+			repo := i.Component("repo")
+			for l := zerolog.DebugLevel; l <= zerolog.ErrorLevel; l++ {
+				i.From(ctx).WithLevel(l).Msg("base " + l.String())
+				repo.From(ctx).WithLevel(l).Msg("repo " + l.String())
+			}
+			if err := i.SetLevel("error"); err != nil {
+				t.Fatal(err)
+			}
+			if err := i.SetComponentLevel("repo", "debug"); err != nil {
+				t.Fatal(err)
+			}
+			i.From(ctx).Warn().Msg("after SetLevel")
+			repo.Logger().Debug().Msg("repo after SetComponentLevel")
+
+			for l := zerolog.DebugLevel; l <= zerolog.ErrorLevel; l++ {
+				want := 0
+				if l >= lvl {
+					want = 1
+				}
+				for _, msg := range []string{"base " + l.String(), "repo " + l.String()} {
+					evs := c.withMessage(t, msg)
+					if len(evs) != want {
+						t.Errorf("%q: %d events, want %d", msg, len(evs), want)
+					}
+					for _, ev := range evs {
+						if ev[FieldService] != level || ev[FieldRequestID] != "r-"+level {
+							t.Errorf("%q came from another instance: %v", msg, ev)
+						}
+					}
+				}
+			}
+			if len(c.withMessage(t, "after SetLevel")) != 0 || len(c.withMessage(t, "repo after SetComponentLevel")) != 1 {
+				t.Errorf("runtime level changes not applied: %v", c.events(t))
+			}
+			if got := i.ComponentLevels()["repo"]; got != "debug" {
+				t.Errorf("ComponentLevels()[repo] = %q", got)
+			}
+			// Two error events went to the failing route.
+			if s := i.Stats(); s.WriteErrors != 2 {
+				t.Errorf("WriteErrors = %d, want 2", s.WriteErrors)
+			}
+		})
+	}
+}
+
+func TestInstanceLeavesDefaultAlone(t *testing.T) {
+	def := initCapture(t, Options{Service: "svc", Level: "info"})
+	before := GetStats()
+	i, c := newInstance(t, Options{Service: "other", Level: "debug", Routes: []Route{{MinLevel: "info", Writer: failingWriter{errors.New("boom")}}}})
+	if err := i.SetLevel("trace"); err != nil {
+		t.Fatal(err)
+	}
+	i.Logger().Info().Msg("instance info")
+	From(context.Background()).Debug().Msg("default debug")
+
+	// The route failed for level_changed and for the info event.
+	if GetStats().WriteErrors != before.WriteErrors || i.Stats().WriteErrors != 2 {
+		t.Errorf("stats: default %+v, instance %+v", GetStats(), i.Stats())
+	}
+	if Default().level() != zerolog.InfoLevel {
+		t.Errorf("default level = %s, want info", Default().level())
+	}
+	if len(def.withMessage(t, "default debug")) != 0 || len(c.withMessage(t, "instance info")) != 1 {
+		t.Errorf("default events %v, instance events %v", def.events(t), c.events(t))
+	}
+}
+
+func TestInstanceRequestStats(t *testing.T) {
+	def := initCapture(t, Options{Service: "svc", RetryStormThreshold: 2})
+	i, c := newInstance(t, Options{Service: "other", RetryStormThreshold: 2, RetryStormWindow: time.Minute})
+	ctx := i.WithRequestStats(WithRequestID(context.Background(), "r1"))
+	TrackRetries(ctx, "db", "query", true)
+	for range 2 {
+		if n := TrackRetries(ctx, "db", "query", true); n == 0 {
+			t.Error("retry not counted")
+		}
+	}
+	const storm = "client retries exceed RetryStormThreshold; check the retry policy"
+	if len(c.withMessage(t, storm)) != 1 || len(def.withMessage(t, storm)) != 0 {
+		t.Errorf("instance events %v, default events %v", c.events(t), def.events(t))
+	}
+}
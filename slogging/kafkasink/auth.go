@@ -0,0 +1,142 @@
+package kafkasink
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/dinhtatuanlinh/source_logging/slogging/sink"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+	"os"
+)
+
+// SASL configures SASL authentication. The password is a sink.Secret, read
+// on every new broker connection, so a rotated password is picked up without
+// restarting the service:
+//
+//	SASL: &kafkasink.SASL{
+//		Mechanism: "SCRAM-SHA-512",
+//		Username:  "billing",
+//		Password:  sink.FileSecret("/var/run/secrets/kafka/password"),
+//	}
+type SASL struct {
+	// Mechanism is "PLAIN" (the default), "SCRAM-SHA-256" or "SCRAM-SHA-512".
+	Mechanism string
+	Username  string
+	Password  sink.Secret
+}
+
+// TLS configures the TLS connection to the brokers. The client certificate
+// and key are PEM sink.Secrets, read on every handshake; leave both nil when
+// the brokers do not ask for a client certificate.
+type TLS struct {
+	// CAFile is a PEM bundle of the CAs that signed the brokers' certificates;
+	// empty uses the system roots.
+	CAFile     string
+	ServerName string
+	Cert       sink.Secret
+	Key        sink.Secret
+}
+
+func newTransport(s *SASL, t *TLS) (*kafka.Transport, error) {
+	tr := &kafka.Transport{}
+	if s != nil {
+		m, err := s.mechanism()
+		if err != nil {
+			return nil, err
+		}
+		tr.SASL = m
+	}
+	if t != nil {
+		c, err := t.config()
+		if err != nil {
+			return nil, err
+		}
+		tr.TLS = c
+	}
+	return tr, nil
+}
+
+func (s *SASL) mechanism() (sasl.Mechanism, error) {
+	if s.Username == "" || s.Password == nil {
+		return nil, errors.New("kafkasink: SASL needs Username and Password")
+	}
+	m := secretMechanism{name: s.Mechanism, user: s.Username, pass: s.Password}
+	switch s.Mechanism {
+	case "", "PLAIN":
+		m.name = plain.Mechanism{}.Name()
+	case "SCRAM-SHA-256":
+		m.algo = scram.SHA256
+	case "SCRAM-SHA-512":
+		m.algo = scram.SHA512
+	default:
+		return nil, fmt.Errorf("kafkasink: SASL mechanism %q: want PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512", s.Mechanism)
+	}
+	return m, nil
+}
+
+// secretMechanism reads the password when a connection authenticates and
+// hands off to kafka-go's PLAIN or SCRAM mechanism.
+type secretMechanism struct {
+	name string
+	algo scram.Algorithm // nil for PLAIN
+	user string
+	pass sink.Secret
+}
+
+func (m secretMechanism) Name() string { return m.name }
+
+func (m secretMechanism) Start(ctx context.Context) (sasl.StateMachine, []byte, error) {
+	pass, err := m.pass.Value(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("kafkasink: SASL password: %w", err)
+	}
+	if m.algo == nil {
+		return plain.Mechanism{Username: m.user, Password: pass}.Start(ctx)
+	}
+	inner, err := scram.Mechanism(m.algo, m.user, pass)
+	if err != nil {
+		return nil, nil, err
+	}
+	return inner.Start(ctx)
+}
+
+func (t *TLS) config() (*tls.Config, error) {
+	c := &tls.Config{ServerName: t.ServerName, MinVersion: tls.VersionTLS12}
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("kafkasink: TLS CAFile: %w", err)
+		}
+		c.RootCAs = x509.NewCertPool()
+		if !c.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("kafkasink: TLS CAFile %s: no certificates", t.CAFile)
+		}
+	}
+	switch {
+	case t.Cert == nil && t.Key == nil:
+	case t.Cert == nil || t.Key == nil:
+		return nil, errors.New("kafkasink: TLS needs both Cert and Key")
+	default:
+		c.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := t.Cert.Value(context.Background())
+			if err != nil {
+				return nil, fmt.Errorf("kafkasink: TLS Cert: %w", err)
+			}
+			key, err := t.Key.Value(context.Background())
+			if err != nil {
+				return nil, fmt.Errorf("kafkasink: TLS Key: %w", err)
+			}
+			pair, err := tls.X509KeyPair([]byte(cert), []byte(key))
+			if err != nil {
+				return nil, fmt.Errorf("kafkasink: TLS client certificate: %w", err)
+			}
+			return &pair, nil
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,72 @@
+package kafkasink
+
+import (
+	"context"
+	"errors"
+	"github.com/dinhtatuanlinh/source_logging/slogging/sink"
+	"strings"
+	"testing"
+)
+
+func TestSASLReadsPasswordPerConnection(t *testing.T) {
+	pass := "first"
+	m, err := (&SASL{Username: "billing", Password: sink.SecretFunc(func(context.Context) (string, error) {
+		return pass, nil
+	})}).mechanism()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Name() != "PLAIN" {
+		t.Errorf("Name() = %q, want PLAIN", m.Name())
+	}
+	for _, want := range []string{"first", "second"} {
+		pass = want
+		_, ir, err := m.Start(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(ir); got != "\x00billing\x00"+want {
+			t.Errorf("initial response = %q, want password %q", got, want)
+		}
+	}
+}
+
+func TestSASLPasswordError(t *testing.T) {
+	boom := errors.New("vault sealed")
+	m, err := (&SASL{Mechanism: "SCRAM-SHA-512", Username: "billing", Password: sink.SecretFunc(func(context.Context) (string, error) {
+		return "", boom
+	})}).mechanism()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Name() != "SCRAM-SHA-512" {
+		t.Errorf("Name() = %q", m.Name())
+	}
+	if _, _, err := m.Start(context.Background()); !errors.Is(err, boom) {
+		t.Errorf("Start error = %v, want %v", err, boom)
+	}
+}
+
+func TestAuthConfigErrors(t *testing.T) {
+	for name, cfg := range map[string]Config{
+		"no password":    {SASL: &SASL{Username: "u"}},
+		"bad mechanism":  {SASL: &SASL{Mechanism: "GSSAPI", Username: "u", Password: sink.StaticSecret("p")}},
+		"key only":       {TLS: &TLS{Key: sink.StaticSecret("k")}},
+		"missing CAFile": {TLS: &TLS{CAFile: "/nonexistent/ca.pem"}},
+	} {
+		cfg.Brokers, cfg.Topic = []string{"localhost:9092"}, "logs"
+		if _, err := NewWriter(cfg); err == nil || !strings.HasPrefix(err.Error(), "kafkasink: ") {
+			t.Errorf("%s: NewWriter error = %v", name, err)
+		}
+	}
+}
+
+func TestTLSClientCertificateError(t *testing.T) {
+	c, err := (&TLS{Cert: sink.StaticSecret("not pem"), Key: sink.StaticSecret("not pem")}).config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetClientCertificate(nil); err == nil {
+		t.Error("GetClientCertificate accepted an invalid key pair")
+	}
+}
@@ -0,0 +1,172 @@
+// Package kafkasink publishes slogging events to a Kafka topic, e.g. to feed
+// error-level events to an alerting consumer without running a log shipper.
+// It is a separate package so services without Kafka do not pull in its
+// client.
+//
+//	w, err := kafkasink.NewWriter(kafkasink.Config{
+//		Brokers:  []string{"kafka-1:9092", "kafka-2:9092"},
+//		Topic:    "alerts.logs",
+//		MinLevel: "error",
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	slogging.Init(slogging.Options{Service: "billing", ExtraWriter: w})
+//	defer slogging.Close(ctx) // closes w too
+//
+// Events are batched, retried and, when the queue is full, dropped by a
+// sink.Batcher; Stats reports how many. Each message is keyed by the event's
+// request_id, so one request's events land on one partition in order.
+package kafkasink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"github.com/dinhtatuanlinh/source_logging/slogging/sink"
+	"github.com/rs/zerolog"
+	"github.com/segmentio/kafka-go"
+	"time"
+)
+
+// Config configures a Writer.
+type Config struct {
+	Brokers []string
+	Topic   string
+	// MinLevel publishes only events at or above this level; empty publishes
+	// every event.
+	MinLevel string
+	// KeyField is the event field used as message key (default request_id).
+	// Events without it are spread over partitions round-robin.
+	KeyField string
+	// SASL authenticates to the brokers; nil connects without SASL.
+	SASL *SASL
+	// TLS connects over TLS; nil connects in plaintext.
+	TLS *TLS
+	// Transport replaces the transport built from SASL and TLS, for settings
+	// they do not cover; it cannot be combined with them. nil uses
+	// kafka.DefaultTransport.
+	Transport kafka.RoundTripper
+	// RequiredAcks defaults to kafka.RequireOne.
+	RequiredAcks kafka.RequiredAcks
+	// Batch configures batching, queue size, retries, breaker and WAL.
+	Batch sink.Options
+}
+
+// Writer is an io.Writer and zerolog.LevelWriter publishing to Kafka from a
+// background goroutine. Writes never block.
+type Writer struct {
+	b   *sink.Batcher
+	kw  *kafka.Writer
+	min zerolog.Level
+	key string
+}
+
+// NewWriter validates cfg and starts a Writer. It does not contact the
+// brokers; connection errors surface as failed batches (Batch.OnError, Stats).
+func NewWriter(cfg Config) (*Writer, error) {
+	if len(cfg.Brokers) == 0 || cfg.Topic == "" {
+		return nil, errors.New("kafkasink: Brokers and Topic are required")
+	}
+	minLevel := zerolog.TraceLevel
+	if cfg.MinLevel != "" {
+		l, err := zerolog.ParseLevel(cfg.MinLevel)
+		if err != nil || l == zerolog.NoLevel {
+			return nil, fmt.Errorf("kafkasink: MinLevel %q: unknown level", cfg.MinLevel)
+		}
+		minLevel = l
+	}
+	transport := cfg.Transport
+	if cfg.SASL != nil || cfg.TLS != nil {
+		if transport != nil {
+			return nil, errors.New("kafkasink: Transport cannot be combined with SASL or TLS")
+		}
+		t, err := newTransport(cfg.SASL, cfg.TLS)
+		if err != nil {
+			return nil, err
+		}
+		transport = t
+	}
+	if cfg.KeyField == "" {
+		cfg.KeyField = slogging.FieldRequestID
+	}
+	if cfg.RequiredAcks == kafka.RequireNone {
+		cfg.RequiredAcks = kafka.RequireOne
+	}
+	if cfg.Batch.MaxEvents <= 0 {
+		cfg.Batch.MaxEvents = 500
+	}
+	w := &Writer{
+		kw: &kafka.Writer{
+			Addr:         kafka.TCP(cfg.Brokers...),
+			Topic:        cfg.Topic,
+			Balancer:     &kafka.Hash{},
+			Transport:    transport,
+			RequiredAcks: cfg.RequiredAcks,
+			MaxAttempts:  1, // the Batcher retries
+			BatchSize:    cfg.Batch.MaxEvents,
+			BatchTimeout: 10 * time.Millisecond,
+		},
+		min: minLevel,
+		key: cfg.KeyField,
+	}
+	w.b = sink.NewBatcher(sink.SenderFunc(w.send), cfg.Batch)
+	return w, nil
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	return w.b.Write(p)
+}
+
+// WriteLevel implements zerolog.LevelWriter, dropping events below MinLevel.
+// Events without a level are published.
+func (w *Writer) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if level < w.min && level != zerolog.NoLevel {
+		return len(p), nil
+	}
+	return w.b.Write(p)
+}
+
+// Stats returns the Batcher's counters.
+func (w *Writer) Stats() sink.Stats { return w.b.Stats() }
+
+// Flush publishes everything queued so far and waits for it, or for ctx.
+func (w *Writer) Flush(ctx context.Context) error { return w.b.Flush(ctx) }
+
+// Close publishes what is queued and closes the Kafka client.
+func (w *Writer) Close() error {
+	w.b.Close()
+	return w.kw.Close()
+}
+
+func (w *Writer) send(ctx context.Context, batch [][]byte) error {
+	msgs := make([]kafka.Message, len(batch))
+	for i, ev := range batch {
+		value := ev
+		if n := len(value); n > 0 && value[n-1] == '\n' {
+			value = value[:n-1]
+		}
+		msgs[i] = kafka.Message{Key: w.keyOf(value), Value: value}
+	}
+	err := w.kw.WriteMessages(ctx, msgs...)
+	var tooLarge kafka.MessageTooLargeError
+	if errors.As(err, &tooLarge) {
+		return sink.Permanent(err)
+	}
+	return err
+}
+
+// keyOf returns the key field of a JSON event, nil when absent or not a string.
+func (w *Writer) keyOf(ev []byte) []byte {
+	var m map[string]json.RawMessage
+	if json.Unmarshal(ev, &m) != nil {
+		return nil
+	}
+	var s string
+	if json.Unmarshal(m[w.key], &s) != nil || s == "" {
+		return nil
+	}
+	return []byte(s)
+}
@@ -0,0 +1,153 @@
+package kafkasink
+
+import (
+	"context"
+	"errors"
+	"github.com/dinhtatuanlinh/source_logging/slogging/sink"
+	"github.com/rs/zerolog"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol"
+	"github.com/segmentio/kafka-go/protocol/metadata"
+	"github.com/segmentio/kafka-go/protocol/produce"
+	"io"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// record is one message the fake broker accepted.
+type record struct {
+	partition  int32
+	key, value string
+}
+
+// fakeBroker is a kafka.RoundTripper serving one topic with three partitions
+// from memory.
+type fakeBroker struct {
+	topic string
+
+	mu      sync.Mutex
+	records []record
+}
+
+func (b *fakeBroker) RoundTrip(ctx context.Context, addr net.Addr, req protocol.Message) (protocol.Message, error) {
+	switch r := req.(type) {
+	case *metadata.Request:
+		res := &metadata.Response{
+			Brokers: []metadata.ResponseBroker{{NodeID: 1, Host: "localhost", Port: 9092}},
+			Topics:  []metadata.ResponseTopic{{Name: b.topic}},
+		}
+		for i := range int32(3) {
+			res.Topics[0].Partitions = append(res.Topics[0].Partitions, metadata.ResponsePartition{PartitionIndex: i, LeaderID: 1})
+		}
+		return res, nil
+	case *produce.Request:
+		res := &produce.Response{}
+		for _, t := range r.Topics {
+			rt := produce.ResponseTopic{Topic: t.Topic}
+			for _, p := range t.Partitions {
+				if err := b.store(p.Partition, p.RecordSet.Records); err != nil {
+					return nil, err
+				}
+				rt.Partitions = append(rt.Partitions, produce.ResponsePartition{Partition: p.Partition})
+			}
+			res.Topics = append(res.Topics, rt)
+		}
+		return res, nil
+	}
+	return nil, errors.New("fake broker: unexpected request")
+}
+
+func (b *fakeBroker) store(partition int32, rr protocol.RecordReader) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for {
+		rec, err := rr.ReadRecord()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		key, _ := protocol.ReadAll(rec.Key)
+		value, _ := protocol.ReadAll(rec.Value)
+		b.records = append(b.records, record{partition, string(key), string(value)})
+	}
+}
+
+func (b *fakeBroker) received() []record {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return slices.Clone(b.records)
+}
+
+func TestWriterPublishes(t *testing.T) {
+	broker := &fakeBroker{topic: "alerts.logs"}
+	w, err := NewWriter(Config{
+		Brokers:   []string{"kafka-1:9092"},
+		Topic:     "alerts.logs",
+		MinLevel:  "warn",
+		Transport: broker,
+		Batch:     sink.Options{FlushInterval: 10 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	events := []struct {
+		level zerolog.Level
+		line  string
+	}{
+		{zerolog.InfoLevel, `{"level":"info","request_id":"r1","message":"below MinLevel"}`},
+		{zerolog.WarnLevel, `{"level":"warn","request_id":"r1","message":"retrying"}`},
+		{zerolog.ErrorLevel, `{"level":"error","request_id":"r1","message":"gave up"}`},
+		{zerolog.ErrorLevel, `{"level":"error","message":"no request"}`},
+	}
+	for _, ev := range events {
+		w.WriteLevel(ev.level, []byte(ev.line+"\n"))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := w.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Messages are ordered within a partition only.
+	byValue := map[string]record{}
+	for _, rec := range broker.received() {
+		byValue[rec.value] = rec
+	}
+	if len(byValue) != 3 {
+		t.Fatalf("broker got %v, want the 3 events at warn and above", broker.received())
+	}
+	warn, failed, other := byValue[events[1].line], byValue[events[2].line], byValue[events[3].line]
+	// One request's events share a key, and so a partition, in order.
+	if warn.key != "r1" || failed.key != "r1" || warn.partition != failed.partition {
+		t.Errorf("request r1 published as %+v and %+v", warn, failed)
+	}
+	if got := broker.received(); slices.IndexFunc(got, func(r record) bool { return r == warn }) > slices.IndexFunc(got, func(r record) bool { return r == failed }) {
+		t.Errorf("request r1's events out of order: %v", got)
+	}
+	if other.value == "" || other.key != "" {
+		t.Errorf("event without request_id published as %+v", other)
+	}
+	if st := w.Stats(); st.Sent != 3 || st.Dropped != 0 {
+		t.Errorf("Stats = %+v, want 3 sent", st)
+	}
+}
+
+func TestNewWriterValidates(t *testing.T) {
+	for name, cfg := range map[string]Config{
+		"no brokers":         {Topic: "t"},
+		"no topic":           {Brokers: []string{"b:9092"}},
+		"unknown level":      {Brokers: []string{"b:9092"}, Topic: "t", MinLevel: "loud"},
+		"transport and SASL": {Brokers: []string{"b:9092"}, Topic: "t", Transport: kafka.DefaultTransport, SASL: &SASL{Username: "u"}},
+	} {
+		if _, err := NewWriter(cfg); err == nil {
+			t.Errorf("%s: NewWriter accepted %+v", name, cfg)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package slogging
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Keyring holds versioned secret keys for keyed features such as stable
+// pseudonyms in redact. Records name the key that produced them, so keys
+// rotate by adding a new one and switching Active, while the old ones stay in
+// the ring to verify what they produced.
+type Keyring struct {
+	Active string            // ID of the key new records use
+	Keys   map[string][]byte // by ID; IDs may not contain ':'
+}
+
+// ActiveKey returns the active key and its ID.
+func (k Keyring) ActiveKey() (id string, key []byte, err error) {
+	key, ok := k.Keys[k.Active]
+	if !ok || len(key) == 0 {
+		return "", nil, fmt.Errorf("slogging: keyring has no key for active ID %q", k.Active)
+	}
+	if strings.Contains(k.Active, ":") {
+		return "", nil, fmt.Errorf("slogging: key ID %q contains ':'", k.Active)
+	}
+	return k.Active, key, nil
+}
+
+// Key returns the key with the given ID, for verifying older records.
+func (k Keyring) Key(id string) ([]byte, bool) {
+	key, ok := k.Keys[id]
+	return key, ok && len(key) > 0
+}
+
+// LoadKeyring reads a keyring file:
+//
+//	{"active": "2025-01", "keys": {"2024-06": "<hex>", "2025-01": "<hex>"}}
+func LoadKeyring(path string) (Keyring, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Keyring{}, err
+	}
+	var f struct {
+		Active string            `json:"active"`
+		Keys   map[string]string `json:"keys"`
+	}
+	if err := json.Unmarshal(b, &f); err != nil {
+		return Keyring{}, fmt.Errorf("%s: %w", path, err)
+	}
+	k := Keyring{Active: f.Active, Keys: make(map[string][]byte, len(f.Keys))}
+	for id, h := range f.Keys {
+		key, err := hex.DecodeString(strings.TrimSpace(h))
+		if err != nil {
+			return Keyring{}, fmt.Errorf("%s: key %q: %w", path, id, err)
+		}
+		k.Keys[id] = key
+	}
+	if _, _, err := k.ActiveKey(); err != nil {
+		return Keyring{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return k, nil
+}
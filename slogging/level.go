@@ -0,0 +1,114 @@
+package slogging
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// SetLevel changes the minimum level of the running service. Every logger
+// from With/IntoContext/From checks it per event, so the change reaches
+// loggers already stored in contexts. Components with a level of their own
+// (see SetComponentLevel) keep it. The next Init resets it to Options.Level.
+func SetLevel(level string) error {
+	return std.SetLevel(level)
+}
+
+// SetLevel is the package-level SetLevel for the instance's loggers.
+func (i *Instance) SetLevel(level string) error {
+	lvl, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+	p := i.current.Load()
+	if p == nil {
+		if i != std {
+			return fmt.Errorf("slogging: SetLevel(%q) before Init", level)
+		}
+		old := log.Logger.GetLevel()
+		if lvl != old {
+			changeLevel(&log.Logger, "level_changed", func() { log.Logger = log.Logger.Level(lvl) }).
+				Str("from", old.String()).Str("to", lvl.String()).Msgf("log level changed to %s", lvl)
+		}
+		return nil
+	}
+	p.levelMu.Lock()
+	defer p.levelMu.Unlock()
+	old := zerolog.Level(p.base.Load())
+	if lvl == old {
+		return nil
+	}
+	changeLevel(&p.self, "level_changed", func() { p.base.Store(int32(lvl)) }).
+		Str("from", old.String()).Str("to", lvl.String()).Msgf("log level changed to %s", lvl)
+	return nil
+}
+
+// level is the level SetLevel last set, or Options.Level.
+func (i *Instance) level() zerolog.Level {
+	if p := i.current.Load(); p != nil {
+		return zerolog.Level(p.base.Load())
+	}
+	return log.Logger.GetLevel()
+}
+
+// changeLevel runs set, which changes the level, and returns a self event of
+// kind on l, created under whichever of the old and new levels lets it through.
+func changeLevel(l *zerolog.Logger, kind string, set func()) *zerolog.Event {
+	e := selfEventOn(l, zerolog.WarnLevel, kind)
+	set()
+	if e == nil {
+		e = selfEventOn(l, zerolog.WarnLevel, kind)
+	}
+	return e
+}
+
+// parseLevel parses a level name case-insensitively, rejecting "" and other
+// spellings of NoLevel, which would mute everything.
+func parseLevel(level string) (zerolog.Level, error) {
+	lvl, err := zerolog.ParseLevel(strings.ToLower(strings.TrimSpace(level)))
+	if err != nil || lvl == zerolog.NoLevel {
+		return zerolog.NoLevel, fmt.Errorf("slogging: invalid level %q", level)
+	}
+	return lvl, nil
+}
+
+// LevelHandler serves the current level on GET and changes it on PUT, for an
+// admin endpoint. Both answer with {"level":"..."}; PUT accepts the same JSON
+// body, a plain-text level or ?level=.
+//
+//	mux.Handle("/admin/log-level", slogging.LevelHandler())
+//	curl -X PUT localhost:8080/admin/log-level -d debug
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPut:
+			level := r.URL.Query().Get("level")
+			if level == "" {
+				body, _ := io.ReadAll(io.LimitReader(r.Body, 1024))
+				var req struct {
+					Level string `json:"level"`
+				}
+				if json.Unmarshal(body, &req) == nil {
+					level = req.Level
+				} else {
+					level = string(body)
+				}
+			}
+			if err := SetLevel(level); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"level": std.level().String()})
+	})
+}
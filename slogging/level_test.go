@@ -0,0 +1,55 @@
+package slogging
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLevelHandler(t *testing.T) {
+	c := initCapture(t, Options{Level: "info"})
+	ctx := IntoContext(context.Background(), "user", "u1") // stored before the change
+	h := LevelHandler()
+	do := func(method, target, body string) (int, string) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec.Code, strings.TrimSpace(rec.Body.String())
+	}
+
+	if code, body := do(http.MethodGet, "/", ""); code != 200 || body != `{"level":"info"}` {
+		t.Errorf("GET = %d %s", code, body)
+	}
+	From(ctx).Debug().Msg("before")
+	if code, body := do(http.MethodPut, "/", "debug"); code != 200 || body != `{"level":"debug"}` {
+		t.Errorf("PUT debug = %d %s", code, body)
+	}
+	From(ctx).Debug().Msg("after")
+	if len(c.withMessage(t, "before")) != 0 || len(c.withMessage(t, "after")) != 1 {
+		t.Error("the new level did not apply to a logger stored in a context")
+	}
+
+	for _, tc := range []struct {
+		method, target, body string
+		code                 int
+		level                string
+	}{
+		{http.MethodPut, "/", `{"level":"WARN"}`, 200, "warn"},
+		{http.MethodPut, "/?level=error", "", 200, "error"},
+		{http.MethodPut, "/?level=loud", "", 400, "error"},
+		{http.MethodPost, "/", "debug", 405, "error"},
+	} {
+		if code, _ := do(tc.method, tc.target, tc.body); code != tc.code || std.level().String() != tc.level {
+			t.Errorf("%s %s %q = %d at %s, want %d at %s", tc.method, tc.target, tc.body, code, std.level(), tc.code, tc.level)
+		}
+	}
+
+	var changes []string
+	for _, ev := range c.selfEvents(t, "level_changed") {
+		changes = append(changes, ev["from"].(string)+">"+ev["to"].(string))
+	}
+	if got := strings.Join(changes, " "); got != "info>debug debug>warn warn>error" {
+		t.Errorf("level_changed events = %q", got)
+	}
+}
@@ -0,0 +1,239 @@
+package slogging
+
+import (
+	"bytes"
+	"github.com/rs/zerolog"
+	"io"
+	"strconv"
+	"unicode/utf8"
+)
+
+// DockerMaxLine is the line length above which Docker's json-file log
+// driver splits a line, breaking the JSON in it; a value for
+// Options.StdoutMaxLine.
+const DockerMaxLine = 16 << 10
+
+// What Options.StdoutOversize does with an event longer than StdoutMaxLine.
+const (
+	LineTruncate = "truncate" // default: shorten or drop fields until it fits
+	LineSplit    = "split"    // write it as "part_data" records linked by event_id
+)
+
+// minMaxLine keeps StdoutMaxLine large enough for a split record's header.
+const minMaxLine = 512
+
+var (
+	levelKey   = []byte(`"` + zerolog.LevelFieldName + `"`)
+	timeKey    = []byte(`"` + zerolog.TimestampFieldName + `"`)
+	serviceKey = []byte(`"` + FieldService + `"`)
+	eventIDKey = []byte(`"` + FieldEventID + `"`)
+	errorKey   = []byte(`"` + zerolog.ErrorFieldName + `"`)
+)
+
+// lineGuard caps the JSON events written to a console sink at max bytes,
+// newline included, so that a container runtime never splits one. Events
+// that are not JSON objects (Pretty output) pass unchanged.
+type lineGuard struct {
+	w     io.Writer
+	max   int
+	split bool
+}
+
+func newLineGuard(w io.Writer, limit int, oversize string) *lineGuard {
+	return &lineGuard{w: w, max: max(limit, minMaxLine), split: oversize == LineSplit}
+}
+
+func (g *lineGuard) Write(p []byte) (int, error) {
+	return g.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (g *lineGuard) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if len(p) <= g.max || p[0] != '{' {
+		return writeLevel(g.w, level, p)
+	}
+	members, _, ok := scanMembers(p)
+	if !ok {
+		// Not an object we can rebuild; a cut line is still better than
+		// one the runtime cuts at a place of its choosing.
+		line := append(bytes.TrimRight(p[:g.max-1:g.max-1], "\n"), '\n')
+		if _, err := writeLevel(g.w, level, line); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	var err error
+	if g.split {
+		err = g.writeParts(level, p, members)
+	} else {
+		err = g.truncate(level, p, members)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// truncate writes the members of p that fit, in their order, and marks the
+// event with "truncated" and "original_bytes". Level, time, message and
+// error are placed first; then each other member in turn, with the first
+// string that does not fit shortened to the room left.
+func (g *lineGuard) truncate(level zerolog.Level, p []byte, members []span) error {
+	marker := `"` + FieldTruncated + `":true,"` + FieldOriginalBytes + `":` + strconv.Itoa(len(p)) + "}\n"
+	room := g.max - len(marker) - 1 // '{'
+	keep := make([][]byte, len(members))
+	shortened := false
+	place := func(i int) {
+		m := members[i]
+		seg := p[m.start:m.end]
+		if len(seg)+1 <= room {
+			keep[i] = seg
+			room -= len(seg) + 1
+			return
+		}
+		if shortened {
+			return
+		}
+		v := valueStart(p, m)
+		head := p[m.start : v+1] // key, colon and opening quote
+		avail := room - 1 - len(head) - len(`…"`)
+		if p[v] != '"' || avail < 16 {
+			return
+		}
+		shortened = true
+		body := p[v+1 : m.end-1]
+		seg = append(append(append([]byte(nil), head...), body[:cutJSONString(body, avail)]...), `…"`...)
+		keep[i] = seg
+		room -= len(seg) + 1
+	}
+	for i, m := range members {
+		if isKey(p[m.start:m.keyEnd], levelKey, timeKey, messageKey, errorKey) {
+			place(i)
+		}
+	}
+	for i, m := range members {
+		if !isKey(p[m.start:m.keyEnd], levelKey, timeKey, messageKey, errorKey) {
+			place(i)
+		}
+	}
+	buf := lineBufs.Get().(*bytes.Buffer)
+	defer lineBufs.Put(buf)
+	buf.Reset()
+	buf.WriteByte('{')
+	for _, seg := range keep {
+		if seg != nil {
+			buf.Write(seg)
+			buf.WriteByte(',')
+		}
+	}
+	buf.WriteString(marker)
+	_, err := writeLevel(g.w, level, buf.Bytes())
+	return err
+}
+
+func isKey(k []byte, keys ...[]byte) bool {
+	for _, key := range keys {
+		if bytes.Equal(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeParts writes p as records that each carry its level, time and
+// service, its event_id (a new one without Options.EventIDs), "part" (from 1),
+// "parts" and "part_data", a slice of the original line. Concatenating
+// part_data in part order gives the line back.
+func (g *lineGuard) writeParts(level zerolog.Level, p []byte, members []span) error {
+	head := []byte{'{'}
+	for _, key := range [][]byte{levelKey, timeKey, serviceKey} {
+		for _, m := range members {
+			if bytes.Equal(p[m.start:m.keyEnd], key) {
+				head = append(head, p[m.start:m.end]...)
+				head = append(head, ',')
+				break
+			}
+		}
+	}
+	id, ok := memberString(p, members, eventIDKey)
+	if !ok || id == "" {
+		id = NewEventID()
+	}
+	head = strconv.AppendQuote(append(head, `"`+FieldEventID+`":`...), id)
+	line := bytes.TrimRight(p, "\n")
+
+	// Room per record for the escaped data, with up to 9,999 parts.
+	room := g.max - len(head) - len(`,"part":9999,"parts":9999,"part_data":""}`+"\n")
+	var cuts []int
+	for i := 0; i < len(line); {
+		n, size := 0, 0
+		for i+n < len(line) {
+			_, w := utf8.DecodeRune(line[i+n:])
+			esc := w
+			if c := line[i+n]; c == '"' || c == '\\' {
+				esc = 2
+			}
+			if size+esc > room {
+				break
+			}
+			n, size = n+w, size+esc
+		}
+		if n == 0 {
+			n = 1 // room is never this small; make progress regardless
+		}
+		i += n
+		cuts = append(cuts, i)
+	}
+	buf := lineBufs.Get().(*bytes.Buffer)
+	defer lineBufs.Put(buf)
+	from := 0
+	for i, to := range cuts {
+		buf.Reset()
+		buf.Write(head)
+		buf.WriteString(`,"` + FieldPart + `":`)
+		buf.WriteString(strconv.Itoa(i + 1))
+		buf.WriteString(`,"` + FieldParts + `":`)
+		buf.WriteString(strconv.Itoa(len(cuts)))
+		buf.WriteString(`,"` + FieldPartData + `":"`)
+		for _, c := range line[from:to] {
+			if c == '"' || c == '\\' {
+				buf.WriteByte('\\')
+			}
+			buf.WriteByte(c)
+		}
+		buf.WriteString("\"}\n")
+		if _, err := writeLevel(g.w, level, buf.Bytes()); err != nil {
+			return err
+		}
+		from = to
+	}
+	return nil
+}
+
+// valueStart returns the offset of m's value in p.
+func valueStart(p []byte, m span) int {
+	i := skipSpace(p, m.keyEnd)
+	return skipSpace(p, i+1) // past ':'
+}
+
+// cutJSONString returns the longest prefix of the JSON string body s, at
+// most n bytes, that ends neither inside an escape nor inside a UTF-8
+// sequence.
+func cutJSONString(s []byte, n int) int {
+	i := 0
+	for i < len(s) {
+		step := 1
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == 'u':
+			step = 6
+		case s[i] == '\\':
+			step = 2
+		case s[i] >= utf8.RuneSelf:
+			_, step = utf8.DecodeRune(s[i:])
+		}
+		if i+step > n {
+			break
+		}
+		i += step
+	}
+	return i
+}
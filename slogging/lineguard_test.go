@@ -0,0 +1,127 @@
+package slogging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+// oversizeEvent is a JSON event of about n bytes, most of them in "body".
+func oversizeEvent(n int) []byte {
+	body := strings.Repeat(`ab"c\é`, n/7)
+	b, _ := json.Marshal(map[string]any{
+		"level": "info", "time": "2024-01-02T03:04:05Z", "service": "svc",
+		"message": "big payload", "body": body, "user": "u1",
+	})
+	return append(b, '\n')
+}
+
+func guardLines(t *testing.T, out *bytes.Buffer, limit int) []map[string]any {
+	t.Helper()
+	var evs []map[string]any
+	for _, l := range bytes.SplitAfter(out.Bytes(), []byte("\n")) {
+		if len(l) == 0 {
+			continue
+		}
+		if len(l) > limit {
+			t.Errorf("line of %d bytes, over the %d limit", len(l), limit)
+		}
+		var ev map[string]any
+		if err := json.Unmarshal(l, &ev); err != nil {
+			t.Fatalf("non-JSON line %q: %v", l, err)
+		}
+		evs = append(evs, ev)
+	}
+	return evs
+}
+
+func TestLineGuardTruncate(t *testing.T) {
+	var out bytes.Buffer
+	g := newLineGuard(&out, 1024, "")
+	p := oversizeEvent(4000)
+	if n, err := g.Write(p); err != nil || n != len(p) {
+		t.Fatalf("Write = %d, %v", n, err)
+	}
+	evs := guardLines(t, &out, 1024)
+	if len(evs) != 1 {
+		t.Fatalf("got %d lines, want 1", len(evs))
+	}
+	ev := evs[0]
+	if ev[FieldTruncated] != true || ev[FieldOriginalBytes] != float64(len(p)) {
+		t.Errorf("truncation marker = %v, %v", ev[FieldTruncated], ev[FieldOriginalBytes])
+	}
+	if ev["message"] != "big payload" || ev["level"] != "info" {
+		t.Errorf("level or message lost: %v", ev)
+	}
+	if body, _ := ev["body"].(string); !strings.HasSuffix(body, "…") {
+		t.Errorf("body not shortened: %.40q", body)
+	}
+}
+
+func TestLineGuardSplit(t *testing.T) {
+	var out bytes.Buffer
+	g := newLineGuard(&out, 1024, LineSplit)
+	p := oversizeEvent(4000)
+	if _, err := g.Write(p); err != nil {
+		t.Fatal(err)
+	}
+	evs := guardLines(t, &out, 1024)
+	if len(evs) < 2 {
+		t.Fatalf("got %d records, want several", len(evs))
+	}
+	var joined strings.Builder
+	for i, ev := range evs {
+		if ev[FieldPart] != float64(i+1) || ev[FieldParts] != float64(len(evs)) {
+			t.Errorf("record %d: part %v of %v", i, ev[FieldPart], ev[FieldParts])
+		}
+		if ev[FieldEventID] != evs[0][FieldEventID] || ev[FieldEventID] == "" {
+			t.Errorf("record %d: event_id %v", i, ev[FieldEventID])
+		}
+		if ev["level"] != "info" || ev["service"] != "svc" {
+			t.Errorf("record %d: missing level or service: %v", i, ev)
+		}
+		data, _ := ev[FieldPartData].(string)
+		joined.WriteString(data)
+	}
+	if want := strings.TrimSuffix(string(p), "\n"); joined.String() != want {
+		t.Errorf("joined part_data differs from the event")
+	}
+}
+
+func TestLineGuardPassesShortAndPretty(t *testing.T) {
+	var out bytes.Buffer
+	g := newLineGuard(&out, 1024, LineSplit)
+	short := []byte(`{"level":"info","message":"hi"}` + "\n")
+	pretty := []byte(strings.Repeat("x", 2000) + "\n")
+	for _, p := range [][]byte{short, pretty} {
+		out.Reset()
+		if _, err := g.Write(p); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(out.Bytes(), p) {
+			t.Errorf("%.20q rewritten to %.40q", p, out.Bytes())
+		}
+	}
+}
+
+func TestStdoutMaxLine(t *testing.T) {
+	stdout := pipeStd(t, &os.Stdout)
+	c := initCapture(t, Options{Service: "svc", AlsoStdout: true, StdoutMaxLine: 1024})
+	body := strings.Repeat("x", 4000)
+	From(context.Background()).Info().Str("body", body).Msg("big payload")
+	Close(context.Background())
+
+	// Stdout gets the shortened event; the other sinks get it whole.
+	var out bytes.Buffer
+	out.WriteString(stdout())
+	evs := guardLines(t, &out, 1024)
+	if len(evs) != 1 || evs[0][FieldTruncated] != true || evs[0]["message"] != "big payload" {
+		t.Errorf("stdout got %v, want the truncated event", evs)
+	}
+	if evs := c.withMessage(t, "big payload"); len(evs) != 1 || evs[0]["body"] != body || evs[0][FieldTruncated] != nil {
+		t.Errorf("extra sink got %.200v, want the whole event", evs)
+	}
+}
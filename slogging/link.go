@@ -0,0 +1,62 @@
+package slogging
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+)
+
+const (
+	ctxLinkKey    ctxKey = "parent_event_id"
+	ctxEventIDKey ctxKey = "event_id"
+)
+
+// LinkTo returns a context whose events carry "parent_event_id": eventID, so
+// tooling can rebuild which events caused which within a request, beyond the
+// flat order of seq and time. Linking a linked context again replaces the
+// parent, so each level of work links to the event that started it:
+//
+//	e := slogging.From(ctx).Info()
+//	id := slogging.EventID(e)
+//	e.Msg("import started")
+//	ctx = slogging.LinkTo(ctx, id)
+func LinkTo(ctx context.Context, eventID string) context.Context {
+	ctx = context.WithValue(ctx, ctxLinkKey, eventID)
+	base := ctxLogger(ctx)
+	if base == nil {
+		base = global()
+	}
+	ll := base.With().Ctx(ctx).Logger()
+	return ll.WithContext(ctx)
+}
+
+// EventID stamps e with a new event_id and returns it, for LinkTo. With
+// Options.EventIDs on, e keeps this ID instead of being given another. A
+// disabled (nil) event gets none and "" is returned.
+func EventID(e *zerolog.Event) string {
+	if e == nil {
+		return ""
+	}
+	id := NewEventID()
+	e.Str(FieldEventID, id).Ctx(context.WithValue(e.GetCtx(), ctxEventIDKey, id))
+	return id
+}
+
+// ParentEventID returns the event ID LinkTo set on ctx, or "".
+func ParentEventID(ctx context.Context) string {
+	if v, ok := ctx.Value(ctxLinkKey).(string); ok {
+		return v
+	}
+	return ""
+}
+
+// linkHook adds parent_event_id from the context an event was logged with.
+// LinkTo binds its context to the logger, so the link follows the logger
+// into derived contexts; the latest LinkTo's context wins.
+type linkHook struct{}
+
+// Run implements zerolog.Hook.
+func (linkHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	if id := ParentEventID(e.GetCtx()); id != "" {
+		e.Str(FieldParentEventID, id)
+	}
+}
@@ -0,0 +1,50 @@
+package slogging
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestEventIDAndLinkTo(t *testing.T) {
+	for _, eventIDs := range []bool{false, true} {
+		c := initCapture(t, Options{Service: "svc", EventIDs: eventIDs})
+		ctx := context.Background()
+		e := From(ctx).Info()
+		id := EventID(e)
+		e.Msg("import started")
+		child := LinkTo(ctx, id)
+		From(child).Info().Msg("row imported")
+		From(LinkTo(child, "other")).Info().Msg("relinked")
+
+		c.mu.Lock()
+		for _, l := range c.lines {
+			if n := bytes.Count(l, []byte(`"`+FieldEventID+`"`)); n > 1 {
+				t.Errorf("EventIDs=%v: %d event_id keys in %s", eventIDs, n, l)
+			}
+		}
+		c.mu.Unlock()
+		parent := c.withMessage(t, "import started")[0]
+		if id == "" || parent[FieldEventID] != id {
+			t.Errorf("EventIDs=%v: parent event_id = %v, EventID returned %q", eventIDs, parent[FieldEventID], id)
+		}
+		row := c.withMessage(t, "row imported")[0]
+		if row[FieldParentEventID] != id {
+			t.Errorf("EventIDs=%v: parent_event_id = %v, want %q", eventIDs, row[FieldParentEventID], id)
+		}
+		if _, ok := row[FieldEventID]; ok != eventIDs {
+			t.Errorf("EventIDs=%v: child event_id = %v", eventIDs, row[FieldEventID])
+		}
+		if ev := c.withMessage(t, "relinked")[0]; ev[FieldParentEventID] != "other" {
+			t.Errorf("EventIDs=%v: relinked parent_event_id = %v", eventIDs, ev[FieldParentEventID])
+		}
+		Close(context.Background())
+	}
+}
+
+func TestEventIDDisabledEvent(t *testing.T) {
+	initCapture(t, Options{Service: "svc", Level: "info"})
+	if id := EventID(From(context.Background()).Debug()); id != "" {
+		t.Errorf("EventID of a disabled event = %q", id)
+	}
+}
@@ -0,0 +1,161 @@
+package slogging
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const defaultLogDirMode = 0o750
+
+// LogDir is how Options.CreateLogDir creates missing log directories.
+type LogDir struct {
+	// Mode of the directories created (default 0750), applied regardless
+	// of the umask.
+	Mode os.FileMode
+	// Owner is "user", "user:group" or ":group", as names or numeric IDs,
+	// given to the created directories. Changing the user needs root (or
+	// CAP_CHOWN); not supported on Windows.
+	Owner string
+	// Label is the SELinux context given to the created directories, e.g.
+	// "system_u:object_r:var_log_t:s0". Linux only.
+	Label string
+}
+
+// ensureLogDir creates dir and its missing parents. Without cfg they are
+// created 0755, as they always were. With cfg, the ones it creates get its
+// mode, owner and label; if any of those fails they are removed again, so
+// the file sink's retry starts over rather than writing into a directory
+// set up halfway.
+func ensureLogDir(dir string, cfg *LogDir) error {
+	if cfg == nil {
+		return os.MkdirAll(dir, 0o755)
+	}
+	uid, gid, err := parseOwner(cfg.Owner)
+	if err != nil {
+		return fmt.Errorf("slogging: CreateLogDir.Owner %q: %w", cfg.Owner, err)
+	}
+	mode := cfg.Mode.Perm()
+	if mode == 0 {
+		mode = defaultLogDirMode
+	}
+	var missing []string
+	for d := filepath.Clean(dir); ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil {
+			break
+		}
+		missing = append(missing, d)
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	var created []string
+	undo := func(err error) error {
+		for i := len(created) - 1; i >= 0; i-- {
+			os.Remove(created[i])
+		}
+		return err
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		d := missing[i]
+		if err := os.Mkdir(d, mode); err != nil {
+			if errors.Is(err, fs.ErrExist) {
+				continue // another process got there first
+			}
+			return undo(err)
+		}
+		created = append(created, d)
+		if err := os.Chmod(d, mode); err != nil {
+			return undo(err)
+		}
+		if uid >= 0 || gid >= 0 {
+			if err := chownDir(d, uid, gid); err != nil {
+				return undo(fmt.Errorf("slogging: CreateLogDir.Owner %q: %w", cfg.Owner, err))
+			}
+		}
+		if cfg.Label != "" {
+			if err := setDirLabel(d, cfg.Label); err != nil {
+				return undo(fmt.Errorf("slogging: CreateLogDir.Label %q: %w", cfg.Label, err))
+			}
+		}
+	}
+	return nil
+}
+
+// parseDirMode parses an octal mode such as "0750" or "750".
+func parseDirMode(s string) (os.FileMode, error) {
+	m, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || m > 0o777 {
+		return 0, fmt.Errorf("want octal permissions like 0750")
+	}
+	return os.FileMode(m), nil
+}
+
+// parseOwner resolves LogDir.Owner; -1 leaves an ID unchanged.
+func parseOwner(owner string) (uid, gid int, err error) {
+	uid, gid = -1, -1
+	if owner == "" {
+		return uid, gid, nil
+	}
+	u, g, _ := strings.Cut(owner, ":")
+	if u != "" {
+		if uid, err = strconv.Atoi(u); err != nil {
+			usr, err := user.Lookup(u)
+			if err != nil {
+				return -1, -1, err
+			}
+			if uid, err = strconv.Atoi(usr.Uid); err != nil {
+				return -1, -1, fmt.Errorf("user %s has non-numeric ID %s", u, usr.Uid)
+			}
+		}
+	}
+	if g != "" {
+		if gid, err = strconv.Atoi(g); err != nil {
+			grp, err := user.LookupGroup(g)
+			if err != nil {
+				return -1, -1, err
+			}
+			if gid, err = strconv.Atoi(grp.Gid); err != nil {
+				return -1, -1, fmt.Errorf("group %s has non-numeric ID %s", g, grp.Gid)
+			}
+		}
+	}
+	return uid, gid, nil
+}
+
+// explainWriteErr turns a permission error on a log file or its directory
+// into one that says why. When the directory's mode and owner allow this
+// process to write, the denial comes from SELinux or AppArmor, which
+// otherwise only shows as a bare "permission denied" on the first write.
+func explainWriteErr(path string, err error) error {
+	if !errors.Is(err, fs.ErrPermission) {
+		return err
+	}
+	// The nearest directory that exists is the one refusing us.
+	dir := filepath.Dir(path)
+	fi, serr := os.Stat(dir)
+	for serr != nil && filepath.Dir(dir) != dir {
+		dir = filepath.Dir(dir)
+		fi, serr = os.Stat(dir)
+	}
+	if serr != nil {
+		return err
+	}
+	ok, perms := dirWritable(fi)
+	if perms == "" {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w (%s is %s, not writable by this process; fix its permissions or set CreateLogDir.Owner)", err, dir, perms)
+	}
+	hint := macHint(dir)
+	if hint == "" {
+		hint = "a mandatory access control policy is likely blocking it"
+	}
+	return fmt.Errorf("%w (%s is %s, which allows this process to write; %s)", err, dir, perms, hint)
+}
@@ -0,0 +1,55 @@
+package slogging
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/sys/unix"
+	"os"
+	"strings"
+)
+
+func setDirLabel(path, label string) error {
+	err := unix.Lsetxattr(path, "security.selinux", []byte(label), 0)
+	if errors.Is(err, unix.ENOTSUP) {
+		return errors.New("the filesystem does not support SELinux labels")
+	}
+	return err
+}
+
+// macHint names the SELinux or AppArmor confinement that may be denying
+// writes to dir, or returns "" when neither is active.
+func macHint(dir string) string {
+	if b, err := os.ReadFile("/sys/fs/selinux/enforce"); err == nil && strings.TrimSpace(string(b)) == "1" {
+		proc := readAttr("/proc/self/attr/current")
+		label := make([]byte, 256)
+		n, err := unix.Lgetxattr(dir, "security.selinux", label)
+		if err != nil {
+			n = 0
+		}
+		return fmt.Sprintf("SELinux is enforcing (process %s, directory %s); check the audit log (ausearch -m avc) and relabel it, e.g. with CreateLogDir.Label",
+			orUnknown(proc), orUnknown(strings.TrimRight(string(label[:n]), "\x00")))
+	}
+	profile := readAttr("/proc/self/attr/apparmor/current")
+	if profile == "" {
+		profile = readAttr("/proc/self/attr/current")
+	}
+	if profile != "" && profile != "unconfined" && strings.HasSuffix(profile, "(enforce)") {
+		return fmt.Sprintf("AppArmor profile %s confines this process; allow the path in the profile", profile)
+	}
+	return ""
+}
+
+func readAttr(path string) string {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(b), "\x00"))
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package slogging
+
+import "errors"
+
+func setDirLabel(path, label string) error {
+	return errors.New("SELinux labels are only supported on Linux")
+}
+
+func macHint(string) string { return "" }
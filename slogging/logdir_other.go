@@ -0,0 +1,15 @@
+//go:build !unix
+
+package slogging
+
+import (
+	"errors"
+	"io/fs"
+)
+
+func chownDir(path string, uid, gid int) error {
+	return errors.New("not supported on this platform")
+}
+
+// dirWritable cannot tell from mode bits here; ACLs decide.
+func dirWritable(fs.FileInfo) (bool, string) { return false, "" }
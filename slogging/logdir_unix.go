@@ -0,0 +1,39 @@
+//go:build unix
+
+package slogging
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"syscall"
+)
+
+func chownDir(path string, uid, gid int) error {
+	return os.Chown(path, uid, gid)
+}
+
+// dirWritable reports whether fi's mode bits and owner let this process
+// create files in it, and describes them.
+func dirWritable(fi fs.FileInfo) (bool, string) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return false, ""
+	}
+	mode := fi.Mode().Perm()
+	uid, gid := int(st.Uid), int(st.Gid)
+	perms := fmt.Sprintf("mode %#o owned by %d:%d", mode, uid, gid)
+	euid := os.Geteuid()
+	groups, _ := os.Getgroups()
+	switch {
+	case euid == 0:
+		return true, perms
+	case euid == uid:
+		return mode&0o300 == 0o300, perms
+	case os.Getegid() == gid || slices.Contains(groups, gid):
+		return mode&0o030 == 0o030, perms
+	default:
+		return mode&0o003 == 0o003, perms
+	}
+}
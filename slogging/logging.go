@@ -2,68 +2,365 @@ package slogging
 
 import (
 	"context"
-	"github.com/natefinch/lumberjack"
+	"crypto/ecdh"
+	"fmt"
 	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
 	"io"
-	"os"
 	"runtime"
+	"strings"
 	"time"
 )
 
+// Logger is the request-scoped logger returned by New. Its events carry the
+// request_id, api_id and operator_name of the context it was made from, under
+// the same keys the With* helpers and From use.
 type Logger struct {
 	requestID string
 	apiID     string
 	operator  string
+	stack     bool
+	base      *zerolog.Logger // WithRequestLevel's logger, or one bound to ctx for Enrichers; nil = global
 }
 
+// Legacy context keys and header names. New still reads plain string keys
+// with these names from ctx, for callers that predate the With* helpers.
 const (
 	XRequestID = "X-Request-ID"
 	APIID      = "api_id"
 	XOperator  = "x-operator"
 )
 
+// New returns a Logger for the IDs stored on ctx by WithRequestID, WithAPIID
+// and WithOperatorName (or the middleware), falling back to the legacy string
+// keys above.
 func New(ctx context.Context) *Logger {
-	requestID, _ := ctx.Value(XRequestID).(string)
-	apiID, _ := ctx.Value(APIID).(string)
-	operator, _ := ctx.Value(XOperator).(string)
-	return &Logger{
-		requestID: requestID,
-		apiID:     apiID,
-		operator:  operator,
+	checkBareContext(current.Load(), ctx, 1)
+	l := &Logger{
+		requestID: firstNonEmpty(GetRequestID(ctx), legacyValue(ctx, XRequestID)),
+		apiID:     firstNonEmpty(GetAPIID(ctx), legacyValue(ctx, APIID)),
+		operator:  firstNonEmpty(GetOperatorID(ctx), legacyValue(ctx, XOperator)),
 	}
+	p := current.Load()
+	if p == nil {
+		return l
+	}
+	if lvl, ok := requestLevel(ctx); ok {
+		ll := p.requestLogger(ctx, &p.logger, lvl)
+		l.base = &ll
+	} else if p.enrich {
+		ll := p.logger.With().Ctx(ctx).Logger()
+		l.base = &ll
+	}
+	return l
+}
+
+// logger is the logger l's events start on.
+func (l *Logger) logger() *zerolog.Logger {
+	if l.base != nil {
+		return l.base
+	}
+	return global()
+}
+
+func legacyValue(ctx context.Context, key string) string {
+	s, _ := ctx.Value(key).(string)
+	return s
 }
 
-func (l *Logger) Info() *zerolog.Event {
-	return log.Info().
-		Str(XRequestID, l.requestID).
-		Str(APIID, l.apiID).
-		Str(XOperator, l.operator)
+// event starts an event on the global logger with l's IDs; empty IDs are left
+// out, as they are for From.
+func (l *Logger) event(e *zerolog.Event) *zerolog.Event {
+	if l.requestID != "" {
+		e = e.Str(FieldRequestID, l.requestID)
+	}
+	if l.apiID != "" {
+		e = e.Str(FieldAPIID, l.apiID)
+	}
+	if l.operator != "" {
+		e = e.Str(FieldOperatorName, l.operator)
+	}
+	return e
+}
+
+func (l *Logger) Debug() *zerolog.Event { return l.event(l.logger().Debug()) }
+func (l *Logger) Info() *zerolog.Event  { return l.event(l.logger().Info()) }
+func (l *Logger) Warn() *zerolog.Event  { return l.event(l.logger().Warn()) }
+
+// Fatal logs at fatal level and exits the process once Msg is called.
+func (l *Logger) Fatal() *zerolog.Event { return l.event(l.logger().Fatal()) }
+
+// Panic logs at panic level and panics with the message once Msg is called.
+func (l *Logger) Panic() *zerolog.Event { return l.event(l.logger().Panic()) }
+
+// Stack returns a copy of l whose Error events carry a stack trace and the
+// error's Unwrap chain, as with Options.WithStack:
+//
+//	slogging.New(ctx).Stack().Error(err).Msg("charge failed")
+func (l *Logger) Stack() *Logger {
+	c := *l
+	c.stack = true
+	return &c
 }
 
 func (l *Logger) Error(err error) *zerolog.Event {
-	return log.Error().
-		Str(XRequestID, l.requestID).
-		Str(APIID, l.apiID).
-		Str(XOperator, l.operator).
-		Err(err)
+	e := l.event(l.logger().Error())
+	if err != nil && (l.stack || withStack()) {
+		e = e.Stack()
+		if chain := ErrorChain(err); len(chain) > 1 {
+			e = e.Strs(FieldErrorChain, chain)
+		}
+	}
+	return e.Err(err)
 }
 
 type Options struct {
 	Service     string
 	Environment string
-	Pretty      bool // keep false in prod for JSON
+	Version     string // build/image version, stamped as "version" when set
+	Pretty      bool   // keep false in prod for JSON
 	Level       string
-	WithCaller  bool
+	// ComponentLevels sets the minimum level of Component loggers by name,
+	// e.g. {"repository": "debug"}, overriding Level for them only; see
+	// SetComponentLevel to change them at runtime.
+	ComponentLevels map[string]string
+	WithCaller      bool
+	// WithStack adds a "stack" trace to every event carrying an error via Err,
+	// and Logger.Error also records the error's Unwrap chain as "error_chain".
+	// Errors from github.com/pkg/errors keep the stack they were created with.
+	// Per call, use Logger.Stack or zerolog's Event.Stack before Err.
+	WithStack   bool
 	SampleEvery int
+	// SampleBudget caps info and lower events at about this many per second
+	// by sampling them adaptively: 1 in N, with N raised during traffic spikes
+	// and lowered again when quiet (see GetStats().SampleRate). Warn and above
+	// are never sampled. It replaces SampleEvery when both are set.
+	SampleBudget int
+	// SampleReportInterval is how often "sampling_suppressed" summaries are
+	// emitted while sampling is active (default 1m).
+	SampleReportInterval time.Duration
 	// New:
-	FilePath    string    // if set, logs go to this file with rotation
-	MaxSizeMB   int       // rotate after size (e.g., 100)
-	MaxBackups  int       // keep N old files
-	MaxAgeDays  int       // days to keep
-	Compress    bool      // gzip old logs
-	AlsoStdout  bool      // tee to stdout as well (useful with system collectors)
-	ExtraWriter io.Writer // optional: any additional writer (e.g., socket)
+	FilePath   string // if set, logs go to this file with rotation
+	MaxSizeMB  int    // rotate after size (e.g., 100)
+	MaxBackups int    // keep N old files
+	MaxAgeDays int    // days to keep
+	Compress   bool   // compress rotated logs in the background (see CompressFormat)
+	// Rotated-file compression, only used when Compress is set.
+	CompressFormat   string // CompressGzip (default) or CompressZstd
+	CompressLevel    int    // format-specific level; 0 = format default
+	CompressRateMBps int    // throttle compression reads; 0 = unthrottled
+
+	// DailyDirs writes FilePath "dir/app.log" as "dir/2006/01/02/app.log";
+	// MaxAgeDays prunes day dirs. With Compress, lumberjack gzips each day's
+	// backups, so CompressFormat and the throttle do not apply.
+	DailyDirs  bool
+	AlsoStdout bool // tee to stdout as well (useful with system collectors)
+	// SplitStdErr sends warn and above to stderr instead of stdout, wherever
+	// stdout is used (no FilePath, AlsoStdout, file fallback). JSON output
+	// only; Pretty output all goes to stdout. For a file per level, see
+	// Route.MinLevel.
+	SplitStdErr bool
+	// StdoutMaxLine caps the JSON events written to stdout and stderr at
+	// this many bytes, newline included (DockerMaxLine for Docker's
+	// json-file driver; at least 512). StdoutOversize says what happens to
+	// a longer one: LineTruncate (default) keeps the fields that fit,
+	// shortens the first string that does not and adds "truncated" and
+	// "original_bytes"; LineSplit writes it whole as continuation records
+	// ("event_id", "part", "parts", "part_data") for slogging's parse
+	// package and CLI to join. Files and other sinks are unaffected.
+	StdoutMaxLine  int
+	StdoutOversize string
+	ExtraWriter    io.Writer // optional: any additional writer (e.g., socket)
+	// WorkerSocket makes this process the parent of worker processes: it
+	// listens on a Unix socket at that path and writes the events workers
+	// send through its own pipeline, tagged with worker and worker_pid, so
+	// rotation and sinks are managed once. ParentSocket makes this process
+	// such a worker: its events go to the parent instead of its own sinks
+	// (to stderr while the parent is unreachable), tagged with WorkerName
+	// (default "pid-<pid>"). See WorkerEnv for passing them to a child.
+	WorkerSocket string
+	ParentSocket string
+	WorkerName   string
+	// SyslogAddr adds a sink sending each event to a syslog collector as an
+	// RFC 5424 message (APP-NAME = Service, MSG = the JSON line), with the
+	// level mapped to the severity. SyslogNetwork is "udp" (default), "tcp"
+	// or "unix"; a path such as /dev/log defaults to "unixgram". A dropped
+	// connection is redialed on the next event. SyslogFacility defaults to
+	// FacilityUser.
+	SyslogAddr     string
+	SyslogNetwork  string
+	SyslogFacility int
+	// GELFAddr adds a sink sending each event to Graylog as a GELF 1.1
+	// message: message is short_message, the level a syslog severity and
+	// every other field an additional field (request_id as _request_id).
+	// GELFNetwork is "udp" (default; large messages are gzipped and chunked)
+	// or "tcp" (null-delimited).
+	GELFAddr    string
+	GELFNetwork string
+	// SentryDSN forwards error, fatal and panic events to Sentry, with
+	// request_id, operator_name, api_id and the other correlation fields as
+	// tags and the logging call's stack trace attached. Requires importing
+	// slogging/sentry. Delivery is asynchronous and best effort: an
+	// unreachable Sentry never slows or fails logging.
+	SentryDSN string
+
+	FileRetryInterval time.Duration // unwritable FilePath: retry period while on stdout (default 30s)
+	// FileLock takes an advisory lock on "<file>.lock" next to each log file
+	// (flock, or LockFileEx on Windows), so a second process started with
+	// the same FilePath falls back to stdout instead of interleaving with
+	// the first and rotating the file under it. The loser retries every
+	// FileRetryInterval and takes over once the holder exits.
+	FileLock bool
+	// CreateLogDir creates missing log directories (FilePath's, DailyDirs'
+	// day directories, Routes' and the audit log's) with its mode, owner and
+	// SELinux label, for hardened hosts where the defaults (0755, the
+	// process's user, the parent's label) are refused or unreadable by the
+	// collector. nil creates them 0755 as before. Either way, a file that
+	// cannot be written because SELinux or AppArmor denies it is reported as
+	// such in "file_sink_degraded", not as a bare "permission denied".
+	CreateLogDir *LogDir
+
+	WriteTimeout   time.Duration // per-sink write deadline; 0 = block as long as the sink does
+	Enrichers      EnricherChain // run in order on every event; see Enrichers
+	RingBufferSize int           // keep the last N events in memory for Recent (0 = off)
+	Routes         []Route       // send matching events to their own files/writers (JSON output only)
+	Async          bool          // write sinks from background goroutines (warn+ never dropped)
+	BufferSize     int           // per-lane queue size for Async (default 1024)
+	// OnHighWatermark is called (on its own goroutine) when an async sink's queue
+	// reaches HighWatermark of its capacity; it re-arms once the queue is half that.
+	OnHighWatermark func(SinkStats)
+	HighWatermark   float64 // fraction of BufferSize, default 0.8
+	// DropPolicy decides what a full normal lane does: DropNewest (default),
+	// DropOldest or DropBlock. Drops are reported as "events_dropped" warnings
+	// every DropReportInterval (default 1m).
+	DropPolicy         string
+	DropReportInterval time.Duration
+
+	// StableFieldOrder writes time, level, service, request_id and message first
+	// in every JSON event, then the remaining fields in the order they were added.
+	// It costs one rescan of each event; ignored with Pretty.
+	StableFieldOrder bool
+	// DedupWindow drops events identical (apart from the timestamp) to one
+	// written less than DedupWindow ago, and reports the dropped counts as
+	// "dedup_suppressed" events ("repeated N times in the last 30s") once per
+	// window. JSON output only; 0 = off. DedupKeyFields narrows "identical"
+	// to the level plus those fields, e.g. DedupMessageAndError.
+	DedupWindow    time.Duration
+	DedupKeyFields []string
+	// RateLimit lets through at most that many events per key and
+	// RateLimitInterval (default 1m), where the key is the event's rate_key
+	// field (FieldRateKey) or else its level and message, and reports the
+	// dropped counts as "rate_limited" events with suppressed_count once per
+	// interval. Fatal and panic are never dropped. JSON output only; 0 = off.
+	//
+	//	slogging.From(ctx).Error().Str(slogging.FieldRateKey, "db-dial").Err(err).Msg("dial failed")
+	RateLimit         int
+	RateLimitInterval time.Duration
+	// TenantQuotas caps the log volume of each tenant (the "tenant" field),
+	// keyed by tenant; the "*" entry applies to tenants not listed. Events over
+	// quota are dropped, except warn and above, and summarized per tenant as
+	// "tenant_quota_exceeded" warnings every TenantQuotaReportInterval
+	// (default 1m). JSON output only.
+	TenantQuotas              map[string]TenantQuota
+	TenantQuotaReportInterval time.Duration
+	// CostReportInterval emits a "log_cost" event this often, projecting the
+	// bytes written in the interval to bytes per day by level, component and
+	// sink, priced with CostPerGB when set. 0 = off.
+	CostReportInterval time.Duration
+	CostPerGB          float64
+	// MetricRules derive counters and histograms from events, served by
+	// MetricsHandler; see MetricRule. MetricReportInterval also emits them as
+	// "metric_summary" events per interval; 0 = none.
+	MetricRules          []MetricRule
+	MetricReportInterval time.Duration
+	// TrimRules drop verbose fields for traffic from trusted networks; see TrimRule.
+	TrimRules []TrimRule
+	// ErrorBurstThreshold: that many error-level events within ErrorBurstWindow
+	// (default 1m) emit one "runtime_snapshot" event (goroutines, heap, GC,
+	// open FDs), at most every 5 minutes. 0 = off.
+	ErrorBurstThreshold int
+	ErrorBurstWindow    time.Duration
+	// RetryStormThreshold: that many retries (client calls following a failed
+	// call to the same host and operation, from any request) within
+	// RetryStormWindow (default 10s) emit one "retry_storm" warning per host,
+	// operation and window. Client calls are reported by TrackRetries. 0 = off.
+	RetryStormThreshold int
+	RetryStormWindow    time.Duration
+	// HostSLOs are latency and error budgets per downstream host, checked
+	// for every call reported through RecordDependency (the grpcmw client
+	// interceptors). Dependency entries then count budget_exceeded, and one
+	// "slo_summary" per host is emitted every SLOReportInterval (default 1m),
+	// at warn level when the budget was missed.
+	HostSLOs          map[string]SLO
+	SLOReportInterval time.Duration
+	// GoroutineDumpSignal logs DumpGoroutines on SIGUSR1 (unix only).
+	GoroutineDumpSignal bool
+	// CrashDir receives a postmortem file (recent events, config summary,
+	// stack, runtime stats) on Fatal/Panic events and on panics caught by
+	// PanicPostmortem. Postmortems found there at Init are logged as
+	// "previous_crash" events. Recent events come from the ring buffer or,
+	// without one, the tail of FilePath.
+	CrashDir string
+	// With CrashDir, Init emits an error-level "crash_loop_suspected" event
+	// when the process started uncleanly CrashLoopStarts times (default 3)
+	// within CrashLoopWindow (default 10m), or a postmortem is that recent. A
+	// start is unclean when the previous run wrote a postmortem or ended
+	// without Close, so deploy restarts that Close do not count.
+	CrashLoopStarts int
+	CrashLoopWindow time.Duration
+	// WarnBareContext (development/staging) logs a "bare_context" warning, once
+	// per call site, when From or New gets a context that never went through
+	// the middleware or a With* helper, i.e. has no request correlation.
+	WarnBareContext bool
+	// Sequence stamps every event with "seq", a per-process counter that only
+	// increases, so events from concurrent goroutines can be totally ordered
+	// downstream even when their timestamps collide.
+	Sequence bool
+	// EventIDs stamps every event with "event_id", a 20-character ID unique
+	// across processes (see NewEventID), for de-duplicating deliveries of
+	// at-least-once sinks and for pointing at one event from elsewhere
+	// ("see log event 0k3m...").
+	EventIDs bool
+	// HighResTime adds time_ns (wall clock, Unix nanoseconds) and mono_ns
+	// (monotonic nanoseconds since process start) to every event, for latency
+	// debugging where second-resolution "time" leaves ordering ambiguous.
+	HighResTime bool
+	// ClockSkewServer is an NTP server ("pool.ntp.org", "10.0.0.1:123") queried
+	// every ClockSkewInterval (default 5m). Events then carry clock_skew_ms, the
+	// local clock's offset from it, and offsets of a second or more log a warning.
+	ClockSkewServer   string
+	ClockSkewInterval time.Duration
+	// CloudMetadata stamps cloud, region, zone, instance_id and instance_type
+	// from the AWS, GCP or Azure instance metadata service. The lookup runs once
+	// per process, during the first Init, and gives up after one second.
+	CloudMetadata bool
+	// OTELCorrelation makes From and IntoContext pick up trace_id and span_id
+	// from the span active in the context. It needs a tracer integration,
+	// normally registered by importing slogging/otel.
+	OTELCorrelation bool
+	// DeployStateFile remembers the last Version between starts. When Version
+	// differs from it, Init emits a "deployment" event (see DeploymentEvent).
+	DeployStateFile string
+	// FlagSampleEvery logs 1 in N FlagEvaluated calls per flag key; the "*"
+	// entry applies to keys not listed. Unsampled flags are always logged.
+	FlagSampleEvery map[string]int
+	// ExposureFilePath also writes Exposure events to this rotating file, in
+	// addition to the default sinks. It is evaluated before Routes.
+	ExposureFilePath string
+	// EncryptFields lists top-level fields whose values are encrypted for
+	// EncryptionKey (X25519) before any sink, the ring buffer included, sees
+	// them. Only the private key's holder can read them back, with DecryptField
+	// or "slog decrypt"; with Pretty they are sealed before the console
+	// rendering, so it shows the envelopes. EncryptionKeyID, when set, is written
+	// into each value so a rotated key set can pick the right private key; it
+	// may not contain ':'.
+	EncryptFields   []string
+	EncryptionKey   *ecdh.PublicKey
+	EncryptionKeyID string
+	// Audit is the destination of Audit records, kept apart from the
+	// application log; Audit().Write fails while it is unset.
+	Audit AuditOptions
+
+	envErrs []error // variables OptionsFromEnv could not parse
 }
 
 type ctxKey string
@@ -78,121 +375,394 @@ const (
 	ctxIPAddressKey    ctxKey = "ip_address"
 )
 
-// Init sets the global logger (log.Logger) and base fields.
+// Init configures the Default instance, the logger behind From, With and
+// IntoContext, and sets the global logger (log.Logger) and base fields; use
+// NewLogger for a second logger with sinks of its own.
+// Calling it again swaps in a freshly built pipeline and closes the writers of
+// the previous one, so re-Init on config change does not leak file handles.
 func Init(opt Options) {
-	zerolog.TimeFieldFormat = time.RFC3339
+	std.Init(opt)
+}
+
+// build turns Options into a pipeline ready to install on i, without touching
+// globals other than zerolog's package-level formatting knobs.
+func build(i *Instance, opt Options) *pipeline {
+	setFormatGlobals()
 
 	lvl, err := zerolog.ParseLevel(opt.Level)
-	if err != nil {
+	if err != nil || lvl == zerolog.NoLevel { // "" parses as NoLevel, which would mute everything
 		lvl = zerolog.InfoLevel
 	}
-	zerolog.SetGlobalLevel(lvl)
+	p := &pipeline{level: lvl, warnBare: opt.WarnBareContext, otel: opt.OTELCorrelation, inst: i, stats: &i.stats}
+	p.setLevels(lvl, opt.ComponentLevels)
+	for _, err := range opt.envErrs {
+		p.onInstall = append(p.onInstall, func() {
+			selfEventOn(&p.self, zerolog.WarnLevel, "invalid_option").Err(err).Msg("ignoring unparsable environment variable")
+		})
+	}
+	if opt.OTELCorrelation && spanContextFn.Load() == nil {
+		p.onInstall = append(p.onInstall, func() {
+			selfEventOn(&p.self, zerolog.WarnLevel, "invalid_option").
+				Msg("OTELCorrelation is set but no tracer integration is registered; import slogging/otel")
+		})
+	}
 
 	// Build the output writer
-	var w io.Writer
-	if opt.FilePath != "" {
-		r := &lumberjack.Logger{
-			Filename:   opt.FilePath,
-			MaxSize:    max(1, opt.MaxSizeMB),
-			MaxBackups: max(0, opt.MaxBackups),
-			MaxAge:     max(0, opt.MaxAgeDays),
-			Compress:   opt.Compress,
+	var sinks []io.Writer
+	var cost *costMeter
+	if opt.CostReportInterval > 0 {
+		cost = startCostMeter(&p.self, opt.CostReportInterval, opt.CostPerGB)
+	}
+	add := func(name string, s io.Writer) {
+		if cost != nil {
+			s = cost.sink(name, s)
 		}
-		switch {
-		case opt.AlsoStdout && opt.ExtraWriter != nil:
-			w = zerolog.MultiLevelWriter(r, os.Stdout, opt.ExtraWriter)
-		case opt.AlsoStdout:
-			w = zerolog.MultiLevelWriter(r, os.Stdout)
-		case opt.ExtraWriter != nil:
-			w = zerolog.MultiLevelWriter(r, opt.ExtraWriter)
-		default:
-			w = r
+		w, closers := p.wrapSink(name, s, opt)
+		p.closers = append(p.closers, closers...)
+		sinks = append(sinks, w)
+	}
+	if opt.ParentSocket != "" {
+		// A worker's events are the parent's to store; Pretty would
+		// hand it text it cannot tag.
+		opt.Pretty = false
+		ws := newWorkerSink(opt.ParentSocket, opt.WorkerName)
+		p.closers = append(p.closers, ws)
+		add("parent", ws)
+	} else if opt.FilePath != "" {
+		// If the file can't be opened we fall back to stdout, unless stdout
+		// already gets every event through AlsoStdout.
+		fallback := stdoutSink(opt)
+		if opt.AlsoStdout {
+			fallback = nil
+		}
+		r := newRotatingFile(opt.FilePath, opt, fallback, p.stats)
+		p.file = r
+		p.closers = append(p.closers, r)
+		p.onInstall = append(p.onInstall, r.start)
+		add("file", r)
+		if opt.AlsoStdout {
+			add("stdout", stdoutSink(opt))
 		}
 	} else {
 		// No file path -> default to stdout (good for containers)
-		w = os.Stdout
+		add("stdout", stdoutSink(opt))
+	}
+	if opt.SyslogAddr != "" {
+		sw := newSyslogWriter(opt.SyslogNetwork, opt.SyslogAddr, opt.SyslogFacility, opt.Service)
+		p.closers = append(p.closers, sw)
+		add("syslog", sw)
+	}
+	if opt.ExtraWriter != nil {
+		if c, ok := opt.ExtraWriter.(io.Closer); ok {
+			p.extra = c // closed by Close only; re-Init may keep using it
+		}
+		add("extra", opt.ExtraWriter)
+	}
+	if opt.GELFAddr != "" {
+		gw := newGELFWriter(opt.GELFNetwork, opt.GELFAddr)
+		p.closers = append(p.closers, gw)
+		add("gelf", gw)
+	}
+	if opt.SentryDSN != "" {
+		p.addSentry(&sinks, opt)
+	}
+	if opt.Audit.enabled() {
+		p.audit = newAuditLog(p, opt)
+	}
+	if opt.RingBufferSize > 0 {
+		// in-memory and cheap, so it skips the deadline/async wrapping
+		p.ring = newRingSink(opt.RingBufferSize)
+		sinks = append(sinks, p.ring)
+	}
+	var w io.Writer = sinks[0]
+	if len(sinks) > 1 {
+		w = zerolog.MultiLevelWriter(sinks...)
+	}
+	if opt.ExposureFilePath != "" {
+		opt.Routes = append([]Route{{Field: FieldEvent, Equals: ExposureEvent, FilePath: opt.ExposureFilePath}}, opt.Routes...)
+	}
+	if len(opt.Routes) > 0 {
+		rt := newRouter(p, w, opt)
+		p.closers = append(p.closers, rt)
+		w = rt
+	}
+	if cost != nil {
+		w = cost.events(w)
+	}
+	// With Pretty the envelopes are sealed ahead of the ConsoleWriter, which
+	// needs JSON in and would otherwise print the values in the clear.
+	var seal func(io.Writer) io.Writer
+	if len(opt.EncryptFields) > 0 {
+		// Without a usable key the fields are replaced, never written in the clear.
+		pub := opt.EncryptionKey
+		if strings.Contains(opt.EncryptionKeyID, ":") {
+			pub = nil
+			p.onInstall = append(p.onInstall, func() {
+				selfEventOn(&p.self, zerolog.ErrorLevel, "invalid_option").
+					Str("key_id", opt.EncryptionKeyID).
+					Msg("EncryptionKeyID may not contain ':'; encrypted values are withheld")
+			})
+		}
+		seal = func(w io.Writer) io.Writer {
+			return newCryptWriter(w, pub, opt.EncryptionKeyID, opt.EncryptFields)
+		}
+		if opt.EncryptionKey == nil {
+			p.onInstall = append(p.onInstall, func() {
+				selfEventOn(&p.self, zerolog.ErrorLevel, "invalid_option").
+					Strs("fields", opt.EncryptFields).
+					Msg("EncryptFields is set without EncryptionKey; their values are withheld")
+			})
+		}
+		if !opt.Pretty {
+			w = seal(w)
+		}
+	}
+	if opt.StableFieldOrder && !opt.Pretty {
+		w = newOrderWriter(w)
+	}
+	if opt.DedupWindow > 0 {
+		d := newDedupWriter(w, &p.self, opt.DedupWindow, opt.DedupKeyFields)
+		p.closers = append(p.closers, d)
+		w = d
+	}
+	if opt.RateLimit > 0 {
+		interval := opt.RateLimitInterval
+		if interval <= 0 {
+			interval = defaultRateLimitInterval
+		}
+		r := newRateLimitWriter(w, &p.self, opt.RateLimit, interval)
+		p.closers = append(p.closers, r)
+		w = r
+	}
+	if len(opt.TenantQuotas) > 0 {
+		q := newQuotaWriter(w, &p.self, opt.TenantQuotas, opt.TenantQuotaReportInterval)
+		p.closers = append(p.closers, q)
+		w = q
+	}
+	if opt.GoroutineDumpSignal {
+		p.onInstall = append(p.onInstall, func() {
+			p.closers = append(p.closers, startDumpOnSignal())
+		})
+	}
+	if len(opt.TrimRules) > 0 {
+		t, errs := newTrimWriter(w, opt.TrimRules)
+		for _, err := range errs {
+			p.onInstall = append(p.onInstall, func() {
+				selfEventOn(&p.self, zerolog.WarnLevel, "invalid_option").Err(err).Msg("ignoring invalid TrimRules entry")
+			})
+		}
+		w = t
+	}
+	if len(opt.MetricRules) > 0 {
+		m, errs := newMetrics(w, &p.self, opt.MetricRules)
+		for _, err := range errs {
+			p.onInstall = append(p.onInstall, func() {
+				selfEventOn(&p.self, zerolog.WarnLevel, "invalid_option").Err(err).Msg("ignoring invalid MetricRules entry")
+			})
+		}
+		if opt.MetricReportInterval > 0 {
+			m.startReporter(opt.MetricReportInterval)
+			p.closers = append(p.closers, m)
+		}
+		p.metrics = m
+		w = m
 	}
 
 	// Pretty should stay false in prod; pretty = human output (not JSON)
-	var base zerolog.Logger
 	if opt.Pretty {
-		base = zerolog.New(zerolog.ConsoleWriter{Out: w}).With().Timestamp().Logger()
-	} else {
-		base = zerolog.New(w).With().Timestamp().Logger()
+		w = zerolog.ConsoleWriter{Out: w}
+		if seal != nil {
+			w = seal(w)
+		}
 	}
+	p.out = w
+	base := zerolog.New(handoffWriter{p}).With().Timestamp().Logger()
 
-	if opt.SampleEvery > 1 {
-		base = base.Sample(&zerolog.BasicSampler{N: uint32(opt.SampleEvery)})
+	if opt.WorkerSocket != "" {
+		p.onInstall = append(p.onInstall, func() {
+			ws, err := listenWorkers(opt.WorkerSocket, w, &p.self)
+			if err != nil {
+				selfEventOn(&p.self, zerolog.ErrorLevel, "invalid_option").Err(err).
+					Msg("cannot listen on WorkerSocket; worker events are not collected")
+				return
+			}
+			p.closers = append(p.closers, ws)
+		})
 	}
 
 	fields := base.With().
-		Str("service", opt.Service).
-		Str("env", opt.Environment)
+		Str(FieldService, opt.Service).
+		Str(FieldEnv, opt.Environment).
+		Int(FieldSchemaVersion, SchemaVersion)
+	if opt.Version != "" {
+		fields = fields.Str(FieldVersion, opt.Version)
+	}
+	if opt.CloudMetadata {
+		if ci := lookupCloud(); ci != nil {
+			fields = ci.fields(fields)
+		}
+	}
 
 	if opt.WithCaller {
-		zerolog.CallerMarshalFunc = func(pc uintptr, file string, line int) string {
-			fn := runtime.FuncForPC(pc)
-			if fn == nil {
-				return file + ":" + itoa(line)
-			}
-			return fn.Name() + " " + file + ":" + itoa(line)
-		}
 		fields = fields.Caller()
 	}
 
-	log.Logger = fields.Logger()
+	// Gated at the base level, since the global one may be lower for
+	// components and requests.
+	p.self = fields.Logger().Sample(levelGate{p: p})
+	if opt.Sequence {
+		p.self = p.self.Hook(seqHook{})
+	}
+	if opt.EventIDs {
+		p.self = p.self.Hook(eventIDHook{})
+	}
+	if opt.HighResTime {
+		p.self = p.self.Hook(hiResHook{})
+	}
+	if opt.ClockSkewServer != "" {
+		c := newClockSkew(opt.ClockSkewServer, opt.ClockSkewInterval, &p.self)
+		p.self = p.self.Hook(c)
+		p.onInstall = append(p.onInstall, func() {
+			c.start()
+			p.closers = append(p.closers, c)
+		})
+	}
+	p.logger = p.self
+	if opt.WithStack {
+		p.stack = true
+		p.logger = p.logger.With().Stack().Logger()
+	}
+	p.logger = p.logger.Hook(linkHook{})
+	if len(opt.Enrichers) > 0 {
+		p.enrich = true
+		p.logger = p.logger.Hook(opt.Enrichers)
+	}
+	if opt.CrashDir != "" {
+		p.crashDir = opt.CrashDir
+		p.config = configSummary(opt, lvl)
+		p.logger = p.logger.Hook(postmortemHook{p})
+		p.onInstall = append(p.onInstall, func() {
+			detectCrashLoop(&p.self, opt.CrashDir, opt.CrashLoopStarts, opt.CrashLoopWindow)
+			reportPostmortems(&p.self, opt.CrashDir)
+		})
+	}
+	if opt.DeployStateFile != "" && opt.Version != "" {
+		p.onInstall = append(p.onInstall, func() {
+			detectDeployment(&p.self, opt.DeployStateFile, opt.Version)
+		})
+	}
+	if opt.ErrorBurstThreshold > 0 {
+		p.logger = p.logger.Hook(newBurstHook(&p.self, opt.ErrorBurstThreshold, opt.ErrorBurstWindow))
+	}
+	if opt.RetryStormThreshold > 0 {
+		p.retries = newRetryDetector(&p.self, opt.RetryStormThreshold, opt.RetryStormWindow)
+	}
+	if opt.SampleBudget > 0 {
+		if opt.SampleEvery > 1 {
+			p.onInstall = append(p.onInstall, func() {
+				selfEventOn(&p.self, zerolog.WarnLevel, "invalid_option").Msg("SampleEvery ignored: SampleBudget is set")
+			})
+		}
+		a := newAdaptiveSampler(opt.SampleBudget)
+		p.adaptive = a
+		s := newCountingSampler("adaptive", a)
+		p.samplers = append(p.samplers, s)
+		p.logger = p.logger.Hook(adaptiveRateHook{a})
+		p.sampler = s
+	} else if opt.SampleEvery > 1 {
+		s := newCountingSampler("sample_every", &zerolog.BasicSampler{N: uint32(opt.SampleEvery)})
+		p.samplers = append(p.samplers, s)
+		p.logger = p.logger.Hook(sampleRateHook(opt.SampleEvery))
+		p.sampler = s
+	}
+	p.logger = p.logger.Sample(levelGate{p: p, next: p.sampler})
+	if len(opt.FlagSampleEvery) > 0 {
+		f, samplers := newFlagSampling(opt.FlagSampleEvery)
+		p.flags = f
+		p.samplers = append(p.samplers, samplers...)
+	}
+	if len(p.samplers) > 0 {
+		p.closers = append(p.closers, startSamplingReporter(&p.self, p.samplers, opt.SampleReportInterval))
+	}
+	if len(opt.HostSLOs) > 0 {
+		p.slos = startSLOTracker(&p.self, opt.HostSLOs, opt.SLOReportInterval)
+		p.closers = append(p.closers, p.slos)
+	}
+	if opt.Async {
+		p.closers = append(p.closers, startDropReporter(p, opt.DropReportInterval))
+	}
+	if cost != nil {
+		p.closers = append(p.closers, cost)
+	}
+	return p
 }
 
 // With returns a child logger with more fields (without touching global).
 func With(kv ...any) zerolog.Logger {
-	return log.Logger.With().Fields(kvToMap(kv...)).Logger()
+	return std.With(kv...)
 }
 
 // IntoContext stores a logger into ctx (merging given fields) using zerolog's native context.
+// With OTELCorrelation, the active span's trace_id is stored too unless ctx already has one.
 func IntoContext(ctx context.Context, kv ...any) context.Context {
-	fields := kvToMap(kv...)
-	if base := log.Ctx(ctx); base != nil && base.GetLevel() != zerolog.Disabled {
-		ll := base.With().Fields(fields).Logger()
-		return ll.WithContext(ctx) // ✅ store under zerolog's key
-	}
-	ll := log.Logger.With().Fields(fields).Logger()
-	return ll.WithContext(ctx) // ✅ store under zerolog's key
+	return std.IntoContext(ctx, kv...)
 }
 
-// From extracts the logger from ctx; falls back to global.
 // From extracts the logger from ctx; falls back to global.
 func From(ctx context.Context) *zerolog.Logger {
-	if ctx == nil {
-		return &log.Logger
-	}
-	if l := log.Ctx(ctx); l != nil { // ← zerolog-native context lookup
+	return std.from(ctx, 1)
+}
+
+// from is From; skip is the number of frames between the caller of the
+// exported function and from, for the bare-context warning.
+func from(ctx context.Context, skip int) *zerolog.Logger {
+	return std.from(ctx, skip+1)
+}
+
+// withEventCtx binds ctx to l's events when enrichers need it, and adds the
+// active span's IDs with OTELCorrelation; otherwise l is returned as is, so
+// From stays allocation-free for the common setup.
+func withEventCtx(p *pipeline, l *zerolog.Logger, ctx context.Context) *zerolog.Logger {
+	if p == nil || (!p.enrich && !p.otel) {
 		return l
 	}
-	// (optional) compat path if you still have old code that used your custom key:
-	if l, ok := ctx.Value(ctxLoggerKey).(*zerolog.Logger); ok && l != nil {
+	c := l.With()
+	if p.enrich {
+		c = c.Ctx(ctx)
+	}
+	if tid, sid, ok := spanIDs(p, ctx); ok {
+		if GetTraceID(ctx) == "" {
+			c = c.Str(FieldTraceID, tid)
+		}
+		c = c.Str(FieldSpanID, sid)
+	} else if !p.enrich {
 		return l
 	}
-	return &log.Logger
+	ll := c.Logger()
+	return &ll
 }
 
 // Helpers to set/read common IDs on context
 func WithRequestID(ctx context.Context, reqID string) context.Context {
-	return IntoContext(context.WithValue(ctx, ctxReqIDKey, reqID), "request_id", reqID)
+	return IntoContext(context.WithValue(ctx, ctxReqIDKey, reqID), FieldRequestID, reqID)
 }
 func WithAPIID(ctx context.Context, apiID string) context.Context {
-	return IntoContext(context.WithValue(ctx, ctxApiIDKey, apiID), "api_id", apiID)
+	return IntoContext(context.WithValue(ctx, ctxApiIDKey, apiID), FieldAPIID, apiID)
 }
 func WithOperatorName(ctx context.Context, operatorID string) context.Context {
-	return IntoContext(context.WithValue(ctx, ctxOperatorNameKey, operatorID), "operator_name", operatorID)
+	return IntoContext(context.WithValue(ctx, ctxOperatorNameKey, operatorID), FieldOperatorName, operatorID)
 }
 func WithRole(ctx context.Context, role any) context.Context {
-	return IntoContext(context.WithValue(ctx, ctxRoleKey, role), "role", role)
+	return IntoContext(context.WithValue(ctx, ctxRoleKey, role), FieldRole, role)
 }
 func WithTraceID(ctx context.Context, traceID string) context.Context {
-	return IntoContext(context.WithValue(ctx, ctxTraceIDKey, traceID), "trace_id", traceID)
+	if GetTraceID(ctx) == traceID { // already stored, e.g. from the OTel span
+		return ctx
+	}
+	return IntoContext(context.WithValue(ctx, ctxTraceIDKey, traceID), FieldTraceID, traceID)
 }
 func WithIPAddress(ctx context.Context, ipAddress string) context.Context {
-	return IntoContext(context.WithValue(ctx, ctxIPAddressKey, ipAddress), "ip_address", ipAddress)
+	return IntoContext(context.WithValue(ctx, ctxIPAddressKey, ipAddress), FieldIPAddress, ipAddress)
 }
 
 func GetRequestID(ctx context.Context) string {
@@ -235,6 +805,55 @@ func GetTraceID(ctx context.Context) string {
 
 // --- internals ---
 
+// ctxLogger returns the logger zerolog stored in ctx, or nil when there is none.
+// zerolog.Ctx never returns nil; it hands back its disabled/default logger instead,
+// which would silently swallow events if From returned it.
+func ctxLogger(ctx context.Context) *zerolog.Logger {
+	l := zerolog.Ctx(ctx)
+	if l == zerolog.Ctx(context.Background()) || l.GetLevel() == zerolog.Disabled {
+		return nil
+	}
+	return l
+}
+
+// caller format: "pkg.Func /path/file.go:42"
+func callerMarshal(pc uintptr, file string, line int) string {
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return file + ":" + itoa(line)
+	}
+	return fn.Name() + " " + file + ":" + itoa(line)
+}
+
+// withFields adds kv pairs to c. LogArrayMarshaler values go through c.Array,
+// since Fields would JSON-encode them; everything else (including
+// LogObjectMarshaler values and errors) is handled natively by Fields.
+// A value whose MarshalZerologObject/MarshalZerologArray panics leaves c as it
+// was plus a "fields_error" field, rather than a half-written context.
+func withFields(c zerolog.Context, kv ...any) (out zerolog.Context) {
+	orig := c
+	defer func() {
+		if r := recover(); r != nil {
+			reportMarshalFailure(fmt.Sprintf("%T", kv), fmt.Errorf("panic: %v", r))
+			out = orig.Str("fields_error", fmt.Sprintf("!PANIC adding fields: %v", r))
+		}
+	}()
+	m := kvToMap(kv...)
+	for i := 0; i+1 < len(kv); i += 2 {
+		k, ok := kv[i].(string)
+		if !ok {
+			continue
+		}
+		if a, ok := kv[i+1].(zerolog.LogArrayMarshaler); ok {
+			if _, obj := a.(zerolog.LogObjectMarshaler); !obj {
+				c = c.Array(k, a)
+				delete(m, k)
+			}
+		}
+	}
+	return c.Fields(m)
+}
+
 func kvToMap(kv ...any) map[string]any {
 	m := make(map[string]any)
 	for i := 0; i+1 < len(kv); i += 2 {
@@ -0,0 +1,122 @@
+package slogging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// eventCapture is an ExtraWriter that keeps every event written to it.
+type eventCapture struct {
+	mu    sync.Mutex
+	lines [][]byte
+}
+
+func (c *eventCapture) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	c.lines = append(c.lines, bytes.Clone(p))
+	c.mu.Unlock()
+	return len(p), nil
+}
+
+// events decodes the captured events, oldest first.
+func (c *eventCapture) events(t testing.TB) []map[string]any {
+	t.Helper()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]map[string]any, 0, len(c.lines))
+	for _, l := range c.lines {
+		var ev map[string]any
+		if err := json.Unmarshal(l, &ev); err != nil {
+			t.Fatalf("non-JSON event %q: %v", l, err)
+		}
+		out = append(out, ev)
+	}
+	return out
+}
+
+// withMessage returns the captured events whose message is msg.
+func (c *eventCapture) withMessage(t testing.TB, msg string) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for _, ev := range c.events(t) {
+		if ev["message"] == msg {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+// initCapture installs a pipeline built from opt whose events also land in
+// the returned eventCapture, with the file sink under t.TempDir so nothing goes
+// to stdout, and closes it when the test ends.
+func initCapture(t testing.TB, opt Options) *eventCapture {
+	t.Helper()
+	c := &eventCapture{}
+	if opt.FilePath == "" {
+		opt.FilePath = filepath.Join(t.TempDir(), "test.log")
+	}
+	opt.ExtraWriter = c
+	Init(opt)
+	t.Cleanup(func() { Close(context.Background()) })
+	return c
+}
+
+func TestFromCarriesContextFields(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc"})
+	ctx := WithRequestID(context.Background(), "r1")
+	ctx = IntoContext(ctx, "user", "u1")
+	From(ctx).Info().Msg("hello")
+
+	evs := c.withMessage(t, "hello")
+	if len(evs) != 1 {
+		t.Fatalf("got %d events, want 1", len(evs))
+	}
+	ev := evs[0]
+	for k, want := range map[string]any{FieldService: "svc", FieldRequestID: "r1", "user": "u1", "level": "info"} {
+		if ev[k] != want {
+			t.Errorf("%s = %v, want %v", k, ev[k], want)
+		}
+	}
+}
+
+func TestNewReadsContextIDs(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc", Level: "debug"})
+	bg := context.Background()
+	typed := WithOperatorName(WithAPIID(WithRequestID(bg, "r1"), "a1"), "op1")
+	legacy := context.WithValue(context.WithValue(context.WithValue(bg, XRequestID, "r2"), APIID, "a2"), XOperator, "op2")
+
+	New(typed).Debug().Msg("typed")
+	New(legacy).Warn().Msg("legacy")
+	New(WithRequestID(legacy, "r3")).Info().Msg("both")
+	New(bg).Info().Msg("none")
+
+	for _, tc := range []struct {
+		msg, level       string
+		req, api, opName any
+	}{
+		{"typed", "debug", "r1", "a1", "op1"},
+		{"legacy", "warn", "r2", "a2", "op2"},
+		{"both", "info", "r3", "a2", "op2"}, // the typed key wins
+		{"none", "info", nil, nil, nil},
+	} {
+		evs := c.withMessage(t, tc.msg)
+		if len(evs) != 1 {
+			t.Fatalf("%s: got %d events, want 1", tc.msg, len(evs))
+		}
+		ev := evs[0]
+		if ev["level"] != tc.level || ev[FieldRequestID] != tc.req || ev[FieldAPIID] != tc.api || ev[FieldOperatorName] != tc.opName {
+			t.Errorf("%s: event = %v", tc.msg, ev)
+		}
+		// The IDs are written under the field names From uses, not the
+		// legacy key names.
+		for _, k := range []string{XRequestID, XOperator} {
+			if _, ok := ev[k]; ok {
+				t.Errorf("%s: event has the legacy key %s", tc.msg, k)
+			}
+		}
+	}
+}
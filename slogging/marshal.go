@@ -0,0 +1,158 @@
+package slogging
+
+import (
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// marshalers is a copy-on-write registry read on every Interface/Any/Fields
+// value, so lookups stay lock-free.
+var (
+	marshalersMu sync.Mutex
+	marshalers   atomic.Pointer[map[reflect.Type]func(any) any]
+
+	// fallbackMarshal and fallbackErrorMarshal are the zerolog funcs in place before ours.
+	fallbackMarshal      func(any) ([]byte, error)
+	fallbackErrorMarshal func(error) any
+)
+
+// RegisterMarshaler makes every logged value of type T (and *T) serialize via fn,
+// whether it reaches the event through With/IntoContext fields, Interface or Any.
+// fn returns the value to encode instead, typically a string or a small map:
+//
+//	slogging.RegisterMarshaler(func(m Money) any { return m.String() })
+//	slogging.RegisterMarshaler(func(id UserID) any { return id.Redacted() })
+//
+// A panicking fn does not take the logger down; the field carries the panic text.
+func RegisterMarshaler[T any](fn func(T) any) {
+	setFormatGlobals()
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	marshalersMu.Lock()
+	defer marshalersMu.Unlock()
+	next := make(map[reflect.Type]func(any) any)
+	if cur := marshalers.Load(); cur != nil {
+		for k, v := range *cur {
+			next[k] = v
+		}
+	}
+	next[t] = func(v any) any { return fn(v.(T)) }
+	marshalers.Store(&next)
+}
+
+// marshalInterface is installed as zerolog.InterfaceMarshalFunc. Whatever the
+// value does (a registered fn or MarshalJSON that panics, NaN inside a map, a
+// reference cycle) the event stays valid JSON: errors become zerolog's
+// "marshaling error: ..." string and panics a "!PANIC ..." string.
+func marshalInterface(v any) (b []byte, err error) {
+	orig := v
+	defer func() {
+		if r := recover(); r != nil {
+			reportMarshalFailure(fmt.Sprintf("%T", orig), fmt.Errorf("panic: %v", r))
+			b, err = fallbackMarshal(fmt.Sprintf("!PANIC in marshaler for %T: %v", orig, r))
+		}
+	}()
+	if fn := lookupMarshaler(v); fn != nil {
+		v = fn(v)
+	}
+	b, err = fallbackMarshal(v)
+	if err != nil {
+		reportMarshalFailure(fmt.Sprintf("%T", orig), err)
+	}
+	return b, err
+}
+
+func lookupMarshaler(v any) func(any) any {
+	m := marshalers.Load()
+	if m == nil || v == nil {
+		return nil
+	}
+	t := reflect.TypeOf(v)
+	if fn, ok := (*m)[t]; ok {
+		return fn
+	}
+	if t.Kind() == reflect.Pointer {
+		if fn, ok := (*m)[t.Elem()]; ok {
+			rv := reflect.ValueOf(v)
+			if rv.IsNil() {
+				return nil
+			}
+			return func(any) any { return fn(rv.Elem().Interface()) }
+		}
+	}
+	return nil
+}
+
+// marshalError is installed as zerolog.ErrorMarshalFunc. zerolog already lets an
+// error implementing LogObjectMarshaler render itself; this extends that to
+// errors wrapped with %w, so a domain error keeps its structure after
+// fmt.Errorf("charge: %w", err). The wrapper's text goes under "message".
+//
+// Error() is called here rather than by zerolog so that an Error method that
+// panics (typically on a nil field) yields a "!PANIC ..." string instead.
+func marshalError(err error) (out any) {
+	if err == nil {
+		return fallbackErrorMarshal(err)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			reportMarshalFailure(fmt.Sprintf("%T", err), fmt.Errorf("panic: %v", r))
+			out = fmt.Sprintf("!PANIC in Error() for %T: %v", err, r)
+		}
+	}()
+	if _, ok := err.(zerolog.LogObjectMarshaler); ok {
+		return err
+	}
+	var m zerolog.LogObjectMarshaler
+	if errors.As(err, &m) {
+		return wrappedObjectError{msg: err.Error(), inner: m}
+	}
+	out = fallbackErrorMarshal(err)
+	if e, ok := out.(error); ok && !isNilValue(e) {
+		return e.Error()
+	}
+	return out
+}
+
+type wrappedObjectError struct {
+	msg   string
+	inner zerolog.LogObjectMarshaler
+}
+
+func (w wrappedObjectError) Error() string { return w.msg }
+
+func (w wrappedObjectError) MarshalZerologObject(e *zerolog.Event) {
+	e.Str("message", w.msg)
+	w.inner.MarshalZerologObject(e)
+}
+
+// isNilValue reports a typed nil inside an interface, which zerolog omits.
+func isNilValue(v any) bool {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// marshalFailures remembers which types already produced a "marshal_failed"
+// event, so a hot path logging a bad value reports it once per process.
+var marshalFailures sync.Map // type name -> struct{}
+
+// reportMarshalFailure emits a warn self event the first time values of type
+// typ (a %T name) fail to serialize. It takes only the name, so the value
+// that failed never reaches the self event.
+func reportMarshalFailure(typ string, err error) {
+	if _, seen := marshalFailures.LoadOrStore(typ, struct{}{}); seen {
+		return
+	}
+	selfEvent(zerolog.WarnLevel, "marshal_failed").
+		Str("type", typ).
+		Str("error", err.Error()).
+		Msg("field value could not be serialized")
+}
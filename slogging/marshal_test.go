@@ -0,0 +1,147 @@
+package slogging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog"
+	"math"
+	"strings"
+	"testing"
+)
+
+type panicJSON struct{ msg string }
+
+func (p panicJSON) MarshalJSON() ([]byte, error) { panic(p.msg) }
+
+type node struct {
+	Name string
+	Next *node
+}
+
+type fuzzMarshaled string
+
+// fuzzValue builds one of the values marshalInterface must survive from the
+// fuzzer's inputs.
+func fuzzValue(kind byte, s string) any {
+	switch kind % 8 {
+	case 0:
+		return panicJSON{s}
+	case 1:
+		n := &node{Name: s}
+		n.Next = n
+		return n
+	case 2:
+		m := map[string]any{"s": s}
+		m["self"] = m
+		return m
+	case 3:
+		return (*node)(nil)
+	case 4:
+		return map[string]float64{s: math.NaN()}
+	case 5:
+		return fuzzMarshaled(s)
+	case 6:
+		return []any{s, panicJSON{s}}
+	default:
+		return s
+	}
+}
+
+func FuzzMarshalInterface(f *testing.F) {
+	RegisterMarshaler(func(v fuzzMarshaled) any {
+		if strings.HasPrefix(string(v), "!") {
+			panic(string(v))
+		}
+		return string(v)
+	})
+	for k := range byte(8) {
+		f.Add(k, "value")
+		f.Add(k, "!boom \"quoted\"\n")
+	}
+	f.Fuzz(func(t *testing.T, kind byte, s string) {
+		var buf bytes.Buffer
+		l := zerolog.New(&buf)
+		v := fuzzValue(kind, s)
+		l.Info().Interface("v", v).Fields(map[string]any{"f": v}).Msg("m")
+		if !json.Valid(buf.Bytes()) {
+			t.Fatalf("invalid event for %T: %s", v, buf.Bytes())
+		}
+	})
+}
+
+type panicErr struct{ msg string }
+
+func (p panicErr) Error() string { panic(p.msg) }
+
+type ptrErr struct{ code *int }
+
+func (p *ptrErr) Error() string { return fmt.Sprint(*p.code) } // panics on a nil *ptrErr or code
+
+type objErr struct{ code string }
+
+func (o objErr) Error() string                         { return o.code }
+func (o objErr) MarshalZerologObject(e *zerolog.Event) { e.Str("code", o.code) }
+
+func fuzzError(kind byte, s string) error {
+	switch kind % 6 {
+	case 0:
+		return panicErr{s}
+	case 1:
+		return (*ptrErr)(nil)
+	case 2:
+		return &ptrErr{}
+	case 3:
+		return fmt.Errorf("wrap %s: %w", s, objErr{s})
+	case 4:
+		return fmt.Errorf("wrap: %w", panicErr{s})
+	default:
+		return errors.New(s)
+	}
+}
+
+func FuzzMarshalError(f *testing.F) {
+	setFormatGlobals()
+	for k := range byte(6) {
+		f.Add(k, "failed")
+		f.Add(k, "\x00\"}")
+	}
+	f.Fuzz(func(t *testing.T, kind byte, s string) {
+		var buf bytes.Buffer
+		l := zerolog.New(&buf)
+		err := fuzzError(kind, s)
+		l.Error().Err(err).Errs("errs", []error{err, nil}).AnErr("cause", err).Msg("m")
+		if !json.Valid(buf.Bytes()) {
+			t.Fatalf("invalid event for %T: %s", err, buf.Bytes())
+		}
+	})
+}
+
+type secretNaN struct {
+	Secret string
+	Ratio  float64
+}
+
+// TestMarshalFailureReportsTypeOnly checks the marshal_failed self event
+// names the value's type without carrying the value itself.
+func TestMarshalFailureReportsTypeOnly(t *testing.T) {
+	marshalFailures.Clear()
+	c := initCapture(t, Options{Service: "svc"})
+	From(nil).Info().Interface("v", secretNaN{"hunter2", math.NaN()}).Msg("ratio")
+	From(nil).Info().Interface("v", secretNaN{"hunter3", math.NaN()}).Msg("ratio")
+
+	var reports int
+	for _, l := range c.lines {
+		if !bytes.Contains(l, []byte(`"marshal_failed"`)) {
+			continue
+		}
+		reports++
+		if !bytes.Contains(l, []byte(`"type":"slogging.secretNaN"`)) || bytes.Contains(l, []byte("hunter")) {
+			t.Errorf("marshal_failed event = %s", l)
+		}
+	}
+	if reports != 1 {
+		t.Errorf("got %d marshal_failed events, want 1", reports)
+	}
+}
@@ -0,0 +1,491 @@
+package slogging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/rs/zerolog"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Metric kinds for MetricRule.Kind.
+const (
+	MetricCounter   = "counter"
+	MetricHistogram = "histogram"
+)
+
+// maxMetricSeries bounds the label combinations kept per rule; events with a
+// new combination beyond it are not counted.
+const maxMetricSeries = 1000
+
+// DefaultMetricBuckets are the histogram buckets used when a rule sets none,
+// sized for millisecond durations.
+var DefaultMetricBuckets = []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// MetricRule derives a metric from the events the pipeline writes, so common
+// metrics need no instrumentation of their own (Options.MetricRules):
+//
+//	{Name: "payment_errors_total", Where: map[string]string{"level": "error", "component": "payments"}}
+//	{Name: "checkout_duration_ms", Kind: slogging.MetricHistogram, Field: "duration_ms",
+//	 Where: map[string]string{"path": "/checkout"}, Labels: []string{"status"}}
+//
+// Where compares top-level fields with their string form (numbers and bools
+// as written, e.g. "500"); all must match. Events that were sampled count
+// sample_rate times. Metrics are served by MetricsHandler in the Prometheus
+// text format, or OpenMetrics with exemplars naming the trace_id and
+// request_id of a recent matching event, and, with MetricReportInterval,
+// summarized as "metric_summary" events. Name counters with a _total suffix,
+// as OpenMetrics requires it. JSON output only.
+type MetricRule struct {
+	Name    string // Prometheus metric name
+	Help    string
+	Kind    string // MetricCounter (default) or MetricHistogram
+	Where   map[string]string
+	Field   string    // histogram: the numeric field observed
+	Buckets []float64 // histogram: upper bounds; default DefaultMetricBuckets
+	Labels  []string  // fields copied to labels; missing ones are ""
+}
+
+var metricNameRE = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+type metricCond struct {
+	key   []byte // quoted
+	value string
+}
+
+type metricRule struct {
+	MetricRule
+	where  []metricCond
+	field  []byte   // quoted
+	labels [][]byte // quoted
+
+	series map[string]*metricSeries // by joined label values; guarded by metrics.mu
+}
+
+type metricSeries struct {
+	labels  []string
+	count   float64
+	sum     float64
+	buckets []float64 // per bucket, not cumulative
+
+	// exemplars holds the latest exemplar of the counter (one entry) or of
+	// each histogram bucket, +Inf last; nil entries have none yet.
+	exemplars []*exemplar
+
+	winCount, winSum, winMax float64 // since the last summary
+}
+
+// metrics evaluates MetricRules against events on their way to the sinks.
+type metrics struct {
+	w     io.Writer
+	log   *zerolog.Logger
+	rules []*metricRule
+
+	mu sync.Mutex
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// newMetrics compiles rules; invalid ones are skipped and returned as errors.
+func newMetrics(w io.Writer, log *zerolog.Logger, rules []MetricRule) (*metrics, []error) {
+	m := &metrics{w: w, log: log}
+	var errs []error
+	for _, r := range rules {
+		if !metricNameRE.MatchString(r.Name) {
+			errs = append(errs, fmt.Errorf("slogging: MetricRule name %q is not a valid metric name", r.Name))
+			continue
+		}
+		switch r.Kind {
+		case "":
+			r.Kind = MetricCounter
+		case MetricCounter:
+		case MetricHistogram:
+			if r.Field == "" {
+				errs = append(errs, fmt.Errorf("slogging: MetricRule %s: histogram without Field", r.Name))
+				continue
+			}
+			if len(r.Buckets) == 0 {
+				r.Buckets = DefaultMetricBuckets
+			}
+			r.Buckets = append([]float64(nil), r.Buckets...)
+			sort.Float64s(r.Buckets)
+		default:
+			errs = append(errs, fmt.Errorf("slogging: MetricRule %s: unknown Kind %q", r.Name, r.Kind))
+			continue
+		}
+		cr := &metricRule{MetricRule: r, series: make(map[string]*metricSeries)}
+		for k, v := range r.Where {
+			cr.where = append(cr.where, metricCond{key: []byte(`"` + k + `"`), value: v})
+		}
+		if r.Field != "" {
+			cr.field = []byte(`"` + r.Field + `"`)
+		}
+		for _, l := range r.Labels {
+			cr.labels = append(cr.labels, []byte(`"`+l+`"`))
+		}
+		m.rules = append(m.rules, cr)
+	}
+	return m, errs
+}
+
+// startReporter emits "metric_summary" events every interval until Close.
+func (m *metrics) startReporter(every time.Duration) {
+	m.stop = make(chan struct{})
+	m.done = make(chan struct{})
+	go m.run(every)
+}
+
+func (m *metrics) Write(p []byte) (int, error) {
+	return m.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (m *metrics) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if !bytes.Contains(p, selfMarker) {
+		m.observe(p)
+	}
+	return writeLevel(m.w, level, p)
+}
+
+func (m *metrics) observe(p []byte) {
+	var members []span
+	scanned := false
+	weight := 1.0
+	var ex string
+	for _, r := range m.rules {
+		if !scanned {
+			var ok bool
+			if members, _, ok = scanMembers(p); !ok {
+				return
+			}
+			scanned = true
+			if v, ok := memberString(p, members, sampleRateKey); ok {
+				if n, err := strconv.ParseFloat(v, 64); err == nil && n > 1 {
+					weight = n
+				}
+			}
+			ex = eventExemplar(p, members)
+		}
+		if !r.matches(p, members) {
+			continue
+		}
+		value := 0.0
+		if r.Kind == MetricHistogram {
+			v, ok := memberString(p, members, r.field)
+			if !ok {
+				continue
+			}
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil || math.IsNaN(f) {
+				continue
+			}
+			value = f
+		}
+		labels := make([]string, len(r.labels))
+		for i, l := range r.labels {
+			labels[i], _ = memberString(p, members, l)
+		}
+		m.record(r, labels, value, weight, ex)
+	}
+}
+
+var (
+	sampleRateKey = []byte(`"sample_rate"`)
+	traceIDKey    = []byte(`"` + FieldTraceID + `"`)
+	requestIDKey  = []byte(`"` + FieldRequestID + `"`)
+)
+
+// maxExemplarRunes is OpenMetrics' limit on the combined length of an
+// exemplar's label names and values.
+const maxExemplarRunes = 128
+
+// exemplar ties an observation to the event it came from.
+type exemplar struct {
+	labels string // rendered {trace_id="...",request_id="..."}
+	value  float64
+	ts     time.Time
+}
+
+// eventExemplar returns the rendered exemplar labels of the event, or "" when
+// it has no trace_id or request_id, or they are too long to attach.
+func eventExemplar(p []byte, members []span) string {
+	var names, values []string
+	n := 0
+	for _, k := range [...]struct {
+		name string
+		key  []byte
+	}{{FieldTraceID, traceIDKey}, {FieldRequestID, requestIDKey}} {
+		v, ok := memberString(p, members, k.key)
+		if !ok || v == "" {
+			continue
+		}
+		if l := len(k.name) + utf8.RuneCountInString(v); n+l <= maxExemplarRunes {
+			names, values = append(names, k.name), append(values, v)
+			n += l
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	return promLabels(names, values, "")
+}
+
+func (r *metricRule) matches(p []byte, members []span) bool {
+	for _, c := range r.where {
+		if v, ok := memberString(p, members, c.key); !ok || v != c.value {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *metrics) record(r *metricRule, labels []string, value, weight float64, ex string) {
+	key := strings.Join(labels, "\x00")
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := r.series[key]
+	if s == nil {
+		if len(r.series) >= maxMetricSeries {
+			return
+		}
+		s = &metricSeries{labels: labels, exemplars: make([]*exemplar, 1)}
+		if r.Kind == MetricHistogram {
+			s.buckets = make([]float64, len(r.Buckets))
+			s.exemplars = make([]*exemplar, len(r.Buckets)+1)
+		}
+		r.series[key] = s
+	}
+	s.count += weight
+	s.winCount += weight
+	if r.Kind != MetricHistogram {
+		if ex != "" {
+			s.exemplars[0] = &exemplar{labels: ex, value: 1, ts: time.Now()}
+		}
+		return
+	}
+	s.sum += value * weight
+	s.winSum += value * weight
+	s.winMax = max(s.winMax, value)
+	i := sort.SearchFloat64s(r.Buckets, value)
+	if i < len(s.buckets) {
+		s.buckets[i] += weight
+	}
+	if ex != "" {
+		s.exemplars[i] = &exemplar{labels: ex, value: value, ts: time.Now()}
+	}
+}
+
+// memberString returns the value of the top-level member key (quoted) as a
+// string: strings unquoted, other scalars as written.
+func memberString(p []byte, members []span, key []byte) (string, bool) {
+	for _, m := range members {
+		if !bytes.Equal(p[m.start:m.keyEnd], key) {
+			continue
+		}
+		v := bytes.TrimSpace(bytes.TrimLeft(p[m.keyEnd:m.end], " :"))
+		if len(v) >= 2 && v[0] == '"' {
+			if bytes.IndexByte(v, '\\') < 0 {
+				return string(v[1 : len(v)-1]), true
+			}
+			var s string
+			if json.Unmarshal(v, &s) != nil {
+				return "", false
+			}
+			return s, true
+		}
+		return string(v), true
+	}
+	return "", false
+}
+
+func (m *metrics) run(every time.Duration) {
+	defer close(m.done)
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-m.stop:
+			m.report(every)
+			return
+		case <-t.C:
+			m.report(every)
+		}
+	}
+}
+
+// report emits one "metric_summary" per series that saw events since the
+// previous report. Events are emitted after the lock is released.
+func (m *metrics) report(window time.Duration) {
+	type summary struct {
+		r                *metricRule
+		labels           []string
+		count, sum, maxv float64
+	}
+	var out []summary
+	m.mu.Lock()
+	for _, r := range m.rules {
+		for _, s := range r.series {
+			if s.winCount == 0 {
+				continue
+			}
+			out = append(out, summary{r, s.labels, s.winCount, s.winSum, s.winMax})
+			s.winCount, s.winSum, s.winMax = 0, 0, 0
+		}
+	}
+	m.mu.Unlock()
+	for _, s := range out {
+		labels := zerolog.Dict()
+		for i, l := range s.r.Labels {
+			labels.Str(l, s.labels[i])
+		}
+		e := selfEventOn(m.log, zerolog.InfoLevel, "metric_summary").
+			Str("metric", s.r.Name).
+			Dict("labels", labels).
+			Float64("count", s.count).
+			Dur("window", window)
+		if s.r.Kind == MetricHistogram {
+			e = e.Float64("sum", s.sum).Float64("avg", s.sum/s.count).Float64("max", s.maxv)
+		}
+		e.Msg(s.r.Name)
+	}
+}
+
+// Close emits a final summary and stops the reporter, if one runs.
+func (m *metrics) Close() error {
+	if m.stop == nil {
+		return nil
+	}
+	m.once.Do(func() { close(m.stop) })
+	<-m.done
+	return nil
+}
+
+// MetricsHandler serves the metrics derived by Options.MetricRules in the
+// Prometheus text exposition format, or in OpenMetrics with exemplars when
+// the scraper accepts it (Prometheus does with exemplar storage enabled):
+//
+//	mux.Handle("/metrics/logs", slogging.MetricsHandler())
+//
+// Values are cumulative since the pipeline was built; a re-Init starts them
+// again from zero, which Prometheus treats as a counter reset.
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		om := strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+		if om {
+			w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+		} else {
+			w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		}
+		if p := current.Load(); p != nil && p.metrics != nil {
+			p.metrics.writeText(w, om)
+		} else if om {
+			io.WriteString(w, "# EOF\n")
+		}
+	})
+}
+
+// writeText writes the metrics in the Prometheus text format or, with om, in
+// OpenMetrics, where counter samples are named family_total and carry
+// exemplars, as do histogram buckets.
+func (m *metrics) writeText(w io.Writer, om bool) {
+	var b bytes.Buffer
+	m.mu.Lock()
+	for _, r := range m.rules {
+		family, sample := r.Name, r.Name
+		if om && r.Kind == MetricCounter {
+			family = strings.TrimSuffix(r.Name, "_total")
+			sample = family + "_total"
+		}
+		if r.Help != "" {
+			fmt.Fprintf(&b, "# HELP %s %s\n", family, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(r.Help))
+		}
+		fmt.Fprintf(&b, "# TYPE %s %s\n", family, r.Kind)
+		keys := make([]string, 0, len(r.series))
+		for k := range r.series {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			s := r.series[k]
+			ex := func(i int) string {
+				if !om || s.exemplars[i] == nil {
+					return ""
+				}
+				e := s.exemplars[i]
+				return fmt.Sprintf(" # %s %s %s", e.labels, promFloat(e.value),
+					strconv.FormatFloat(float64(e.ts.UnixMilli())/1000, 'f', 3, 64))
+			}
+			if r.Kind == MetricCounter {
+				fmt.Fprintf(&b, "%s%s %s%s\n", sample, promLabels(r.Labels, s.labels, ""), promFloat(s.count), ex(0))
+				continue
+			}
+			cum := 0.0
+			for i, ub := range r.Buckets {
+				cum += s.buckets[i]
+				fmt.Fprintf(&b, "%s_bucket%s %s%s\n", r.Name, promLabels(r.Labels, s.labels, promFloat(ub)), promFloat(cum), ex(i))
+			}
+			fmt.Fprintf(&b, "%s_bucket%s %s%s\n", r.Name, promLabels(r.Labels, s.labels, "+Inf"), promFloat(s.count), ex(len(r.Buckets)))
+			fmt.Fprintf(&b, "%s_sum%s %s\n", r.Name, promLabels(r.Labels, s.labels, ""), promFloat(s.sum))
+			fmt.Fprintf(&b, "%s_count%s %s\n", r.Name, promLabels(r.Labels, s.labels, ""), promFloat(s.count))
+		}
+	}
+	m.mu.Unlock()
+	if om {
+		b.WriteString("# EOF\n")
+	}
+	w.Write(b.Bytes())
+}
+
+var promEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// promLabels renders {name="value",...}, adding le when set.
+func promLabels(names, values []string, le string) string {
+	if len(names) == 0 && le == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, n := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(promLabelName(n))
+		b.WriteString(`="`)
+		b.WriteString(promEscaper.Replace(values[i]))
+		b.WriteByte('"')
+	}
+	if le != "" {
+		if len(names) > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(`le="` + le + `"`)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// promLabelName maps a field name to a valid label name.
+func promLabelName(n string) string {
+	b := []byte(n)
+	for i, c := range b {
+		if c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9' {
+			continue
+		}
+		b[i] = '_'
+	}
+	return string(b)
+}
+
+func promFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,125 @@
+package slogging
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scrape returns the body MetricsHandler serves for accept.
+func scrape(t *testing.T, accept string) string {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	MetricsHandler().ServeHTTP(w, req)
+	b, _ := io.ReadAll(w.Result().Body)
+	return string(b)
+}
+
+// checkoutRules are a counter and a histogram over the events logCheckout
+// writes.
+var checkoutRules = []MetricRule{
+	{Name: "payment_errors_total", Help: "Failed payments.", Where: map[string]string{"level": "error", FieldComponent: "payments"}},
+	{Name: "checkout_duration_ms", Kind: MetricHistogram, Field: FieldDurationMs, Buckets: []float64{100, 10},
+		Where: map[string]string{FieldPath: "/checkout"}, Labels: []string{FieldStatus}},
+}
+
+func logCheckout(ctx context.Context) {
+	pay := Component("payments")
+	pay.Error().Msg("card declined")
+	pay.Error().Int("sample_rate", 4).Msg("card declined") // stands for 4 events
+	pay.Warn().Msg("retrying")
+	Component("orders").Error().Msg("not payments")
+	for _, c := range []struct {
+		status int
+		ms     float64
+	}{{200, 5}, {200, 50}, {200, 500}, {500, 20}} {
+		From(ctx).Info().Str(FieldPath, "/checkout").Int(FieldStatus, c.status).Float64(FieldDurationMs, c.ms).Msg("request completed")
+	}
+	From(ctx).Info().Str(FieldPath, "/health").Float64(FieldDurationMs, 1).Msg("request completed")
+}
+
+func TestMetricRules(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc", MetricReportInterval: time.Hour, MetricRules: append([]MetricRule{
+		{Name: "bad-name"},
+		{Name: "no_field", Kind: MetricHistogram},
+	}, checkoutRules...)})
+	logCheckout(context.Background())
+
+	want := `# HELP payment_errors_total Failed payments.
+# TYPE payment_errors_total counter
+payment_errors_total 5
+# TYPE checkout_duration_ms histogram
+checkout_duration_ms_bucket{status="200",le="10"} 1
+checkout_duration_ms_bucket{status="200",le="100"} 2
+checkout_duration_ms_bucket{status="200",le="+Inf"} 3
+checkout_duration_ms_sum{status="200"} 555
+checkout_duration_ms_count{status="200"} 3
+checkout_duration_ms_bucket{status="500",le="10"} 0
+checkout_duration_ms_bucket{status="500",le="100"} 1
+checkout_duration_ms_bucket{status="500",le="+Inf"} 1
+checkout_duration_ms_sum{status="500"} 20
+checkout_duration_ms_count{status="500"} 1
+`
+	if got := scrape(t, ""); got != want {
+		t.Errorf("metrics:\n%s\nwant:\n%s", got, want)
+	}
+	if n := len(c.selfEvents(t, "invalid_option")); n != 2 {
+		t.Errorf("got %d invalid_option events, want one per bad rule", n)
+	}
+
+	// Close reports the last window.
+	Close(context.Background())
+	sums := map[string]map[string]any{}
+	for _, ev := range c.selfEvents(t, "metric_summary") {
+		labels, _ := ev["labels"].(map[string]any)
+		status, _ := labels[FieldStatus].(string)
+		sums[ev["metric"].(string)+status] = ev
+	}
+	if e := sums["payment_errors_total"]; e["count"] != 5.0 {
+		t.Errorf("counter summary = %v", e)
+	}
+	if e := sums["checkout_duration_ms200"]; e["count"] != 3.0 || e["sum"] != 555.0 || e["avg"] != 185.0 || e["max"] != 500.0 {
+		t.Errorf("histogram summary = %v", e)
+	}
+}
+
+func TestMetricExemplars(t *testing.T) {
+	initCapture(t, Options{Service: "svc", MetricRules: checkoutRules})
+	bg := context.Background()
+	long := strings.Repeat("x", maxExemplarRunes)
+
+	From(WithTraceID(WithRequestID(bg, "r1"), "t1")).Error().Str(FieldComponent, "payments").Msg("card declined")
+	From(WithRequestID(bg, "r2")).Info().Str(FieldPath, "/checkout").Float64(FieldDurationMs, 50).Msg("request completed")
+	From(bg).Info().Str(FieldPath, "/checkout").Float64(FieldDurationMs, 5).Msg("request completed")
+	// A request ID too long for an exemplar is left out, the trace ID kept.
+	From(WithTraceID(WithRequestID(bg, long), "t3")).Info().Str(FieldPath, "/checkout").Float64(FieldDurationMs, 500).Msg("request completed")
+
+	want := `# HELP payment_errors Failed payments.
+# TYPE payment_errors counter
+payment_errors_total 1 # {trace_id="t1",request_id="r1"} 1 TS
+# TYPE checkout_duration_ms histogram
+checkout_duration_ms_bucket{status="",le="10"} 1
+checkout_duration_ms_bucket{status="",le="100"} 2 # {request_id="r2"} 50 TS
+checkout_duration_ms_bucket{status="",le="+Inf"} 3 # {trace_id="t3"} 500 TS
+checkout_duration_ms_sum{status=""} 555
+checkout_duration_ms_count{status=""} 3
+# EOF
+`
+	got := regexp.MustCompile(` \d+\.\d{3}\n`).ReplaceAllString(scrape(t, "application/openmetrics-text; version=1.0.0"), " TS\n")
+	if got != want {
+		t.Errorf("OpenMetrics:\n%s\nwant:\n%s", got, want)
+	}
+	// The plain Prometheus format has no exemplars.
+	if got := scrape(t, ""); strings.Contains(got, " # {") {
+		t.Errorf("Prometheus text has exemplars:\n%s", got)
+	}
+}
@@ -0,0 +1,381 @@
+package slogging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/rs/zerolog"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// maxRequestIDLen bounds an inbound X-Request-ID; longer values are replaced
+// by a generated ID rather than copied into every event of the request.
+const maxRequestIDLen = 128
+
+// HTTPMiddleware prepares the request context and logs one access line per
+// request. It reads X-Request-ID (generating one when missing), X-Trace-ID,
+// x-operator and api_id from the request headers, stores them with the With*
+// helpers, echoes the request ID in the response and, when next returns,
+// emits an event with method, path, status, bytes, response_bytes,
+// content_type and duration_ms. 5xx responses log at error level, 4xx at
+// warn, everything else at info.
+//
+// bytes is what the handler chain wrote, response_bytes the body before
+// compression. They differ only when a compressing middleware runs inside
+// this one with CountResponseBody inside it; content_encoding and
+// compression_ratio are then logged too:
+//
+//	mux := http.NewServeMux()
+//	http.ListenAndServe(addr, slogging.HTTPMiddleware(mux))
+//	http.ListenAndServe(addr, slogging.HTTPMiddleware(gzipMiddleware(slogging.CountResponseBody(mux))))
+func HTTPMiddleware(next http.Handler) http.Handler {
+	return NewHTTPMiddleware(MiddlewareOptions{})(next)
+}
+
+// MiddlewareOptions adds request details to the access line of
+// NewHTTPMiddleware.
+type MiddlewareOptions struct {
+	// LogHeaders and LogQuery name request headers and query parameters to log
+	// as the "headers" and "query" objects (header names lower-cased; a
+	// repeated value becomes an array). "*" logs all of them. Names matching the denylist
+	// (DenyParams plus Deny) are never logged, even when listed.
+	LogHeaders []string
+	LogQuery   []string
+	Deny       []string
+	// A request with "X-Debug-Log: true" from a client in DebugAllow (CIDRs
+	// or addresses, matched against RemoteAddr), or with a DebugToken signed
+	// by DebugKey from anywhere, is logged at debug; see WithRequestLevel.
+	// Without either, the header is ignored.
+	DebugAllow []string
+	DebugKey   []byte
+	// DebugTail, when positive, keeps the last DebugTail debug events of
+	// every other request in memory and writes them just before the
+	// request's error, including the error-level access line of a 5xx; see
+	// WithDebugTail.
+	DebugTail int
+}
+
+// DenyParams are header and query parameter names NewHTTPMiddleware never
+// logs. A name is denied when, lower-cased, it contains any of them.
+var DenyParams = []string{
+	"authorization", "cookie", "token", "secret", "password", "passwd",
+	"api_key", "apikey", "api-key", "session", "signature", "credential",
+}
+
+// NewHTTPMiddleware is HTTPMiddleware with options:
+//
+//	mw := slogging.NewHTTPMiddleware(slogging.MiddlewareOptions{
+//		LogHeaders: []string{"User-Agent", "X-Forwarded-For", "Accept-Language"},
+//		LogQuery:   []string{"*"}, // ?access_token=... is still stripped
+//	})
+//	http.ListenAndServe(addr, mw(mux))
+func NewHTTPMiddleware(opt MiddlewareOptions) func(http.Handler) http.Handler {
+	a := NewAccessLog(opt)
+	return func(next http.Handler) http.Handler {
+		return a.handler(next)
+	}
+}
+
+// AccessLog is the request handling of NewHTTPMiddleware, for middlewares of
+// other HTTP frameworks (see ginmw), so that their requests get the same
+// context and their access lines the same fields:
+//
+//	start := time.Now()
+//	ctx := a.Begin(r)
+//	// echo slogging.GetRequestID(ctx) as X-Request-ID, run the handlers
+//	// with ctx, then:
+//	a.Event(r.WithContext(ctx), status, written, header, start).Msg("request completed")
+type AccessLog struct {
+	headers, query paramFilter
+	debug          debugGate
+	tail           int
+}
+
+// NewAccessLog returns an AccessLog with the options of NewHTTPMiddleware.
+func NewAccessLog(opt MiddlewareOptions) *AccessLog {
+	return &AccessLog{
+		headers: newParamFilter(opt.LogHeaders, opt.Deny),
+		query:   newParamFilter(opt.LogQuery, opt.Deny),
+		debug:   newDebugGate(opt.DebugAllow, opt.DebugKey),
+		tail:    opt.DebugTail,
+	}
+}
+
+// Begin returns the context to serve r with: its correlation headers stored
+// with the With* helpers (a request ID generated when missing), the debug
+// level or debug tail the options give it, and fresh request stats.
+func (a *AccessLog) Begin(r *http.Request) context.Context {
+	ctx := r.Context()
+	ctx = WithRequestID(ctx, RequestIDFromHeader(r.Header.Get(HeaderRequestID)))
+	if v := r.Header.Get(HeaderTraceID); v != "" {
+		ctx = WithTraceID(ctx, v)
+	}
+	if v := r.Header.Get(XOperator); v != "" {
+		ctx = WithOperatorName(ctx, v)
+	}
+	if v := r.Header.Get(APIID); v != "" {
+		ctx = WithAPIID(ctx, v)
+	}
+	if a.debug.allowed(r) {
+		ctx = WithRequestLevel(ctx, zerolog.DebugLevel)
+	} else if a.tail > 0 {
+		ctx = WithDebugTail(ctx, a.tail)
+	}
+	ctx = context.WithValue(ctx, ctxBodyBytesKey, &bodyCounter{})
+	return WithRequestStats(ctx)
+}
+
+// Event returns the access line of r, served with the context Begin
+// returned: at error for a 5xx status, warn for a 4xx and info otherwise,
+// with method, path, status, bytes (the written size), response_bytes,
+// content_type and content_encoding from header, the request stats,
+// duration_ms since start and the selected headers and query parameters.
+// The caller adds its own fields and sends it with Msg("request completed").
+// It is nil when the level is disabled.
+func (a *AccessLog) Event(r *http.Request, status int, bytes int64, header http.Header, start time.Time) *zerolog.Event {
+	level := zerolog.InfoLevel
+	switch {
+	case status >= 500:
+		level = zerolog.ErrorLevel
+	case status >= 400:
+		level = zerolog.WarnLevel
+	}
+	ctx := r.Context()
+	e := From(ctx).WithLevel(level)
+	if e == nil {
+		return nil
+	}
+	e.Str(FieldMethod, r.Method).
+		Str(FieldPath, r.URL.Path).
+		Int(FieldStatus, status).
+		Int64(FieldBytes, bytes)
+	body := bytes
+	counter, _ := ctx.Value(ctxBodyBytesKey).(*bodyCounter)
+	if counter != nil && counter.counted.Load() {
+		body = counter.Load()
+	}
+	e.Int64(FieldResponseBytes, body)
+	if ct := header.Get("Content-Type"); ct != "" {
+		e.Str(FieldContentType, ct)
+	}
+	if ce := header.Get("Content-Encoding"); ce != "" {
+		e.Str(FieldContentEncoding, ce)
+		if counter != nil && counter.counted.Load() && bytes > 0 {
+			e.Float64(FieldCompressionRatio, math.Round(float64(body)/float64(bytes)*100)/100)
+		}
+	}
+	AppendRequestStats(ctx, e)
+	e.Float64(FieldDurationMs, float64(time.Since(start).Microseconds())/1000)
+	if d := a.headers.dict(r.Header, true); d != nil {
+		e.Dict(FieldHeaders, d)
+	}
+	if !a.query.empty() && r.URL.RawQuery != "" {
+		if d := a.query.dict(r.URL.Query(), false); d != nil {
+			e.Dict(FieldQuery, d)
+		}
+	}
+	return e
+}
+
+func (a *AccessLog) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ctx := a.Begin(r)
+		w.Header().Set(HeaderRequestID, GetRequestID(ctx))
+
+		rw := &responseWriter{ResponseWriter: w}
+		r = r.WithContext(ctx)
+		defer func() {
+			rec := recover()
+			if rec != nil && rw.status == 0 {
+				rw.status = http.StatusInternalServerError
+			}
+			status := rw.status
+			if status == 0 {
+				status = http.StatusOK // handler wrote nothing
+			}
+			if e := a.Event(r, status, rw.bytes, rw.Header(), start); e != nil {
+				e.Msg("request completed")
+			}
+			if rec != nil {
+				panic(rec) // leave recovery policy to net/http or an outer middleware
+			}
+		}()
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// paramFilter selects the headers or query parameters to log.
+type paramFilter struct {
+	all   bool
+	names []string // lower-cased
+	deny  []string // lower-cased, on top of DenyParams
+}
+
+func newParamFilter(names, deny []string) paramFilter {
+	var f paramFilter
+	for _, n := range names {
+		if n == "*" {
+			f.all = true
+			continue
+		}
+		f.names = append(f.names, strings.ToLower(n))
+	}
+	for _, d := range deny {
+		f.deny = append(f.deny, strings.ToLower(d))
+	}
+	return f
+}
+
+func (f paramFilter) empty() bool { return !f.all && len(f.names) == 0 }
+
+func (f paramFilter) denied(name string) bool {
+	for _, d := range DenyParams {
+		if strings.Contains(name, d) {
+			return true
+		}
+	}
+	return slices.Contains(f.deny, name)
+}
+
+// dict returns the selected entries of vals as a zerolog dict, or nil when
+// none are present. Names match case-insensitively; header keys are logged
+// lower-cased, query keys as sent.
+func (f paramFilter) dict(vals map[string][]string, header bool) *zerolog.Event {
+	if f.empty() || len(vals) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(vals))
+	for k := range vals {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var d *zerolog.Event
+	for _, k := range keys {
+		lower := strings.ToLower(k)
+		if (!f.all && !slices.Contains(f.names, lower)) || f.denied(lower) {
+			continue
+		}
+		name := k
+		if header {
+			name = lower
+		}
+		if d == nil {
+			d = zerolog.Dict()
+		}
+		if v := vals[k]; len(v) == 1 {
+			d.Str(name, v[0])
+		} else {
+			d.Strs(name, v)
+		}
+	}
+	return d
+}
+
+// RequestIDFromHeader returns the request ID to use for an inbound
+// X-Request-ID value: v itself, or a generated ID when v is empty or longer
+// than 128 bytes. Middlewares for other frameworks use it so they treat
+// inbound IDs like HTTPMiddleware does.
+func RequestIDFromHeader(v string) string {
+	if v == "" || len(v) > maxRequestIDLen {
+		return newRequestID()
+	}
+	return v
+}
+
+// newRequestID returns 16 random bytes, hex encoded.
+func newRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// ctxBodyBytesKey carries the access line's uncompressed body counter to
+// CountResponseBody.
+const ctxBodyBytesKey ctxKey = "response_body_bytes"
+
+// CountResponseBody counts the body the handler writes before any compression
+// applied around it, for the response_bytes and compression_ratio fields of
+// HTTPMiddleware. Place it inside the compressing middleware; outside an
+// HTTPMiddleware request it does nothing.
+func CountResponseBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, ok := r.Context().Value(ctxBodyBytesKey).(*bodyCounter)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		n.counted.Store(true)
+		next.ServeHTTP(&countingWriter{ResponseWriter: w, n: n}, r)
+	})
+}
+
+// bodyCounter is the uncompressed body size, set by CountResponseBody.
+type bodyCounter struct {
+	atomic.Int64
+	counted atomic.Bool
+}
+
+// countingWriter adds what the handler writes to n.
+type countingWriter struct {
+	http.ResponseWriter
+	n *bodyCounter
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.n.Add(int64(n))
+	return n, err
+}
+
+func (w *countingWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *countingWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// responseWriter records the status and body size. Unwrap lets
+// http.ResponseController reach the underlying writer's Flush, Hijack and
+// deadline methods.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush keeps streaming handlers working for callers that type-assert
+// http.Flusher instead of using http.ResponseController.
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+func (w *responseWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
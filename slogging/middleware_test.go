@@ -0,0 +1,164 @@
+package slogging
+
+import (
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc"})
+	h := NewHTTPMiddleware(MiddlewareOptions{
+		LogHeaders: []string{"User-Agent", "Cookie"},
+		LogQuery:   []string{"*"},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		From(r.Context()).Info().Msg("handling")
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("no such order"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/7?page=2&access_token=x", nil)
+	req.Header.Set(HeaderRequestID, "r1")
+	req.Header.Set(XOperator, "op1")
+	req.Header.Set(APIID, "api1")
+	req.Header.Set("User-Agent", "curl/8")
+	req.Header.Set("Cookie", "session=1")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if got := w.Header().Get(HeaderRequestID); got != "r1" {
+		t.Errorf("echoed request ID = %q", got)
+	}
+
+	handled := c.withMessage(t, "handling")
+	if len(handled) != 1 || handled[0][FieldRequestID] != "r1" || handled[0][FieldOperatorName] != "op1" || handled[0][FieldAPIID] != "api1" {
+		t.Errorf("handler event = %v", handled)
+	}
+	evs := c.withMessage(t, "request completed")
+	if len(evs) != 1 {
+		t.Fatalf("got %d access lines, want 1", len(evs))
+	}
+	ev := evs[0]
+	for k, want := range map[string]any{
+		"level":            "warn",
+		FieldMethod:        "GET",
+		FieldPath:          "/orders/7",
+		FieldStatus:        float64(http.StatusNotFound),
+		FieldBytes:         float64(len("no such order")),
+		FieldResponseBytes: float64(len("no such order")),
+		FieldContentType:   "text/plain",
+	} {
+		if ev[k] != want {
+			t.Errorf("%s = %v, want %v", k, ev[k], want)
+		}
+	}
+	if _, ok := ev[FieldDurationMs].(float64); !ok {
+		t.Errorf("duration_ms = %v", ev[FieldDurationMs])
+	}
+	headers, _ := ev[FieldHeaders].(map[string]any)
+	if headers["user-agent"] != "curl/8" || headers["cookie"] != nil {
+		t.Errorf("headers = %v", ev[FieldHeaders])
+	}
+	query, _ := ev[FieldQuery].(map[string]any)
+	if query["page"] != "2" || query["access_token"] != nil {
+		t.Errorf("query = %v", ev[FieldQuery])
+	}
+}
+
+func TestHTTPMiddlewareGeneratesRequestID(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc"})
+	var seen string
+	h := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = GetRequestID(r.Context())
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if seen == "" || w.Header().Get(HeaderRequestID) != seen {
+		t.Errorf("request ID %q, echoed %q", seen, w.Header().Get(HeaderRequestID))
+	}
+	ev := c.withMessage(t, "request completed")[0]
+	if ev[FieldRequestID] != seen || ev[FieldStatus] != float64(http.StatusOK) || ev["level"] != "info" {
+		t.Errorf("access line = %v", ev)
+	}
+}
+
+func TestHTTPMiddlewarePanic(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc"})
+	h := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	func() {
+		defer func() {
+			if rec := recover(); rec != "boom" {
+				t.Errorf("recovered %v, want the handler's panic passed on", rec)
+			}
+		}()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+	ev := c.withMessage(t, "request completed")[0]
+	if ev[FieldStatus] != float64(http.StatusInternalServerError) || ev["level"] != "error" {
+		t.Errorf("access line = %v", ev)
+	}
+}
+
+func TestCountResponseBody(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc"})
+	body := strings.Repeat("order ", 1000)
+	gzipped := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Encoding", "gzip")
+			zw := gzip.NewWriter(w)
+			defer zw.Close()
+			next.ServeHTTP(gzipWriter{ResponseWriter: w, w: zw}, r)
+		})
+	}
+	h := HTTPMiddleware(gzipped(CountResponseBody(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	ev := c.withMessage(t, "request completed")[0]
+	written, _ := ev[FieldBytes].(float64)
+	if ev[FieldResponseBytes] != float64(len(body)) || written == 0 || written >= float64(len(body)) {
+		t.Errorf("bytes = %v, response_bytes = %v, want the compressed and plain sizes", ev[FieldBytes], ev[FieldResponseBytes])
+	}
+	if ev[FieldContentEncoding] != "gzip" || ev[FieldCompressionRatio] == nil {
+		t.Errorf("content_encoding = %v, compression_ratio = %v", ev[FieldContentEncoding], ev[FieldCompressionRatio])
+	}
+
+	// Outside the middleware it passes the request through.
+	w := httptest.NewRecorder()
+	CountResponseBody(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(context.Background()))
+	if w.Body.String() != "ok" {
+		t.Errorf("body = %q", w.Body.String())
+	}
+}
+
+// gzipWriter sends what the handler writes through w.
+type gzipWriter struct {
+	http.ResponseWriter
+	w *gzip.Writer
+}
+
+func (g gzipWriter) Write(p []byte) (int, error) { return g.w.Write(p) }
+
+func TestRequestIDFromHeader(t *testing.T) {
+	if got := RequestIDFromHeader("abc"); got != "abc" {
+		t.Errorf("RequestIDFromHeader(abc) = %q", got)
+	}
+	if got := RequestIDFromHeader(strings.Repeat("a", maxRequestIDLen)); len(got) != maxRequestIDLen {
+		t.Errorf("an ID of the maximum length was replaced by %q", got)
+	}
+	for _, in := range []string{"", strings.Repeat("a", maxRequestIDLen+1)} {
+		got := RequestIDFromHeader(in)
+		if len(got) != 32 || got == RequestIDFromHeader(in) {
+			t.Errorf("RequestIDFromHeader(%d bytes) = %q, want a fresh generated ID", len(in), got)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package slogging
+
+import (
+	"bytes"
+	"github.com/rs/zerolog"
+	"io"
+	"sync"
+)
+
+// fieldOrder is the prefix StableFieldOrder guarantees; the remaining fields
+// keep the order the event wrote them in.
+func fieldOrder() []string {
+	return []string{
+		zerolog.TimestampFieldName,
+		zerolog.LevelFieldName,
+		FieldService,
+		FieldRequestID,
+		zerolog.MessageFieldName,
+	}
+}
+
+// orderWriter rewrites each JSON event so the fieldOrder keys come first.
+// Lines it cannot scan are passed through untouched.
+type orderWriter struct {
+	w    io.Writer
+	keys [][]byte // quoted, as they appear on the wire
+}
+
+func newOrderWriter(w io.Writer) *orderWriter {
+	o := &orderWriter{w: w}
+	for _, k := range fieldOrder() {
+		o.keys = append(o.keys, []byte(`"`+k+`"`))
+	}
+	return o
+}
+
+var lineBufs = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+func (o *orderWriter) Write(p []byte) (int, error) {
+	return o.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (o *orderWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	buf := lineBufs.Get().(*bytes.Buffer)
+	defer lineBufs.Put(buf)
+	buf.Reset()
+	if !o.reorder(buf, p) {
+		return writeLevel(o.w, level, p)
+	}
+	if _, err := writeLevel(o.w, level, buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// span is one top-level member: p[start:end] is `"key":value`, p[start:keyEnd] the quoted key.
+type span struct{ start, keyEnd, end int }
+
+func (o *orderWriter) reorder(buf *bytes.Buffer, p []byte) bool {
+	members, tail, ok := scanMembers(p)
+	if !ok {
+		return false
+	}
+	used := make([]bool, len(members))
+	buf.WriteByte('{')
+	first := true
+	emit := func(i int) {
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		buf.Write(p[members[i].start:members[i].end])
+		used[i] = true
+	}
+	for _, k := range o.keys {
+		for i, m := range members {
+			if !used[i] && bytes.Equal(p[m.start:m.keyEnd], k) {
+				emit(i)
+				break
+			}
+		}
+	}
+	for i := range members {
+		if !used[i] {
+			emit(i)
+		}
+	}
+	buf.WriteByte('}')
+	buf.Write(p[tail:])
+	return true
+}
+
+// scanMembers splits a single-line JSON object into its top-level members.
+// tail is the offset just past the closing brace (typically the newline).
+func scanMembers(p []byte) (members []span, tail int, ok bool) {
+	i := skipSpace(p, 0)
+	if i >= len(p) || p[i] != '{' {
+		return nil, 0, false
+	}
+	i = skipSpace(p, i+1)
+	if i < len(p) && p[i] == '}' {
+		return nil, i + 1, true
+	}
+	for i < len(p) {
+		var m span
+		m.start = i
+		if p[i] != '"' {
+			return nil, 0, false
+		}
+		if i = skipString(p, i); i < 0 {
+			return nil, 0, false
+		}
+		m.keyEnd = i
+		i = skipSpace(p, i)
+		if i >= len(p) || p[i] != ':' {
+			return nil, 0, false
+		}
+		if i = skipValue(p, skipSpace(p, i+1)); i < 0 {
+			return nil, 0, false
+		}
+		m.end = i
+		members = append(members, m)
+		i = skipSpace(p, i)
+		if i >= len(p) {
+			return nil, 0, false
+		}
+		switch p[i] {
+		case ',':
+			i = skipSpace(p, i+1)
+		case '}':
+			return members, i + 1, true
+		default:
+			return nil, 0, false
+		}
+	}
+	return nil, 0, false
+}
+
+func skipSpace(p []byte, i int) int {
+	for i < len(p) && (p[i] == ' ' || p[i] == '\t' || p[i] == '\r' || p[i] == '\n') {
+		i++
+	}
+	return i
+}
+
+// skipString returns the offset after the string starting at p[i] == '"', or -1.
+func skipString(p []byte, i int) int {
+	for i++; i < len(p); i++ {
+		switch p[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// skipValue returns the offset after the JSON value starting at i, or -1.
+func skipValue(p []byte, i int) int {
+	if i >= len(p) {
+		return -1
+	}
+	switch p[i] {
+	case '"':
+		return skipString(p, i)
+	case '{', '[':
+		depth := 0
+		for ; i < len(p); i++ {
+			switch p[i] {
+			case '"':
+				if i = skipString(p, i); i < 0 {
+					return -1
+				}
+				i--
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return i + 1
+				}
+			}
+		}
+		return -1
+	default: // number, true, false, null
+		for ; i < len(p); i++ {
+			switch p[i] {
+			case ',', '}', ']', ' ', '\t', '\r', '\n':
+				return i
+			}
+		}
+		return -1
+	}
+}
@@ -0,0 +1,71 @@
+package slogging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/rs/zerolog"
+	"slices"
+	"testing"
+)
+
+// topKeys returns the top-level keys of a JSON object in wire order.
+func topKeys(t *testing.T, line []byte) []string {
+	t.Helper()
+	dec := json.NewDecoder(bytes.NewReader(line))
+	if _, err := dec.Token(); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for dec.More() {
+		k, err := dec.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, k.(string))
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return keys
+}
+
+func TestStableFieldOrder(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc", StableFieldOrder: true})
+	ctx := WithRequestID(context.Background(), "r1")
+	From(ctx).Info().
+		Str("b", `quoted "message":{`).
+		Dict("nested", zerolog.Dict().Str("level", "inner").Str("message", "inner")).
+		Int("a", 1).
+		Msg("ordered")
+
+	c.mu.Lock()
+	lines := slices.Clone(c.lines)
+	c.mu.Unlock()
+	var line []byte
+	for _, l := range lines {
+		if bytes.Contains(l, []byte(`"ordered"`)) {
+			line = l
+		}
+	}
+	if line == nil {
+		t.Fatal("event not captured")
+	}
+	keys := topKeys(t, line)
+	if want := []string{"time", "level", "service", "request_id", "message"}; !slices.Equal(keys[:len(want)], want) {
+		t.Errorf("keys = %q, want them to start with %q", keys, want)
+	}
+	// The rest keep the order they were written in.
+	rest := keys[5:]
+	if i, j, k := slices.Index(rest, "b"), slices.Index(rest, "nested"), slices.Index(rest, "a"); i < 0 || i > j || j > k {
+		t.Errorf("keys = %q, want b, nested, a in that order", keys)
+	}
+	var ev map[string]any
+	if err := json.Unmarshal(line, &ev); err != nil {
+		t.Fatalf("reordered line is not JSON: %v", err)
+	}
+	if ev["b"] != `quoted "message":{` || ev["nested"].(map[string]any)["level"] != "inner" {
+		t.Errorf("values changed by reordering: %v", ev)
+	}
+}
@@ -0,0 +1,32 @@
+package slogging
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// SpanContextFunc returns the IDs of the span active in ctx, if any.
+type SpanContextFunc func(ctx context.Context) (traceID, spanID string, ok bool)
+
+var spanContextFn atomic.Pointer[SpanContextFunc]
+
+// RegisterSpanContext installs the tracer integration used by
+// Options.OTELCorrelation. Importing slogging/otel registers the OpenTelemetry
+// one, so slogging itself does not depend on OpenTelemetry:
+//
+//	import _ "github.com/dinhtatuanlinh/source_logging/slogging/otel"
+func RegisterSpanContext(fn SpanContextFunc) {
+	spanContextFn.Store(&fn)
+}
+
+// spanIDs returns the active span's IDs when OTELCorrelation is on.
+func spanIDs(p *pipeline, ctx context.Context) (traceID, spanID string, ok bool) {
+	if p == nil || !p.otel || ctx == nil {
+		return "", "", false
+	}
+	fn := spanContextFn.Load()
+	if fn == nil {
+		return "", "", false
+	}
+	return (*fn)(ctx)
+}
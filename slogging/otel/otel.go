@@ -0,0 +1,28 @@
+// Package otel lets slogging read trace and span IDs from OpenTelemetry spans.
+// Import it for its side effect and set Options.OTELCorrelation:
+//
+//	import _ "github.com/dinhtatuanlinh/source_logging/slogging/otel"
+//
+//	slogging.Init(slogging.Options{Service: "billing", OTELCorrelation: true})
+//
+// From(ctx) then adds trace_id and span_id of the span active in ctx, and
+// IntoContext stores its trace_id, without any WithTraceID call.
+package otel
+
+import (
+	"context"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func init() {
+	slogging.RegisterSpanContext(spanContext)
+}
+
+func spanContext(ctx context.Context) (traceID, spanID string, ok bool) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return "", "", false
+	}
+	return sc.TraceID().String(), sc.SpanID().String(), true
+}
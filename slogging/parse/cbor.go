@@ -0,0 +1,81 @@
+package parse
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"github.com/fxamacker/cbor/v2"
+	"io"
+	"reflect"
+	"time"
+)
+
+var mapStringAny = reflect.TypeOf(map[string]any(nil))
+
+var cborMode, _ = cbor.DecOptions{
+	DefaultMapType:  mapStringAny,
+	TimeTag:         cbor.DecTagOptional,
+	MaxNestedLevels: 64,
+}.DecMode()
+
+// CBORScanner reads a stream of CBOR maps as written by zerolog with the
+// binary_log build tag. Line returns the event re-encoded as JSON.
+type CBORScanner struct {
+	dec  *cbor.Decoder
+	ev   slogging.Entry
+	line []byte
+	err  error
+}
+
+// NewCBORScanner returns a CBORScanner reading from r.
+func NewCBORScanner(r io.Reader) *CBORScanner {
+	return &CBORScanner{dec: cborMode.NewDecoder(r)}
+}
+
+// Next decodes the next event, returning false at the end of input or on a
+// decoding error (reported by Err); a corrupt CBOR stream cannot be resynced.
+func (s *CBORScanner) Next() bool {
+	var m map[string]any
+	if err := s.dec.Decode(&m); err != nil {
+		if !errors.Is(err, io.EOF) {
+			s.err = err
+		}
+		return false
+	}
+	for k, v := range m {
+		m[k] = normalizeCBOR(v)
+	}
+	s.ev, _ = Upgrade(slogging.Entry(m))
+	s.line, _ = json.Marshal(s.ev)
+	return true
+}
+
+func (s *CBORScanner) Entry() slogging.Entry { return s.ev }
+func (s *CBORScanner) Line() []byte          { return s.line }
+func (s *CBORScanner) Err() error            { return s.err }
+
+// normalizeCBOR maps CBOR-specific decoded values onto what encoding/json
+// produces for the equivalent JSON event, so predicates behave the same.
+func normalizeCBOR(v any) any {
+	switch t := v.(type) {
+	case time.Time:
+		return t.Format(time.RFC3339Nano)
+	case uint64:
+		return float64(t)
+	case int64:
+		return float64(t)
+	case float32:
+		return float64(t)
+	case []byte:
+		return string(t)
+	case map[string]any:
+		for k, e := range t {
+			t[k] = normalizeCBOR(e)
+		}
+	case []any:
+		for i, e := range t {
+			t[i] = normalizeCBOR(e)
+		}
+	}
+	return v
+}
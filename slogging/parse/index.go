@@ -0,0 +1,109 @@
+package parse
+
+import (
+	"encoding/json"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"sort"
+)
+
+// Index is a transient in-memory index over log files: it keeps the raw lines
+// of admitted events plus postings lists (field -> value -> event numbers) for
+// the indexed fields, so equality predicates resolve without rescanning.
+type Index struct {
+	lines    [][]byte
+	postings map[string]map[string][]int
+}
+
+// BuildIndex scans files once, admitting events for which keep returns true
+// (nil keeps all) and indexing the given fields.
+func BuildIndex(files []string, fields []string, keep func(slogging.Entry) bool) (*Index, error) {
+	ix := &Index{postings: make(map[string]map[string][]int, len(fields))}
+	for _, f := range fields {
+		ix.postings[f] = make(map[string][]int)
+	}
+	err := ForEach(files, func(_ string, ev slogging.Entry, line []byte) bool {
+		if keep != nil && !keep(ev) {
+			return true
+		}
+		n := len(ix.lines)
+		ix.lines = append(ix.lines, append([]byte(nil), line...))
+		for f, post := range ix.postings {
+			if v, ok := Lookup(ev, f); ok {
+				s := Stringify(v)
+				post[s] = append(post[s], n)
+			}
+		}
+		return true
+	})
+	return ix, err
+}
+
+// Len returns the number of indexed events.
+func (ix *Index) Len() int { return len(ix.lines) }
+
+// Query returns the raw lines of events matching every predicate, in scan order.
+// Equality predicates on indexed fields are answered from postings (smallest
+// list first); the rest are evaluated on the candidates.
+func (ix *Index) Query(preds []Predicate) [][]byte {
+	var cand []int
+	var rest []Predicate
+	var lists [][]int
+	for _, p := range preds {
+		if post, ok := ix.postings[p.Field]; ok && p.Op == OpEq {
+			lists = append(lists, post[p.Value])
+			continue
+		}
+		rest = append(rest, p)
+	}
+	if len(lists) == 0 {
+		cand = make([]int, len(ix.lines))
+		for i := range cand {
+			cand[i] = i
+		}
+	} else {
+		sort.Slice(lists, func(i, j int) bool { return len(lists[i]) < len(lists[j]) })
+		cand = lists[0]
+		for _, l := range lists[1:] {
+			cand = intersect(cand, l)
+		}
+	}
+
+	var out [][]byte
+	for _, i := range cand {
+		if len(rest) > 0 {
+			var ev slogging.Entry
+			if json.Unmarshal(ix.lines[i], &ev) != nil || !matchAll(ev, rest) {
+				continue
+			}
+		}
+		out = append(out, ix.lines[i])
+	}
+	return out
+}
+
+func matchAll(ev slogging.Entry, preds []Predicate) bool {
+	for _, p := range preds {
+		if !p.Match(ev) {
+			return false
+		}
+	}
+	return true
+}
+
+// intersect merges two ascending lists.
+func intersect(a, b []int) []int {
+	var out []int
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			out = append(out, a[i])
+			i++
+			j++
+		case a[i] < b[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	return out
+}
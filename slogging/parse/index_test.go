@@ -0,0 +1,88 @@
+package parse
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeLog writes events as a JSON-lines log file and returns its path.
+func writeLog(t *testing.T, name string, events ...slogging.Entry) string {
+	t.Helper()
+	var b strings.Builder
+	for _, ev := range events {
+		line, err := json.Marshal(ev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestIndexQuery(t *testing.T) {
+	var events []slogging.Entry
+	for i := range 12 {
+		events = append(events, slogging.Entry{
+			"message":    fmt.Sprint("e", i),
+			"status":     []float64{200, 404, 500}[i%3],
+			"service":    []string{"api", "worker"}[i%2],
+			"latency_ms": float64(i * 50),
+		})
+	}
+	old := writeLog(t, "app.log.1", events[:6]...)
+	cur := writeLog(t, "app.log", events[6:]...)
+	ix, err := BuildIndex([]string{old, cur}, []string{"status", "service"}, func(ev slogging.Entry) bool {
+		return ev["message"] != "e11"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ix.Len() != 11 {
+		t.Fatalf("Len = %d, want 11", ix.Len())
+	}
+
+	for _, tc := range []struct {
+		preds []string
+		want  string
+	}{
+		{[]string{"status=500"}, "e2 e5 e8"},
+		{[]string{"status=500", "service=worker"}, "e5"}, // two postings intersected
+		{[]string{"service=api", "status=200", "status=200"}, "e0 e6"},
+		{[]string{"status=500", "status=404"}, ""},
+		{[]string{"status=201"}, ""},
+		{[]string{"latency_ms>=250", "latency_ms<450"}, "e5 e6 e7 e8"}, // range, not indexed
+		{[]string{"service=api", "latency_ms>100"}, "e4 e6 e8 e10"},
+		{[]string{"status!=200", "service=worker", "latency_ms<=250"}, "e1 e5"},
+		{nil, "e0 e1 e2 e3 e4 e5 e6 e7 e8 e9 e10"},
+	} {
+		var preds []Predicate
+		for _, s := range tc.preds {
+			p, err := ParsePredicate(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			preds = append(preds, p)
+		}
+		var got []string
+		for _, line := range ix.Query(preds) {
+			var ev slogging.Entry
+			if err := json.Unmarshal(line, &ev); err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, ev["message"].(string))
+		}
+		if strings.Join(got, " ") != tc.want {
+			t.Errorf("Query(%q) = %q, want %q", tc.preds, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package parse
+
+import (
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LineParser turns one line of a legacy plaintext log into an event with
+// slogging's field names (time, level, message, ...). ok is false for lines
+// the format does not recognize.
+type LineParser func(line string) (ev slogging.Entry, ok bool)
+
+// LineParsers are the legacy formats slog import understands, by name.
+var LineParsers = map[string]LineParser{
+	"nginx":  ParseCombined,
+	"apache": ParseCombined,
+	"glog":   ParseGlog,
+	"plain":  ParsePlain,
+}
+
+// combinedRe matches the NCSA common and combined formats shared by nginx
+// ("combined" log_format) and Apache (LogFormat common/combined).
+var combinedRe = regexp.MustCompile(`^(\S+) \S+ (\S+) \[([^\]]+)\] "([^"]*)" (\d{3}) (\d+|-)(?: "([^"]*)" "([^"]*)")?`)
+
+// ParseCombined parses an NCSA common or combined access log line.
+// 5xx responses become error events, 4xx warn, the rest info.
+func ParseCombined(line string) (slogging.Entry, bool) {
+	m := combinedRe.FindStringSubmatch(line)
+	if m == nil {
+		return nil, false
+	}
+	ts, err := time.Parse("02/Jan/2006:15:04:05 -0700", m[3])
+	if err != nil {
+		return nil, false
+	}
+	status, _ := strconv.Atoi(m[5])
+	ev := slogging.Entry{
+		"time":       ts.Format(time.RFC3339Nano),
+		"level":      levelForStatus(status),
+		"ip_address": m[1],
+		"status":     status,
+		"request":    m[4],
+	}
+	if m[2] != "-" {
+		ev["user"] = m[2]
+	}
+	if parts := strings.Fields(m[4]); len(parts) == 3 {
+		ev["method"], ev["path"], ev["protocol"] = parts[0], parts[1], parts[2]
+		delete(ev, "request")
+	}
+	if m[6] != "-" {
+		n, _ := strconv.Atoi(m[6])
+		ev["bytes"] = n
+	}
+	if m[7] != "" && m[7] != "-" {
+		ev["referer"] = m[7]
+	}
+	if m[8] != "" && m[8] != "-" {
+		ev["user_agent"] = m[8]
+	}
+	ev["message"] = strings.TrimSpace(m[4] + " " + m[5])
+	return ev, true
+}
+
+func levelForStatus(status int) string {
+	switch {
+	case status >= 500:
+		return "error"
+	case status >= 400:
+		return "warn"
+	}
+	return "info"
+}
+
+// glogRe matches "Lmmdd hh:mm:ss.uuuuuu threadid file:line] msg".
+var glogRe = regexp.MustCompile(`^([IWEF])(\d{2})(\d{2}) (\d{2}:\d{2}:\d{2}\.\d{6})\s+(\d+) ([^\]\s]+:\d+)\] ?(.*)$`)
+
+var glogLevels = map[string]string{"I": "info", "W": "warn", "E": "error", "F": "fatal"}
+
+// ParseGlog parses a glog/klog line. glog omits the year and zone, so the
+// line is placed in the local zone in the most recent year that does not put
+// it in the future.
+func ParseGlog(line string) (slogging.Entry, bool) {
+	m := glogRe.FindStringSubmatch(line)
+	if m == nil {
+		return nil, false
+	}
+	now := time.Now()
+	ts, err := time.ParseInLocation("2006 01 02 15:04:05.000000", strconv.Itoa(now.Year())+" "+m[2]+" "+m[3]+" "+m[4], time.Local)
+	if err != nil {
+		return nil, false
+	}
+	if ts.After(now.Add(24 * time.Hour)) {
+		ts = ts.AddDate(-1, 0, 0)
+	}
+	tid, _ := strconv.Atoi(m[5])
+	return slogging.Entry{
+		"time":    ts.Format(time.RFC3339Nano),
+		"level":   glogLevels[m[1]],
+		"thread":  tid,
+		"caller":  m[6],
+		"message": m[7],
+	}, true
+}
+
+// plainTimeLayouts are the leading timestamps ParsePlain recognizes, with the
+// number of space-separated words each one spans.
+var plainTimeLayouts = []struct {
+	layout string
+	words  int
+}{
+	{time.RFC3339Nano, 1},
+	{"2006/01/02 15:04:05.000000", 2}, // Go log with Lmicroseconds
+	{"2006/01/02 15:04:05", 2},        // Go log default
+	{"2006-01-02 15:04:05.000", 2},
+	{"2006-01-02 15:04:05,000", 2}, // log4j / Python logging
+	{"2006-01-02 15:04:05", 2},
+}
+
+var plainLevels = map[string]string{
+	"TRACE": "trace", "DEBUG": "debug", "INFO": "info", "NOTICE": "info",
+	"WARN": "warn", "WARNING": "warn", "ERROR": "error", "ERR": "error",
+	"CRITICAL": "fatal", "FATAL": "fatal", "PANIC": "panic",
+}
+
+// ParsePlain handles printf-style lines: an optional leading timestamp in a
+// common layout, an optional level word ("ERROR", "[warn]", "level=info",
+// "INFO:"), then the message. Any non-blank line is accepted; lines without a
+// level are info and lines without a timestamp carry none.
+func ParsePlain(line string) (slogging.Entry, bool) {
+	rest := strings.TrimSpace(line)
+	if rest == "" {
+		return nil, false
+	}
+	ev := slogging.Entry{"level": "info"}
+	words := strings.Fields(rest)
+	for _, l := range plainTimeLayouts {
+		if len(words) < l.words {
+			continue
+		}
+		cand := strings.Join(words[:l.words], " ")
+		ts, err := time.ParseInLocation(l.layout, cand, time.Local)
+		if err != nil {
+			continue
+		}
+		ev["time"] = ts.Format(time.RFC3339Nano)
+		rest = strings.TrimSpace(rest[strings.Index(rest, cand)+len(cand):])
+		break
+	}
+	if word, tail, _ := strings.Cut(rest, " "); word != "" {
+		w := strings.Trim(word, "[]:")
+		w = strings.TrimPrefix(strings.TrimPrefix(w, "level="), "LEVEL=")
+		if lvl, ok := plainLevels[strings.ToUpper(w)]; ok {
+			ev["level"] = lvl
+			rest = strings.TrimSpace(tail)
+		}
+	}
+	ev["message"] = rest
+	return ev, true
+}
@@ -0,0 +1,124 @@
+package parse
+
+import (
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseCombined(t *testing.T) {
+	for _, tc := range []struct {
+		line string
+		want slogging.Entry
+	}{
+		{
+			`10.0.0.1 - alice [01/May/2024:10:00:00 +0200] "GET /v1/users?id=1 HTTP/1.1" 200 512 "https://example.com/" "curl/8.0"`,
+			slogging.Entry{
+				"time": "2024-05-01T10:00:00+02:00", "level": "info", "ip_address": "10.0.0.1", "user": "alice",
+				"status": 200, "method": "GET", "path": "/v1/users?id=1", "protocol": "HTTP/1.1", "bytes": 512,
+				"referer": "https://example.com/", "user_agent": "curl/8.0", "message": "GET /v1/users?id=1 HTTP/1.1 200",
+			},
+		},
+		{
+			// common format, no body, 5xx
+			`::1 - - [01/May/2024:10:00:00 +0000] "POST /pay HTTP/2.0" 503 -`,
+			slogging.Entry{
+				"time": "2024-05-01T10:00:00Z", "level": "error", "ip_address": "::1", "status": 503,
+				"method": "POST", "path": "/pay", "protocol": "HTTP/2.0", "message": "POST /pay HTTP/2.0 503",
+			},
+		},
+		{
+			// a request line that is not method, path and protocol is kept whole
+			`10.0.0.2 - - [01/May/2024:10:00:00 +0000] "\x16\x03" 400 0 "-" "-"`,
+			slogging.Entry{
+				"time": "2024-05-01T10:00:00Z", "level": "warn", "ip_address": "10.0.0.2", "status": 400,
+				"request": `\x16\x03`, "bytes": 0, "message": `\x16\x03 400`,
+			},
+		},
+	} {
+		got, ok := ParseCombined(tc.line)
+		if !ok || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ParseCombined(%q) = %v, %v\nwant %v", tc.line, got, ok, tc.want)
+		}
+	}
+	for _, line := range []string{"", "not a log line", `10.0.0.1 - - [yesterday] "GET / HTTP/1.1" 200 1`} {
+		if ev, ok := ParseCombined(line); ok {
+			t.Errorf("ParseCombined(%q) = %v", line, ev)
+		}
+	}
+}
+
+func TestParseGlog(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-48 * time.Hour)
+	line := "E" + past.Format("0102 15:04:05.000000") + "   4242 server.go:87] dial tcp: refused"
+	got, ok := ParseGlog(line)
+	want := slogging.Entry{"level": "error", "thread": 4242, "caller": "server.go:87", "message": "dial tcp: refused"}
+	ts, _ := time.Parse(time.RFC3339Nano, got.Str("time"))
+	delete(got, "time")
+	if !ok || !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseGlog = %v, %v; want %v", got, ok, want)
+	}
+	if !ts.Equal(past.Truncate(time.Microsecond)) {
+		t.Errorf("time = %s, want %s", ts, past)
+	}
+
+	// A date later in the year than today is last year's.
+	future := now.AddDate(0, 0, 2)
+	got, ok = ParseGlog("I" + future.Format("0102 15:04:05.000000") + " 1 main.go:1] up")
+	ts, _ = time.Parse(time.RFC3339Nano, got.Str("time"))
+	if !ok || ts.Year() != future.Year()-1 || got.Str("level") != "info" {
+		t.Errorf("ParseGlog(future) = %v, %v", got, ok)
+	}
+
+	for _, line := range []string{"", "X0501 10:00:00.000000 1 a.go:1] m", "I0501 10:00:00 1 a.go:1] m", "I1332 10:00:00.000000 1 a.go:1] m"} {
+		if ev, ok := ParseGlog(line); ok {
+			t.Errorf("ParseGlog(%q) = %v", line, ev)
+		}
+	}
+}
+
+func TestParsePlain(t *testing.T) {
+	local := func(layout, s string) string {
+		ts, err := time.ParseInLocation(layout, s, time.Local)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts.Format(time.RFC3339Nano)
+	}
+	for _, tc := range []struct {
+		line string
+		want slogging.Entry
+	}{
+		{"2024-05-01T10:00:00.5Z ERROR disk full", slogging.Entry{"time": "2024-05-01T10:00:00.5Z", "level": "error", "message": "disk full"}},
+		{"2024/05/01 10:00:00.000001 [warn] slow", slogging.Entry{"time": local("2006/01/02 15:04:05.000000", "2024/05/01 10:00:00.000001"), "level": "warn", "message": "slow"}},
+		{"2024/05/01 10:00:00 started", slogging.Entry{"time": local("2006/01/02 15:04:05", "2024/05/01 10:00:00"), "level": "info", "message": "started"}},
+		{"2024-05-01 10:00:00,123 level=debug cache miss", slogging.Entry{"time": local("2006-01-02 15:04:05,000", "2024-05-01 10:00:00,123"), "level": "debug", "message": "cache miss"}},
+		{"2024-05-01 10:00:00 CRITICAL: out of memory", slogging.Entry{"time": local("2006-01-02 15:04:05", "2024-05-01 10:00:00"), "level": "fatal", "message": "out of memory"}},
+		{"WARNING: no timestamp", slogging.Entry{"level": "warn", "message": "no timestamp"}},
+		{"  just text  ", slogging.Entry{"level": "info", "message": "just text"}},
+		{"Errors happened", slogging.Entry{"level": "info", "message": "Errors happened"}},
+	} {
+		got, ok := ParsePlain(tc.line)
+		if !ok || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ParsePlain(%q) = %v, %v; want %v", tc.line, got, ok, tc.want)
+		}
+	}
+	if ev, ok := ParsePlain("   "); ok {
+		t.Errorf("ParsePlain(blank) = %v", ev)
+	}
+}
+
+func TestLineParsers(t *testing.T) {
+	for name, line := range map[string]string{
+		"nginx":  `1.2.3.4 - - [01/May/2024:10:00:00 +0000] "GET / HTTP/1.1" 200 1`,
+		"apache": `1.2.3.4 - - [01/May/2024:10:00:00 +0000] "GET / HTTP/1.1" 200 1`,
+		"glog":   "I0101 00:00:00.000000 1 a.go:1] m",
+		"plain":  "hello",
+	} {
+		if _, ok := LineParsers[name](line); !ok {
+			t.Errorf("%s parser rejected %q", name, line)
+		}
+	}
+}
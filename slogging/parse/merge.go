@@ -0,0 +1,141 @@
+package parse
+
+import (
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"io"
+	"time"
+)
+
+// Source is one input to Merge: a service or host whose files are read in
+// order as a single stream (typically Discover output for its directory).
+type Source struct {
+	Name  string
+	Files []string
+}
+
+// MergeOptions tunes Merge.
+type MergeOptions struct {
+	// TimeField holds the event timestamp (default "time"). RFC3339 strings
+	// and Unix times in seconds or milliseconds are understood.
+	TimeField string
+	// Tolerance is the clock drift to absorb between sources: once Merge is
+	// emitting from a source, it stays on it while that source's next event
+	// is no more than Tolerance later than the earliest pending event
+	// elsewhere. Sub-tolerance differences therefore never interleave sources
+	// line by line, and each source's own order is always preserved.
+	Tolerance time.Duration
+}
+
+// Merge interleaves the events of several sources by timestamp, calling fn
+// with the source name, the event and its raw line for each, until fn
+// returns false. Events without a timestamp stay right after the event
+// preceding them in their source.
+func Merge(sources []Source, opt MergeOptions, fn func(source string, ev slogging.Entry, line []byte) bool) error {
+	if opt.TimeField == "" {
+		opt.TimeField = "time"
+	}
+	cursors := make([]*mergeCursor, 0, len(sources))
+	for _, s := range sources {
+		c := &mergeCursor{src: s, field: opt.TimeField}
+		defer c.close()
+		if err := c.advance(); err != nil {
+			return err
+		}
+		cursors = append(cursors, c)
+	}
+
+	var cur *mergeCursor
+	for {
+		var first *mergeCursor
+		for _, c := range cursors {
+			if c.ok && (first == nil || c.ts.Before(first.ts)) {
+				first = c
+			}
+		}
+		if first == nil {
+			return nil
+		}
+		if cur == nil || !cur.ok || cur.ts.Sub(first.ts) > opt.Tolerance {
+			cur = first
+		}
+		if !fn(cur.src.Name, cur.ev, cur.line) {
+			return nil
+		}
+		if err := cur.advance(); err != nil {
+			return err
+		}
+	}
+}
+
+// mergeCursor streams one Source, holding its next event.
+type mergeCursor struct {
+	src   Source
+	field string
+	next  int // index into src.Files of the file to open next
+	r     io.ReadCloser
+	sc    EventReader
+
+	ok   bool
+	ev   slogging.Entry
+	line []byte
+	ts   time.Time // of ev, or inherited from the previous event
+}
+
+func (c *mergeCursor) advance() error {
+	for {
+		if c.sc != nil && c.sc.Next() {
+			c.ev = c.sc.Entry()
+			c.line = append([]byte(nil), c.sc.Line()...)
+			if ts := timeField(c.ev, c.field); !ts.IsZero() {
+				c.ts = ts
+			}
+			c.ok = true
+			return nil
+		}
+		if c.sc != nil {
+			err := c.sc.Err()
+			c.close()
+			if err != nil {
+				return err
+			}
+		}
+		if c.next >= len(c.src.Files) {
+			c.ok = false
+			return nil
+		}
+		path := c.src.Files[c.next]
+		c.next++
+		r, err := Open(path)
+		if err != nil {
+			return err
+		}
+		c.r, c.sc = r, NewReader(path, r)
+	}
+}
+
+func (c *mergeCursor) close() {
+	if c.r != nil {
+		c.r.Close()
+		c.r, c.sc = nil, nil
+	}
+}
+
+// timeField reads a timestamp from field: an RFC3339 string, or a number of
+// Unix seconds (milliseconds when it is too large to be seconds).
+func timeField(ev slogging.Entry, field string) time.Time {
+	switch v := ev[field].(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return time.Time{}
+		}
+		return t
+	case float64:
+		if v > 1e11 {
+			return time.UnixMilli(int64(v))
+		}
+		sec := int64(v)
+		return time.Unix(sec, int64((v-float64(sec))*1e9))
+	}
+	return time.Time{}
+}
@@ -0,0 +1,78 @@
+package parse
+
+import (
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMerge(t *testing.T) {
+	at := func(sec float64) string {
+		return time.Unix(1714557600, 0).Add(time.Duration(sec * float64(time.Second))).UTC().Format(time.RFC3339Nano)
+	}
+	api := Source{Name: "api", Files: []string{
+		writeLog(t, "api.log.1",
+			slogging.Entry{"time": at(0), "message": "a0"},
+			slogging.Entry{"time": at(2), "message": "a2"},
+			slogging.Entry{"message": "a2+"}, // no time: stays after a2
+		),
+		writeLog(t, "api.log",
+			slogging.Entry{"time": at(4), "message": "a4"},
+		),
+	}}
+	db := Source{Name: "db", Files: []string{
+		writeLog(t, "db.log",
+			slogging.Entry{"time": at(1), "message": "b1"},
+			slogging.Entry{"time": at(2.5), "message": "b2.5"},
+			slogging.Entry{"time": at(3), "message": "b3"},
+		),
+	}}
+	// Unix seconds and milliseconds in another field.
+	edge := Source{Name: "edge", Files: []string{
+		writeLog(t, "edge.log",
+			slogging.Entry{"ts": 1714557600.5, "message": "e0.5"},
+			slogging.Entry{"ts": 1714557603500.0, "message": "e3.5"},
+		),
+	}}
+
+	run := func(opt MergeOptions, limit int, sources ...Source) string {
+		var got []string
+		err := Merge(sources, opt, func(src string, ev slogging.Entry, line []byte) bool {
+			if !strings.Contains(string(line), ev.Str("message")) {
+				t.Errorf("line %q does not match event %v", line, ev)
+			}
+			got = append(got, src+":"+ev.Str("message"))
+			return len(got) != limit
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Join(got, " ")
+	}
+
+	for _, tc := range []struct {
+		name    string
+		opt     MergeOptions
+		limit   int
+		sources []Source
+		want    string
+	}{
+		{"by time", MergeOptions{}, 0, []Source{api, db}, "api:a0 db:b1 api:a2 api:a2+ db:b2.5 db:b3 api:a4"},
+		{"tolerance keeps runs", MergeOptions{Tolerance: time.Second}, 0, []Source{api, db}, "api:a0 api:a2 api:a2+ db:b1 db:b2.5 db:b3 api:a4"},
+		{"tolerance never reorders a source", MergeOptions{Tolerance: time.Hour}, 0, []Source{db, api}, "api:a0 api:a2 api:a2+ api:a4 db:b1 db:b2.5 db:b3"},
+		{"numeric times", MergeOptions{TimeField: "ts"}, 0, []Source{edge}, "edge:e0.5 edge:e3.5"},
+		{"stop early", MergeOptions{}, 2, []Source{api, db}, "api:a0 db:b1"},
+	} {
+		if got := run(tc.opt, tc.limit, tc.sources...); got != tc.want {
+			t.Errorf("%s:\n got %s\nwant %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestMergeMissingFile(t *testing.T) {
+	err := Merge([]Source{{Name: "x", Files: []string{"/nonexistent/app.log"}}}, MergeOptions{}, func(string, slogging.Entry, []byte) bool { return true })
+	if err == nil {
+		t.Error("Merge of a missing file succeeded")
+	}
+}
@@ -0,0 +1,222 @@
+// Package parse reads slogging output back: plain, gzip and zstd log files,
+// line-delimited JSON events, including ones split into continuation records,
+// and simple field predicates. It backs the slog CLI.
+package parse
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// maxLine bounds a single event line; longer lines are skipped.
+const maxLine = 4 << 20
+
+// Open opens a log file, transparently decompressing .gz and .zst backups.
+func Open(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case strings.HasSuffix(path, ".gz"):
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return readCloser{zr, func() error { zr.Close(); return f.Close() }}, nil
+	case strings.HasSuffix(path, ".zst"):
+		zr, err := zstd.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return readCloser{zr, func() error { zr.Close(); return f.Close() }}, nil
+	}
+	return f, nil
+}
+
+type readCloser struct {
+	io.Reader
+	close func() error
+}
+
+func (r readCloser) Close() error { return r.close() }
+
+// IsLogFile reports whether name looks like a slogging file or rotated backup.
+func IsLogFile(name string) bool {
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ".zst")
+	return strings.HasSuffix(name, ".log") || strings.HasSuffix(name, ".json") ||
+		strings.HasSuffix(name, ".jsonl") || strings.HasSuffix(name, ".cbor")
+}
+
+// IsCBOR reports whether path holds zerolog binary (binary_log build tag) output.
+func IsCBOR(path string) bool {
+	return strings.HasSuffix(strings.TrimSuffix(strings.TrimSuffix(path, ".gz"), ".zst"), ".cbor")
+}
+
+// Discover expands paths into log files: files are kept as given, directories
+// are walked recursively (covering DailyDirs layouts). The result is ordered by
+// modification time, oldest first, which is chronological for rotated sets.
+func Discover(paths ...string) ([]string, error) {
+	type file struct {
+		path string
+		mod  int64
+	}
+	var files []file
+	for _, p := range paths {
+		fi, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !fi.IsDir() {
+			files = append(files, file{p, fi.ModTime().UnixNano()})
+			continue
+		}
+		err = filepath.WalkDir(p, func(path string, d os.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !IsLogFile(d.Name()) {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			files = append(files, file{path, info.ModTime().UnixNano()})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].mod < files[j].mod })
+	out := make([]string, len(files))
+	for i, f := range files {
+		out[i] = f.path
+	}
+	return out, nil
+}
+
+// EventReader is the common interface of Scanner and CBORScanner.
+type EventReader interface {
+	Next() bool
+	Entry() slogging.Entry
+	Line() []byte
+	Err() error
+}
+
+// NewReader returns the EventReader matching path's format.
+func NewReader(path string, r io.Reader) EventReader {
+	if IsCBOR(path) {
+		return NewCBORScanner(r)
+	}
+	return NewScanner(r)
+}
+
+// Scanner reads events line by line. Lines that are not JSON objects are skipped;
+// events from older schema versions are upgraded (see Upgrade). Events split
+// into continuation records (Options.StdoutOversize LineSplit) are joined
+// back and returned once, where their last record was; the records of one
+// that never completes are returned as they are.
+type Scanner struct {
+	sc    *bufio.Scanner
+	line  []byte
+	ev    slogging.Entry
+	err   error
+	parts joiner
+	ready [][]byte // given-up records, returned before reading on
+}
+
+// NewScanner returns a Scanner reading JSON lines from r.
+func NewScanner(r io.Reader) *Scanner {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64<<10), maxLine)
+	return &Scanner{sc: sc}
+}
+
+// Next advances to the next event, returning false at the end of input.
+func (s *Scanner) Next() bool {
+	for {
+		var line []byte
+		switch {
+		case len(s.ready) > 0:
+			line, s.ready = s.ready[0], s.ready[1:]
+		case s.sc.Scan():
+			line = bytes.TrimSpace(s.sc.Bytes())
+			if len(line) == 0 || line[0] != '{' {
+				continue
+			}
+			line = s.parts.add(line)
+			s.ready = s.parts.take(false)
+			if line == nil {
+				continue
+			}
+		default:
+			if s.ready = s.parts.take(true); len(s.ready) > 0 {
+				continue
+			}
+			s.err = s.sc.Err()
+			return false
+		}
+		if s.decode(line) {
+			return true
+		}
+	}
+}
+
+// decode makes line the current event, reporting whether it is one.
+func (s *Scanner) decode(line []byte) bool {
+	var ev slogging.Entry
+	if json.Unmarshal(line, &ev) != nil {
+		return false
+	}
+	if up, changed := Upgrade(ev); changed {
+		// keep Line consistent with Entry for callers that re-decode it
+		ev = up
+		line, _ = json.Marshal(ev)
+	}
+	s.line = append(s.line[:0], line...)
+	s.ev = ev
+	return true
+}
+
+// Entry returns the current event.
+func (s *Scanner) Entry() slogging.Entry { return s.ev }
+
+// Line returns the raw JSON of the current event; valid until the next call to Next.
+func (s *Scanner) Line() []byte { return s.line }
+
+// Err returns the first non-EOF read error.
+func (s *Scanner) Err() error { return s.err }
+
+// ForEach scans every event in files, in order, stopping early when fn returns false.
+func ForEach(files []string, fn func(file string, ev slogging.Entry, line []byte) bool) error {
+	for _, path := range files {
+		r, err := Open(path)
+		if err != nil {
+			return err
+		}
+		sc := NewReader(path, r)
+		stop := false
+		for !stop && sc.Next() {
+			stop = !fn(path, sc.Entry(), sc.Line())
+		}
+		err = sc.Err()
+		r.Close()
+		if err != nil {
+			return err
+		}
+		if stop {
+			return nil
+		}
+	}
+	return nil
+}
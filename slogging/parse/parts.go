@@ -0,0 +1,113 @@
+package parse
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"strings"
+)
+
+const (
+	// maxPendingEvents bounds the split events being joined at once; the
+	// oldest incomplete one is given up first.
+	maxPendingEvents = 64
+	// maxParts bounds the parts of one event, well above what a writer
+	// with a 512-byte line limit needs for maxLine.
+	maxParts = 10000
+)
+
+var partDataKey = []byte(`"` + slogging.FieldPartData + `"`)
+
+// partRecord is one continuation record, as written for an event longer
+// than Options.StdoutMaxLine with StdoutOversize LineSplit: the records of
+// an event share its event_id, are numbered by part from 1 to parts, and
+// their part_data, concatenated in part order, is the event's JSON line.
+type partRecord struct {
+	EventID string  `json:"event_id"`
+	Part    int     `json:"part"`
+	Parts   int     `json:"parts"`
+	Data    *string `json:"part_data"`
+}
+
+// joiner reassembles split events. Records of different events may
+// interleave, as when several processes share one stdout.
+type joiner struct {
+	pending map[string]*partGroup
+	order   []string // event_ids of pending, oldest first
+	given   [][]byte // records of events given up on, for take
+}
+
+type partGroup struct {
+	parts int
+	data  map[int]string
+	raw   [][]byte // the records, for when the event cannot be completed
+}
+
+// add takes one line. It returns the line to decode: line itself when it is
+// not a continuation record, the joined event when line completes one, and
+// nil when line was kept for a later record. Records of events given up on,
+// including those kept before a record disagreeing on parts, are returned by
+// take.
+func (j *joiner) add(line []byte) []byte {
+	if !bytes.Contains(line, partDataKey) {
+		return line
+	}
+	var r partRecord
+	if json.Unmarshal(line, &r) != nil || r.Data == nil || r.EventID == "" ||
+		r.Parts < 1 || r.Parts > maxParts || r.Part < 1 || r.Part > r.Parts {
+		return line
+	}
+	if j.pending == nil {
+		j.pending = make(map[string]*partGroup)
+	}
+	g := j.pending[r.EventID]
+	if g == nil || g.parts != r.Parts {
+		if g != nil {
+			j.given = append(j.given, g.raw...)
+			j.drop(r.EventID)
+		}
+		g = &partGroup{parts: r.Parts, data: make(map[int]string)}
+		j.pending[r.EventID] = g
+		j.order = append(j.order, r.EventID)
+	}
+	g.data[r.Part] = *r.Data
+	g.raw = append(g.raw, append([]byte(nil), line...))
+	if len(g.data) < g.parts {
+		return nil
+	}
+	var b strings.Builder
+	for i := 1; i <= g.parts; i++ {
+		b.WriteString(g.data[i])
+	}
+	delete(j.pending, r.EventID)
+	j.order = remove(j.order, r.EventID)
+	return []byte(b.String())
+}
+
+// take returns the records of events given up on: those whose records
+// disagreed on parts, the oldest ones beyond maxPendingEvents, and all of
+// them at the end of input.
+func (j *joiner) take(all bool) [][]byte {
+	out := j.given
+	j.given = nil
+	for len(j.order) > 0 && (all || len(j.order) > maxPendingEvents) {
+		out = append(out, j.pending[j.order[0]].raw...)
+		delete(j.pending, j.order[0])
+		j.order = j.order[1:]
+	}
+	return out
+}
+
+func (j *joiner) drop(id string) {
+	delete(j.pending, id)
+	j.order = remove(j.order, id)
+}
+
+func remove(ids []string, id string) []string {
+	for i, x := range ids {
+		if x == id {
+			return append(ids[:i], ids[i+1:]...)
+		}
+	}
+	return ids
+}
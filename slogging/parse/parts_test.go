@@ -0,0 +1,84 @@
+package parse
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"strings"
+	"testing"
+)
+
+// parts splits the event with message msg into n continuation records of
+// event id, as a LineSplit writer does.
+func parts(id, msg string, n int) []string {
+	line := `{"level":"info","message":"` + msg + `"}`
+	size := (len(line) + n - 1) / n
+	var out []string
+	for i := 1; i <= n; i++ {
+		chunk := line[min((i-1)*size, len(line)):min(i*size, len(line))]
+		b, _ := json.Marshal(map[string]any{
+			slogging.FieldEventID:  id,
+			slogging.FieldPart:     i,
+			slogging.FieldParts:    n,
+			slogging.FieldPartData: chunk,
+		})
+		out = append(out, string(b))
+	}
+	return out
+}
+
+func TestScannerJoinsParts(t *testing.T) {
+	a, b := parts("a", "first", 3), parts("b", "second", 2)
+	c3 := parts("c", "third", 3)
+	c2 := parts("c", "stale", 2)
+	for _, tc := range []struct {
+		name  string
+		lines []string
+		want  []string
+	}{
+		{"whole", a, []string{"first"}},
+		{"interleaved", []string{a[0], b[0], a[1], b[1], a[2]}, []string{"second", "first"}},
+		{"out of order", []string{a[2], a[0], a[1]}, []string{"first"}},
+		{"plain between", []string{a[0], `{"message":"plain"}`, a[1], a[2]}, []string{"plain", "first"}},
+		{"incomplete", []string{a[0], a[2], b[0], b[1]}, []string{"second", "a 1/3", "a 3/3"}},
+		{"duplicate", []string{a[0], a[0], a[1], a[2]}, []string{"first"}},
+		{"mismatched count", []string{c2[0], c3[0], c3[1], c3[2]}, []string{"c 1/2", "third"}},
+		{"bad record", []string{`{"event_id":"x","part":2,"parts":1,"part_data":"{"}`}, []string{"x 2/1"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewScanner(strings.NewReader(strings.Join(tc.lines, "\n")))
+			var got []string
+			for s.Next() {
+				ev := s.Entry()
+				if _, ok := ev[slogging.FieldPartData]; ok {
+					got = append(got, fmt.Sprintf("%v %v/%v", ev[slogging.FieldEventID], ev[slogging.FieldPart], ev[slogging.FieldParts]))
+				} else {
+					got = append(got, fmt.Sprint(ev["message"]))
+				}
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+				t.Errorf("events = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestScannerGivesUpOldestParts(t *testing.T) {
+	var lines []string
+	for i := range maxPendingEvents + 1 {
+		lines = append(lines, parts(fmt.Sprint("e", i), "never", 2)[0])
+	}
+	lines = append(lines, `{"message":"last"}`)
+	s := NewScanner(strings.NewReader(strings.Join(lines, "\n")))
+	var first []string
+	for s.Next() {
+		first = append(first, fmt.Sprint(s.Entry()[slogging.FieldEventID], s.Entry()["message"]))
+		if len(first) == 2 {
+			break
+		}
+	}
+	// e0 is given up as soon as one event too many is pending, ahead of "last".
+	if want := []string{"e0<nil>", "<nil>last"}; fmt.Sprint(first) != fmt.Sprint(want) {
+		t.Errorf("first events = %q, want %q", first, want)
+	}
+}
@@ -0,0 +1,149 @@
+package parse
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Op is a predicate comparison operator.
+type Op string
+
+// Supported operators. Ordering operators compare numerically when both sides
+// are numbers and lexically otherwise; OpContains is a substring match.
+const (
+	OpEq       Op = "="
+	OpNe       Op = "!="
+	OpGt       Op = ">"
+	OpGe       Op = ">="
+	OpLt       Op = "<"
+	OpLe       Op = "<="
+	OpContains Op = "~"
+)
+
+// Predicate is a single field condition such as status=500.
+type Predicate struct {
+	Field string // top-level key or dotted path into nested objects
+	Op    Op
+	Value string
+}
+
+// two-character operators first so ">=" is not read as ">".
+var opsByLen = []Op{OpNe, OpGe, OpLe, OpEq, OpGt, OpLt, OpContains}
+
+// ParsePredicate parses "field<op>value", e.g. "status=500" or "latency_ms>=250".
+func ParsePredicate(s string) (Predicate, error) {
+	best, bestAt := Op(""), -1
+	for _, op := range opsByLen {
+		if i := strings.Index(s, string(op)); i >= 0 && (bestAt < 0 || i < bestAt) {
+			best, bestAt = op, i
+		}
+	}
+	if bestAt < 0 {
+		return Predicate{}, fmt.Errorf("parse: invalid predicate %q (want field=value)", s)
+	}
+	if bestAt == 0 {
+		return Predicate{}, fmt.Errorf("parse: invalid predicate %q: no field before %q", s, best)
+	}
+	return Predicate{Field: s[:bestAt], Op: best, Value: s[bestAt+len(best):]}, nil
+}
+
+// Match reports whether ev satisfies p. A missing field only matches OpNe.
+func (p Predicate) Match(ev slogging.Entry) bool {
+	v, ok := Lookup(ev, p.Field)
+	if !ok {
+		return p.Op == OpNe
+	}
+	got := Stringify(v)
+	switch p.Op {
+	case OpEq:
+		return got == p.Value
+	case OpNe:
+		return got != p.Value
+	case OpContains:
+		return strings.Contains(got, p.Value)
+	}
+	c := compare(v, got, p.Value)
+	switch p.Op {
+	case OpGt:
+		return c > 0
+	case OpGe:
+		return c >= 0
+	case OpLt:
+		return c < 0
+	case OpLe:
+		return c <= 0
+	}
+	return false
+}
+
+func compare(v any, got, want string) int {
+	if f, ok := v.(float64); ok {
+		if w, err := strconv.ParseFloat(want, 64); err == nil {
+			switch {
+			case f < w:
+				return -1
+			case f > w:
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(got, want)
+}
+
+// Lookup resolves a top-level key, falling back to a dotted path into nested objects.
+func Lookup(ev slogging.Entry, field string) (any, bool) {
+	if v, ok := ev[field]; ok {
+		return v, true
+	}
+	var cur any = map[string]any(ev)
+	for _, part := range strings.Split(field, ".") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// Stringify renders a decoded JSON value the way it is written in predicates.
+func Stringify(v any) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(t)
+	case nil:
+		return "null"
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// Since keeps events at or after t; events without a parsable time are kept.
+func Since(t time.Time) func(slogging.Entry) bool {
+	return func(ev slogging.Entry) bool {
+		ts := EventTime(ev)
+		return ts.IsZero() || !ts.Before(t)
+	}
+}
+
+// EventTime parses the event timestamp, accepting RFC3339 with or without
+// fractional seconds.
+func EventTime(ev slogging.Entry) time.Time {
+	s, _ := ev["time"].(string)
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,62 @@
+package parse
+
+import (
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"testing"
+)
+
+func TestParsePredicate(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want Predicate
+	}{
+		{"status=500", Predicate{"status", OpEq, "500"}},
+		{"status!=500", Predicate{"status", OpNe, "500"}},
+		{"latency_ms>=250", Predicate{"latency_ms", OpGe, "250"}},
+		{"latency_ms>250", Predicate{"latency_ms", OpGt, "250"}},
+		{"latency_ms<=250", Predicate{"latency_ms", OpLe, "250"}},
+		{"latency_ms<250", Predicate{"latency_ms", OpLt, "250"}},
+		{"message~timeout", Predicate{"message", OpContains, "timeout"}},
+		// The leftmost operator splits; the value keeps the rest.
+		{"query=a>=b", Predicate{"query", OpEq, "a>=b"}},
+		{"path~/a=b", Predicate{"path", OpContains, "/a=b"}},
+		{"http.status=", Predicate{"http.status", OpEq, ""}},
+	} {
+		got, err := ParsePredicate(tc.in)
+		if err != nil || got != tc.want {
+			t.Errorf("ParsePredicate(%q) = %+v, %v; want %+v", tc.in, got, err, tc.want)
+		}
+	}
+	// An operator must follow a field name.
+	for _, in := range []string{"", "status", "=500", ">=1", "~x"} {
+		if p, err := ParsePredicate(in); err == nil {
+			t.Errorf("ParsePredicate(%q) = %+v, want an error", in, p)
+		}
+	}
+}
+
+func TestPredicateMatch(t *testing.T) {
+	ev := slogging.Entry{"status": 500.0, "path": "/v1/users", "ok": false, "http": map[string]any{"method": "GET"}}
+	for in, want := range map[string]bool{
+		"status=500":        true,
+		"status>=500":       true,
+		"status>99":         true, // numeric, not lexical
+		"status<1000":       true,
+		"status!=500":       false,
+		"path~users":        true,
+		"path>/v0":          true,
+		"ok=false":          true,
+		"http.method=GET":   true,
+		"missing=x":         false,
+		"missing!=x":        true,
+		"http.method.x=GET": false,
+	} {
+		p, err := ParsePredicate(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := p.Match(ev); got != want {
+			t.Errorf("%s: Match = %v, want %v", in, got, want)
+		}
+	}
+}
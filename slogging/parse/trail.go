@@ -0,0 +1,99 @@
+package parse
+
+import (
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"sort"
+	"time"
+)
+
+// operatorFields are the keys an operator ID is logged under: WithOperatorName
+// and the legacy Logger's x-operator header field.
+var operatorFields = []string{"operator_name", slogging.XOperator}
+
+// TrailEntry is one thing an operator did.
+type TrailEntry struct {
+	Time      time.Time `json:"time"`
+	Level     string    `json:"level,omitempty"`
+	Action    string    `json:"action"` // "action" field, else the message
+	Resource  string    `json:"resource,omitempty"`
+	IP        string    `json:"ip,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
+	File      string    `json:"file"`
+}
+
+// Trail is an operator's activity over a time range, with per-action,
+// per-resource and per-IP counts for a quick overview.
+type Trail struct {
+	Operator  string         `json:"operator"`
+	From      time.Time      `json:"from"`
+	To        time.Time      `json:"to"`
+	Entries   []TrailEntry   `json:"entries"`
+	Actions   map[string]int `json:"actions"`
+	Resources map[string]int `json:"resources"`
+	IPs       map[string]int `json:"ips"`
+}
+
+// BuildTrail collects the events in files attributed to operator with a
+// timestamp in [from, to); a zero from or to leaves that side open. Events
+// without a parsable time are skipped, since they cannot be placed in the range.
+// Entries are sorted by time.
+func BuildTrail(files []string, operator string, from, to time.Time) (*Trail, error) {
+	t := &Trail{
+		Operator:  operator,
+		From:      from,
+		To:        to,
+		Actions:   make(map[string]int),
+		Resources: make(map[string]int),
+		IPs:       make(map[string]int),
+	}
+	err := ForEach(files, func(file string, ev slogging.Entry, _ []byte) bool {
+		if !isOperator(ev, operator) {
+			return true
+		}
+		ts := EventTime(ev)
+		if ts.IsZero() || (!from.IsZero() && ts.Before(from)) || (!to.IsZero() && !ts.Before(to)) {
+			return true
+		}
+		e := TrailEntry{
+			Time:      ts,
+			Level:     ev.Level(),
+			Action:    firstField(ev, "action", "message"),
+			Resource:  firstField(ev, "resource", "api_id"),
+			IP:        firstField(ev, "ip_address"),
+			RequestID: firstField(ev, "request_id", slogging.XRequestID),
+			File:      file,
+		}
+		t.Entries = append(t.Entries, e)
+		t.Actions[e.Action]++
+		if e.Resource != "" {
+			t.Resources[e.Resource]++
+		}
+		if e.IP != "" {
+			t.IPs[e.IP]++
+		}
+		return true
+	})
+	sort.SliceStable(t.Entries, func(i, j int) bool { return t.Entries[i].Time.Before(t.Entries[j].Time) })
+	return t, err
+}
+
+func isOperator(ev slogging.Entry, operator string) bool {
+	for _, f := range operatorFields {
+		if operator != "" && ev.Str(f) == operator {
+			return true
+		}
+	}
+	return false
+}
+
+// firstField returns the first non-empty value among keys, stringified.
+func firstField(ev slogging.Entry, keys ...string) string {
+	for _, k := range keys {
+		if v, ok := ev[k]; ok && v != nil {
+			if s := Stringify(v); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
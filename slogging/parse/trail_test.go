@@ -0,0 +1,53 @@
+package parse
+
+import (
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"testing"
+	"time"
+)
+
+func TestBuildTrail(t *testing.T) {
+	at := func(min int) string { return time.Date(2024, 5, 1, 10, min, 0, 0, time.UTC).Format(time.RFC3339) }
+	a := writeLog(t, "a.log",
+		slogging.Entry{"time": at(5), "operator_name": "alice", "action": "refund", "resource": "order/1", "ip_address": "10.0.0.1", "request_id": "r2"},
+		slogging.Entry{"time": at(1), "operator_name": "alice", "message": "login", "ip_address": "10.0.0.1"},
+		slogging.Entry{"time": at(2), "operator_name": "bob", "action": "refund"},
+		slogging.Entry{"operator_name": "alice", "action": "no time"},
+	)
+	b := writeLog(t, "b.log",
+		slogging.Entry{"time": at(3), slogging.XOperator: "alice", "message": "export", "api_id": "reports", slogging.XRequestID: "r1", "level": "warn"},
+		slogging.Entry{"time": at(9), "operator_name": "alice", "action": "logout"}, // at the end of the range
+		slogging.Entry{"time": at(0), "operator_name": "alice", "action": "too early"},
+	)
+
+	tr, err := BuildTrail([]string{a, b}, "alice", time.Date(2024, 5, 1, 10, 1, 0, 0, time.UTC), time.Date(2024, 5, 1, 10, 9, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []TrailEntry{
+		{Action: "login", IP: "10.0.0.1", File: a},
+		{Action: "export", Level: "warn", Resource: "reports", RequestID: "r1", File: b},
+		{Action: "refund", Resource: "order/1", IP: "10.0.0.1", RequestID: "r2", File: a},
+	}
+	if len(tr.Entries) != len(want) {
+		t.Fatalf("entries = %+v", tr.Entries)
+	}
+	for i, e := range tr.Entries {
+		e.Time = time.Time{}
+		if e != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, e, want[i])
+		}
+	}
+	if tr.Actions["refund"] != 1 || tr.IPs["10.0.0.1"] != 2 || tr.Resources["reports"] != 1 || len(tr.Actions) != 3 {
+		t.Errorf("counts: actions %v, ips %v, resources %v", tr.Actions, tr.IPs, tr.Resources)
+	}
+
+	// Open ranges keep everything with a time.
+	tr, err = BuildTrail([]string{a, b}, "alice", time.Time{}, time.Time{})
+	if err != nil || len(tr.Entries) != 5 || tr.Entries[0].Action != "too early" || tr.Entries[4].Action != "logout" {
+		t.Errorf("open range: %+v, %v", tr, err)
+	}
+	if tr, _ := BuildTrail([]string{a, b}, "", time.Time{}, time.Time{}); len(tr.Entries) != 0 {
+		t.Errorf("empty operator matched %+v", tr.Entries)
+	}
+}
@@ -0,0 +1,96 @@
+package parse
+
+import (
+	"fmt"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"sync"
+)
+
+// UpgradeFunc rewrites an event of one schema version into the next one.
+// It may modify ev in place and return it.
+type UpgradeFunc func(ev slogging.Entry) slogging.Entry
+
+// builtinUpgrades[v] turns a version v event into version v+1 for the
+// package's standard fields.
+var builtinUpgrades = map[int]UpgradeFunc{
+	0: func(ev slogging.Entry) slogging.Entry { return ev }, // v1 only added the version stamp
+	1: upgradeLegacyIDs,
+}
+
+var (
+	upgradesMu sync.RWMutex
+	// upgrades[v] is RegisterUpgrade's, run after builtinUpgrades[v].
+	upgrades = map[int]UpgradeFunc{}
+)
+
+// upgradeLegacyIDs moves the legacy Logger's header-named IDs to the
+// canonical keys. An event that already has the canonical key keeps it.
+func upgradeLegacyIDs(ev slogging.Entry) slogging.Entry {
+	for from, to := range map[string]string{
+		slogging.XRequestID: slogging.FieldRequestID,
+		slogging.XOperator:  slogging.FieldOperatorName,
+	} {
+		v, ok := ev[from]
+		if !ok {
+			continue
+		}
+		delete(ev, from)
+		if s, _ := v.(string); s == "" {
+			continue
+		}
+		if _, ok := ev[to]; !ok {
+			ev[to] = v
+		}
+	}
+	if s, _ := ev[slogging.APIID].(string); s == "" {
+		delete(ev, slogging.APIID)
+	}
+	return ev
+}
+
+// RegisterUpgrade installs an upgrade from schema version from to from+1 for
+// custom fields your service renamed along with that version, replacing any
+// previous one registered for from. It runs after the package's own upgrade
+// of the standard fields, which it cannot replace. Events are only upgraded
+// up to slogging.SchemaVersion, so a from outside [0, SchemaVersion) is
+// refused.
+func RegisterUpgrade(from int, fn UpgradeFunc) error {
+	if from < 0 || from >= slogging.SchemaVersion {
+		return fmt.Errorf("parse: no upgrade from schema version %d: events are upgraded from 0 up to %d", from, slogging.SchemaVersion)
+	}
+	upgradesMu.Lock()
+	defer upgradesMu.Unlock()
+	upgrades[from] = fn
+	return nil
+}
+
+// SchemaOf returns the schema version an event was written with.
+func SchemaOf(ev slogging.Entry) int {
+	if v, ok := ev[slogging.FieldSchemaVersion].(float64); ok {
+		return int(v)
+	}
+	return 0
+}
+
+// Upgrade brings ev to slogging.SchemaVersion, applying registered upgrades in
+// order, the package's before RegisterUpgrade's for each version. It reports
+// whether the event was changed. Events from newer versions
+// are returned untouched.
+func Upgrade(ev slogging.Entry) (slogging.Entry, bool) {
+	v := SchemaOf(ev)
+	if v >= slogging.SchemaVersion {
+		return ev, false
+	}
+	upgradesMu.RLock()
+	defer upgradesMu.RUnlock()
+	for ; v < slogging.SchemaVersion; v++ {
+		if fn := builtinUpgrades[v]; fn != nil {
+			ev = fn(ev)
+		}
+		if fn := upgrades[v]; fn != nil {
+			ev = fn(ev)
+		}
+	}
+	ev[slogging.FieldSchemaVersion] = float64(v)
+	return ev, true
+}
@@ -0,0 +1,75 @@
+package parse
+
+import (
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"reflect"
+	"testing"
+)
+
+func TestUpgrade(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		in      slogging.Entry
+		want    slogging.Entry
+		changed bool
+	}{
+		{
+			"v0 legacy IDs",
+			slogging.Entry{"message": "m", slogging.XRequestID: "r1", slogging.XOperator: "bob", slogging.APIID: ""},
+			slogging.Entry{"message": "m", slogging.FieldRequestID: "r1", slogging.FieldOperatorName: "bob", slogging.FieldSchemaVersion: float64(slogging.SchemaVersion)},
+			true,
+		},
+		{
+			"v1 keeps canonical IDs",
+			slogging.Entry{slogging.FieldSchemaVersion: 1.0, slogging.FieldRequestID: "r1", slogging.XRequestID: "old", slogging.XOperator: ""},
+			slogging.Entry{slogging.FieldRequestID: "r1", slogging.FieldSchemaVersion: float64(slogging.SchemaVersion)},
+			true,
+		},
+		{
+			"current",
+			slogging.Entry{slogging.FieldSchemaVersion: float64(slogging.SchemaVersion), slogging.XRequestID: "r1"},
+			slogging.Entry{slogging.FieldSchemaVersion: float64(slogging.SchemaVersion), slogging.XRequestID: "r1"},
+			false,
+		},
+		{
+			"newer",
+			slogging.Entry{slogging.FieldSchemaVersion: float64(slogging.SchemaVersion + 1), "x": "y"},
+			slogging.Entry{slogging.FieldSchemaVersion: float64(slogging.SchemaVersion + 1), "x": "y"},
+			false,
+		},
+	} {
+		got, changed := Upgrade(tc.in)
+		if changed != tc.changed || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: Upgrade = %v, %v; want %v, %v", tc.name, got, changed, tc.want, tc.changed)
+		}
+	}
+}
+
+func TestRegisterUpgrade(t *testing.T) {
+	for _, from := range []int{-1, slogging.SchemaVersion, slogging.SchemaVersion + 1} {
+		if err := RegisterUpgrade(from, func(ev slogging.Entry) slogging.Entry { return ev }); err == nil {
+			t.Errorf("RegisterUpgrade(%d) was accepted", from)
+		}
+	}
+
+	// A custom upgrade runs after the built-in one for the same version,
+	// which it does not replace.
+	err := RegisterUpgrade(1, func(ev slogging.Entry) slogging.Entry {
+		ev["user"], ev["rid"] = ev["usr"], ev[slogging.FieldRequestID]
+		delete(ev, "usr")
+		return ev
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		upgradesMu.Lock()
+		delete(upgrades, 1)
+		upgradesMu.Unlock()
+	})
+	got, _ := Upgrade(slogging.Entry{"usr": "u1", slogging.XRequestID: "r1"})
+	want := slogging.Entry{"user": "u1", "rid": "r1", slogging.FieldRequestID: "r1", slogging.FieldSchemaVersion: float64(slogging.SchemaVersion)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Upgrade = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,148 @@
+package slogging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/rs/zerolog"
+	"io"
+	"math/rand/v2"
+	"strings"
+	"unicode/utf8"
+)
+
+const defaultPayloadMaxBytes = 4096
+
+// PayloadSampling configures client adapters (grpcmw.NewUnaryClientInterceptor,
+// NewRoundTripper) to log a copy of request and response payloads for a fraction of calls, to
+// debug contract drift in third-party APIs:
+//
+//	slogging.PayloadSampling{
+//		Rate:  0.01,                                   // 1% of calls to any host
+//		Hosts: map[string]float64{"api.vendor.com": 0.2}, // 20% to this one
+//	}
+//
+// JSON payloads have the values of secret-looking keys (DenyParams plus Deny,
+// at any depth) replaced by "[REDACTED]"; other payloads are logged by size
+// only. Payloads over MaxBytes are cut and logged as a string.
+type PayloadSampling struct {
+	Rate     float64            // fraction of calls captured, 0..1
+	Hosts    map[string]float64 // per-host rates, overriding Rate
+	MaxBytes int                // per payload (default 4096)
+	Deny     []string           // extra keys to redact, lower-case
+}
+
+// Enabled reports whether any call may be sampled.
+func (s PayloadSampling) Enabled() bool {
+	if s.Rate > 0 {
+		return true
+	}
+	for _, r := range s.Hosts {
+		if r > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Sample decides whether to capture one call to host.
+func (s PayloadSampling) Sample(host string) bool {
+	rate, ok := s.Hosts[host]
+	if !ok {
+		rate = s.Rate
+	}
+	return rate > 0 && (rate >= 1 || rand.Float64() < rate)
+}
+
+// LogPayload writes a "payload sample" event with the redacted, size-limited
+// request and response payloads of one call; nil payloads are left out.
+func (s PayloadSampling) LogPayload(ctx context.Context, host, operation string, req, resp []byte) {
+	e := From(ctx).Info()
+	if e == nil {
+		return
+	}
+	e.Str("host", host).Str("operation", operation)
+	s.addPayload(e, FieldRequestPayload, req)
+	s.addPayload(e, FieldResponsePayload, resp)
+	e.Msg("payload sample")
+}
+
+// addPayload adds p under key, or only its size under key+"_bytes" when it
+// is not JSON.
+func (s PayloadSampling) addPayload(e *zerolog.Event, key string, p []byte) {
+	if p == nil {
+		return
+	}
+	v, err := decodePayload(p)
+	if err != nil {
+		e.Int(key+"_bytes", len(p))
+		return
+	}
+	b, err := json.Marshal(s.redact(v))
+	if err != nil {
+		e.Int(key+"_bytes", len(p))
+		return
+	}
+	limit := s.MaxBytes
+	if limit <= 0 {
+		limit = defaultPayloadMaxBytes
+	}
+	if len(b) <= limit {
+		e.RawJSON(key, b)
+		return
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(b[cut]) {
+		cut--
+	}
+	e.Str(key, string(b[:cut])+"…")
+	e.Int(key+"_bytes", len(b))
+}
+
+// decodePayload parses one JSON value, keeping numbers as json.Number so IDs
+// beyond 2^53 are logged as sent rather than rounded through float64.
+func decodePayload(p []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("trailing data after JSON value")
+	}
+	return v, nil
+}
+
+func (s PayloadSampling) redact(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, x := range v {
+			if s.denied(strings.ToLower(k)) {
+				v[k] = "[REDACTED]"
+				continue
+			}
+			v[k] = s.redact(x)
+		}
+	case []any:
+		for i, x := range v {
+			v[i] = s.redact(x)
+		}
+	}
+	return v
+}
+
+func (s PayloadSampling) denied(k string) bool {
+	for _, d := range DenyParams {
+		if strings.Contains(k, d) {
+			return true
+		}
+	}
+	for _, d := range s.Deny {
+		if k == d {
+			return true
+		}
+	}
+	return false
+}
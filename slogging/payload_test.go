@@ -0,0 +1,45 @@
+package slogging
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestLogPayload(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc"})
+	s := PayloadSampling{Rate: 1, Deny: []string{"card"}}
+	ctx := context.Background()
+	s.LogPayload(ctx, "api.vendor.com", "POST /charges",
+		[]byte(`{"id":12345678901234567890,"amount":10.50,"card":"4111","auth":{"password":"x"}}`),
+		[]byte(`<html>busy</html>`))
+
+	var line []byte
+	for _, l := range c.lines {
+		if bytes.Contains(l, []byte(`"payload sample"`)) {
+			line = l
+		}
+	}
+	for _, want := range []string{
+		`"request_payload":{"amount":10.50,"auth":{"password":"[REDACTED]"},"card":"[REDACTED]","id":12345678901234567890}`,
+		`"response_payload_bytes":17`,
+	} {
+		if !bytes.Contains(line, []byte(want)) {
+			t.Errorf("payload sample lacks %s:\n%s", want, line)
+		}
+	}
+}
+
+func TestDecodePayload(t *testing.T) {
+	for in, ok := range map[string]bool{
+		`{"a":1}`:         true,
+		` [1, 2] `:        true,
+		`{"a":1} {"b":2}`: false,
+		`{"a":1}x`:        false,
+		`nope`:            false,
+	} {
+		if _, err := decodePayload([]byte(in)); (err == nil) != ok {
+			t.Errorf("decodePayload(%s) error = %v", in, err)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package slogging
+
+import (
+	"errors"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrAlreadyInitialized is returned by InitOnce when Init/InitOnce already ran.
+var ErrAlreadyInitialized = errors.New("slogging: already initialized")
+
+// pipeline is everything a single Init built: the configured logger and the
+// writers it owns. Re-Init installs a new pipeline and closes the old one.
+type pipeline struct {
+	logger   zerolog.Logger
+	self     zerolog.Logger // same output and base fields, never sampled
+	level    zerolog.Level
+	enrich   bool      // Enrichers configured: From binds ctx to events
+	warnBare bool      // WarnBareContext
+	otel     bool      // OTELCorrelation
+	stack    bool      // WithStack
+	file     *fileSink // main FilePath sink, nil when logging to stdout only
+	ring     *ringSink // nil unless RingBufferSize > 0
+	audit    *auditLog // nil unless Audit names a destination
+	extra    io.Closer // ExtraWriter, when it is one
+	out      io.Writer // the writer chain this pipeline built; see sink
+	closers  []io.Closer
+
+	samplers []*countingSampler
+	sampler  zerolog.Sampler  // SampleEvery or SampleBudget, behind levelGate
+	adaptive *adaptiveSampler // nil unless SampleBudget is set
+	flags    *flagSampling    // nil unless FlagSampleEvery is set
+	retries  *retryDetector   // nil unless RetryStormThreshold is set
+	slos     *sloTracker      // nil unless HostSLOs is set
+	metrics  *metrics         // nil unless MetricRules is set
+
+	// base is Level and comps ComponentLevels, as SetLevel and
+	// SetComponentLevel change them; comps is replaced, never mutated.
+	levelMu sync.Mutex
+	base    atomic.Int32
+	comps   atomic.Pointer[map[string]zerolog.Level]
+
+	queuesMu  sync.Mutex
+	queues    []*asyncWriter // async sinks, including lazily opened route partitions
+	onInstall []func()       // run once the pipeline is the global one (e.g. sink probes)
+
+	inst     *Instance      // the instance the pipeline was built for
+	stats    *selfStats     // inst's counters, fed by the writers
+	crashDir string         // Options.CrashDir
+	config   map[string]any // configSummary, for postmortems
+
+	// next is the pipeline a re-Init installed in place of this one, so
+	// loggers handed out earlier write through its writers instead of the
+	// closed ones.
+	next atomic.Pointer[pipeline]
+}
+
+var (
+	// std is the default Instance: the one Init configures and the
+	// package-level functions use.
+	std = &Instance{}
+
+	// current is std's installed pipeline. It is only stored with std.mu held
+	// and is read lock-free on the logging path; see doc.go.
+	current = &std.current
+
+	formatOnce sync.Once
+)
+
+// global returns the installed logger, or zerolog's log.Logger before any Init.
+func global() *zerolog.Logger {
+	return std.global()
+}
+
+// selfLogger is global without sampling, for events about slogging itself.
+func selfLogger() *zerolog.Logger {
+	if p := current.Load(); p != nil {
+		return &p.self
+	}
+	return &log.Logger
+}
+
+// withStack reports whether the installed pipeline has Options.WithStack.
+func withStack() bool {
+	p := current.Load()
+	return p != nil && p.stack
+}
+
+// setFormatGlobals sets zerolog's package-level format knobs exactly once, so
+// re-Init never writes variables that in-flight events are reading.
+func setFormatGlobals() {
+	formatOnce.Do(func() {
+		zerolog.TimeFieldFormat = time.RFC3339
+		zerolog.CallerMarshalFunc = callerMarshal
+		fallbackMarshal = zerolog.InterfaceMarshalFunc
+		zerolog.InterfaceMarshalFunc = marshalInterface
+		fallbackErrorMarshal = zerolog.ErrorMarshalFunc
+		zerolog.ErrorMarshalFunc = marshalError
+		zerolog.ErrorStackMarshaler = marshalStack
+	})
+}
+
+// InitOnce initializes the global logger only if nothing has been installed yet;
+// otherwise it leaves the running pipeline untouched and returns ErrAlreadyInitialized.
+func InitOnce(opt Options) error {
+	std.mu.Lock()
+	defer std.mu.Unlock()
+	if std.current.Load() != nil {
+		return ErrAlreadyInitialized
+	}
+	std.install(build(std, opt))
+	return nil
+}
+
+// MustInitOnce is InitOnce that panics on a second call; use it in main()
+// to catch accidental double initialization early.
+func MustInitOnce(opt Options) {
+	if err := InitOnce(opt); err != nil {
+		panic(err)
+	}
+}
+
+// install swaps p in as i's pipeline and closes the previous one. Callers
+// must hold i.mu.
+func (i *Instance) install(p *pipeline) {
+	old := i.current.Load()
+	i.seedPressure()
+	i.current.Store(p)
+	if old != nil {
+		old.next.Store(p)
+	}
+	if i == std {
+		log.Logger = p.logger // compat for direct log.Logger users; not race-free, see doc.go
+	}
+	if old != nil {
+		old.close()
+	}
+	for _, f := range p.onInstall {
+		f()
+	}
+}
+
+// sink returns the writer chain of the newest pipeline that replaced p, or
+// p's own while p is installed (or was the last one).
+func (p *pipeline) sink() io.Writer {
+	for n := p.next.Load(); n != nil; n = p.next.Load() {
+		p = n
+	}
+	return p.out
+}
+
+// handoffWriter is what a pipeline's loggers write to: its sink, so they
+// follow re-Init.
+type handoffWriter struct{ p *pipeline }
+
+func (w handoffWriter) Write(b []byte) (int, error) {
+	return w.p.sink().Write(b)
+}
+
+func (w handoffWriter) WriteLevel(level zerolog.Level, b []byte) (int, error) {
+	return writeLevel(w.p.sink(), level, b)
+}
+
+// close releases the writers owned by the pipeline, returning the first error.
+// Closers run in reverse order so wrappers flush into sinks that are still open.
+func (p *pipeline) close() error {
+	var first error
+	for i := len(p.closers) - 1; i >= 0; i-- {
+		if err := p.closers[i].Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// wrapSink applies the per-sink delivery options (write deadline, async lanes)
+// to s. It returns the wrapped writer and the closers it introduced, in the
+// order they must be appended to a pipeline (close runs them in reverse).
+// Async queues are registered under name for GetSinkStats and Drain.
+func (p *pipeline) wrapSink(name string, s io.Writer, opt Options) (io.Writer, []io.Closer) {
+	var closers []io.Closer
+	if opt.WriteTimeout > 0 {
+		dw := newDeadlineWriter(s, opt.WriteTimeout, p.stats)
+		closers = append(closers, dw)
+		s = dw
+	}
+	if opt.Async {
+		aw := newAsyncWriter(name, s, opt, p.stats)
+		closers = append(closers, aw)
+		p.queuesMu.Lock()
+		p.queues = append(p.queues, aw)
+		p.queuesMu.Unlock()
+		s = aw
+	}
+	return s, closers
+}
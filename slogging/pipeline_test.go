@@ -0,0 +1,83 @@
+package slogging
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestReInitHandsOffContextLoggers(t *testing.T) {
+	first := initCapture(t, Options{Service: "svc"})
+	ctx := IntoContext(context.Background(), "user", "u1")
+
+	second := initCapture(t, Options{Service: "svc"})
+	From(ctx).Info().Msg("after re-init")
+
+	if n := len(first.withMessage(t, "after re-init")); n != 0 {
+		t.Errorf("closed pipeline got %d events", n)
+	}
+	evs := second.withMessage(t, "after re-init")
+	if len(evs) != 1 {
+		t.Fatalf("new pipeline got %d events, want 1", len(evs))
+	}
+	if evs[0]["user"] != "u1" {
+		t.Errorf("user = %v, want the field of the handed-out logger", evs[0]["user"])
+	}
+}
+
+func TestInitOnce(t *testing.T) {
+	initCapture(t, Options{Service: "svc"})
+	if err := InitOnce(Options{Service: "other"}); err != ErrAlreadyInitialized {
+		t.Fatalf("InitOnce after Init = %v, want ErrAlreadyInitialized", err)
+	}
+}
+
+// TestConcurrentInitSetLevelFrom hammers the pipeline swap from every side;
+// run it with -race.
+func TestConcurrentInitSetLevelFrom(t *testing.T) {
+	initCapture(t, Options{Service: "svc"})
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rctx := WithRequestID(context.Background(), "r"+strconv.Itoa(i))
+			for ctx.Err() == nil {
+				From(rctx).Info().Int("i", i).Msg("event")
+				l := With("k", i)
+				l.Debug().Msg("debug")
+				Component("db").From(rctx).Info().Msg("component")
+			}
+		}()
+	}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		levels := []string{"debug", "info", "warn"}
+		for n := 0; ctx.Err() == nil; n++ {
+			SetLevel(levels[n%len(levels)])
+			SetComponentLevel("db", levels[(n+1)%len(levels)])
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for n := 0; ctx.Err() == nil; n++ {
+			rctx, done := context.WithCancel(ctx)
+			From(WithRequestLevel(rctx, zerolog.DebugLevel)).Debug().Msg("request debug")
+			done()
+		}
+	}()
+	for n := range 20 {
+		Init(Options{Service: "svc", FilePath: filepath.Join(dir, "app.log"), Level: "info", Sequence: n%2 == 0})
+	}
+	cancel()
+	wg.Wait()
+	if p := current.Load(); p == nil || p.next.Load() != nil {
+		t.Fatal("the last Init is not the installed pipeline")
+	}
+}
@@ -0,0 +1,189 @@
+package slogging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/rs/zerolog"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// postmortemEvents is how many recent events a postmortem keeps.
+const postmortemEvents = 100
+
+// Postmortem is the file written to Options.CrashDir when the process dies
+// through a Fatal/Panic event or a panic caught by PanicPostmortem.
+type Postmortem struct {
+	Time    time.Time       `json:"time"`
+	Reason  string          `json:"reason"` // "fatal", "panic" or "panic_event"
+	Message string          `json:"message"`
+	PID     int             `json:"pid"`
+	Config  map[string]any  `json:"config"`
+	Runtime json.RawMessage `json:"runtime"`
+	Stack   string          `json:"stack"`
+	Events  []Entry         `json:"events,omitempty"` // oldest first
+}
+
+// postmortemHook writes a postmortem for fatal and panic events before
+// zerolog exits or panics.
+type postmortemHook struct{ p *pipeline }
+
+func (h postmortemHook) Run(_ *zerolog.Event, level zerolog.Level, msg string) {
+	switch level {
+	case zerolog.FatalLevel:
+		h.p.writePostmortem("fatal", msg, debug.Stack())
+	case zerolog.PanicLevel:
+		h.p.writePostmortem("panic_event", msg, debug.Stack())
+	}
+}
+
+// PanicPostmortem records a postmortem for a panic unwinding through the
+// calling function, logs it, then re-panics. Defer it first thing in main and
+// in goroutine entry points:
+//
+//	func main() {
+//		slogging.Init(opt)
+//		defer slogging.PanicPostmortem()
+//		...
+//	}
+//
+// Without Options.CrashDir it only logs the panic.
+func PanicPostmortem() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	stack := debug.Stack()
+	msg := fmt.Sprint(r)
+	if p := current.Load(); p != nil {
+		p.writePostmortem("panic", msg, stack)
+	}
+	selfEvent(zerolog.PanicLevel, "panic").
+		Str("panic", msg).
+		Str("stack", string(stack)).
+		Msg("unrecovered panic")
+	panic(r)
+}
+
+func (p *pipeline) writePostmortem(reason, msg string, stack []byte) {
+	if p.crashDir == "" {
+		return
+	}
+	pm := Postmortem{
+		Time:    time.Now().UTC(),
+		Reason:  reason,
+		Message: msg,
+		PID:     os.Getpid(),
+		Config:  p.config,
+		Stack:   string(stack),
+	}
+	var rt bytes.Buffer
+	l := zerolog.New(&rt)
+	runtimeFields(l.Log()).Send()
+	pm.Runtime = bytes.TrimSpace(rt.Bytes())
+	pm.Events, _ = Recent(postmortemEvents, nil)
+
+	if err := writeFileAtomic(p.crashDir, postmortemName(pm.Time, pm.PID), pm); err != nil {
+		fmt.Fprintf(os.Stderr, "slogging: writing postmortem: %v\n", err)
+	}
+}
+
+func postmortemName(t time.Time, pid int) string {
+	return "postmortem-" + t.Format("20060102T150405.000000000Z") + "-" + strconv.Itoa(pid) + ".json"
+}
+
+// writeFileAtomic writes v as JSON to dir/name via a synced temp file, so a
+// reader never sees a half-written postmortem.
+func writeFileAtomic(dir, name string, v any) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, ".tmp-"+name)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, name))
+}
+
+// reportPostmortems logs each postmortem left in dir by an earlier process as
+// a "previous_crash" event and renames it to *.reported, so it is shipped
+// exactly once by whatever collects the logs.
+func reportPostmortems(l *zerolog.Logger, dir string) {
+	paths, _ := filepath.Glob(filepath.Join(dir, "postmortem-*.json"))
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var pm Postmortem
+		if err := json.Unmarshal(b, &pm); err != nil {
+			continue
+		}
+		selfEventOn(l, zerolog.ErrorLevel, "previous_crash").
+			Str("postmortem", path).
+			Str("reason", pm.Reason).
+			Str("crash_message", pm.Message).
+			Time("crashed_at", pm.Time).
+			Int("crashed_pid", pm.PID).
+			Int("recent_events", len(pm.Events)).
+			RawJSON("crash_runtime", compactJSON(pm.Runtime)).
+			Str("crash_stack", pm.Stack).
+			Msg("previous process crashed: " + firstLine(pm.Message))
+		os.Rename(path, path+".reported")
+	}
+}
+
+// compactJSON returns b on a single line, as an event field requires.
+func compactJSON(b []byte) []byte {
+	var out bytes.Buffer
+	if json.Compact(&out, b) != nil {
+		return []byte("null")
+	}
+	return out.Bytes()
+}
+
+func firstLine(s string) string {
+	s, _, _ = strings.Cut(s, "\n")
+	return s
+}
+
+// configSummary is the part of Options worth having in a postmortem; writers,
+// callbacks and anything secret-bearing are left out.
+func configSummary(opt Options, level zerolog.Level) map[string]any {
+	return map[string]any{
+		"service":          opt.Service,
+		"env":              opt.Environment,
+		"version":          opt.Version,
+		"level":            level.String(),
+		"file_path":        opt.FilePath,
+		"also_stdout":      opt.AlsoStdout,
+		"async":            opt.Async,
+		"buffer_size":      opt.BufferSize,
+		"write_timeout":    opt.WriteTimeout.String(),
+		"sample_every":     opt.SampleEvery,
+		"ring_buffer_size": opt.RingBufferSize,
+		"routes":           len(opt.Routes),
+		"dedup_window":     opt.DedupWindow.String(),
+	}
+}
@@ -0,0 +1,56 @@
+package slogging
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPanicPostmortem(t *testing.T) {
+	dir := t.TempDir()
+	initCapture(t, Options{Service: "svc", CrashDir: dir})
+	From(context.Background()).Info().Msg("before the crash")
+
+	func() {
+		defer func() { recover() }()
+		defer PanicPostmortem()
+		panic("nil map write")
+	}()
+
+	paths, _ := filepath.Glob(filepath.Join(dir, "postmortem-*.json"))
+	if len(paths) != 1 {
+		t.Fatalf("postmortems = %v, want 1", paths)
+	}
+	b, err := os.ReadFile(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pm Postmortem
+	if err := json.Unmarshal(b, &pm); err != nil {
+		t.Fatal(err)
+	}
+	if pm.Reason != "panic" || pm.Message != "nil map write" || pm.Config["service"] != "svc" ||
+		!strings.Contains(pm.Stack, "TestPanicPostmortem") || len(pm.Runtime) == 0 {
+		t.Errorf("postmortem = %+v", pm)
+	}
+	var seen bool
+	for _, e := range pm.Events {
+		seen = seen || e.Message() == "before the crash"
+	}
+	if !seen {
+		t.Errorf("postmortem events %v miss the last logged one", pm.Events)
+	}
+
+	// The next process reports it once.
+	c := initCapture(t, Options{Service: "svc", CrashDir: dir})
+	evs := c.selfEvents(t, "previous_crash")
+	if len(evs) != 1 || evs[0]["crash_message"] != "nil map write" || evs[0]["reason"] != "panic" {
+		t.Errorf("previous_crash events = %v", evs)
+	}
+	if _, err := os.Stat(paths[0] + ".reported"); err != nil {
+		t.Errorf("postmortem not marked reported: %v", err)
+	}
+}
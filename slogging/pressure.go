@@ -0,0 +1,89 @@
+package slogging
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// pressureHold is how long a drop or write error keeps Pressure raised, so a
+// burst of losses is not forgotten between two polls.
+const pressureHold = 5 * time.Second
+
+// Pressure levels contributed by sink health rather than queue occupancy.
+const (
+	pressureDegraded    = 0.5  // a file sink is writing to its fallback
+	pressureWriteErrors = 0.75 // sink writes failed within pressureHold
+	pressureDropping    = 1.0  // events were dropped within pressureHold
+)
+
+// pressureMarks remembers the self-monitoring counters an Instance's last
+// Pressure call saw and when they last grew.
+type pressureMarks struct {
+	dropped     atomic.Uint64
+	writeErrors atomic.Uint64
+	droppedAt   atomic.Int64 // unix nanos
+	erroredAt   atomic.Int64 // unix nanos
+}
+
+// Pressure returns how saturated logging is, from 0 (idle) to 1 (losing
+// events), for load shedding and admission control:
+//
+//	if slogging.Pressure() > 0.9 {
+//		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+//		return
+//	}
+//
+// It is the highest of: the fullest async queue's occupancy (Options.Async),
+// 0.5 while a file sink writes to its fallback, 0.75 for a few seconds after a
+// sink write fails, and 1 for a few seconds after an event is dropped. Without
+// Async only the health signals apply. It is cheap enough to call per request.
+func Pressure() float64 {
+	return std.Pressure()
+}
+
+// Pressure is the package-level Pressure for the instance's own sinks.
+func (i *Instance) Pressure() float64 {
+	p := i.current.Load()
+	if p == nil {
+		return 0
+	}
+	level := 0.0
+	p.queuesMu.Lock()
+	for _, q := range p.queues {
+		if c := cap(q.normal); c > 0 {
+			level = max(level, float64(q.pending.Load())/float64(c))
+		}
+	}
+	p.queuesMu.Unlock()
+	if i.stats.degradedSinks.Load() > 0 {
+		level = max(level, pressureDegraded)
+	}
+	now := time.Now().UnixNano()
+	if recent(&i.pressure.dropped, &i.pressure.droppedAt, i.stats.dropped.Load(), now) {
+		level = max(level, pressureDropping)
+	}
+	if recent(&i.pressure.writeErrors, &i.pressure.erroredAt, i.stats.writeErrors.Load(), now) {
+		level = max(level, pressureWriteErrors)
+	}
+	return min(level, 1)
+}
+
+// seedPressure makes the current counters the baseline and forgets when they
+// last grew, so losses from before an Init do not read as recent afterwards,
+// whether or not a Pressure call saw them.
+func (i *Instance) seedPressure() {
+	i.pressure.dropped.Store(i.stats.dropped.Load())
+	i.pressure.writeErrors.Store(i.stats.writeErrors.Load())
+	i.pressure.droppedAt.Store(0)
+	i.pressure.erroredAt.Store(0)
+}
+
+// recent records counter n against the value last seen and reports whether
+// it grew within pressureHold of now.
+func recent(seen *atomic.Uint64, at *atomic.Int64, n uint64, now int64) bool {
+	if old := seen.Load(); n != old && seen.CompareAndSwap(old, n) {
+		at.Store(now)
+	}
+	last := at.Load()
+	return last != 0 && now-last < int64(pressureHold)
+}
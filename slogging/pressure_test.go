@@ -0,0 +1,47 @@
+package slogging
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestPressure(t *testing.T) {
+	ctx := context.Background()
+	t.Run("queue and drops", func(t *testing.T) {
+		_, open := initGated(t, Options{BufferSize: 10, DropPolicy: DropNewest})
+		if p := Pressure(); p != 0 {
+			t.Errorf("idle Pressure = %v, want 0", p)
+		}
+		for range 5 {
+			From(ctx).Info().Msg("info")
+		}
+		if p := Pressure(); p != 0.5 {
+			t.Errorf("Pressure = %v with 5 of 10 pending, want 0.5", p)
+		}
+		for range 20 {
+			From(ctx).Info().Msg("info")
+		}
+		if extraStats(t).Dropped == 0 {
+			t.Fatal("nothing dropped")
+		}
+		open()
+		eventually(t, "the queue to drain", func() bool { return extraStats(t).QueueDepth == 0 })
+		// The queue is empty again, but the drops are recent.
+		if p := Pressure(); p != 1 {
+			t.Errorf("Pressure = %v after drops, want 1", p)
+		}
+	})
+
+	t.Run("write errors", func(t *testing.T) {
+		Init(Options{FilePath: filepath.Join(t.TempDir(), "app.log"), ExtraWriter: failingWriter{errors.New("broken pipe")}, Async: true})
+		t.Cleanup(func() { Close(ctx) })
+		// The drops under the previous Init no longer count.
+		if p := Pressure(); p != 0 {
+			t.Errorf("Pressure = %v after Init, want 0", p)
+		}
+		From(ctx).Info().Msg("info")
+		eventually(t, "Pressure 0.75 after a failed write", func() bool { return Pressure() == 0.75 })
+	})
+}
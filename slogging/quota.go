@@ -0,0 +1,178 @@
+package slogging
+
+import (
+	"bytes"
+	"github.com/rs/zerolog"
+	"io"
+	"sync"
+	"time"
+)
+
+// maxQuotaTenants bounds the per-tenant table; once full, tenants not yet
+// tracked pass through until the next report frees idle entries.
+const maxQuotaTenants = 4096
+
+const defaultTenantQuotaReportInterval = time.Minute
+
+// TenantQuota limits the log volume of one tenant (Options.TenantQuotas).
+// Either limit may be 0, meaning none.
+type TenantQuota struct {
+	EventsPerSec int
+	BytesPerMin  int
+}
+
+// quotaWriter drops events of tenants over their TenantQuota. The tenant is
+// the event's "tenant" field; events without one, warn and above, and
+// slogging's own events are never dropped. Drops are summarized per tenant as
+// "tenant_quota_exceeded" warnings every report interval.
+type quotaWriter struct {
+	w      io.Writer
+	log    *zerolog.Logger
+	quotas map[string]TenantQuota
+	every  time.Duration
+
+	mu      sync.Mutex
+	tenants map[string]*tenantUsage
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+type tenantUsage struct {
+	quota       TenantQuota
+	secStart    time.Time
+	events      int // in the current second
+	minStart    time.Time
+	bytes       int // in the current minute
+	dropped     uint64
+	droppedSize uint64
+	active      bool // wrote since the last report
+}
+
+var tenantMarker = []byte(`"` + FieldTenant + `"`)
+
+func newQuotaWriter(w io.Writer, log *zerolog.Logger, quotas map[string]TenantQuota, every time.Duration) *quotaWriter {
+	if every <= 0 {
+		every = defaultTenantQuotaReportInterval
+	}
+	q := &quotaWriter{
+		w:       w,
+		log:     log,
+		quotas:  quotas,
+		every:   every,
+		tenants: make(map[string]*tenantUsage),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+func (q *quotaWriter) Write(p []byte) (int, error) {
+	return q.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (q *quotaWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if level >= zerolog.WarnLevel || !bytes.Contains(p, tenantMarker) ||
+		bytes.Contains(p, selfMarker) || bytes.Contains(p, exposureMarker) {
+		return writeLevel(q.w, level, p)
+	}
+	if tenant, ok := jsonField(p, FieldTenant); ok && !q.admit(tenant, len(p), time.Now()) {
+		return len(p), nil
+	}
+	return writeLevel(q.w, level, p)
+}
+
+// admit charges an event of n bytes to tenant and reports whether it is
+// within quota.
+func (q *quotaWriter) admit(tenant string, n int, now time.Time) bool {
+	quota, ok := q.quotas[tenant]
+	if !ok {
+		if quota, ok = q.quotas["*"]; !ok {
+			return true
+		}
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	u := q.tenants[tenant]
+	if u == nil {
+		if len(q.tenants) >= maxQuotaTenants {
+			return true
+		}
+		u = &tenantUsage{quota: quota, secStart: now, minStart: now}
+		q.tenants[tenant] = u
+	}
+	u.active = true
+	if now.Sub(u.secStart) >= time.Second {
+		u.secStart, u.events = now, 0
+	}
+	if now.Sub(u.minStart) >= time.Minute {
+		u.minStart, u.bytes = now, 0
+	}
+	if (u.quota.EventsPerSec > 0 && u.events >= u.quota.EventsPerSec) ||
+		(u.quota.BytesPerMin > 0 && u.bytes+n > u.quota.BytesPerMin) {
+		u.dropped++
+		u.droppedSize += uint64(n)
+		return false
+	}
+	u.events++
+	u.bytes += n
+	return true
+}
+
+func (q *quotaWriter) run() {
+	defer close(q.done)
+	t := time.NewTicker(q.every)
+	defer t.Stop()
+	for {
+		select {
+		case <-q.stop:
+			q.report()
+			return
+		case <-t.C:
+			q.report()
+		}
+	}
+}
+
+// report emits one warning per tenant that went over quota since the last
+// report and forgets tenants that were idle. Events are emitted after the
+// lock is released, since they come back through WriteLevel.
+func (q *quotaWriter) report() {
+	type overflow struct {
+		tenant string
+		quota  TenantQuota
+		n, b   uint64
+	}
+	var reports []overflow
+	q.mu.Lock()
+	for t, u := range q.tenants {
+		if u.dropped > 0 {
+			reports = append(reports, overflow{t, u.quota, u.dropped, u.droppedSize})
+			u.dropped, u.droppedSize = 0, 0
+		}
+		if !u.active {
+			delete(q.tenants, t)
+		}
+		u.active = false
+	}
+	q.mu.Unlock()
+	for _, r := range reports {
+		selfEventOn(q.log, zerolog.WarnLevel, "tenant_quota_exceeded").
+			Str(FieldTenant, r.tenant).
+			Uint64("suppressed", r.n).
+			Uint64("suppressed_bytes", r.b).
+			Int("events_per_sec", r.quota.EventsPerSec).
+			Int("bytes_per_min", r.quota.BytesPerMin).
+			Dur("window", q.every).
+			Msgf("tenant over log quota, suppressed %d events", r.n)
+	}
+}
+
+// Close emits the final summaries and stops the reporter.
+func (q *quotaWriter) Close() error {
+	q.once.Do(func() { close(q.stop) })
+	<-q.done
+	return nil
+}
@@ -0,0 +1,64 @@
+package slogging
+
+import (
+	"bytes"
+	"context"
+	"github.com/rs/zerolog"
+	"testing"
+	"time"
+)
+
+func TestTenantQuotas(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc", TenantQuotas: map[string]TenantQuota{
+		"acme": {EventsPerSec: 2},
+		"*":    {EventsPerSec: 1},
+	}})
+	acme := IntoContext(context.Background(), FieldTenant, "acme")
+	other := IntoContext(context.Background(), FieldTenant, "globex")
+	for range 5 {
+		From(acme).Info().Msg("acme info")
+		From(other).Info().Msg("globex info")
+	}
+	From(acme).Warn().Msg("acme warn")
+	From(context.Background()).Info().Msg("no tenant")
+
+	for msg, want := range map[string]int{"acme info": 2, "globex info": 1, "acme warn": 1, "no tenant": 1} {
+		if got := len(c.withMessage(t, msg)); got != want {
+			t.Errorf("%q: %d events, want %d", msg, got, want)
+		}
+	}
+}
+
+func TestQuotaWriterReportsDrops(t *testing.T) {
+	var out, self bytes.Buffer
+	log := zerolog.New(&self)
+	q := newQuotaWriter(&out, &log, map[string]TenantQuota{"acme": {BytesPerMin: 100}}, time.Hour)
+	ev := []byte(`{"level":"info","tenant":"acme","message":"` + string(bytes.Repeat([]byte("x"), 40)) + `"}` + "\n")
+	for range 4 {
+		q.WriteLevel(zerolog.InfoLevel, ev)
+	}
+	q.Close()
+
+	if got := bytes.Count(out.Bytes(), []byte("\n")); got != 1 {
+		t.Errorf("%d events passed a 100 bytes/min quota with %d-byte events, want 1", got, len(ev))
+	}
+	for _, want := range []string{`"tenant_quota_exceeded"`, `"tenant":"acme"`, `"suppressed":3`, `"bytes_per_min":100`} {
+		if !bytes.Contains(self.Bytes(), []byte(want)) {
+			t.Errorf("summary lacks %s: %s", want, self.Bytes())
+		}
+	}
+}
+
+func TestQuotaAdmitWindows(t *testing.T) {
+	q := &quotaWriter{quotas: map[string]TenantQuota{"acme": {EventsPerSec: 1}}, tenants: map[string]*tenantUsage{}}
+	now := time.Now()
+	if !q.admit("acme", 10, now) || q.admit("acme", 10, now.Add(500*time.Millisecond)) {
+		t.Fatal("second event within the same second was admitted")
+	}
+	if !q.admit("acme", 10, now.Add(time.Second)) {
+		t.Error("event in the next second was dropped")
+	}
+	if !q.admit("unknown", 10, now) || !q.admit("unknown", 10, now) {
+		t.Error("tenant without a quota was limited")
+	}
+}
@@ -0,0 +1,170 @@
+package slogging
+
+import (
+	"bytes"
+	"github.com/rs/zerolog"
+	"hash/fnv"
+	"io"
+	"sync"
+	"time"
+)
+
+// maxRateKeys bounds the rate limit table; once full, events with new keys
+// pass through untracked until the next sweep frees entries.
+const maxRateKeys = 4096
+
+const defaultRateLimitInterval = time.Minute
+
+// rateLimitWriter lets through at most limit events per key and interval.
+// The key is the event's rate_key field when set, otherwise its level and
+// message, so a hot loop logging the same error with changing fields is
+// capped where DedupWindow would let every variant through. Fatal and panic
+// events, exposures and slogging's own events are never dropped.
+//
+// A background sweep runs once per interval and emits one "rate_limited"
+// event, at the original level, for every key that had events dropped.
+type rateLimitWriter struct {
+	w        io.Writer
+	log      *zerolog.Logger
+	limit    int
+	interval time.Duration
+
+	mu      sync.Mutex
+	entries map[uint64]*rateEntry
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+type rateEntry struct {
+	start      time.Time
+	count      int
+	level      zerolog.Level
+	key        string // rate_key, "" when keyed by message
+	message    string
+	suppressed uint64
+}
+
+var (
+	rateKeyField = []byte(`"` + FieldRateKey + `"`)
+	messageKey   = []byte(`"` + zerolog.MessageFieldName + `"`)
+)
+
+func newRateLimitWriter(w io.Writer, log *zerolog.Logger, limit int, interval time.Duration) *rateLimitWriter {
+	r := &rateLimitWriter{
+		w:        w,
+		log:      log,
+		limit:    limit,
+		interval: interval,
+		entries:  make(map[uint64]*rateEntry),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+func (r *rateLimitWriter) Write(p []byte) (int, error) {
+	return r.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (r *rateLimitWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if level >= zerolog.FatalLevel && level != zerolog.NoLevel || bytes.Contains(p, selfMarker) || bytes.Contains(p, exposureMarker) {
+		return writeLevel(r.w, level, p)
+	}
+	members, _, ok := scanMembers(p)
+	if !ok {
+		return writeLevel(r.w, level, p)
+	}
+	key, _ := memberString(p, members, rateKeyField)
+	var msg string
+	if key == "" {
+		msg, _ = memberString(p, members, messageKey)
+	}
+	h := fnv.New64a()
+	h.Write([]byte{byte(level)})
+	h.Write([]byte(key))
+	h.Write([]byte{0})
+	h.Write([]byte(msg))
+	hash := h.Sum64()
+
+	now := time.Now()
+	r.mu.Lock()
+	e, ok := r.entries[hash]
+	if !ok {
+		if len(r.entries) >= maxRateKeys {
+			r.mu.Unlock()
+			return writeLevel(r.w, level, p)
+		}
+		e = &rateEntry{start: now, level: level, key: key, message: msg}
+		r.entries[hash] = e
+	} else if now.Sub(e.start) >= r.interval {
+		e.start, e.count = now, 0
+	}
+	e.count++
+	if e.count > r.limit {
+		e.suppressed++
+		r.mu.Unlock()
+		return len(p), nil
+	}
+	r.mu.Unlock()
+	return writeLevel(r.w, level, p)
+}
+
+func (r *rateLimitWriter) run() {
+	defer close(r.done)
+	t := time.NewTicker(r.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-r.stop:
+			r.sweep(true)
+			return
+		case <-t.C:
+			r.sweep(false)
+		}
+	}
+}
+
+// sweep reports suppressed counts and forgets keys that were quiet for a
+// whole interval. Events are emitted after the lock is released, since they
+// come back through WriteLevel.
+func (r *rateLimitWriter) sweep(all bool) {
+	type report struct {
+		level        zerolog.Level
+		key, message string
+		n            uint64
+	}
+	var reports []report
+	now := time.Now()
+	r.mu.Lock()
+	for k, e := range r.entries {
+		if e.suppressed > 0 {
+			reports = append(reports, report{e.level, e.key, e.message, e.suppressed})
+			e.suppressed = 0
+		} else if all || now.Sub(e.start) >= r.interval {
+			delete(r.entries, k)
+		}
+	}
+	r.mu.Unlock()
+	for _, rep := range reports {
+		e := selfEventOn(r.log, rep.level, "rate_limited")
+		if rep.key != "" {
+			e = e.Str(FieldRateKey, rep.key)
+		} else {
+			e = e.Str("rate_message", rep.message)
+		}
+		e.Uint64("suppressed_count", rep.n).
+			Int("limit", r.limit).
+			Dur("interval", r.interval).
+			Msgf("suppressed %d events over the rate limit", rep.n)
+	}
+}
+
+// Close emits the final summaries and stops the sweep.
+func (r *rateLimitWriter) Close() error {
+	r.once.Do(func() { close(r.stop) })
+	<-r.done
+	return nil
+}
@@ -0,0 +1,49 @@
+package slogging
+
+import (
+	"bytes"
+	"context"
+	"github.com/rs/zerolog"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc", RateLimit: 2, RateLimitInterval: time.Hour})
+	l := From(context.Background())
+	for i := range 5 {
+		l.Error().Int("attempt", i).Msg("db down") // keyed by level and message
+		l.Warn().Msg("db down")
+		l.Info().Str(FieldRateKey, "poll").Msgf("poll %d", i) // keyed by rate_key
+	}
+	for msg, want := range map[string]int{"db down": 4, "poll 0": 1, "poll 1": 1, "poll 2": 0} {
+		if got := len(c.withMessage(t, msg)); got != want {
+			t.Errorf("%q: %d events, want %d", msg, got, want)
+		}
+	}
+}
+
+func TestRateLimitSummaries(t *testing.T) {
+	var out, self bytes.Buffer
+	log := zerolog.New(&self)
+	r := newRateLimitWriter(&out, &log, 1, time.Hour)
+	for range 3 {
+		r.WriteLevel(zerolog.ErrorLevel, []byte(`{"level":"error","message":"db down"}`+"\n"))
+		r.WriteLevel(zerolog.InfoLevel, []byte(`{"level":"info","rate_key":"poll","message":"x"}`+"\n"))
+	}
+	r.WriteLevel(zerolog.FatalLevel, []byte(`{"level":"fatal","message":"db down"}`+"\n"))
+	r.WriteLevel(zerolog.FatalLevel, []byte(`{"level":"fatal","message":"db down"}`+"\n"))
+	r.Close()
+
+	if n := bytes.Count(out.Bytes(), []byte("\n")); n != 4 {
+		t.Errorf("%d events passed, want 2 plus 2 fatal:\n%s", n, out.Bytes())
+	}
+	for _, want := range []string{
+		`"level":"error","component":"slogging","slogging_event":"rate_limited","rate_message":"db down","suppressed_count":2`,
+		`"slogging_event":"rate_limited","rate_key":"poll","suppressed_count":2`,
+	} {
+		if !bytes.Contains(self.Bytes(), []byte(want)) {
+			t.Errorf("summaries lack %s:\n%s", want, self.Bytes())
+		}
+	}
+}
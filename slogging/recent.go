@@ -0,0 +1,164 @@
+package slogging
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/rs/zerolog"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Entry is one parsed log event, keyed by JSON field name.
+type Entry map[string]any
+
+// Level returns the event's level field.
+func (e Entry) Level() string { s, _ := e[zerolog.LevelFieldName].(string); return s }
+
+// Message returns the event's message field.
+func (e Entry) Message() string { s, _ := e[zerolog.MessageFieldName].(string); return s }
+
+// Time returns the event's timestamp, or the zero time if it is missing or unparsable.
+func (e Entry) Time() time.Time {
+	s, _ := e[zerolog.TimestampFieldName].(string)
+	t, _ := time.Parse(zerolog.TimeFieldFormat, s)
+	return t
+}
+
+// Str returns a string field, or "" when absent or not a string.
+func (e Entry) Str(key string) string { s, _ := e[key].(string); return s }
+
+// Filter selects entries for Recent; a nil Filter keeps everything.
+type Filter func(Entry) bool
+
+const tailBlock = 64 << 10
+
+// Recent returns up to n of the most recent events that pass filter, oldest
+// first. It reads the in-memory ring buffer (Options.RingBufferSize) when that
+// holds enough matches and otherwise the tail of the active log file. Lines
+// that are not JSON (Pretty output) are skipped.
+func Recent(n int, filter Filter) ([]Entry, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	p := current.Load()
+	if p == nil {
+		return nil, nil
+	}
+	var fromRing []Entry
+	if p.ring != nil {
+		fromRing = lastMatching(p.ring.snapshot(), n, filter)
+		if len(fromRing) == n || p.file == nil {
+			return fromRing, nil
+		}
+	}
+	if p.file == nil {
+		return nil, nil
+	}
+	return tailFile(p.file.lj.currentPath(), n, filter)
+}
+
+func lastMatching(lines [][]byte, n int, filter Filter) []Entry {
+	var out []Entry
+	for i := len(lines) - 1; i >= 0 && len(out) < n; i-- {
+		if e, ok := parseEntry(lines[i]); ok && (filter == nil || filter(e)) {
+			out = append(out, e)
+		}
+	}
+	reverse(out)
+	return out
+}
+
+// tailFile reads path backwards block by block until n matching events are found.
+func tailFile(path string, n int, filter Filter) ([]Entry, error) {
+	f, err := openShared(path, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Entry
+	var carry []byte // partial first line of the block read after this one
+	for off := fi.Size(); off > 0 && len(out) < n; {
+		size := int64(tailBlock)
+		if off < size {
+			size = off
+		}
+		off -= size
+		buf := make([]byte, size, size+int64(len(carry)))
+		if _, err := f.ReadAt(buf, off); err != nil && err != io.EOF {
+			return nil, err
+		}
+		buf = append(buf, carry...)
+		lines := bytes.Split(buf, []byte{'\n'})
+		if off > 0 {
+			carry, lines = lines[0], lines[1:]
+		}
+		for i := len(lines) - 1; i >= 0 && len(out) < n; i-- {
+			if e, ok := parseEntry(lines[i]); ok && (filter == nil || filter(e)) {
+				out = append(out, e)
+			}
+		}
+	}
+	reverse(out)
+	return out, nil
+}
+
+func parseEntry(line []byte) (Entry, bool) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 || line[0] != '{' {
+		return nil, false
+	}
+	var e Entry
+	if err := json.Unmarshal(line, &e); err != nil {
+		return nil, false
+	}
+	return e, true
+}
+
+func reverse(es []Entry) {
+	for i, j := 0, len(es)-1; i < j; i, j = i+1, j-1 {
+		es[i], es[j] = es[j], es[i]
+	}
+}
+
+// ringSink keeps the last N raw events in memory.
+type ringSink struct {
+	mu   sync.Mutex
+	buf  [][]byte
+	next int
+	full bool
+}
+
+func newRingSink(size int) *ringSink {
+	return &ringSink{buf: make([][]byte, size)}
+}
+
+func (r *ringSink) Write(p []byte) (int, error) {
+	line := append([]byte(nil), p...)
+	r.mu.Lock()
+	r.buf[r.next] = line
+	r.next = (r.next + 1) % len(r.buf)
+	if r.next == 0 {
+		r.full = true
+	}
+	r.mu.Unlock()
+	return len(p), nil
+}
+
+// snapshot returns the buffered events, oldest first.
+func (r *ringSink) snapshot() [][]byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([][]byte(nil), r.buf[:r.next]...)
+	}
+	out := make([][]byte, 0, len(r.buf))
+	out = append(out, r.buf[r.next:]...)
+	return append(out, r.buf[:r.next]...)
+}
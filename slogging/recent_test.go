@@ -0,0 +1,46 @@
+package slogging
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRecent(t *testing.T) {
+	initCapture(t, Options{RingBufferSize: 3})
+	pad := strings.Repeat("x", 100)
+	for i := range 2000 { // about 250KB, so the file is read in several blocks
+		From(context.Background()).Info().Int("n", i).Str("pad", pad).Msg("event")
+	}
+	every := func(k int) Filter {
+		return func(e Entry) bool { n, _ := e["n"].(float64); return int(n)%k == 0 }
+	}
+	for _, tc := range []struct {
+		name   string
+		n      int
+		filter Filter
+		want   []int
+	}{
+		{"from the ring", 2, nil, []int{1998, 1999}},
+		{"ring too short", 2, every(2), []int{1996, 1998}},
+		{"across blocks", 3, every(500), []int{500, 1000, 1500}},
+		{"fewer than asked", 5, every(900), []int{0, 900, 1800}},
+		{"none", 0, nil, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			es, err := Recent(tc.n, tc.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []int
+			for _, e := range es {
+				n, _ := e["n"].(float64)
+				got = append(got, int(n))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+				t.Errorf("Recent = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+// Package redact scrubs decoded log events before they leave the team that
+// owns them, e.g. in a bundle attached to a vendor support ticket.
+//
+// A Profile removes secret-looking fields outright, replaces identifying fields
+// with pseudonyms that stay consistent within one Redactor (so a reader can
+// still follow "user A" across events without learning who A is), and masks
+// secrets and PII embedded in free-text values.
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Replacement written in place of removed values.
+const Redacted = "[REDACTED]"
+
+// Pattern masks matches of Re inside string values with "[REDACTED:<Name>]".
+type Pattern struct {
+	Name string
+	Re   *regexp.Regexp
+}
+
+// Profile describes what to scrub.
+type Profile struct {
+	Name string
+	// SecretKeys: fields whose lowercased key contains any of these are
+	// replaced with Redacted, at any depth.
+	SecretKeys []string
+	// Pseudonymize: fields with exactly these keys get a stable pseudonym.
+	Pseudonymize []string
+	// Patterns are applied to every remaining string value.
+	Patterns []Pattern
+}
+
+var (
+	patBearer = Pattern{"token", regexp.MustCompile(`(?i)\bbearer\s+[a-z0-9._~+/=-]+`)}
+	patJWT    = Pattern{"jwt", regexp.MustCompile(`\beyJ[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]+`)}
+	patEmail  = Pattern{"email", regexp.MustCompile(`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`)}
+	patCard   = Pattern{"card", regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)}
+	patIPv4   = Pattern{"ip", regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)}
+)
+
+var secretKeys = []string{"password", "passwd", "secret", "token", "authorization", "cookie", "api_key", "apikey", "private_key"}
+
+// Profiles are the built-in profiles by name.
+var Profiles = map[string]*Profile{
+	// internal keeps identities but never secrets; for sharing across teams.
+	"internal": {
+		Name:       "internal",
+		SecretKeys: secretKeys,
+		Patterns:   []Pattern{patBearer, patJWT},
+	},
+	// external also hides who and where; for vendors and support tickets.
+	"external": {
+		Name:         "external",
+		SecretKeys:   append(append([]string(nil), secretKeys...), "headers", "body", "cookies"),
+		Pseudonymize: []string{"ip_address", "operator_name", "x-operator", "user", "user_id", "email", "phone", "tenant"},
+		Patterns:     []Pattern{patBearer, patJWT, patEmail, patCard, patIPv4},
+	},
+}
+
+// Names returns the built-in profile names, sorted.
+func Names() []string {
+	names := make([]string, 0, len(Profiles))
+	for n := range Profiles {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Redactor applies a Profile with a random per-Redactor pseudonym key, so
+// pseudonyms match within one bundle but cannot be linked across bundles or
+// reversed by hashing candidate values.
+type Redactor struct {
+	p      *Profile
+	key    []byte
+	kid    string // set by NewKeyed, written into each pseudonym
+	pseudo map[string]struct{}
+}
+
+// New returns a Redactor for p.
+func New(p *Profile) *Redactor {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return newRedactor(p, key, "")
+}
+
+// NewKeyed returns a Redactor for p whose pseudonyms come from the active key
+// of kr, so they stay stable across bundles. Each pseudonym names its key
+// ("anon:<key id>:<hash>"); Matches checks one against a known value with
+// whichever key of the ring produced it, so older bundles still verify after
+// the key is rotated.
+func NewKeyed(p *Profile, kr slogging.Keyring) (*Redactor, error) {
+	kid, key, err := kr.ActiveKey()
+	if err != nil {
+		return nil, err
+	}
+	return newRedactor(p, key, kid), nil
+}
+
+func newRedactor(p *Profile, key []byte, kid string) *Redactor {
+	r := &Redactor{p: p, key: key, kid: kid, pseudo: make(map[string]struct{}, len(p.Pseudonymize))}
+	for _, k := range p.Pseudonymize {
+		r.pseudo[k] = struct{}{}
+	}
+	return r
+}
+
+// Matches reports whether pseudonym, as written by a NewKeyed Redactor, stands
+// for v under the key it names in kr.
+func Matches(kr slogging.Keyring, v any, pseudonym string) bool {
+	rest, ok := strings.CutPrefix(pseudonym, "anon:")
+	if !ok {
+		return false
+	}
+	kid, _, ok := strings.Cut(rest, ":")
+	if !ok {
+		return false
+	}
+	key, ok := kr.Key(kid)
+	if !ok {
+		return false
+	}
+	return hmac.Equal([]byte(pseudonym), []byte(pseudonymWith(key, kid, v)))
+}
+
+// Event scrubs ev in place and returns it.
+func (r *Redactor) Event(ev map[string]any) map[string]any {
+	for k, v := range ev {
+		ev[k] = r.field(k, v)
+	}
+	return ev
+}
+
+func (r *Redactor) field(key string, v any) any {
+	if r.isSecret(key) {
+		return Redacted
+	}
+	if _, ok := r.pseudo[key]; ok && v != nil {
+		if s, ok := v.(string); !ok || s != "" {
+			return r.pseudonym(v)
+		}
+	}
+	return r.value(v)
+}
+
+func (r *Redactor) value(v any) any {
+	switch t := v.(type) {
+	case string:
+		return r.text(t)
+	case map[string]any:
+		return r.Event(t)
+	case []any:
+		for i := range t {
+			t[i] = r.value(t[i])
+		}
+	}
+	return v
+}
+
+func (r *Redactor) isSecret(key string) bool {
+	k := strings.ToLower(key)
+	for _, s := range r.p.SecretKeys {
+		if strings.Contains(k, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// text masks pattern matches inside s.
+func (r *Redactor) text(s string) string {
+	for _, p := range r.p.Patterns {
+		s = p.Re.ReplaceAllString(s, "[REDACTED:"+p.Name+"]")
+	}
+	return s
+}
+
+func (r *Redactor) pseudonym(v any) string {
+	return pseudonymWith(r.key, r.kid, v)
+}
+
+func pseudonymWith(key []byte, kid string, v any) string {
+	m := hmac.New(sha256.New, key)
+	fmt.Fprint(m, v)
+	if kid != "" {
+		return "anon:" + kid + ":" + hex.EncodeToString(m.Sum(nil)[:6])
+	}
+	return "anon:" + hex.EncodeToString(m.Sum(nil)[:6])
+}
@@ -0,0 +1,101 @@
+package redact
+
+import (
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// sample is an event with something for every rule of the built-in profiles.
+func sample() map[string]any {
+	return map[string]any{
+		"message":       "login by bob@example.com from 10.1.2.3 with Bearer abc.def",
+		"password":      "hunter2",
+		"Authorization": "Basic xyz",
+		"operator_name": "bob",
+		"user_id":       42.0,
+		"email":         "",
+		"card_note":     "paid with 4111 1111 1111 1111",
+		"headers":       map[string]any{"Accept": "json"},
+		"request":       map[string]any{"db_secret": "s", "tags": []any{"eyJhbGciOi.eyJzdWIi.c2ln", "ok"}},
+		"status":        200.0,
+	}
+}
+
+func TestInternalProfile(t *testing.T) {
+	got := New(Profiles["internal"]).Event(sample())
+	want := sample()
+	want["message"] = "login by bob@example.com from 10.1.2.3 with [REDACTED:token]"
+	want["password"] = Redacted
+	want["Authorization"] = Redacted
+	want["request"] = map[string]any{"db_secret": Redacted, "tags": []any{"[REDACTED:jwt]", "ok"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("internal:\n got %v\nwant %v", got, want)
+	}
+}
+
+func TestExternalProfile(t *testing.T) {
+	r := New(Profiles["external"])
+	got := r.Event(sample())
+	for k, want := range map[string]any{
+		"message":   "login by [REDACTED:email] from [REDACTED:ip] with [REDACTED:token]",
+		"password":  Redacted,
+		"headers":   Redacted,
+		"card_note": "paid with [REDACTED:card]",
+		"email":     "", // empty values are not pseudonymized
+		"status":    200.0,
+	} {
+		if !reflect.DeepEqual(got[k], want) {
+			t.Errorf("%s = %v, want %v", k, got[k], want)
+		}
+	}
+	op, _ := got["operator_name"].(string)
+	uid, _ := got["user_id"].(string)
+	if !strings.HasPrefix(op, "anon:") || !strings.HasPrefix(uid, "anon:") || op == uid {
+		t.Errorf("pseudonyms: operator_name %v, user_id %v", got["operator_name"], got["user_id"])
+	}
+	// Stable within a Redactor, unlinkable across two.
+	if again := r.Event(sample()); again["operator_name"] != op {
+		t.Errorf("pseudonym changed within one Redactor: %v, %v", op, again["operator_name"])
+	}
+	if other := New(Profiles["external"]).Event(sample()); other["operator_name"] == op {
+		t.Error("two Redactors produced the same pseudonym")
+	}
+}
+
+func TestNewKeyed(t *testing.T) {
+	kr := slogging.Keyring{Active: "k2", Keys: map[string][]byte{"k1": []byte("old key"), "k2": []byte("new key")}}
+	r, err := NewKeyed(Profiles["external"], kr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := r.Event(map[string]any{"user": "alice"})["user"].(string)
+	if !strings.HasPrefix(p, "anon:k2:") || !Matches(kr, "alice", p) || Matches(kr, "bob", p) {
+		t.Errorf("pseudonym %q does not verify", p)
+	}
+	again, _ := NewKeyed(Profiles["external"], kr)
+	if q := again.Event(map[string]any{"user": "alice"})["user"]; q != p {
+		t.Errorf("keyed pseudonyms differ across Redactors: %v, %v", p, q)
+	}
+	// After rotation the old pseudonym still verifies with the key it names.
+	kr.Active = "k3"
+	kr.Keys["k3"] = []byte("newest")
+	if !Matches(kr, "alice", p) {
+		t.Error("pseudonym stopped verifying after rotation")
+	}
+	for _, bad := range []string{"alice", "anon:abc", "anon:k9:abc"} {
+		if Matches(kr, "alice", bad) {
+			t.Errorf("Matches accepted %q", bad)
+		}
+	}
+	if _, err := NewKeyed(Profiles["external"], slogging.Keyring{}); err == nil {
+		t.Error("NewKeyed accepted an empty keyring")
+	}
+}
+
+func TestNames(t *testing.T) {
+	if got := Names(); !reflect.DeepEqual(got, []string{"external", "internal"}) {
+		t.Errorf("Names = %v", got)
+	}
+}
@@ -0,0 +1,123 @@
+package slogging
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/rs/zerolog"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HeaderDebugLog asks NewHTTPMiddleware to log one request at debug; see
+// MiddlewareOptions.DebugAllow and DebugKey.
+const HeaderDebugLog = "X-Debug-Log"
+
+const ctxReqLevelKey ctxKey = "request_level"
+
+// WithRequestLevel makes the request behind ctx log at level, whatever Level,
+// SetLevel and ComponentLevels say, so one request can be followed at debug
+// while the service stays at info. From(ctx), Component loggers' From, New
+// and the loggers IntoContext derives from the returned context all honor
+// it, as does the From of an Instance; its events are not sampled and carry
+// no sample_rate.
+func WithRequestLevel(ctx context.Context, level zerolog.Level) context.Context {
+	ctx = context.WithValue(ctx, ctxReqLevelKey, level)
+	p := current.Load()
+	if p == nil {
+		return ctx
+	}
+	base := ctxLogger(ctx)
+	if base == nil {
+		base = &p.logger
+	}
+	ll := p.requestLogger(ctx, base, level)
+	return ll.WithContext(ctx)
+}
+
+// requestLogger derives l's logger for a request at level. The events carry
+// ctx, so the sample_rate hooks can tell they were not sampled.
+func (p *pipeline) requestLogger(ctx context.Context, l *zerolog.Logger, level zerolog.Level) zerolog.Logger {
+	return l.With().Ctx(ctx).Logger().Sample(levelGate{p: p, request: level, override: true})
+}
+
+// unsampled reports whether e belongs to a WithRequestLevel request, whose
+// events bypass sampling.
+func unsampled(e *zerolog.Event) bool {
+	_, ok := requestLevel(e.GetCtx())
+	return ok
+}
+
+// requestLevel returns the level WithRequestLevel set on ctx.
+func requestLevel(ctx context.Context) (zerolog.Level, bool) {
+	lvl, ok := ctx.Value(ctxReqLevelKey).(zerolog.Level)
+	return lvl, ok
+}
+
+// DebugToken returns an X-Debug-Log value that NewHTTPMiddleware accepts,
+// from any client, until ttl has passed, when its DebugKey is key:
+//
+//	req.Header.Set(slogging.HeaderDebugLog, slogging.DebugToken(key, 15*time.Minute))
+func DebugToken(key []byte, ttl time.Duration) string {
+	exp := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	return exp + "." + debugSig(key, exp)
+}
+
+func debugSig(key []byte, exp string) string {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(exp))
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+// debugGate decides whether a request may turn on debug logging.
+type debugGate struct {
+	nets []netip.Prefix
+	key  []byte
+}
+
+func newDebugGate(allow []string, key []byte) debugGate {
+	g := debugGate{key: key}
+	for _, c := range allow {
+		pfx, err := parsePrefix(c)
+		if err != nil {
+			selfEvent(zerolog.WarnLevel, "invalid_option").Str("cidr", c).Err(err).
+				Msg("ignoring invalid MiddlewareOptions.DebugAllow entry")
+			continue
+		}
+		g.nets = append(g.nets, pfx)
+	}
+	return g
+}
+
+// allowed reports whether r asks for debug logging and may have it: "true"
+// from a DebugAllow network, or an unexpired DebugToken from anywhere.
+func (g debugGate) allowed(r *http.Request) bool {
+	v := strings.TrimSpace(r.Header.Get(HeaderDebugLog))
+	if v == "" || (len(g.nets) == 0 && len(g.key) == 0) {
+		return false
+	}
+	if exp, sig, ok := strings.Cut(v, "."); ok && len(g.key) > 0 {
+		unix, err := strconv.ParseInt(exp, 10, 64)
+		return err == nil && time.Now().Unix() < unix &&
+			hmac.Equal([]byte(sig), []byte(debugSig(g.key, exp)))
+	}
+	if ok, _ := strconv.ParseBool(v); !ok {
+		return false
+	}
+	// RemoteAddr, not X-Forwarded-For, which the client controls.
+	ap, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	addr := ap.Addr().Unmap()
+	for _, n := range g.nets {
+		if n.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package slogging
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWithRequestLevel(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc", Level: "info", SampleEvery: 1000})
+	ctx := WithRequestLevel(WithRequestID(context.Background(), "r1"), zerolog.DebugLevel)
+
+	From(ctx).Debug().Msg("request debug")
+	Component("repo").From(ctx).Debug().Msg("component debug")
+	for range 3 {
+		From(ctx).Info().Msg("request info") // not sampled
+	}
+	From(context.Background()).Debug().Msg("other debug")
+	for range 3 {
+		From(context.Background()).Info().Msg("other info") // sampled
+	}
+
+	for msg, want := range map[string]int{"request debug": 1, "component debug": 1, "request info": 3, "other debug": 0, "other info": 1} {
+		if got := len(c.withMessage(t, msg)); got != want {
+			t.Errorf("%q: %d events, want %d", msg, got, want)
+		}
+	}
+}
+
+func TestDebugGate(t *testing.T) {
+	key := []byte("k")
+	g := newDebugGate([]string{"10.0.0.0/8", "192.168.1.7"}, key)
+	for _, tc := range []struct {
+		remote, header string
+		want           bool
+	}{
+		{"10.1.2.3:5000", "true", true},
+		{"192.168.1.7:5000", "1", true},
+		{"192.168.1.8:5000", "true", false},
+		{"10.1.2.3:5000", "", false},
+		{"10.1.2.3:5000", "false", false},
+		{"8.8.8.8:5000", DebugToken(key, time.Minute), true},
+		{"8.8.8.8:5000", DebugToken(key, -time.Minute), false},
+		{"8.8.8.8:5000", DebugToken([]byte("other"), time.Minute), false},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = tc.remote
+		if tc.header != "" {
+			r.Header.Set(HeaderDebugLog, tc.header)
+		}
+		if got := g.allowed(r); got != tc.want {
+			t.Errorf("allowed(%s, %q) = %v, want %v", tc.remote, tc.header, got, tc.want)
+		}
+	}
+	if (debugGate{}).allowed(httptest.NewRequest(http.MethodGet, "/", nil)) {
+		t.Error("a gate without networks or key allowed a request")
+	}
+}
+
+func TestMiddlewareDebugHeader(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc", Level: "info"})
+	mw := NewHTTPMiddleware(MiddlewareOptions{DebugAllow: []string{"127.0.0.1"}})
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		From(r.Context()).Debug().Str("client", r.RemoteAddr).Msg("handler debug")
+	}))
+	for _, remote := range []string{"127.0.0.1:1234", "10.9.9.9:1234"} {
+		r := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		r.RemoteAddr = remote
+		r.Header.Set(HeaderDebugLog, "true")
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	evs := c.withMessage(t, "handler debug")
+	if len(evs) != 1 || evs[0]["client"] != "127.0.0.1:1234" {
+		t.Errorf("debug events = %v", evs)
+	}
+}
@@ -0,0 +1,224 @@
+package slogging
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// requestStats accumulates per-request annotations (CacheResult) that are
+// written once, on the request's access line, instead of as separate events.
+type requestStats struct {
+	inst   *Instance // whose SLOs and retry detector the calls count against
+	mu     sync.Mutex
+	caches map[string]*cacheStats
+	deps   []*depStats // in order of first call
+	calls  map[retryKey]*callStreak
+}
+
+// callStreak is the request's last call to one operation on a host.
+type callStreak struct {
+	retries int
+	failed  bool
+}
+
+type cacheStats struct {
+	hits, misses int
+	latency      time.Duration
+}
+
+type depStats struct {
+	host, service string
+	calls, errors int
+	latency       time.Duration
+	budgeted      bool // the host has an SLO
+	overBudget    int
+}
+
+// ctxReqStatsKey carries the *requestStats of the current request.
+const ctxReqStatsKey ctxKey = "request_stats"
+
+// WithRequestStats returns ctx carrying a fresh accumulator for the request's
+// access line. HTTPMiddleware and grpcmw call it; a custom transport's
+// middleware does the same and adds AppendRequestStats to its own line.
+func WithRequestStats(ctx context.Context) context.Context {
+	return std.WithRequestStats(ctx)
+}
+
+// WithRequestStats is the package-level WithRequestStats for a request logged
+// by the instance: RecordDependency and TrackRetries check its calls against
+// the instance's Options.HostSLOs and RetryStormThreshold.
+func (i *Instance) WithRequestStats(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ctxReqStatsKey, &requestStats{inst: i})
+}
+
+// requestStatsOf returns the stats WithRequestStats put on ctx, if any, and
+// the instance they belong to: Default outside a request.
+func requestStatsOf(ctx context.Context) (*requestStats, *Instance) {
+	if ctx == nil {
+		return nil, std
+	}
+	rs, _ := ctx.Value(ctxReqStatsKey).(*requestStats)
+	if rs == nil {
+		return nil, std
+	}
+	return rs, rs.inst
+}
+
+// AppendRequestStats adds what was accumulated in ctx since WithRequestStats
+// to e: a "cache" object with hits, misses and latency_ms per cache name, and
+// a "dependencies" array with host, service, calls, errors and latency_ms per
+// downstream called.
+func AppendRequestStats(ctx context.Context, e *zerolog.Event) *zerolog.Event {
+	rs, _ := ctx.Value(ctxReqStatsKey).(*requestStats)
+	if rs == nil || e == nil {
+		return e
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if len(rs.deps) > 0 {
+		a := zerolog.Arr()
+		for _, d := range rs.deps {
+			o := zerolog.Dict().Str("host", d.host)
+			if d.service != "" {
+				o.Str("service", d.service)
+			}
+			o.Int("calls", d.calls).
+				Int("errors", d.errors).
+				Float64("latency_ms", float64(d.latency.Microseconds())/1000)
+			if d.budgeted {
+				o.Int(FieldBudgetExceeded, d.overBudget)
+			}
+			a.Dict(o)
+		}
+		e.Array(FieldDependencies, a)
+	}
+	if len(rs.caches) == 0 {
+		return e
+	}
+	names := make([]string, 0, len(rs.caches))
+	for n := range rs.caches {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	d := zerolog.Dict()
+	for _, n := range names {
+		c := rs.caches[n]
+		d.Dict(n, zerolog.Dict().
+			Int("hits", c.hits).
+			Int("misses", c.misses).
+			Float64("latency_ms", float64(c.latency.Microseconds())/1000))
+	}
+	return e.Dict(FieldCache, d)
+}
+
+// CacheResult records one cache lookup. Within a request it is aggregated into
+// the access line's "cache" object, so hit rates per endpoint can be read
+// from the logs:
+//
+//	u, ok := userCache.Get(id)
+//	slogging.CacheResult(ctx, "user_cache", ok, time.Since(start))
+//
+// Outside a request (no WithRequestStats in ctx) it logs a debug event.
+func CacheResult(ctx context.Context, name string, hit bool, latency time.Duration) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	rs, _ := ctx.Value(ctxReqStatsKey).(*requestStats)
+	if rs == nil {
+		From(ctx).Debug().
+			Str(FieldCacheName, name).
+			Bool(FieldCacheHit, hit).
+			Float64(FieldDurationMs, float64(latency.Microseconds())/1000).
+			Msg("cache lookup")
+		return
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.caches == nil {
+		rs.caches = make(map[string]*cacheStats)
+	}
+	c := rs.caches[name]
+	if c == nil {
+		c = &cacheStats{}
+		rs.caches[name] = c
+	}
+	if hit {
+		c.hits++
+	} else {
+		c.misses++
+	}
+	c.latency += latency
+}
+
+// RecordDependency records one call to a downstream host (and, when known, the
+// service on it) for the "dependencies" array of the request's access line,
+// from which a service map can be built. Client instrumentation such as the
+// grpcmw client interceptors calls it. Calls to hosts with an SLO
+// (Options.HostSLOs) are checked against it, inside a request or not; the
+// entry then carries budget_exceeded, the calls over budget.
+func RecordDependency(ctx context.Context, host, service string, latency time.Duration, failed bool) {
+	var budgeted, exceeded bool
+	rs, inst := requestStatsOf(ctx)
+	if p := inst.current.Load(); p != nil && p.slos != nil {
+		budgeted, exceeded = p.slos.observe(host, latency, failed)
+	}
+	if rs == nil {
+		return
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	var d *depStats
+	for _, x := range rs.deps {
+		if x.host == host && x.service == service {
+			d = x
+			break
+		}
+	}
+	if d == nil {
+		if len(rs.deps) >= maxDependencies {
+			return
+		}
+		d = &depStats{host: host, service: service}
+		rs.deps = append(rs.deps, d)
+	}
+	d.calls++
+	if failed {
+		d.errors++
+	}
+	d.latency += latency
+	d.budgeted = budgeted
+	if exceeded {
+		d.overBudget++
+	}
+}
+
+// retry records one call for TrackRetries and returns the retries so far: the
+// calls to k in a row, this one included, that followed a failed one.
+func (rs *requestStats) retry(k retryKey, failed bool) int {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	c := rs.calls[k]
+	if c == nil {
+		if len(rs.calls) >= maxDependencies {
+			return 0
+		}
+		if rs.calls == nil {
+			rs.calls = make(map[retryKey]*callStreak)
+		}
+		c = &callStreak{}
+		rs.calls[k] = c
+	} else if c.failed {
+		c.retries++
+	} else {
+		c.retries = 0
+	}
+	c.failed = failed
+	return c.retries
+}
+
+// maxDependencies bounds the dependencies array of one access line, and the
+// operations whose retries one request tracks.
+const maxDependencies = 32
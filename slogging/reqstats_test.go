@@ -0,0 +1,93 @@
+package slogging
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCacheResult(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc", Level: "debug"})
+	h := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		CacheResult(ctx, "user_cache", true, 2*time.Millisecond)
+		CacheResult(ctx, "user_cache", false, 3*time.Millisecond)
+		CacheResult(ctx, "user_cache", true, time.Millisecond)
+		CacheResult(ctx, "price_cache", false, 500*time.Microsecond)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/7", nil))
+
+	evs := c.withMessage(t, "request completed")
+	if len(evs) != 1 {
+		t.Fatalf("got %d access lines, want 1", len(evs))
+	}
+	want := map[string]any{
+		"price_cache": map[string]any{"hits": 0.0, "misses": 1.0, "latency_ms": 0.5},
+		"user_cache":  map[string]any{"hits": 2.0, "misses": 1.0, "latency_ms": 6.0},
+	}
+	if got := evs[0][FieldCache]; !reflect.DeepEqual(got, want) {
+		t.Errorf("%s = %v, want %v", FieldCache, got, want)
+	}
+	// Within a request the lookups only reach the access line.
+	if n := len(c.withMessage(t, "cache lookup")); n != 0 {
+		t.Errorf("got %d cache lookup events inside the request, want 0", n)
+	}
+
+	// Outside one, each lookup is its own debug event.
+	CacheResult(context.Background(), "user_cache", false, 4*time.Millisecond)
+	evs = c.withMessage(t, "cache lookup")
+	if len(evs) != 1 {
+		t.Fatalf("got %d cache lookup events, want 1", len(evs))
+	}
+	if e := evs[0]; e["level"] != "debug" || e[FieldCacheName] != "user_cache" || e[FieldCacheHit] != false || e[FieldDurationMs] != 4.0 {
+		t.Errorf("cache lookup = %v", e)
+	}
+
+	// A request without lookups gets no cache object.
+	h = HTTPMiddleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	if evs := c.withMessage(t, "request completed"); len(evs) != 2 || evs[1][FieldCache] != nil {
+		t.Errorf("access lines = %v, want the second without %s", evs, FieldCache)
+	}
+}
+
+func TestRecordDependency(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc"})
+	h := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		RecordDependency(ctx, "users:8080", "users.v1.Users", 2*time.Millisecond, false)
+		RecordDependency(ctx, "db:5432", "", 4*time.Millisecond, false)
+		RecordDependency(ctx, "users:8080", "users.v1.Users", 3*time.Millisecond, true)
+		for i := range maxDependencies {
+			RecordDependency(ctx, fmt.Sprintf("shard-%d", i), "", 0, false)
+		}
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+	evs := c.withMessage(t, "request completed")
+	if len(evs) != 1 {
+		t.Fatalf("got %d access lines, want 1", len(evs))
+	}
+	deps, _ := evs[0][FieldDependencies].([]any)
+	if len(deps) != maxDependencies {
+		t.Fatalf("%s has %d entries, want the cap of %d", FieldDependencies, len(deps), maxDependencies)
+	}
+	// In order of first call, aggregated per host and service.
+	want := []any{
+		map[string]any{"host": "users:8080", "service": "users.v1.Users", "calls": 2.0, "errors": 1.0, "latency_ms": 5.0},
+		map[string]any{"host": "db:5432", "calls": 1.0, "errors": 0.0, "latency_ms": 4.0},
+	}
+	if !reflect.DeepEqual(deps[:2], want) {
+		t.Errorf("%s starts %v, want %v", FieldDependencies, deps[:2], want)
+	}
+
+	// Outside a request there is nothing to attach it to.
+	RecordDependency(context.Background(), "users:8080", "", time.Millisecond, false)
+	if n := len(c.events(t)); n != 1 {
+		t.Errorf("got %d events, want only the access line", n)
+	}
+}
@@ -0,0 +1,133 @@
+package slogging
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRetryStormWindow = 10 * time.Second
+	maxRetryKeys            = 4096 // tracked keys; beyond this new keys are ignored until a sweep
+	maxRetryRequestIDs      = 5    // request IDs sampled per key for the warning
+)
+
+// retryDetector counts retries per host and operation: calls made after a
+// failed one within the current window, whichever requests made them, since a
+// storm is many requests retrying as much as one request looping. When a key's
+// retries reach the threshold it emits one "retry_storm" warning per window,
+// the signature of a retry policy amplifying an outage.
+type retryDetector struct {
+	log       *zerolog.Logger
+	threshold int
+	window    time.Duration
+
+	mu    sync.Mutex
+	keys  map[retryKey]*retryCount
+	swept time.Time
+}
+
+type retryKey struct {
+	host, operation string
+}
+
+type retryCount struct {
+	start           time.Time
+	calls, failures int
+	retries         int
+	failedLast      bool
+	reported        bool
+	requestIDs      []string // distinct, at most maxRetryRequestIDs
+}
+
+func newRetryDetector(log *zerolog.Logger, threshold int, window time.Duration) *retryDetector {
+	if window <= 0 {
+		window = defaultRetryStormWindow
+	}
+	return &retryDetector{log: log, threshold: threshold, window: window, keys: make(map[retryKey]*retryCount)}
+}
+
+// TrackRetries records one client call to operation on host for retry-storm
+// detection (Options.RetryStormThreshold). The warning lists a sample of the
+// request IDs in ctx as request_ids, to tell one request's retry loop from
+// many requests retrying a failing host. It returns how many times in a row
+// the request behind ctx (WithRequestStats) has called operation on host
+// again after a failure, 0 outside a request. NewRoundTripper and the grpcmw
+// client interceptors call it.
+func TrackRetries(ctx context.Context, host, operation string, failed bool) int {
+	k := retryKey{host: host, operation: operation}
+	var reqID string
+	retries := 0
+	rs, inst := requestStatsOf(ctx)
+	if ctx != nil {
+		reqID = GetRequestID(ctx)
+	}
+	if rs != nil {
+		retries = rs.retry(k, failed)
+	}
+	if p := inst.current.Load(); p != nil && p.retries != nil {
+		p.retries.track(k, reqID, failed)
+	}
+	return retries
+}
+
+func (d *retryDetector) track(k retryKey, reqID string, failed bool) {
+	now := time.Now()
+	d.mu.Lock()
+	if now.Sub(d.swept) >= d.window {
+		d.sweep(now)
+	}
+	c := d.keys[k]
+	if c == nil || now.Sub(c.start) >= d.window {
+		if c == nil && len(d.keys) >= maxRetryKeys {
+			d.mu.Unlock()
+			return
+		}
+		c = &retryCount{start: now}
+		d.keys[k] = c
+	}
+	c.calls++
+	if c.failedLast {
+		c.retries++
+	}
+	if failed {
+		c.failures++
+	}
+	c.failedLast = failed
+	if reqID != "" && len(c.requestIDs) < maxRetryRequestIDs && !slices.Contains(c.requestIDs, reqID) {
+		c.requestIDs = append(c.requestIDs, reqID)
+	}
+	fire := c.retries >= d.threshold && !c.reported
+	if fire {
+		c.reported = true
+	}
+	snap := *c
+	snap.requestIDs = slices.Clone(c.requestIDs)
+	d.mu.Unlock()
+
+	if fire {
+		e := selfEventOn(d.log, zerolog.WarnLevel, "retry_storm")
+		if len(snap.requestIDs) > 0 {
+			e.Strs("request_ids", snap.requestIDs)
+		}
+		e.Str("host", k.host).
+			Str("operation", k.operation).
+			Int("retries", snap.retries).
+			Int("calls", snap.calls).
+			Int("failures", snap.failures).
+			Int64("window_ms", d.window.Milliseconds()).
+			Msg("client retries exceed RetryStormThreshold; check the retry policy")
+	}
+}
+
+// sweep drops keys whose window has ended. Callers hold d.mu.
+func (d *retryDetector) sweep(now time.Time) {
+	for k, c := range d.keys {
+		if now.Sub(c.start) >= d.window {
+			delete(d.keys, k)
+		}
+	}
+	d.swept = now
+}
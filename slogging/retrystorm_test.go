@@ -0,0 +1,54 @@
+package slogging
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestRetryStormAcrossRequests checks that retries are counted per host and
+// operation whichever request makes them, and that the warning samples the
+// request IDs involved.
+func TestRetryStormAcrossRequests(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc", RetryStormThreshold: 4, RetryStormWindow: time.Minute})
+	for i := range 10 {
+		ctx := WithRequestID(context.Background(), fmt.Sprintf("r%d", i))
+		TrackRetries(ctx, "payments:443", "/billing.Payments/Charge", true)
+	}
+	TrackRetries(context.Background(), "ledger:443", "/ledger.Ledger/Post", false)
+
+	var storms []map[string]any
+	for _, ev := range c.events(t) {
+		if ev[FieldSloggingEvent] == "retry_storm" {
+			storms = append(storms, ev)
+		}
+	}
+	if len(storms) != 1 {
+		t.Fatalf("got %d retry_storm events, want 1", len(storms))
+	}
+	ev := storms[0]
+	if ev["host"] != "payments:443" || ev["retries"] != float64(4) || ev["calls"] != float64(5) {
+		t.Errorf("retry_storm = %v", ev)
+	}
+	if _, ok := ev[FieldRequestID]; ok {
+		t.Errorf("retry_storm carries a single request_id: %v", ev)
+	}
+	ids, _ := ev["request_ids"].([]any)
+	if len(ids) != 5 || ids[0] != "r0" || ids[4] != "r4" {
+		t.Errorf("request_ids = %v, want r0..r4", ev["request_ids"])
+	}
+}
+
+func TestRetryStormIgnoresSuccesses(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc", RetryStormThreshold: 2})
+	ctx := WithRequestID(context.Background(), "r1")
+	for range 10 {
+		TrackRetries(ctx, "payments:443", "/billing.Payments/Charge", false)
+	}
+	for _, ev := range c.events(t) {
+		if ev[FieldSloggingEvent] == "retry_storm" {
+			t.Fatalf("successful calls reported as a storm: %v", ev)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package slogging
+
+import (
+	"bytes"
+	"context"
+	"github.com/rs/zerolog"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultCaptureBytes = 64 << 10
+
+// RoundTripperOptions configures NewRoundTripper.
+type RoundTripperOptions struct {
+	// RedactQuery names query parameters whose values are logged as
+	// "[REDACTED]", on top of DenyParams. Userinfo is always dropped.
+	RedactQuery []string
+	// Payloads logs the request and response bodies of a sample of calls
+	// per host, redacted as PayloadSampling does. Bodies are copied as
+	// they stream, up to MaxCaptureBytes each (default 64KiB); a longer
+	// one is logged by size only.
+	Payloads        PayloadSampling
+	MaxCaptureBytes int
+}
+
+// NewRoundTripper wraps base (http.DefaultTransport when nil) so that every
+// outbound call is logged through From(req.Context()) as "http call
+// completed", with method, url (query redacted), host, status and
+// duration_ms, at info, warn for a 4xx and error for a 5xx or a transport
+// error. A call repeating one that failed, in the same request, carries
+// "retry", the number of retries so far, as TrackRetries counts them. Each call is recorded with
+// RecordDependency and TrackRetries, so it shows in the access line's
+// dependencies and in retry-storm detection:
+//
+//	client := &http.Client{Transport: slogging.NewRoundTripper(nil, slogging.RoundTripperOptions{
+//		RedactQuery: []string{"sig"},
+//	})}
+func NewRoundTripper(base http.RoundTripper, opt RoundTripperOptions) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if opt.MaxCaptureBytes <= 0 {
+		opt.MaxCaptureBytes = defaultCaptureBytes
+	}
+	return &roundTripper{
+		base:   base,
+		opt:    opt,
+		query:  newParamFilter(nil, opt.RedactQuery),
+		sample: opt.Payloads.Enabled(),
+	}
+}
+
+type roundTripper struct {
+	base   http.RoundTripper
+	opt    RoundTripperOptions
+	query  paramFilter
+	sample bool
+}
+
+func (t *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	host := req.URL.Host
+	operation := req.Method + " " + req.URL.Path
+
+	var reqBody, respBody *capture
+	if t.sample && t.opt.Payloads.Sample(host) {
+		reqBody = &capture{limit: t.opt.MaxCaptureBytes}
+		if req.Body != nil && req.Body != http.NoBody {
+			req = req.Clone(ctx)
+			req.Body = &captureBody{ReadCloser: req.Body, c: reqBody}
+		}
+		respBody = &capture{limit: t.opt.MaxCaptureBytes}
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	latency := time.Since(start)
+
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+	}
+	failed := err != nil || status >= 500
+	RecordDependency(ctx, host, "", latency, failed)
+	retries := TrackRetries(ctx, host, operation, failed)
+
+	level := zerolog.InfoLevel
+	switch {
+	case failed:
+		level = zerolog.ErrorLevel
+	case status >= 400:
+		level = zerolog.WarnLevel
+	}
+	if e := From(ctx).WithLevel(level); e != nil {
+		e.Str(FieldMethod, req.Method).
+			Str(FieldURL, t.redactURL(req)).
+			Str("host", host).
+			Float64(FieldDurationMs, float64(latency.Microseconds())/1000)
+		if status != 0 {
+			e.Int(FieldStatus, status)
+		}
+		if retries > 0 {
+			e.Int(FieldRetry, retries)
+		}
+		e.Err(err).Msg("http call completed")
+	}
+
+	if respBody != nil {
+		if resp == nil || resp.Body == nil {
+			t.logPayloads(ctx, host, operation, reqBody, nil)
+		} else {
+			resp.Body = &captureBody{ReadCloser: resp.Body, c: respBody, done: func() {
+				t.logPayloads(ctx, host, operation, reqBody, respBody)
+			}}
+		}
+	}
+	return resp, err
+}
+
+// redactURL renders req's URL without userinfo and with the values of
+// denied query parameters replaced.
+func (t *roundTripper) redactURL(req *http.Request) string {
+	u := *req.URL
+	u.User = nil
+	if u.RawQuery == "" {
+		return u.String()
+	}
+	// Edited in place, keeping the order and encoding of the rest.
+	params := strings.Split(u.RawQuery, "&")
+	for i, kv := range params {
+		k, _, _ := strings.Cut(kv, "=")
+		name, err := url.QueryUnescape(k)
+		if err != nil {
+			name = k
+		}
+		if t.query.denied(strings.ToLower(name)) {
+			params[i] = k + "=[REDACTED]"
+		}
+	}
+	u.RawQuery = strings.Join(params, "&")
+	return u.String()
+}
+
+// logPayloads writes a "payload sample" event, like PayloadSampling.LogPayload,
+// from the captured bodies.
+func (t *roundTripper) logPayloads(ctx context.Context, host, operation string, req, resp *capture) {
+	e := From(ctx).Info()
+	if e == nil {
+		return
+	}
+	e.Str("host", host).Str("operation", operation)
+	for _, c := range []struct {
+		key string
+		c   *capture
+	}{{FieldRequestPayload, req}, {FieldResponsePayload, resp}} {
+		if c.c == nil {
+			continue
+		}
+		buf, n, over := c.c.snapshot()
+		switch {
+		case n == 0:
+		case over:
+			e.Int(c.key+"_bytes", n)
+		default:
+			t.opt.Payloads.addPayload(e, c.key, buf)
+		}
+	}
+	e.Msg("payload sample")
+}
+
+// capture keeps the first limit bytes of a body, and its size. The request
+// body is written by the transport, which may still be sending it when the
+// response is logged, hence the lock.
+type capture struct {
+	limit int
+
+	mu   sync.Mutex
+	buf  []byte
+	n    int
+	over bool
+}
+
+func (c *capture) write(p []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.n += len(p)
+	if c.over {
+		return
+	}
+	if len(c.buf)+len(p) > c.limit {
+		c.over, c.buf = true, nil
+		return
+	}
+	c.buf = append(c.buf, p...)
+}
+
+// snapshot returns a copy of what was captured so far.
+func (c *capture) snapshot() (buf []byte, n int, over bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return bytes.Clone(c.buf), c.n, c.over
+}
+
+// captureBody copies what is read through it into c, and calls done once,
+// at EOF or Close.
+type captureBody struct {
+	io.ReadCloser
+	c    *capture
+	done func()
+	once sync.Once
+}
+
+func (b *captureBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.c.write(p[:n])
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *captureBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.finish()
+	return err
+}
+
+func (b *captureBody) finish() {
+	if b.done != nil {
+		b.once.Do(b.done)
+	}
+}
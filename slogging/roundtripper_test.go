@@ -0,0 +1,101 @@
+package slogging
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRoundTripperRetries(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc"})
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: NewRoundTripper(nil, RoundTripperOptions{RedactQuery: []string{"sig"}})}
+
+	ctx := WithRequestStats(WithRequestID(context.Background(), "r1"))
+	for range 3 {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/orders?sig=secret&page=2", nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	// Another request starts counting afresh.
+	req, _ := http.NewRequestWithContext(WithRequestStats(context.Background()), http.MethodGet, srv.URL+"/orders", nil)
+	if resp, err := client.Do(req); err == nil {
+		resp.Body.Close()
+	}
+
+	evs := c.withMessage(t, "http call completed")
+	if len(evs) != 4 {
+		t.Fatalf("got %d events, want 4", len(evs))
+	}
+	for i, want := range []any{nil, float64(1), float64(2), nil} {
+		if got := evs[i][FieldRetry]; got != want {
+			t.Errorf("call %d: retry = %v, want %v", i, got, want)
+		}
+	}
+	if u, _ := evs[0][FieldURL].(string); !strings.Contains(u, "sig=[REDACTED]") || !strings.Contains(u, "page=2") {
+		t.Errorf("url = %q", u)
+	}
+	if evs[0]["level"] != "error" || evs[2]["level"] != "info" {
+		t.Errorf("levels = %v, %v", evs[0]["level"], evs[2]["level"])
+	}
+}
+
+// roundTripFunc answers with a response while it is still reading the request
+// body, as a transport streaming a large upload may.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestRoundTripperPayloads(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc"})
+	var wg sync.WaitGroup
+	base := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			io.Copy(io.Discard, r.Body)
+			r.Body.Close()
+		}()
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"id":7,"token":"t0p"}`)),
+			Request:    r,
+		}, nil
+	})
+	client := &http.Client{Transport: NewRoundTripper(base, RoundTripperOptions{Payloads: PayloadSampling{Rate: 1}})}
+
+	for range 20 {
+		body := strings.NewReader(`{"name":"x","password":"hunter2"}`)
+		req, _ := http.NewRequest(http.MethodPost, "http://users.internal/users", body)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	wg.Wait()
+
+	evs := c.withMessage(t, "payload sample")
+	if len(evs) != 20 {
+		t.Fatalf("got %d payload samples, want 20", len(evs))
+	}
+	resp, _ := evs[0][FieldResponsePayload].(map[string]any)
+	if resp["id"] != float64(7) || resp["token"] == "t0p" {
+		t.Errorf("response_payload = %v", evs[0][FieldResponsePayload])
+	}
+}
@@ -0,0 +1,237 @@
+package slogging
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/rs/zerolog"
+	"io"
+	"strings"
+	"sync"
+)
+
+// maxPartitions caps how many files a single {value} route may open; events
+// with further values go to the default sinks.
+const maxPartitions = 64
+
+// Route sends events whose top-level Field matches to a dedicated destination.
+//
+//	{Field: "component", FilePath: "logs/{value}.log"}                 // one file per component
+//	{Field: "event", FilePath: "logs/business.log", Exclusive: true}   // business events only there
+//	{MinLevel: "warn", FilePath: "logs/errors.log", Exclusive: true}    // warn+ to their own file
+//	{MinLevel: "debug", FilePath: "logs/{value}.log", Exclusive: true}  // one file per level
+//
+// Routes are evaluated in order and the first match wins. Routing inspects the
+// JSON event, so it has no effect with Pretty output.
+type Route struct {
+	Field     string    // top-level field to inspect; may be empty with MinLevel
+	Equals    string    // match only this value; empty matches any value of Field
+	MinLevel  string    // match only events at or above this level; without Field, {value} is the level
+	FilePath  string    // rotating file (Options rotation settings); "{value}" partitions by value
+	Writer    io.Writer // used instead of FilePath when set
+	Exclusive bool      // matched events skip the default sinks
+}
+
+type router struct {
+	p      *pipeline
+	def    io.Writer
+	opt    Options
+	routes []*routeSink
+}
+
+type routeSink struct {
+	Route
+	min     zerolog.Level // parsed MinLevel; NoLevel when unset
+	p       *pipeline
+	mu      sync.Mutex
+	fixed   io.Writer            // Writer or a FilePath without {value}
+	parts   map[string]io.Writer // {value} partitions, created on first use
+	closers []io.Closer
+}
+
+func newRouter(p *pipeline, def io.Writer, opt Options) *router {
+	r := &router{p: p, def: def, opt: opt}
+	for _, rt := range opt.Routes {
+		if (rt.Field == "" && rt.MinLevel == "") || (rt.Writer == nil && rt.FilePath == "") {
+			continue
+		}
+		rs := &routeSink{Route: rt, p: p, min: zerolog.NoLevel}
+		if rt.MinLevel != "" {
+			lvl, err := zerolog.ParseLevel(rt.MinLevel)
+			if err != nil || lvl == zerolog.NoLevel {
+				p.onInstall = append(p.onInstall, func() {
+					selfEventOn(&p.self, zerolog.WarnLevel, "invalid_option").
+						Str("min_level", rt.MinLevel).
+						Msg("ignoring Route with an unknown MinLevel")
+				})
+				continue
+			}
+			rs.min = lvl
+		}
+		switch {
+		case rt.Writer != nil:
+			rs.fixed = rs.own("route:"+firstNonEmpty(rt.Field, rt.MinLevel), rt.Writer, opt)
+		case !strings.Contains(rt.FilePath, "{value}"):
+			rs.fixed = rs.file(rt.FilePath, opt)
+		default:
+			rs.parts = make(map[string]io.Writer)
+		}
+		r.routes = append(r.routes, rs)
+	}
+	return r
+}
+
+func (r *router) Write(p []byte) (int, error) {
+	return r.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (r *router) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	for _, rs := range r.routes {
+		v, ok := rs.match(level, p)
+		if !ok {
+			continue
+		}
+		dst := rs.sink(v, r.opt)
+		if dst == nil {
+			break
+		}
+		if _, err := writeLevel(dst, level, p); err != nil {
+			r.p.stats.writeErrors.Add(1)
+		}
+		if rs.Exclusive {
+			return len(p), nil
+		}
+		break
+	}
+	return writeLevel(r.def, level, p)
+}
+
+// match reports whether an event at level matches the route, and the value
+// that names its partition.
+func (rs *routeSink) match(level zerolog.Level, p []byte) (string, bool) {
+	if rs.min != zerolog.NoLevel && (level < rs.min || level == zerolog.NoLevel || level == zerolog.Disabled) {
+		return "", false
+	}
+	if rs.Field == "" {
+		return level.String(), true
+	}
+	v, ok := jsonField(p, rs.Field)
+	if !ok || (rs.Equals != "" && v != rs.Equals) {
+		return "", false
+	}
+	return v, true
+}
+
+// sink returns the destination for value v, opening a partition if needed.
+// It returns nil when the partition cap is reached.
+func (rs *routeSink) sink(v string, opt Options) io.Writer {
+	if rs.fixed != nil {
+		return rs.fixed
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if w, ok := rs.parts[v]; ok {
+		return w
+	}
+	if len(rs.parts) >= maxPartitions {
+		return nil
+	}
+	w := rs.file(strings.ReplaceAll(rs.FilePath, "{value}", safeFileName(v)), opt)
+	rs.parts[v] = w
+	return w
+}
+
+// file opens a rotating file for the route; callers hold rs.mu or run during build.
+func (rs *routeSink) file(path string, opt Options) io.Writer {
+	fs := newRotatingFile(path, opt, nil, rs.p.stats)
+	rs.closers = append(rs.closers, fs)
+	fs.start()
+	return rs.own("route:"+path, fs, opt)
+}
+
+func (rs *routeSink) own(name string, w io.Writer, opt Options) io.Writer {
+	w, closers := rs.p.wrapSink(name, w, opt)
+	rs.closers = append(rs.closers, closers...)
+	return w
+}
+
+// Close closes every file and wrapper the routes opened, newest first.
+func (r *router) Close() error {
+	var first error
+	for _, rs := range r.routes {
+		rs.mu.Lock()
+		for i := len(rs.closers) - 1; i >= 0; i-- {
+			if err := rs.closers[i].Close(); err != nil && first == nil {
+				first = err
+			}
+		}
+		rs.closers = nil
+		rs.mu.Unlock()
+	}
+	return first
+}
+
+// jsonField returns the top-level field key of a JSON object as a string.
+// Strings are returned unquoted, numbers and booleans as their literal text;
+// objects, arrays, null and missing keys report false.
+func jsonField(p []byte, key string) (string, bool) {
+	// Cheap reject before tokenizing.
+	if !bytes.Contains(p, []byte(`"`+key+`"`)) {
+		return "", false
+	}
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return "", false
+	}
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return "", false
+		}
+		k, _ := t.(string)
+		if k != key {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return "", false
+			}
+			continue
+		}
+		t, err = dec.Token()
+		if err != nil {
+			return "", false
+		}
+		switch v := t.(type) {
+		case string:
+			return v, true
+		case json.Number:
+			return v.String(), true
+		case bool:
+			if v {
+				return "true", true
+			}
+			return "false", true
+		}
+		return "", false
+	}
+	return "", false
+}
+
+// safeFileName maps an arbitrary field value to a single path element.
+func safeFileName(v string) string {
+	if len(v) > 64 {
+		v = v[:64]
+	}
+	b := []byte(v)
+	for i, c := range b {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_':
+		case c == '.' && i > 0:
+		default:
+			b[i] = '_'
+		}
+	}
+	if len(b) == 0 {
+		return "_"
+	}
+	return string(b)
+}
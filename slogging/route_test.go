@@ -0,0 +1,44 @@
+package slogging
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRoutes(t *testing.T) {
+	dir := t.TempDir()
+	business := &eventCapture{}
+	c := initCapture(t, Options{Routes: []Route{
+		{Field: FieldEvent, Writer: business, Exclusive: true},
+		{Field: FieldComponent, FilePath: filepath.Join(dir, "{value}.log")},
+	}})
+	ctx := context.Background()
+	From(ctx).Info().Str(FieldEvent, "order_placed").Msg("business")
+	Component("db").Info().Msg("db query")
+	Component("cache").Info().Msg("cache miss")
+	From(ctx).Info().Msg("plain")
+
+	if evs := business.withMessage(t, "business"); len(evs) != 1 {
+		t.Errorf("business stream got %v, want the one event", business.events(t))
+	}
+	if evs := c.withMessage(t, "business"); len(evs) != 0 {
+		t.Errorf("exclusive route's event also reached the default sinks")
+	}
+	for _, msg := range []string{"db query", "cache miss", "plain"} {
+		if len(c.withMessage(t, msg)) != 1 {
+			t.Errorf("default sinks missed %q", msg)
+		}
+	}
+	for name, want := range map[string]string{"db": "db query", "cache": "cache miss"} {
+		b, err := os.ReadFile(filepath.Join(dir, name+".log"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Count(string(b), "\n"); got != 1 || !strings.Contains(string(b), want) {
+			t.Errorf("%s.log = %q, want only %q", name, b, want)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package slogging
+
+import (
+	"github.com/rs/zerolog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultSampleReportInterval = time.Minute
+
+// countingSampler wraps a zerolog sampler and counts the events it rejects, so
+// the suppressed volume can be reported instead of silently disappearing.
+type countingSampler struct {
+	name       string
+	inner      zerolog.Sampler
+	suppressed atomic.Uint64
+}
+
+func newCountingSampler(name string, inner zerolog.Sampler) *countingSampler {
+	return &countingSampler{name: name, inner: inner}
+}
+
+func (s *countingSampler) Sample(lvl zerolog.Level) bool {
+	if s.inner.Sample(lvl) {
+		return true
+	}
+	s.suppressed.Add(1)
+	return false
+}
+
+// sampleRateHook stamps sample_rate on events of the sampled logger. A hook
+// rather than a context field, so that Exposure, which opts out of sampling
+// with a NoLevel event, is not mistaken for a 1-in-N sample downstream.
+type sampleRateHook int
+
+// Run implements zerolog.Hook.
+func (n sampleRateHook) Run(e *zerolog.Event, level zerolog.Level, _ string) {
+	if level != zerolog.NoLevel && !unsampled(e) {
+		e.Int("sample_rate", int(n))
+	}
+}
+
+// samplingReporter periodically emits one "sampling_suppressed" event per
+// sampler that rejected anything since the previous report.
+type samplingReporter struct {
+	log      *zerolog.Logger
+	samplers []*countingSampler
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+func startSamplingReporter(log *zerolog.Logger, samplers []*countingSampler, every time.Duration) *samplingReporter {
+	if every <= 0 {
+		every = defaultSampleReportInterval
+	}
+	r := &samplingReporter{log: log, samplers: samplers, stop: make(chan struct{}), done: make(chan struct{})}
+	go r.run(every)
+	return r
+}
+
+func (r *samplingReporter) run(every time.Duration) {
+	defer close(r.done)
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-r.stop:
+			r.report(every)
+			return
+		case <-t.C:
+			r.report(every)
+		}
+	}
+}
+
+func (r *samplingReporter) report(window time.Duration) {
+	for _, s := range r.samplers {
+		if n := s.suppressed.Swap(0); n > 0 {
+			selfEventOn(r.log, zerolog.InfoLevel, "sampling_suppressed").
+				Str("sampler", s.name).
+				Uint64("suppressed", n).
+				Dur("window", window).
+				Msgf("sampler suppressed %d events", n)
+		}
+	}
+}
+
+// Close emits a final report and stops the reporter.
+func (r *samplingReporter) Close() error {
+	r.once.Do(func() { close(r.stop) })
+	<-r.done
+	return nil
+}
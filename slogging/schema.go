@@ -0,0 +1,16 @@
+package slogging
+
+// SchemaVersion is the shape of events this package emits, stamped on every
+// event as FieldSchemaVersion. Bump it whenever a standard field is renamed or
+// changes meaning, and add the matching upgrade to the parse package's
+// built-in ones so tooling can still read files written by older versions.
+//
+// Events without the field predate versioning and are treated as version 0.
+//
+//	1: the version stamp itself
+//	2: Logger writes request_id and operator_name instead of X-Request-ID and
+//	   x-operator, and leaves out empty IDs
+const SchemaVersion = 2
+
+// FieldSchemaVersion is the key carrying SchemaVersion.
+const FieldSchemaVersion = "event_schema_version"
@@ -0,0 +1,192 @@
+// Package security turns authentication outcomes into lightweight security
+// signals for services that have no SIEM: a burst of failed logins from one IP,
+// and a successful login from a country the user has not logged in from before.
+//
+// Detections are logged as error-level events with a stable security_alert
+// name and handed to an optional Notifier. State is in memory and per process,
+// so the thresholds are per replica.
+//
+//	det := security.NewDetector(security.Options{
+//		Notify: func(ctx context.Context, a security.Alert) { pager.Send(a.Summary()) },
+//	})
+//	...
+//	if err != nil {
+//		det.LoginFailed(ctx, user, ip)
+//	} else {
+//		det.LoginSucceeded(ctx, user, ip, geo.Country(ip))
+//	}
+package security
+
+import (
+	"context"
+	"fmt"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"github.com/rs/zerolog"
+	"sync"
+	"time"
+)
+
+// Alert kinds, logged as the security_alert field.
+const (
+	KindFailedLogins = "failed_logins"
+	KindNewCountry   = "new_country"
+)
+
+// maxTracked bounds the IP and user tables so a spray across many addresses
+// cannot grow memory without limit; beyond it new keys are not tracked.
+const maxTracked = 100_000
+
+// Alert describes one detection.
+type Alert struct {
+	Kind    string
+	User    string
+	IP      string
+	Country string        // new_country: the unseen country
+	Count   int           // failed_logins: failures within Window
+	Window  time.Duration // failed_logins
+	Time    time.Time
+}
+
+// Summary is a one-line description suitable for chat or pager notifications.
+func (a Alert) Summary() string {
+	switch a.Kind {
+	case KindFailedLogins:
+		return fmt.Sprintf("%d failed logins from %s within %s (last user %q)", a.Count, a.IP, a.Window, a.User)
+	case KindNewCountry:
+		return fmt.Sprintf("user %q logged in from new country %s (ip %s)", a.User, a.Country, a.IP)
+	}
+	return a.Kind
+}
+
+// Notifier is called for every alert, on its own goroutine, with a context
+// that is not cancelled when the login request finishes.
+type Notifier func(ctx context.Context, a Alert)
+
+// Options configures a Detector. Zero values pick the defaults.
+type Options struct {
+	FailedLogins int           // failures per IP within Window that raise an alert (default 5)
+	Window       time.Duration // sliding window for FailedLogins (default 5m)
+	NewCountry   bool          // alert when a user logs in from a country not seen for them before
+	Notify       Notifier      // optional
+}
+
+// Detector tracks login outcomes. It is safe for concurrent use.
+type Detector struct {
+	opt Options
+
+	mu        sync.Mutex
+	failures  map[string][]time.Time         // ip -> failure times within the window
+	countries map[string]map[string]struct{} // user -> countries seen
+}
+
+// NewDetector returns a Detector configured by opt.
+func NewDetector(opt Options) *Detector {
+	if opt.FailedLogins <= 0 {
+		opt.FailedLogins = 5
+	}
+	if opt.Window <= 0 {
+		opt.Window = 5 * time.Minute
+	}
+	return &Detector{
+		opt:       opt,
+		failures:  make(map[string][]time.Time),
+		countries: make(map[string]map[string]struct{}),
+	}
+}
+
+// LoginFailed records a failed login. Reaching FailedLogins failures from ip
+// within Window raises a failed_logins alert and starts a fresh count.
+func (d *Detector) LoginFailed(ctx context.Context, user, ip string) {
+	if ip == "" {
+		return
+	}
+	now := time.Now()
+	d.mu.Lock()
+	if _, ok := d.failures[ip]; !ok && len(d.failures) >= maxTracked {
+		d.pruneLocked(now)
+		if len(d.failures) >= maxTracked {
+			d.mu.Unlock()
+			return
+		}
+	}
+	times := append(d.recentLocked(ip, now), now)
+	fire := len(times) >= d.opt.FailedLogins
+	if fire {
+		delete(d.failures, ip)
+	} else {
+		d.failures[ip] = times
+	}
+	d.mu.Unlock()
+
+	if fire {
+		d.raise(ctx, Alert{Kind: KindFailedLogins, User: user, IP: ip, Count: len(times), Window: d.opt.Window, Time: now})
+	}
+}
+
+// LoginSucceeded records a successful login. With NewCountry set, a login from
+// a country not seen before for user raises a new_country alert; the first
+// login of a user only establishes the baseline. An empty country is ignored.
+func (d *Detector) LoginSucceeded(ctx context.Context, user, ip, country string) {
+	if !d.opt.NewCountry || user == "" || country == "" {
+		return
+	}
+	d.mu.Lock()
+	seen, ok := d.countries[user]
+	if !ok {
+		if len(d.countries) < maxTracked {
+			d.countries[user] = map[string]struct{}{country: {}}
+		}
+		d.mu.Unlock()
+		return
+	}
+	_, known := seen[country]
+	seen[country] = struct{}{}
+	d.mu.Unlock()
+
+	if !known {
+		d.raise(ctx, Alert{Kind: KindNewCountry, User: user, IP: ip, Country: country, Time: time.Now()})
+	}
+}
+
+// recentLocked returns ip's failures still inside the window.
+func (d *Detector) recentLocked(ip string, now time.Time) []time.Time {
+	times := d.failures[ip]
+	i := 0
+	for i < len(times) && now.Sub(times[i]) >= d.opt.Window {
+		i++
+	}
+	return times[i:]
+}
+
+func (d *Detector) pruneLocked(now time.Time) {
+	for ip := range d.failures {
+		if rest := d.recentLocked(ip, now); len(rest) == 0 {
+			delete(d.failures, ip)
+		} else {
+			d.failures[ip] = rest
+		}
+	}
+}
+
+func (d *Detector) raise(ctx context.Context, a Alert) {
+	e := slogging.From(ctx).WithLevel(zerolog.ErrorLevel).
+		Str("security_alert", a.Kind).
+		Str("severity", "high")
+	if slogging.GetIPAddress(ctx) == "" { // WithIPAddress already put it on the logger
+		e = e.Str(slogging.FieldIPAddress, a.IP)
+	}
+	if a.User != "" {
+		e = e.Str("user", a.User)
+	}
+	switch a.Kind {
+	case KindFailedLogins:
+		e = e.Int("failed_logins", a.Count).Dur("window", a.Window)
+	case KindNewCountry:
+		e = e.Str("country", a.Country)
+	}
+	e.Msg(a.Summary())
+
+	if d.opt.Notify != nil {
+		go d.opt.Notify(context.WithoutCancel(ctx), a)
+	}
+}
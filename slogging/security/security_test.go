@@ -0,0 +1,103 @@
+package security
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lines is an ExtraWriter keeping what the pipeline writes.
+type lines struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (l *lines) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.Write(p)
+}
+
+// alerts returns the raw security_alert events.
+func (l *lines) alerts() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var out []string
+	for _, line := range strings.Split(l.buf.String(), "\n") {
+		if strings.Contains(line, `"security_alert"`) {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+func capture(t *testing.T) *lines {
+	t.Helper()
+	l := &lines{}
+	slogging.Init(slogging.Options{Service: "svc", FilePath: filepath.Join(t.TempDir(), "app.log"), ExtraWriter: l})
+	t.Cleanup(func() { slogging.Close(context.Background()) })
+	return l
+}
+
+func TestFailedLoginsAlert(t *testing.T) {
+	out := capture(t)
+	notified := make(chan Alert, 1)
+	d := NewDetector(Options{FailedLogins: 3, Window: time.Minute, Notify: func(_ context.Context, a Alert) { notified <- a }})
+	for range 3 {
+		d.LoginFailed(context.Background(), "bob", "10.0.0.1")
+	}
+	alerts := out.alerts()
+	if len(alerts) != 1 {
+		t.Fatalf("got %d alerts, want 1: %v", len(alerts), alerts)
+	}
+	var ev map[string]any
+	if err := json.Unmarshal([]byte(alerts[0]), &ev); err != nil {
+		t.Fatal(err)
+	}
+	if ev["security_alert"] != KindFailedLogins || ev[slogging.FieldIPAddress] != "10.0.0.1" {
+		t.Errorf("unexpected alert %s", alerts[0])
+	}
+	select {
+	case a := <-notified:
+		if a.Count != 3 {
+			t.Errorf("Count = %d, want 3", a.Count)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("notifier not called")
+	}
+}
+
+func TestAlertWithIPAddressOnContext(t *testing.T) {
+	out := capture(t)
+	d := NewDetector(Options{FailedLogins: 1})
+	ctx := slogging.WithIPAddress(context.Background(), "10.0.0.2")
+	d.LoginFailed(ctx, "bob", "10.0.0.2")
+
+	alerts := out.alerts()
+	if len(alerts) != 1 {
+		t.Fatalf("got %d alerts, want 1", len(alerts))
+	}
+	if n := strings.Count(alerts[0], `"`+slogging.FieldIPAddress+`"`); n != 1 {
+		t.Errorf("%s appears %d times: %s", slogging.FieldIPAddress, n, alerts[0])
+	}
+}
+
+func TestNewCountryAlert(t *testing.T) {
+	out := capture(t)
+	d := NewDetector(Options{NewCountry: true})
+	ctx := context.Background()
+	d.LoginSucceeded(ctx, "ann", "1.1.1.1", "DE")
+	d.LoginSucceeded(ctx, "ann", "1.1.1.1", "DE")
+	d.LoginSucceeded(ctx, "ann", "2.2.2.2", "BR")
+
+	alerts := out.alerts()
+	if len(alerts) != 1 || !strings.Contains(alerts[0], `"country":"BR"`) {
+		t.Fatalf("want one new_country alert for BR, got %v", alerts)
+	}
+}
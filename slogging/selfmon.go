@@ -0,0 +1,60 @@
+package slogging
+
+import (
+	"github.com/rs/zerolog"
+	"sync/atomic"
+)
+
+// Stats is a point-in-time snapshot of the package's self-monitoring counters.
+type Stats struct {
+	Dropped       uint64 // events lost by a sink (fallback unavailable, timeouts, full buffers)
+	WriteErrors   uint64 // sink writes that returned an error
+	DegradedSinks int64  // file sinks currently writing to their fallback
+	SampleRate    int    // info and lower events are kept 1 in SampleRate by Options.SampleBudget; 1 when not sampling
+}
+
+// selfStats are an Instance's self-monitoring counters, fed by the writers
+// of its pipelines.
+type selfStats struct {
+	dropped       atomic.Uint64
+	writeErrors   atomic.Uint64
+	degradedSinks atomic.Int64
+}
+
+// GetStats returns the Default instance's self-monitoring counters.
+func GetStats() Stats {
+	return std.Stats()
+}
+
+// Stats returns the instance's self-monitoring counters.
+func (i *Instance) Stats() Stats {
+	return Stats{
+		Dropped:       i.stats.dropped.Load(),
+		WriteErrors:   i.stats.writeErrors.Load(),
+		DegradedSinks: i.stats.degradedSinks.Load(),
+		SampleRate:    i.sampleRate(),
+	}
+}
+
+// sampleRate is the adaptive sampler's rate, or 1 without one.
+func (i *Instance) sampleRate() int {
+	if p := i.current.Load(); p != nil && p.adaptive != nil {
+		return p.adaptive.currentRate()
+	}
+	return 1
+}
+
+// selfEvent starts an event about slogging itself. Such events carry
+// component=slogging plus a stable slogging_event name so they can be alerted on,
+// and bypass sampling so they are never lost to it.
+func selfEvent(level zerolog.Level, name string) *zerolog.Event {
+	return selfEventOn(selfLogger(), level, name)
+}
+
+// selfEventOn is selfEvent for a specific pipeline's logger, used by components
+// that may still report while their pipeline is being replaced.
+func selfEventOn(l *zerolog.Logger, level zerolog.Level, name string) *zerolog.Event {
+	return l.WithLevel(level).
+		Str(FieldComponent, "slogging").
+		Str(FieldSloggingEvent, name)
+}
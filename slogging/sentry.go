@@ -0,0 +1,54 @@
+package slogging
+
+import (
+	"github.com/rs/zerolog"
+	"io"
+	"sync/atomic"
+)
+
+// SentryConfig is what Options.SentryDSN hands to the registered integration.
+type SentryConfig struct {
+	DSN         string
+	Environment string // Options.Environment
+	Release     string // Options.Version
+	Service     string // Options.Service
+}
+
+// SentryFunc builds the sink that forwards error, fatal and panic events to
+// Sentry. It receives every event through WriteLevel, synchronously on the
+// logging goroutine, and must not block on the network.
+type SentryFunc func(SentryConfig) (io.WriteCloser, error)
+
+var sentryFn atomic.Pointer[SentryFunc]
+
+// RegisterSentry installs the integration used by Options.SentryDSN. Importing
+// slogging/sentry registers the sentry-go one, so slogging itself does not
+// depend on the Sentry SDK:
+//
+//	import _ "github.com/dinhtatuanlinh/source_logging/slogging/sentry"
+func RegisterSentry(fn SentryFunc) {
+	sentryFn.Store(&fn)
+}
+
+// addSentry appends the Sentry sink. It is not wrapped by the async or
+// deadline options: the integration is already non-blocking, and it must run
+// on the logging goroutine to capture the caller's stack.
+func (p *pipeline) addSentry(sinks *[]io.Writer, opt Options) {
+	fn := sentryFn.Load()
+	if fn == nil {
+		p.onInstall = append(p.onInstall, func() {
+			selfEventOn(&p.self, zerolog.WarnLevel, "invalid_option").
+				Msg("SentryDSN is set but no Sentry integration is registered; import slogging/sentry")
+		})
+		return
+	}
+	w, err := (*fn)(SentryConfig{DSN: opt.SentryDSN, Environment: opt.Environment, Release: opt.Version, Service: opt.Service})
+	if err != nil {
+		p.onInstall = append(p.onInstall, func() {
+			selfEventOn(&p.self, zerolog.WarnLevel, "invalid_option").Err(err).Msg("SentryDSN rejected; not forwarding to Sentry")
+		})
+		return
+	}
+	p.closers = append(p.closers, w)
+	*sinks = append(*sinks, w)
+}
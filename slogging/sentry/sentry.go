@@ -0,0 +1,172 @@
+// Package sentry forwards slogging error, fatal and panic events to Sentry.
+// Import it for its side effect and set Options.SentryDSN:
+//
+//	import _ "github.com/dinhtatuanlinh/source_logging/slogging/sentry"
+//
+//	slogging.Init(slogging.Options{Service: "billing", SentryDSN: os.Getenv("SENTRY_DSN")})
+//
+// Each event becomes a Sentry event with the message, the error (as the
+// exception, typed by error_kind when set), the correlation fields as tags,
+// the remaining fields under the "fields" context and the stack of the
+// logging call. Events are queued to the SDK's asynchronous transport, which
+// drops them when Sentry is slow or unreachable.
+package sentry
+
+import (
+	"encoding/json"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"github.com/getsentry/sentry-go"
+	"github.com/rs/zerolog"
+	"io"
+	"strings"
+	"time"
+)
+
+func init() {
+	slogging.RegisterSentry(newWriter)
+}
+
+// tagFields become Sentry tags, so issues can be searched and grouped by them.
+var tagFields = []string{
+	slogging.FieldService, slogging.FieldEnv, slogging.FieldVersion, slogging.FieldComponent,
+	slogging.FieldRequestID, slogging.FieldTraceID, slogging.FieldSpanID,
+	slogging.FieldOperatorName, slogging.XOperator, slogging.FieldAPIID, slogging.FieldTenant,
+	slogging.FieldErrorKind, slogging.FieldMethod, slogging.FieldPath, slogging.FieldSloggingEvent,
+}
+
+// skipFields are carried by the Sentry event itself.
+var skipFields = []string{zerolog.LevelFieldName, zerolog.MessageFieldName, zerolog.TimestampFieldName, zerolog.ErrorFieldName}
+
+// framePrefixes are the logging frames trimmed from the top of the stack.
+var framePrefixes = []string{"github.com/rs/zerolog", "github.com/dinhtatuanlinh/source_logging/slogging"}
+
+type writer struct {
+	client *sentry.Client
+}
+
+func newWriter(cfg slogging.SentryConfig) (io.WriteCloser, error) {
+	c, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:         cfg.DSN,
+		Environment: cfg.Environment,
+		Release:     cfg.Release,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &writer{client: c}, nil
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	return len(p), nil // no level: not an error event
+}
+
+// WriteLevel forwards error and above. It always reports success so the other
+// sinks of a MultiLevelWriter are never affected.
+func (w *writer) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if level < zerolog.ErrorLevel || level == zerolog.NoLevel || level == zerolog.Disabled {
+		return len(p), nil
+	}
+	var fields map[string]any
+	if json.Unmarshal(p, &fields) != nil {
+		return len(p), nil
+	}
+	w.client.CaptureEvent(event(level, fields, stack()), nil, nil)
+	return len(p), nil
+}
+
+// Close delivers what is queued, waiting at most 2s.
+func (w *writer) Close() error {
+	w.client.Flush(2 * time.Second)
+	return nil
+}
+
+func event(level zerolog.Level, fields map[string]any, st *sentry.Stacktrace) *sentry.Event {
+	ev := sentry.NewEvent()
+	ev.Level = sentry.LevelError
+	if level >= zerolog.FatalLevel {
+		ev.Level = sentry.LevelFatal
+	}
+	ev.Logger = "slogging"
+	ev.Timestamp = time.Now()
+	ev.Message, _ = fields[zerolog.MessageFieldName].(string)
+	if s, ok := fields[zerolog.TimestampFieldName].(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			ev.Timestamp = t
+		}
+	}
+	for _, k := range tagFields {
+		if v, ok := fields[k]; ok && v != nil {
+			if s := toString(v); s != "" {
+				ev.Tags[k] = s
+			}
+		}
+	}
+	extra := make(map[string]any, len(fields))
+	for k, v := range fields {
+		if _, tag := ev.Tags[k]; tag || contains(skipFields, k) {
+			continue
+		}
+		extra[k] = v
+	}
+	if len(extra) > 0 {
+		ev.Contexts["fields"] = extra
+	}
+	if msg, ok := fields[zerolog.ErrorFieldName]; ok {
+		typ, _ := fields[slogging.FieldErrorKind].(string)
+		if typ == "" {
+			typ = "error"
+		}
+		ev.Exception = []sentry.Exception{{Type: typ, Value: toString(msg), Stacktrace: st}}
+	} else if st != nil {
+		ev.Threads = []sentry.Thread{{Stacktrace: st, Current: true}}
+	}
+	return ev
+}
+
+// stack is the current goroutine's stack without the logging frames, so the
+// innermost frame is the logging call site. Frames of _test packages are kept,
+// as sentry-go does, so tests of slogging's own packages see their call site.
+func stack() *sentry.Stacktrace {
+	st := sentry.NewStacktrace()
+	if st == nil {
+		return nil
+	}
+	n := len(st.Frames)
+	for n > 0 && isLoggingFrame(st.Frames[n-1].Module) {
+		n--
+	}
+	st.Frames = st.Frames[:n]
+	if n == 0 {
+		return nil
+	}
+	return st
+}
+
+func toString(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func isLoggingFrame(module string) bool {
+	if strings.HasSuffix(module, "_test") {
+		return false
+	}
+	for _, p := range framePrefixes {
+		if strings.HasPrefix(module, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package sentry_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	_ "github.com/dinhtatuanlinh/source_logging/slogging/sentry"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeSentry collects the events posted to its envelope endpoint.
+type fakeSentry struct {
+	mu     sync.Mutex
+	events []map[string]any
+}
+
+func (f *fakeSentry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		body = zr
+	}
+	// An envelope is a header line, then an item header and payload per item.
+	sc := bufio.NewScanner(body)
+	sc.Buffer(nil, 1<<20)
+	sc.Scan()
+	for sc.Scan() {
+		var item struct{ Type string }
+		json.Unmarshal(sc.Bytes(), &item)
+		if !sc.Scan() {
+			break
+		}
+		if item.Type == "event" {
+			var ev map[string]any
+			if json.Unmarshal(bytes.Clone(sc.Bytes()), &ev) == nil {
+				f.mu.Lock()
+				f.events = append(f.events, ev)
+				f.mu.Unlock()
+			}
+		}
+	}
+	w.Write([]byte(`{}`))
+}
+
+func TestErrorEventsReachSentry(t *testing.T) {
+	f := &fakeSentry{}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+	dsn := strings.Replace(srv.URL, "http://", "http://public@", 1) + "/42"
+
+	slogging.Init(slogging.Options{
+		Service:     "billing",
+		Environment: "test",
+		FilePath:    filepath.Join(t.TempDir(), "app.log"),
+		SentryDSN:   dsn,
+	})
+	ctx := slogging.WithRequestID(context.Background(), "r1")
+	slogging.From(ctx).Info().Msg("paid")
+	slogging.From(ctx).Error().Err(errors.New("card declined")).
+		Str(slogging.FieldErrorKind, "payment").Int("amount", 7).Msg("charge failed")
+	slogging.Close(context.Background())
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.events) != 1 {
+		t.Fatalf("Sentry got %d events, want only the error", len(f.events))
+	}
+	ev := f.events[0]
+	if ev["message"] != "charge failed" || ev["level"] != "error" || ev["environment"] != "test" {
+		t.Errorf("event = %v", ev)
+	}
+	tags, _ := ev["tags"].(map[string]any)
+	if tags[slogging.FieldRequestID] != "r1" || tags[slogging.FieldService] != "billing" {
+		t.Errorf("tags = %v, want request_id and service", tags)
+	}
+	fields, _ := ev["contexts"].(map[string]any)["fields"].(map[string]any)
+	if fields["amount"] != 7.0 {
+		t.Errorf("fields context = %v, want amount", fields)
+	}
+	exc, _ := ev["exception"].([]any)
+	if len(exc) != 1 {
+		t.Fatalf("exception = %v", ev["exception"])
+	}
+	e := exc[0].(map[string]any)
+	if e["type"] != "payment" || e["value"] != "card declined" {
+		t.Errorf("exception = %v, want payment: card declined", e)
+	}
+	frames, _ := e["stacktrace"].(map[string]any)["frames"].([]any)
+	if len(frames) == 0 {
+		t.Fatal("exception has no stack trace")
+	}
+	// The innermost frame is the logging call, not zerolog or slogging.
+	if fn, _ := frames[len(frames)-1].(map[string]any)["function"].(string); fn != "TestErrorEventsReachSentry" {
+		t.Errorf("innermost frame is %q, want the test", fn)
+	}
+}
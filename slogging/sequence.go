@@ -0,0 +1,57 @@
+package slogging
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/binary"
+	"github.com/rs/zerolog"
+	"sync/atomic"
+)
+
+// seq is the per-process event counter behind Options.Sequence. It is package
+// level so numbers keep increasing across re-Init.
+var seq atomic.Uint64
+
+// seqHook stamps each event with the next sequence number. It is the first
+// hook on both the main and the self logger, so every event that is actually
+// emitted (sampled-out events never reach hooks) gets a number.
+type seqHook struct{}
+
+// Run implements zerolog.Hook.
+func (seqHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	e.Uint64(FieldSeq, seq.Add(1))
+}
+
+// eventIDEncoding is base32hex in lower case, so IDs sort like the counter.
+var eventIDEncoding = base32.NewEncoding("0123456789abcdefghijklmnopqrstuv").WithPadding(base32.NoPadding)
+
+// The event IDs behind Options.EventIDs are a random 48-bit prefix per
+// process and a 48-bit counter, 20 characters once encoded.
+var (
+	eventIDPrefix = func() (b [6]byte) { rand.Read(b[:]); return }()
+	eventIDCount  atomic.Uint64
+)
+
+// NewEventID returns an ID in the event_id format: unique across processes
+// with overwhelming probability, and increasing within one.
+func NewEventID() string {
+	var b [12]byte
+	copy(b[:6], eventIDPrefix[:])
+	var n [8]byte
+	binary.BigEndian.PutUint64(n[:], eventIDCount.Add(1))
+	copy(b[6:], n[2:])
+	return eventIDEncoding.EncodeToString(b[:])
+}
+
+// eventIDHook stamps each event with a new event_id, unless EventID gave it
+// one. Like seqHook it runs on the self logger, so every emitted event has
+// one.
+type eventIDHook struct{}
+
+// Run implements zerolog.Hook.
+func (eventIDHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	if _, ok := e.GetCtx().Value(ctxEventIDKey).(string); ok {
+		return
+	}
+	e.Str(FieldEventID, NewEventID())
+}
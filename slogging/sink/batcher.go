@@ -0,0 +1,267 @@
+package sink
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Sender delivers one batch of events. Each event is a complete line as
+// written by the logger, trailing newline included. Return Permanent(err) for
+// failures that retrying cannot fix.
+type Sender interface {
+	Send(ctx context.Context, batch [][]byte) error
+}
+
+// SenderFunc adapts a function to Sender.
+type SenderFunc func(ctx context.Context, batch [][]byte) error
+
+// Send implements Sender.
+func (f SenderFunc) Send(ctx context.Context, batch [][]byte) error { return f(ctx, batch) }
+
+// Options configures a Batcher. Zero values pick the defaults.
+type Options struct {
+	MaxEvents     int           // flush when a batch has this many events (default 500)
+	MaxBytes      int           // flush when a batch reaches this size (default 1 MiB)
+	FlushInterval time.Duration // flush a partial batch after this long (default 1s)
+	QueueSize     int           // events buffered ahead of the batching loop (default 10000)
+
+	SendTimeout time.Duration // per attempt (default 10s)
+	Attempts    int           // attempts per batch, including the first (default 3)
+	Backoff     Backoff       // between attempts (default DefaultBackoff)
+
+	Breaker *Breaker    // optional: skip sending while the destination is down
+	WAL     *WAL        // optional: spill failed batches and replay them later
+	OnError func(error) // optional: called from the batching goroutine on a failed batch
+}
+
+// Stats counts what a Batcher did with the events it was given.
+type Stats struct {
+	Queued  int    // waiting in the queue
+	Sent    uint64 // delivered, including replayed from the WAL
+	Spilled uint64 // written to the WAL after delivery failed
+	Dropped uint64 // lost: queue full, or delivery and spilling both failed
+}
+
+// Batcher is an io.Writer (and zerolog.LevelWriter) that groups events into
+// batches and hands them to a Sender from a background goroutine. Writes never
+// block; when the queue is full the event is dropped.
+type Batcher struct {
+	s   Sender
+	opt Options
+
+	in    chan []byte
+	flush chan chan struct{}
+	done  chan struct{}
+
+	mu     sync.RWMutex // held for reading by Write, for writing by Close
+	closed bool
+
+	sent, spilled, dropped atomic.Uint64
+}
+
+// NewBatcher starts a Batcher delivering to s.
+func NewBatcher(s Sender, opt Options) *Batcher {
+	if opt.MaxEvents <= 0 {
+		opt.MaxEvents = 500
+	}
+	if opt.MaxBytes <= 0 {
+		opt.MaxBytes = 1 << 20
+	}
+	if opt.FlushInterval <= 0 {
+		opt.FlushInterval = time.Second
+	}
+	if opt.QueueSize <= 0 {
+		opt.QueueSize = 10000
+	}
+	if opt.SendTimeout <= 0 {
+		opt.SendTimeout = 10 * time.Second
+	}
+	if opt.Attempts <= 0 {
+		opt.Attempts = 3
+	}
+	if opt.Backoff == (Backoff{}) {
+		opt.Backoff = DefaultBackoff
+	}
+	b := &Batcher{
+		s:     s,
+		opt:   opt,
+		in:    make(chan []byte, opt.QueueSize),
+		flush: make(chan chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+func (b *Batcher) Write(p []byte) (int, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		b.dropped.Add(1)
+		return len(p), nil
+	}
+	select {
+	case b.in <- append([]byte(nil), p...):
+	default:
+		b.dropped.Add(1)
+	}
+	return len(p), nil
+}
+
+// WriteLevel implements zerolog.LevelWriter.
+func (b *Batcher) WriteLevel(_ zerolog.Level, p []byte) (int, error) {
+	return b.Write(p)
+}
+
+// Stats returns the current counters.
+func (b *Batcher) Stats() Stats {
+	return Stats{
+		Queued:  len(b.in),
+		Sent:    b.sent.Load(),
+		Spilled: b.spilled.Load(),
+		Dropped: b.dropped.Load(),
+	}
+}
+
+// Flush delivers everything queued so far and waits for it, or for ctx.
+func (b *Batcher) Flush(ctx context.Context) error {
+	req := make(chan struct{})
+	select {
+	case b.flush <- req:
+	case <-b.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-req:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting events, delivers what is queued and waits for the
+// batching goroutine. Events written after Close are dropped.
+func (b *Batcher) Close() error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.in)
+	}
+	b.mu.Unlock()
+	<-b.done
+	return nil
+}
+
+func (b *Batcher) run() {
+	defer close(b.done)
+	t := time.NewTicker(b.opt.FlushInterval)
+	defer t.Stop()
+
+	var batch [][]byte
+	size := 0
+	add := func(ev []byte) {
+		batch = append(batch, ev)
+		size += len(ev)
+		if len(batch) >= b.opt.MaxEvents || size >= b.opt.MaxBytes {
+			b.deliver(batch)
+			batch, size = nil, 0
+		}
+	}
+	for {
+		select {
+		case ev, ok := <-b.in:
+			if !ok {
+				if len(batch) > 0 {
+					b.deliver(batch)
+				}
+				return
+			}
+			add(ev)
+		case <-t.C:
+			if len(batch) > 0 {
+				b.deliver(batch)
+				batch, size = nil, 0
+			} else {
+				b.replay()
+			}
+		case req := <-b.flush:
+			for n := len(b.in); n > 0; n-- {
+				add(<-b.in)
+			}
+			if len(batch) > 0 {
+				b.deliver(batch)
+				batch, size = nil, 0
+			}
+			close(req)
+		}
+	}
+}
+
+// deliver sends batch, spilling it to the WAL if that fails. After a success,
+// spilled batches are replayed.
+func (b *Batcher) deliver(batch [][]byte) {
+	if err := b.send(batch); err != nil {
+		b.fail(batch, err)
+		return
+	}
+	b.sent.Add(uint64(len(batch)))
+	b.replay()
+}
+
+// send makes up to Attempts attempts through the breaker.
+func (b *Batcher) send(batch [][]byte) error {
+	if br := b.opt.Breaker; br != nil && !br.Allow() {
+		return ErrOpen
+	}
+	err := Retry(context.Background(), b.opt.Attempts, b.opt.Backoff, func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, b.opt.SendTimeout)
+		defer cancel()
+		return b.s.Send(ctx, batch)
+	})
+	if br := b.opt.Breaker; br != nil {
+		if err != nil {
+			br.Failure()
+		} else {
+			br.Success()
+		}
+	}
+	return err
+}
+
+func (b *Batcher) fail(batch [][]byte, err error) {
+	if b.opt.OnError != nil {
+		b.opt.OnError(err)
+	}
+	if b.opt.WAL != nil && !IsPermanent(err) {
+		if werr := b.opt.WAL.Append(batch); werr == nil {
+			b.spilled.Add(uint64(len(batch)))
+			return
+		} else if b.opt.OnError != nil {
+			b.opt.OnError(werr)
+		}
+	}
+	b.dropped.Add(uint64(len(batch)))
+}
+
+// replay re-sends spilled batches while the destination accepts them.
+func (b *Batcher) replay() {
+	if b.opt.WAL == nil || b.opt.WAL.Size() == 0 {
+		return
+	}
+	b.opt.WAL.Replay(func(batch [][]byte) error {
+		err := b.send(batch)
+		if IsPermanent(err) {
+			b.dropped.Add(uint64(len(batch)))
+			return nil // replaying it again cannot succeed
+		}
+		if err == nil {
+			b.sent.Add(uint64(len(batch)))
+		}
+		return err
+	})
+}
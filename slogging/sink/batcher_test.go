@@ -0,0 +1,35 @@
+package sink_test
+
+import (
+	"github.com/dinhtatuanlinh/source_logging/slogging/sink"
+	"github.com/dinhtatuanlinh/source_logging/slogging/sink/sinktest"
+	"testing"
+	"time"
+)
+
+func TestBatcherConformance(t *testing.T) {
+	sinktest.Run(t, func(t *testing.T) sinktest.Harness {
+		rec := sinktest.NewRecorder()
+		b := sink.NewBatcher(rec, sink.Options{
+			MaxEvents:     64,
+			FlushInterval: 10 * time.Millisecond,
+			Attempts:      3,
+			Backoff:       sink.Backoff{Initial: time.Millisecond, Max: 5 * time.Millisecond, Multiplier: 2},
+		})
+		return sinktest.Harness{Sink: b, Received: rec.Events, FailNext: rec.FailNext}
+	})
+}
+
+func TestBatcherStats(t *testing.T) {
+	rec := sinktest.NewRecorder()
+	b := sink.NewBatcher(rec, sink.Options{FlushInterval: time.Millisecond})
+	for range 3 {
+		b.Write([]byte("{}\n"))
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if s := b.Stats(); s.Sent != 3 || s.Dropped != 0 {
+		t.Errorf("Stats = %+v, want 3 sent", s)
+	}
+}
@@ -0,0 +1,43 @@
+// Package sink has the building blocks for shipping slogging output to a
+// destination of your own: a batching writer, retry with backoff, a circuit
+// breaker, a disk spill (WAL) for batches that could not be delivered, and
+// Secrets for credentials that rotate.
+//
+// A sink for an in-house ingest API is a Sender plus configuration:
+//
+//	token := sink.FileSecret("/var/run/secrets/ingest/token") // or EnvSecret, RefreshingSecret
+//	send := sink.SenderFunc(func(ctx context.Context, batch [][]byte) error {
+//		tok, err := token.Value(ctx)
+//		if err != nil {
+//			return err
+//		}
+//		req, err := http.NewRequestWithContext(ctx, "POST", ingestURL, bytes.NewReader(bytes.Join(batch, nil)))
+//		if err != nil {
+//			return sink.Permanent(err)
+//		}
+//		req.Header.Set("Authorization", "Bearer "+tok)
+//		resp, err := http.DefaultClient.Do(req)
+//		if err != nil {
+//			return err
+//		}
+//		resp.Body.Close()
+//		if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+//			return sink.Permanent(fmt.Errorf("ingest: %s", resp.Status))
+//		}
+//		if resp.StatusCode >= 500 {
+//			return fmt.Errorf("ingest: %s", resp.Status)
+//		}
+//		return nil
+//	})
+//	wal, err := sink.OpenWAL("/var/spool/myapp/ingest", 512<<20)
+//	...
+//	b := sink.NewBatcher(send, sink.Options{
+//		Breaker: sink.NewBreaker(5, 30*time.Second),
+//		WAL:     wal,
+//	})
+//	slogging.Init(slogging.Options{Service: "myapp", ExtraWriter: b})
+//	defer b.Close() // after the last log line; slogging does not close ExtraWriter
+//
+// Nothing in this package blocks the logging path: a Batcher that cannot keep
+// up drops events and counts them in Stats.
+package sink
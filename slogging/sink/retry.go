@@ -0,0 +1,77 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff computes exponentially growing delays between attempts.
+type Backoff struct {
+	Initial    time.Duration // delay before the first retry
+	Max        time.Duration // cap on any single delay
+	Multiplier float64       // growth per attempt
+	Jitter     float64       // +/- fraction of randomization, 0..1
+}
+
+// DefaultBackoff is used when Options.Backoff is left zero.
+var DefaultBackoff = Backoff{Initial: 100 * time.Millisecond, Max: 30 * time.Second, Multiplier: 2, Jitter: 0.2}
+
+// Delay returns the wait before retry number attempt (0 for the first retry).
+func (b Backoff) Delay(attempt int) time.Duration {
+	d := float64(b.Initial)
+	for i := 0; i < attempt && d < float64(b.Max); i++ {
+		d *= max(b.Multiplier, 1)
+	}
+	if b.Max > 0 && d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+	if b.Jitter > 0 {
+		d += d * b.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(d)
+}
+
+type permanentError struct{ err error }
+
+func (p permanentError) Error() string { return p.err.Error() }
+func (p permanentError) Unwrap() error { return p.err }
+
+// Permanent marks err as not worth retrying (bad request, auth failure).
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err}
+}
+
+// IsPermanent reports whether err was marked with Permanent.
+func IsPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
+
+// Retry calls fn up to attempts times, sleeping per b between failures.
+// It stops early on success, on a Permanent error, or when ctx is done, and
+// returns the last error.
+func Retry(ctx context.Context, attempts int, b Backoff, fn func(ctx context.Context) error) error {
+	attempts = max(attempts, 1)
+	var err error
+	for i := 0; i < attempts; i++ {
+		if err = fn(ctx); err == nil || IsPermanent(err) {
+			return err
+		}
+		if i == attempts-1 {
+			break
+		}
+		t := time.NewTimer(b.Delay(i))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-t.C:
+		}
+	}
+	return err
+}
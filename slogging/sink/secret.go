@@ -0,0 +1,176 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Secret is a credential a Sender reads on each attempt (an ingest token, a
+// SASL password, a cloud key), so a rotated value is picked up without
+// restarting or rebuilding the sink.
+type Secret interface {
+	Value(ctx context.Context) (string, error)
+}
+
+// SecretFunc adapts a function to Secret.
+type SecretFunc func(ctx context.Context) (string, error)
+
+// Value implements Secret.
+func (f SecretFunc) Value(ctx context.Context) (string, error) { return f(ctx) }
+
+// StaticSecret is a fixed value, for tests and local runs.
+type StaticSecret string
+
+// Value implements Secret.
+func (s StaticSecret) Value(context.Context) (string, error) { return string(s), nil }
+
+// EnvSecret reads the environment variable name on every call.
+func EnvSecret(name string) Secret {
+	return SecretFunc(func(context.Context) (string, error) {
+		v, ok := os.LookupEnv(name)
+		if !ok || v == "" {
+			return "", fmt.Errorf("sink: secret env %s is not set", name)
+		}
+		return v, nil
+	})
+}
+
+// FileSecret reads path on every call, trimming surrounding whitespace. It
+// suits mounted secrets (Kubernetes, Vault agent) that are replaced in place.
+func FileSecret(path string) Secret {
+	return SecretFunc(func(context.Context) (string, error) {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("sink: secret file: %w", err)
+		}
+		v := strings.TrimSpace(string(b))
+		if v == "" {
+			return "", fmt.Errorf("sink: secret file %s is empty", path)
+		}
+		return v, nil
+	})
+}
+
+// Provider fetches named secrets from a secret store. Adapt a Vault or AWS
+// Secrets Manager client with ProviderFunc:
+//
+//	p := sink.ProviderFunc(func(ctx context.Context, name string) (string, error) {
+//		out, err := sm.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: &name})
+//		if err != nil {
+//			return "", err
+//		}
+//		return *out.SecretString, nil
+//	})
+//	token := sink.RefreshingSecret(p, "prod/splunk-hec-token", 5*time.Minute)
+//	defer token.Close()
+type Provider interface {
+	Fetch(ctx context.Context, name string) (string, error)
+}
+
+// ProviderFunc adapts a function to Provider.
+type ProviderFunc func(ctx context.Context, name string) (string, error)
+
+// Fetch implements Provider.
+func (f ProviderFunc) Fetch(ctx context.Context, name string) (string, error) { return f(ctx, name) }
+
+// CachedSecret is a Secret from a Provider, fetched on first use and then
+// refreshed in the background. A failed refresh keeps the last good value, so
+// a secret store outage does not take log shipping down with it.
+type CachedSecret struct {
+	p     Provider
+	name  string
+	every time.Duration
+
+	mu    sync.Mutex // held by Value while it does the first fetch
+	value string
+	err   error // last fetch error
+
+	quit chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// RefreshingSecret returns a CachedSecret for name that refetches every
+// interval (default 5m). Close stops the refresh.
+func RefreshingSecret(p Provider, name string, every time.Duration) *CachedSecret {
+	if every <= 0 {
+		every = 5 * time.Minute
+	}
+	s := &CachedSecret{p: p, name: name, every: every, quit: make(chan struct{}), done: make(chan struct{})}
+	go s.loop()
+	return s
+}
+
+// Value returns the cached value, fetching it if there is none yet.
+func (s *CachedSecret) Value(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.value != "" {
+		return s.value, nil
+	}
+	s.store(s.fetch(ctx))
+	if s.value == "" {
+		return "", s.err
+	}
+	return s.value, nil
+}
+
+// Err returns the error of the last fetch, nil if it succeeded.
+func (s *CachedSecret) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close stops the background refresh.
+func (s *CachedSecret) Close() error {
+	s.once.Do(func() { close(s.quit) })
+	<-s.done
+	return nil
+}
+
+func (s *CachedSecret) loop() {
+	defer close(s.done)
+	t := time.NewTicker(s.every)
+	defer t.Stop()
+	for {
+		select {
+		case <-s.quit:
+			return
+		case <-t.C:
+			// Fetch unlocked so a slow store does not stall senders that
+			// already have a value.
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			v, err := s.fetch(ctx)
+			cancel()
+			s.mu.Lock()
+			s.store(v, err)
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *CachedSecret) fetch(ctx context.Context) (string, error) {
+	v, err := s.p.Fetch(ctx, s.name)
+	if err == nil && v == "" {
+		err = errors.New("empty value")
+	}
+	if err != nil {
+		return "", fmt.Errorf("sink: secret %s: %w", s.name, err)
+	}
+	return v, nil
+}
+
+// store records a fetch result, keeping the old value on failure. Callers
+// hold s.mu.
+func (s *CachedSecret) store(v string, err error) {
+	s.err = err
+	if err == nil {
+		s.value = v
+	}
+}
@@ -0,0 +1,262 @@
+// Package sinktest is a conformance suite for slogging sinks. Sink authors run
+// it from their own tests against a harness wrapping their implementation:
+//
+//	func TestConformance(t *testing.T) {
+//		sinktest.Run(t, func(t *testing.T) sinktest.Harness {
+//			rec := sinktest.NewRecorder()
+//			b := sink.NewBatcher(myapi.Sender(rec), sink.Options{FlushInterval: 10 * time.Millisecond})
+//			return sinktest.Harness{Sink: b, Received: rec.Events, FailNext: rec.FailNext}
+//		})
+//	}
+//
+// The suite checks ordering, delivery of everything written before Close,
+// safety under concurrent writers, independence from the caller's buffer
+// (zerolog reuses it after Write returns) and, when the harness can inject
+// failures, that transient failures are retried.
+package sinktest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+)
+
+// Harness connects the suite to a sink under test.
+type Harness struct {
+	// Sink is the sink under test, fresh for each check.
+	Sink io.WriteCloser
+	// Received returns the events the destination has accepted so far, in
+	// arrival order. It is called after Sink.Close.
+	Received func() [][]byte
+	// FailNext, if set, makes the destination reject its next n deliveries
+	// with a transient error. Without it the retry check is skipped.
+	FailNext func(n int)
+}
+
+// Factory builds a fresh Harness for one check.
+type Factory func(t *testing.T) Harness
+
+// Run runs every conformance check as a subtest of t.
+func Run(t *testing.T, f Factory) {
+	t.Run("Ordering", func(t *testing.T) { testOrdering(t, f(t)) })
+	t.Run("FlushOnClose", func(t *testing.T) { testFlushOnClose(t, f(t)) })
+	t.Run("Concurrency", func(t *testing.T) { testConcurrency(t, f(t)) })
+	t.Run("BufferReuse", func(t *testing.T) { testBufferReuse(t, f(t)) })
+	t.Run("WriteAfterClose", func(t *testing.T) { testWriteAfterClose(t, f(t)) })
+	t.Run("Retry", func(t *testing.T) {
+		h := f(t)
+		if h.FailNext == nil {
+			h.Sink.Close()
+			t.Skip("harness cannot inject failures")
+		}
+		testRetry(t, h)
+	})
+}
+
+// event is the line shape the suite writes, mimicking a zerolog event.
+type event struct {
+	Level   string `json:"level"`
+	Writer  int    `json:"writer"`
+	Seq     int    `json:"seq"`
+	Message string `json:"message"`
+}
+
+func line(writer, seq int) []byte {
+	b, _ := json.Marshal(event{Level: "info", Writer: writer, Seq: seq, Message: "sinktest"})
+	return append(b, '\n')
+}
+
+func write(t *testing.T, w io.Writer, p []byte) {
+	t.Helper()
+	if n, err := w.Write(p); err != nil || n != len(p) {
+		t.Fatalf("Write = %d, %v; want %d, nil", n, err, len(p))
+	}
+}
+
+func closeSink(t *testing.T, h Harness) {
+	t.Helper()
+	if err := h.Sink.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+// decode parses received events, failing on anything that is not one intact event.
+func decode(t *testing.T, h Harness) []event {
+	t.Helper()
+	var out []event
+	for i, raw := range h.Received() {
+		var ev event
+		if err := json.Unmarshal(bytes.TrimSpace(raw), &ev); err != nil {
+			t.Fatalf("received event %d is not valid JSON (%v): %q", i, err, raw)
+		}
+		out = append(out, ev)
+	}
+	return out
+}
+
+func testOrdering(t *testing.T, h Harness) {
+	const n = 500
+	for i := 0; i < n; i++ {
+		write(t, h.Sink, line(0, i))
+	}
+	closeSink(t, h)
+	got := decode(t, h)
+	if len(got) != n {
+		t.Fatalf("received %d events, want %d", len(got), n)
+	}
+	for i, ev := range got {
+		if ev.Seq != i {
+			t.Fatalf("event %d has seq %d: sink reordered events", i, ev.Seq)
+		}
+	}
+}
+
+func testFlushOnClose(t *testing.T, h Harness) {
+	const n = 7 // well under any sensible batch size
+	for i := 0; i < n; i++ {
+		write(t, h.Sink, line(0, i))
+	}
+	closeSink(t, h)
+	if got := len(decode(t, h)); got != n {
+		t.Fatalf("after Close the destination has %d events, want %d", got, n)
+	}
+}
+
+func testConcurrency(t *testing.T, h Harness) {
+	const writers, per = 8, 200
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < per; i++ {
+				h.Sink.Write(line(w, i))
+			}
+		}(w)
+	}
+	wg.Wait()
+	closeSink(t, h)
+
+	got := decode(t, h)
+	next := make([]int, writers)
+	for _, ev := range got {
+		if ev.Writer < 0 || ev.Writer >= writers {
+			t.Fatalf("unexpected writer %d", ev.Writer)
+		}
+		if ev.Seq != next[ev.Writer] {
+			t.Fatalf("writer %d: got seq %d, want %d (lost, duplicated or reordered)", ev.Writer, ev.Seq, next[ev.Writer])
+		}
+		next[ev.Writer]++
+	}
+	if len(got) != writers*per {
+		t.Fatalf("received %d events, want %d", len(got), writers*per)
+	}
+}
+
+func testBufferReuse(t *testing.T, h Harness) {
+	buf := make([]byte, 0, 256)
+	const n = 50
+	for i := 0; i < n; i++ {
+		buf = append(buf[:0], line(0, i)...)
+		write(t, h.Sink, buf)
+		for j := range buf {
+			buf[j] = 'X' // what a reused zerolog buffer looks like to a sink that kept it
+		}
+	}
+	closeSink(t, h)
+	got := decode(t, h)
+	if len(got) != n {
+		t.Fatalf("received %d events, want %d", len(got), n)
+	}
+	for i, ev := range got {
+		if ev.Seq != i {
+			t.Fatalf("event %d has seq %d", i, ev.Seq)
+		}
+	}
+}
+
+func testWriteAfterClose(t *testing.T, h Harness) {
+	write(t, h.Sink, line(0, 0))
+	closeSink(t, h)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("Write after Close panicked: %v", r)
+		}
+	}()
+	h.Sink.Write(line(0, 1))
+	if err := h.Sink.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+}
+
+func testRetry(t *testing.T, h Harness) {
+	h.FailNext(2)
+	const n = 10
+	for i := 0; i < n; i++ {
+		write(t, h.Sink, line(0, i))
+	}
+	closeSink(t, h)
+	got := decode(t, h)
+	if len(got) != n {
+		t.Fatalf("received %d events after 2 transient failures, want %d", len(got), n)
+	}
+}
+
+// Recorder is an in-memory destination for harnesses: it implements
+// sink.Sender, records every accepted batch and can fail on demand.
+type Recorder struct {
+	mu     sync.Mutex
+	events [][]byte
+	fail   int
+}
+
+// NewRecorder returns an empty Recorder.
+func NewRecorder() *Recorder { return &Recorder{} }
+
+// ErrInjected is returned for deliveries failed via FailNext.
+var ErrInjected = errors.New("sinktest: injected failure")
+
+// Send implements sink.Sender.
+func (r *Recorder) Send(_ context.Context, batch [][]byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fail > 0 {
+		r.fail--
+		return ErrInjected
+	}
+	for _, ev := range batch {
+		r.events = append(r.events, append([]byte(nil), ev...))
+	}
+	return nil
+}
+
+// Write records p as a single event, so a Recorder can also stand in for a
+// plain io.Writer destination.
+func (r *Recorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fail > 0 {
+		r.fail--
+		return 0, ErrInjected
+	}
+	r.events = append(r.events, append([]byte(nil), p...))
+	return len(p), nil
+}
+
+// FailNext makes the next n deliveries fail.
+func (r *Recorder) FailNext(n int) {
+	r.mu.Lock()
+	r.fail = n
+	r.mu.Unlock()
+}
+
+// Events returns a copy of everything recorded so far.
+func (r *Recorder) Events() [][]byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([][]byte(nil), r.events...)
+}
@@ -0,0 +1,189 @@
+package sink
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	walPrefix = "seg-"
+	walSuffix = ".log"
+)
+
+// WAL spills undeliverable batches to a directory, one segment file per batch,
+// and replays them oldest first once the destination recovers. Segments are
+// newline-delimited events, so they can also be read with slog query.
+// When the directory grows past its byte limit the oldest segments are removed.
+type WAL struct {
+	dir      string
+	maxBytes int64
+
+	mu   sync.Mutex
+	seq  uint64
+	size int64
+}
+
+// OpenWAL opens (creating if needed) a WAL in dir holding at most maxBytes of
+// segments; maxBytes <= 0 means unlimited. Segments left by a previous run are
+// kept for replay.
+func OpenWAL(dir string, maxBytes int64) (*WAL, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	w := &WAL{dir: dir, maxBytes: maxBytes}
+	segs, err := w.segments()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range segs {
+		w.size += s.size
+		w.seq = max(w.seq, s.seq)
+	}
+	return w, nil
+}
+
+type segment struct {
+	path string
+	seq  uint64
+	size int64
+}
+
+// segments lists segment files oldest first.
+func (w *WAL) segments() ([]segment, error) {
+	ents, err := os.ReadDir(w.dir)
+	if err != nil {
+		return nil, err
+	}
+	var segs []segment
+	for _, e := range ents {
+		name := e.Name()
+		if !strings.HasPrefix(name, walPrefix) || !strings.HasSuffix(name, walSuffix) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, walPrefix), walSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		segs = append(segs, segment{path: filepath.Join(w.dir, name), seq: seq, size: info.Size()})
+	}
+	sort.Slice(segs, func(i, j int) bool { return segs[i].seq < segs[j].seq })
+	return segs, nil
+}
+
+// Append writes batch as a new segment. The segment appears atomically, so a
+// crash mid-write never leaves a partial batch to replay.
+func (w *WAL) Append(batch [][]byte) error {
+	var buf bytes.Buffer
+	for _, ev := range batch {
+		buf.Write(ev)
+		if len(ev) == 0 || ev[len(ev)-1] != '\n' {
+			buf.WriteByte('\n')
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.seq++
+	path := filepath.Join(w.dir, fmt.Sprintf("%s%020d%s", walPrefix, w.seq, walSuffix))
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	w.size += int64(buf.Len())
+	return w.trimLocked()
+}
+
+// trimLocked removes the oldest segments until the WAL fits maxBytes.
+func (w *WAL) trimLocked() error {
+	if w.maxBytes <= 0 || w.size <= w.maxBytes {
+		return nil
+	}
+	segs, err := w.segments()
+	if err != nil {
+		return err
+	}
+	for _, s := range segs {
+		if w.size <= w.maxBytes {
+			break
+		}
+		if err := os.Remove(s.path); err == nil {
+			w.size -= s.size
+		}
+	}
+	return nil
+}
+
+// Replay hands segments to fn oldest first, deleting each one fn accepts.
+// It stops at the first error, leaving that segment and newer ones in place.
+func (w *WAL) Replay(fn func(batch [][]byte) error) error {
+	w.mu.Lock()
+	segs, err := w.segments()
+	w.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	for _, s := range segs {
+		batch, err := readSegment(s.path)
+		if os.IsNotExist(err) {
+			continue // trimmed meanwhile
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+		w.mu.Lock()
+		if err := os.Remove(s.path); err == nil {
+			w.size -= s.size
+		}
+		w.mu.Unlock()
+	}
+	return nil
+}
+
+func readSegment(path string) ([][]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var batch [][]byte
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			batch = append(batch, line)
+		}
+		if err == io.EOF {
+			return batch, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Size returns the bytes currently held in segments.
+func (w *WAL) Size() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.size
+}
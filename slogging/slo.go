@@ -0,0 +1,149 @@
+package slogging
+
+import (
+	"github.com/rs/zerolog"
+	"sort"
+	"sync"
+	"time"
+)
+
+const defaultSLOReportInterval = time.Minute
+
+// SLO is the budget for calls to one downstream host (Options.HostSLOs).
+type SLO struct {
+	Latency   time.Duration // calls slower than this exceed the budget; 0 = no latency budget
+	Target    float64       // fraction of calls that must be within Latency (default 0.99)
+	ErrorRate float64       // fraction of calls allowed to fail; 0 = no error budget
+}
+
+// sloTracker checks the calls reported through RecordDependency against
+// Options.HostSLOs and emits one "slo_summary" per host and interval.
+type sloTracker struct {
+	log  *zerolog.Logger
+	slos map[string]SLO
+
+	mu  sync.Mutex
+	win map[string]*sloWindow
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+type sloWindow struct {
+	calls, errors, slow int
+}
+
+func startSLOTracker(log *zerolog.Logger, slos map[string]SLO, every time.Duration) *sloTracker {
+	if every <= 0 {
+		every = defaultSLOReportInterval
+	}
+	t := &sloTracker{
+		log:  log,
+		slos: make(map[string]SLO, len(slos)),
+		win:  make(map[string]*sloWindow),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	for h, s := range slos {
+		if s.Target <= 0 || s.Target > 1 {
+			s.Target = 0.99
+		}
+		t.slos[h] = s
+	}
+	go t.run(every)
+	return t
+}
+
+// observe counts one call. It reports whether host has an SLO and whether
+// the call exceeded its budget (too slow, or failed with an error budget set).
+func (t *sloTracker) observe(host string, latency time.Duration, failed bool) (budgeted, exceeded bool) {
+	s, ok := t.slos[host]
+	if !ok {
+		return false, false
+	}
+	slow := s.Latency > 0 && latency > s.Latency
+	t.mu.Lock()
+	w := t.win[host]
+	if w == nil {
+		w = &sloWindow{}
+		t.win[host] = w
+	}
+	w.calls++
+	if failed {
+		w.errors++
+	}
+	if slow {
+		w.slow++
+	}
+	t.mu.Unlock()
+	return true, slow || (failed && s.ErrorRate > 0)
+}
+
+func (t *sloTracker) run(every time.Duration) {
+	defer close(t.done)
+	tk := time.NewTicker(every)
+	defer tk.Stop()
+	for {
+		select {
+		case <-t.stop:
+			t.report(every)
+			return
+		case <-tk.C:
+			t.report(every)
+		}
+	}
+}
+
+// report emits a summary for every host called since the previous one: info
+// when within budget, warn when not.
+func (t *sloTracker) report(window time.Duration) {
+	t.mu.Lock()
+	win := t.win
+	t.win = make(map[string]*sloWindow)
+	t.mu.Unlock()
+
+	hosts := make([]string, 0, len(win))
+	for h := range win {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+	for _, h := range hosts {
+		w, s := win[h], t.slos[h]
+		within := float64(w.calls-w.slow) / float64(w.calls)
+		errRate := float64(w.errors) / float64(w.calls)
+		ok := (s.Latency == 0 || within >= s.Target) && (s.ErrorRate == 0 || errRate <= s.ErrorRate)
+		level := zerolog.InfoLevel
+		if !ok {
+			level = zerolog.WarnLevel
+		}
+		e := selfEventOn(t.log, level, "slo_summary").
+			Str("host", h).
+			Int("calls", w.calls).
+			Int("errors", w.errors).
+			Float64("error_rate", errRate)
+		if s.Latency > 0 {
+			e.Int("slow", w.slow).
+				Float64("within_latency", within).
+				Float64("latency_budget_ms", float64(s.Latency.Microseconds())/1000).
+				Float64("target", s.Target)
+		}
+		if s.ErrorRate > 0 {
+			e.Float64("error_budget", s.ErrorRate)
+		}
+		msg := "calls met their SLO"
+		if !ok {
+			msg = "calls missed their SLO"
+		}
+		e.Bool("compliant", ok).
+			Dur("window", window).
+			Msg(msg)
+	}
+}
+
+// Close emits a final summary and stops the tracker.
+func (t *sloTracker) Close() error {
+	t.once.Do(func() { close(t.stop) })
+	<-t.done
+	return nil
+}
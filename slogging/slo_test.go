@@ -0,0 +1,60 @@
+package slogging
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHostSLOs(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc", SLOReportInterval: time.Hour, HostSLOs: map[string]SLO{
+		"users:8080": {Latency: 10 * time.Millisecond, Target: 0.9},
+		"db:5432":    {ErrorRate: 0.5},
+	}})
+	h := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		for _, d := range []time.Duration{time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond, 50 * time.Millisecond} {
+			RecordDependency(ctx, "users:8080", "", d, false)
+		}
+		RecordDependency(ctx, "db:5432", "", time.Millisecond, false)
+		RecordDependency(ctx, "db:5432", "", time.Millisecond, true)
+		RecordDependency(ctx, "cache:6379", "", time.Second, true)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+	evs := c.withMessage(t, "request completed")
+	if len(evs) != 1 {
+		t.Fatalf("got %d access lines, want 1", len(evs))
+	}
+	deps, _ := evs[0][FieldDependencies].([]any)
+	if len(deps) != 3 {
+		t.Fatalf("%s = %v, want 3 hosts", FieldDependencies, deps)
+	}
+	// The slow users call and the failed db call are over budget; the cache
+	// has no SLO, so no count at all.
+	for i, want := range []any{1.0, 1.0, nil} {
+		if got := deps[i].(map[string]any)[FieldBudgetExceeded]; got != want {
+			t.Errorf("%v: %s = %v, want %v", deps[i], FieldBudgetExceeded, got, want)
+		}
+	}
+
+	// Close emits the summaries of the last window.
+	Close(context.Background())
+	sum := map[string]map[string]any{}
+	for _, ev := range c.selfEvents(t, "slo_summary") {
+		sum[ev["host"].(string)] = ev
+	}
+	if len(sum) != 2 {
+		t.Fatalf("summaries = %v, want users and db", sum)
+	}
+	// 3 of 4 users calls within 10ms misses the 90% target.
+	if u := sum["users:8080"]; u["level"] != "warn" || u["compliant"] != false || u["calls"] != 4.0 || u["slow"] != 1.0 || u["within_latency"] != 0.75 {
+		t.Errorf("users summary = %v", u)
+	}
+	// 1 failure in 2 calls is just within a 50% error budget.
+	if d := sum["db:5432"]; d["level"] != "info" || d["compliant"] != true || d["error_rate"] != 0.5 || d["slow"] != nil {
+		t.Errorf("db summary = %v", d)
+	}
+}
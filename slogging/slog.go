@@ -0,0 +1,183 @@
+package slogging
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+	"log/slog"
+)
+
+// NewSlogHandler returns a log/slog Handler that writes through the installed
+// pipeline, so slog-based libraries land in the same stream with the same base
+// fields, context fields, sampling and sinks:
+//
+//	slog.SetDefault(slog.New(slogging.NewSlogHandler()))
+//
+// Each record goes through From(ctx), so a context prepared by the middleware
+// or the With* helpers contributes its fields, and re-Init is picked up. slog
+// groups become nested objects. The event time is the pipeline's, not the
+// record's.
+func NewSlogHandler() slog.Handler {
+	return &slogHandler{}
+}
+
+type slogHandler struct {
+	// goas holds WithGroup and WithAttrs calls in order. Each entry is either
+	// a group name or a batch of attrs.
+	goas []groupOrAttrs
+}
+
+type groupOrAttrs struct {
+	group string
+	attrs []slog.Attr
+}
+
+// Enabled implements slog.Handler.
+func (h *slogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	lvl := zerologLevel(level)
+	if lvl < From(ctx).GetLevel() {
+		return false
+	}
+	if ctx != nil {
+		if r, ok := requestLevel(ctx); ok {
+			return lvl >= r
+		}
+		if debugTailOf(ctx) != nil {
+			return true // levelGate decides between the tail and the output
+		}
+	}
+	if p := current.Load(); p != nil {
+		return lvl >= p.levelOf("")
+	}
+	return true
+}
+
+// Handle implements slog.Handler.
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	e := From(ctx).WithLevel(zerologLevel(r.Level))
+	if e == nil {
+		return nil
+	}
+	var rec []slog.Attr
+	if r.NumAttrs() > 0 {
+		rec = make([]slog.Attr, 0, r.NumAttrs())
+		r.Attrs(func(a slog.Attr) bool {
+			rec = append(rec, a)
+			return true
+		})
+	}
+	appendGroups(e, h.goas, rec)
+	e.Msg(r.Message)
+	return nil
+}
+
+// WithAttrs implements slog.Handler.
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return h.with(groupOrAttrs{attrs: attrs})
+}
+
+// WithGroup implements slog.Handler.
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.with(groupOrAttrs{group: name})
+}
+
+func (h *slogHandler) with(g groupOrAttrs) *slogHandler {
+	goas := make([]groupOrAttrs, len(h.goas), len(h.goas)+1)
+	copy(goas, h.goas)
+	return &slogHandler{goas: append(goas, g)}
+}
+
+// appendGroups writes the handler's attrs and then the record's into e,
+// opening a nested object for each group. It reports whether anything was
+// written, since slog drops groups that end up empty.
+func appendGroups(e *zerolog.Event, goas []groupOrAttrs, rec []slog.Attr) bool {
+	n := false
+	for i, g := range goas {
+		if g.group != "" {
+			d := zerolog.Dict()
+			if appendGroups(d, goas[i+1:], rec) {
+				e.Dict(g.group, d)
+				return true
+			}
+			return n
+		}
+		for _, a := range g.attrs {
+			n = appendAttr(e, a) || n
+		}
+	}
+	for _, a := range rec {
+		n = appendAttr(e, a) || n
+	}
+	return n
+}
+
+// appendAttr writes one attr into e, reporting whether it wrote anything.
+func appendAttr(e *zerolog.Event, a slog.Attr) bool {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return false
+	}
+	v := a.Value
+	switch v.Kind() {
+	case slog.KindString:
+		e.Str(a.Key, v.String())
+	case slog.KindInt64:
+		e.Int64(a.Key, v.Int64())
+	case slog.KindUint64:
+		e.Uint64(a.Key, v.Uint64())
+	case slog.KindFloat64:
+		e.Float64(a.Key, v.Float64())
+	case slog.KindBool:
+		e.Bool(a.Key, v.Bool())
+	case slog.KindDuration:
+		e.Dur(a.Key, v.Duration())
+	case slog.KindTime:
+		e.Time(a.Key, v.Time())
+	case slog.KindGroup:
+		attrs := v.Group()
+		if a.Key == "" { // inline, per slog's rules
+			n := false
+			for _, ga := range attrs {
+				n = appendAttr(e, ga) || n
+			}
+			return n
+		}
+		d := zerolog.Dict()
+		n := false
+		for _, ga := range attrs {
+			n = appendAttr(d, ga) || n
+		}
+		if !n {
+			return false
+		}
+		e.Dict(a.Key, d)
+	default:
+		if err, ok := v.Any().(error); ok {
+			e.AnErr(a.Key, err)
+		} else {
+			e.Interface(a.Key, v.Any())
+		}
+	}
+	return true
+}
+
+// zerologLevel maps slog levels, which may sit between the named ones, down
+// to the nearest zerolog level.
+func zerologLevel(l slog.Level) zerolog.Level {
+	switch {
+	case l < slog.LevelDebug:
+		return zerolog.TraceLevel
+	case l < slog.LevelInfo:
+		return zerolog.DebugLevel
+	case l < slog.LevelWarn:
+		return zerolog.InfoLevel
+	case l < slog.LevelError:
+		return zerolog.WarnLevel
+	}
+	return zerolog.ErrorLevel
+}
@@ -0,0 +1,39 @@
+package slogging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+	"testing"
+)
+
+func TestSlogHandler(t *testing.T) {
+	c := initCapture(t, Options{Level: "info"})
+	l := slog.New(NewSlogHandler())
+	ctx := WithRequestID(context.Background(), "r1")
+
+	l.DebugContext(ctx, "hidden")
+	if l.Enabled(ctx, slog.LevelDebug) {
+		t.Error("debug enabled at level info")
+	}
+	l.With("svc", "billing").WithGroup("http").With("method", "GET").
+		InfoContext(ctx, "request", "status", 200, slog.Group("empty"), "err", errors.New("boom"))
+	l.Log(ctx, slog.LevelWarn+2, "between levels")
+
+	if evs := c.withMessage(t, "hidden"); len(evs) != 0 {
+		t.Errorf("debug written: %v", evs)
+	}
+	evs := c.withMessage(t, "request")
+	if len(evs) != 1 {
+		t.Fatalf("got %d request events", len(evs))
+	}
+	ev := evs[0]
+	want := map[string]any{"method": "GET", "status": float64(200), "err": "boom"}
+	if ev["svc"] != "billing" || ev[FieldRequestID] != "r1" || ev["level"] != "info" || !reflect.DeepEqual(ev["http"], want) {
+		t.Errorf("event = %v, want svc and request_id at the top and http = %v", ev, want)
+	}
+	if evs := c.withMessage(t, "between levels"); len(evs) != 1 || evs[0]["level"] != "warn" {
+		t.Errorf("WARN+2 events = %v, want one at warn", evs)
+	}
+}
@@ -0,0 +1,170 @@
+package sloggingtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"github.com/rs/zerolog"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// maxBudgetMessages bounds the per-message tally kept for the failure report.
+const maxBudgetMessages = 1000
+
+// budgetTop is how many of the noisiest messages a failure lists.
+const budgetTop = 5
+
+// Budget is how much a test, or a whole test binary, may log at MinLevel
+// ("info" when empty) and above. A zero limit is not checked. Events without
+// a level (written at zerolog.NoLevel, such as exposure and audit records)
+// and slogging's own events (those with slogging_event) are not counted:
+// they are not the code under test logging.
+type Budget struct {
+	MaxEvents int
+	MaxBytes  int
+	MinLevel  string
+}
+
+// BudgetWriter counts the events written through it against a Budget. Use it
+// as slogging.Options.ExtraWriter, so it sees what the pipeline writes:
+//
+//	func TestMain(m *testing.M) {
+//		budget := sloggingtest.NewBudgetWriter(sloggingtest.Budget{MaxEvents: 2000, MaxBytes: 1 << 20})
+//		slogging.Init(slogging.Options{Service: "billing", ExtraWriter: budget})
+//		code := m.Run()
+//		if err := budget.Err(); err != nil {
+//			fmt.Fprintln(os.Stderr, err)
+//			code = 1
+//		}
+//		os.Exit(code)
+//	}
+//
+// For one test, NewBudget checks at cleanup instead. It is safe for
+// concurrent use.
+type BudgetWriter struct {
+	budget Budget
+	min    zerolog.Level
+
+	mu       sync.Mutex
+	events   int
+	bytes    int
+	messages map[string]int
+}
+
+// NewBudgetWriter returns a BudgetWriter for b. An unknown MinLevel counts
+// every level.
+func NewBudgetWriter(b Budget) *BudgetWriter {
+	lvl := zerolog.InfoLevel
+	if b.MinLevel != "" {
+		if l, err := zerolog.ParseLevel(strings.ToLower(b.MinLevel)); err == nil {
+			lvl = l
+		} else {
+			lvl = zerolog.TraceLevel
+		}
+	}
+	return &BudgetWriter{budget: b, min: lvl, messages: make(map[string]int)}
+}
+
+// NewBudget returns a BudgetWriter whose budget is checked when t ends,
+// failing the test when it was exceeded.
+func NewBudget(t testing.TB, b Budget) *BudgetWriter {
+	w := NewBudgetWriter(b)
+	t.Cleanup(func() {
+		t.Helper()
+		if err := w.Err(); err != nil {
+			t.Error(err)
+		}
+	})
+	return w
+}
+
+// Write counts an event whose level is read from the JSON line.
+func (w *BudgetWriter) Write(p []byte) (int, error) {
+	var ev struct {
+		Level   string `json:"level"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(p, &ev) != nil {
+		return len(p), nil
+	}
+	lvl, err := zerolog.ParseLevel(ev.Level)
+	if err != nil {
+		lvl = zerolog.NoLevel
+	}
+	w.count(lvl, ev.Message, p)
+	return len(p), nil
+}
+
+// WriteLevel counts an event at level.
+func (w *BudgetWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if level < w.min || level == zerolog.NoLevel {
+		return len(p), nil
+	}
+	var ev struct {
+		Message string `json:"message"`
+	}
+	json.Unmarshal(p, &ev)
+	w.count(level, ev.Message, p)
+	return len(p), nil
+}
+
+var selfMarker = []byte(`"` + slogging.FieldSloggingEvent + `":`)
+
+func (w *BudgetWriter) count(level zerolog.Level, msg string, p []byte) {
+	if level < w.min || level == zerolog.NoLevel || bytes.Contains(p, selfMarker) {
+		return
+	}
+	n := len(p)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.events++
+	w.bytes += n
+	if _, ok := w.messages[msg]; ok || len(w.messages) < maxBudgetMessages {
+		w.messages[msg]++
+	}
+}
+
+// Err describes how the budget was exceeded, naming the most frequent
+// messages, or returns nil while within it.
+func (w *BudgetWriter) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var over []string
+	if w.budget.MaxEvents > 0 && w.events > w.budget.MaxEvents {
+		over = append(over, fmt.Sprintf("%d events (budget %d)", w.events, w.budget.MaxEvents))
+	}
+	if w.budget.MaxBytes > 0 && w.bytes > w.budget.MaxBytes {
+		over = append(over, fmt.Sprintf("%d bytes (budget %d)", w.bytes, w.budget.MaxBytes))
+	}
+	if len(over) == 0 {
+		return nil
+	}
+	type tally struct {
+		msg string
+		n   int
+	}
+	tallies := make([]tally, 0, len(w.messages))
+	for m, n := range w.messages {
+		tallies = append(tallies, tally{m, n})
+	}
+	sort.Slice(tallies, func(i, j int) bool {
+		if tallies[i].n != tallies[j].n {
+			return tallies[i].n > tallies[j].n
+		}
+		return tallies[i].msg < tallies[j].msg
+	})
+	var b strings.Builder
+	fmt.Fprintf(&b, "sloggingtest: logged %s at %s and above; noisiest messages:", strings.Join(over, " and "), w.min)
+	for i, t := range tallies {
+		if i == budgetTop {
+			break
+		}
+		fmt.Fprintf(&b, "\n\t%6d  %q", t.n, t.msg)
+	}
+	return errors.New(b.String())
+}
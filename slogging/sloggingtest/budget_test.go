@@ -0,0 +1,52 @@
+package sloggingtest
+
+import (
+	"context"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"github.com/rs/zerolog"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBudgetWriter(t *testing.T) {
+	w := NewBudgetWriter(Budget{MaxEvents: 3, MinLevel: "info"})
+	l := zerolog.New(w)
+	l.Debug().Msg("below the level")
+	l.Info().Msg("noisy")
+	l.Info().Msg("noisy")
+	l.Warn().Msg("once")
+	if err := w.Err(); err != nil {
+		t.Fatalf("within budget: %v", err)
+	}
+	l.Info().Msg("noisy")
+	err := w.Err()
+	if err == nil || !strings.Contains(err.Error(), "4 events (budget 3)") || !strings.Contains(err.Error(), `3  "noisy"`) {
+		t.Errorf("Err() = %v", err)
+	}
+}
+
+func TestBudgetSkipsNoLevelAndSelfEvents(t *testing.T) {
+	w := NewBudgetWriter(Budget{MaxEvents: 1})
+	l := zerolog.New(w)
+	l.WithLevel(zerolog.NoLevel).Str(slogging.FieldEvent, slogging.ExposureEvent).Msg("exposure")
+	l.Log().Msg("no level")
+	w.Write([]byte(`{"level":"warn","component":"slogging","slogging_event":"file_sink_degraded","message":"x"}` + "\n"))
+	w.Write([]byte(`{"message":"unparsable level"}` + "\n"))
+	l.Info().Msg("counted")
+	if err := w.Err(); err != nil {
+		t.Errorf("Err() = %v, want only one counted event", err)
+	}
+}
+
+func TestBudgetAsExtraWriter(t *testing.T) {
+	f := &fakeTB{T: t}
+	w := NewBudget(f, Budget{MaxBytes: 1})
+	slogging.Init(slogging.Options{Service: "svc", FilePath: filepath.Join(t.TempDir(), "app.log"), ExtraWriter: w})
+	t.Cleanup(func() { slogging.Close(context.Background()) })
+	slogging.From(context.Background()).Info().Msg("over budget")
+	f.runCleanups()
+	if errs := f.failures(); len(errs) != 1 || !strings.Contains(errs[0], "over budget") {
+		t.Errorf("failures = %q", errs)
+	}
+}
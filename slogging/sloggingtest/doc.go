@@ -0,0 +1,25 @@
+// Package sloggingtest helps tests assert on what code under test logs.
+//
+// A Mock captures every event written through it and checks expectations
+// when the test ends:
+//
+//	func TestCharge(t *testing.T) {
+//		ml := sloggingtest.NewMock(t)
+//		ml.ExpectError().WithField("order_id", "42").Times(1)
+//		ml.Expect("info").WithMessage("charged").Never()
+//
+//		charge(ml.Context(context.Background()), "42")
+//	}
+//
+// Code under test keeps using slogging.From(ctx); the mock's logger is stored
+// in the context the same way slogging.IntoContext stores one. Events logged
+// through slogging.New(ctx) are not captured: a Logger from New always writes
+// to the installed pipeline.
+//
+// Snapshot compares everything a function logs with a golden file, for code
+// whose log format is part of its contract.
+//
+// A Budget fails a test, or a CI run of a whole test binary, that logs more
+// than a set number of events or bytes at info and above, so noisy hot paths
+// are caught before they ship; see BudgetWriter.
+package sloggingtest
@@ -0,0 +1,244 @@
+package sloggingtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"github.com/rs/zerolog"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Mock is a logger that records events and verifies expectations on them.
+// It is safe for concurrent use.
+type Mock struct {
+	t      testing.TB
+	logger zerolog.Logger
+
+	mu      sync.Mutex
+	events  []slogging.Entry
+	expects []*Expectation
+}
+
+// NewMock returns a Mock that logs at every level and verifies its
+// expectations in t.Cleanup.
+func NewMock(t testing.TB) *Mock {
+	m := &Mock{t: t}
+	m.logger = zerolog.New(m).Level(zerolog.TraceLevel).With().Timestamp().Logger()
+	t.Cleanup(m.Verify)
+	return m
+}
+
+// Write records one JSON event. Lines that are not JSON fail the test.
+func (m *Mock) Write(p []byte) (int, error) {
+	var e slogging.Entry
+	if err := json.Unmarshal(p, &e); err != nil {
+		m.t.Errorf("sloggingtest: non-JSON event %q: %v", p, err)
+		return len(p), nil
+	}
+	m.mu.Lock()
+	m.events = append(m.events, e)
+	m.mu.Unlock()
+	return len(p), nil
+}
+
+// Logger returns the mock's logger.
+func (m *Mock) Logger() *zerolog.Logger { return &m.logger }
+
+// Context returns ctx carrying the mock's logger, for slogging.From and the
+// helpers built on it. The Loggers slogging.New returns do not read it: their
+// events go to the installed pipeline and the mock never sees them.
+func (m *Mock) Context(ctx context.Context) context.Context {
+	return m.logger.WithContext(ctx)
+}
+
+// Events returns a copy of the events recorded so far, oldest first.
+func (m *Mock) Events() []slogging.Entry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]slogging.Entry(nil), m.events...)
+}
+
+// Reset forgets recorded events; expectations are kept.
+func (m *Mock) Reset() {
+	m.mu.Lock()
+	m.events = nil
+	m.mu.Unlock()
+}
+
+// Expect adds an expectation for events at level ("debug", "info", ...).
+// An empty level matches any level. Like gomock, an expectation without a
+// call count must match exactly once.
+func (m *Mock) Expect(level string) *Expectation {
+	x := &Expectation{level: level, min: 1, max: 1}
+	m.mu.Lock()
+	m.expects = append(m.expects, x)
+	m.mu.Unlock()
+	return x
+}
+
+// ExpectDebug, ExpectInfo, ExpectWarn and ExpectError are Expect for that level.
+func (m *Mock) ExpectDebug() *Expectation { return m.Expect(zerolog.LevelDebugValue) }
+func (m *Mock) ExpectInfo() *Expectation  { return m.Expect(zerolog.LevelInfoValue) }
+func (m *Mock) ExpectWarn() *Expectation  { return m.Expect(zerolog.LevelWarnValue) }
+func (m *Mock) ExpectError() *Expectation { return m.Expect(zerolog.LevelErrorValue) }
+
+// Verify reports every expectation whose match count is out of range. NewMock
+// schedules it at cleanup; call it earlier to check at a specific point.
+func (m *Mock) Verify() {
+	m.t.Helper()
+	m.mu.Lock()
+	events := append([]slogging.Entry(nil), m.events...)
+	expects := append([]*Expectation(nil), m.expects...)
+	m.mu.Unlock()
+	for _, x := range expects {
+		n := 0
+		for _, e := range events {
+			if x.matches(e) {
+				n++
+			}
+		}
+		if n < x.min || (x.max >= 0 && n > x.max) {
+			m.t.Errorf("sloggingtest: expected %s %s, got %d matching events%s", x, x.count(), n, dump(events))
+		}
+	}
+}
+
+// Expectation describes events a test requires. Methods return the receiver
+// so calls chain; configure an expectation before the code under test runs.
+type Expectation struct {
+	level    string
+	msg      *string
+	contains []string
+	fields   []fieldMatch
+	min, max int // max < 0: unbounded
+}
+
+type fieldMatch struct {
+	key  string
+	want any // JSON-normalized
+	raw  any
+}
+
+// WithField requires field key to equal value. value is compared after a JSON
+// round trip, so WithField("count", 3) matches the number 3 however it was logged.
+func (x *Expectation) WithField(key string, value any) *Expectation {
+	x.fields = append(x.fields, fieldMatch{key: key, want: normalize(value), raw: value})
+	return x
+}
+
+// WithMessage requires the message to equal msg.
+func (x *Expectation) WithMessage(msg string) *Expectation {
+	x.msg = &msg
+	return x
+}
+
+// WithMessageContaining requires the message to contain sub.
+func (x *Expectation) WithMessageContaining(sub string) *Expectation {
+	x.contains = append(x.contains, sub)
+	return x
+}
+
+// Times requires exactly n matching events.
+func (x *Expectation) Times(n int) *Expectation {
+	x.min, x.max = n, n
+	return x
+}
+
+// AtLeast requires n or more matching events.
+func (x *Expectation) AtLeast(n int) *Expectation {
+	x.min, x.max = n, -1
+	return x
+}
+
+// AtMost requires no more than n matching events.
+func (x *Expectation) AtMost(n int) *Expectation {
+	x.min, x.max = 0, n
+	return x
+}
+
+// Never requires that no event matches.
+func (x *Expectation) Never() *Expectation { return x.Times(0) }
+
+func (x *Expectation) matches(e slogging.Entry) bool {
+	if x.level != "" && e.Level() != x.level {
+		return false
+	}
+	if x.msg != nil && e.Message() != *x.msg {
+		return false
+	}
+	for _, s := range x.contains {
+		if !strings.Contains(e.Message(), s) {
+			return false
+		}
+	}
+	for _, f := range x.fields {
+		v, ok := e[f.key]
+		if !ok || !reflect.DeepEqual(v, f.want) {
+			return false
+		}
+	}
+	return true
+}
+
+func (x *Expectation) String() string {
+	var b strings.Builder
+	if x.level == "" {
+		b.WriteString("event")
+	} else {
+		b.WriteString(x.level + " event")
+	}
+	if x.msg != nil {
+		fmt.Fprintf(&b, " message=%q", *x.msg)
+	}
+	for _, s := range x.contains {
+		fmt.Fprintf(&b, " message~%q", s)
+	}
+	for _, f := range x.fields {
+		fmt.Fprintf(&b, " %s=%#v", f.key, f.raw)
+	}
+	return b.String()
+}
+
+func (x *Expectation) count() string {
+	switch {
+	case x.max < 0:
+		return fmt.Sprintf("at least %d time(s)", x.min)
+	case x.min == x.max:
+		return fmt.Sprintf("exactly %d time(s)", x.min)
+	default:
+		return fmt.Sprintf("%d to %d time(s)", x.min, x.max)
+	}
+}
+
+// normalize maps v to what decoding its JSON encoding yields, so expected
+// values compare equal to decoded event fields.
+func normalize(v any) any {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out any
+	if json.Unmarshal(b, &out) != nil {
+		return v
+	}
+	return out
+}
+
+// dump lists recorded events for failure messages.
+func dump(events []slogging.Entry) string {
+	if len(events) == 0 {
+		return " (no events recorded)"
+	}
+	var b strings.Builder
+	b.WriteString("; recorded:")
+	for _, e := range events {
+		line, _ := json.Marshal(e)
+		b.WriteString("\n\t")
+		b.Write(line)
+	}
+	return b.String()
+}
@@ -0,0 +1,135 @@
+package sloggingtest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeTB records failures and cleanups instead of acting on them, so the
+// helpers' own failure paths can be tested.
+type fakeTB struct {
+	*testing.T
+	mu       sync.Mutex
+	errs     []string
+	cleanups []func()
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.mu.Lock()
+	f.errs = append(f.errs, fmt.Sprintf(format, args...))
+	f.mu.Unlock()
+}
+
+func (f *fakeTB) Error(args ...any)                 { f.Errorf("%s", fmt.Sprint(args...)) }
+func (f *fakeTB) Fatalf(format string, args ...any) { f.Errorf(format, args...) }
+func (f *fakeTB) Cleanup(fn func())                 { f.cleanups = append(f.cleanups, fn) }
+
+func (f *fakeTB) runCleanups() {
+	for i := len(f.cleanups) - 1; i >= 0; i-- {
+		f.cleanups[i]()
+	}
+}
+
+func (f *fakeTB) failures() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.errs...)
+}
+
+func TestMockCapturesLevelsAndFields(t *testing.T) {
+	m := NewMock(t)
+	m.ExpectInfo().WithMessage("charged").WithField("order_id", "42").WithField("amount", 3)
+	m.ExpectWarn().WithMessageContaining("retry").Times(2)
+	m.ExpectError().Never()
+
+	ctx := m.Context(context.Background())
+	slogging.From(ctx).Info().Str("order_id", "42").Int("amount", 3).Msg("charged")
+	slogging.From(ctx).Warn().Msg("retry 1")
+	slogging.From(ctx).Warn().Msg("retry 2")
+
+	evs := m.Events()
+	if len(evs) != 3 {
+		t.Fatalf("recorded %d events, want 3", len(evs))
+	}
+	if evs[0].Level() != "info" || evs[0]["order_id"] != "42" || evs[0]["amount"] != float64(3) {
+		t.Errorf("first event = %v", evs[0])
+	}
+}
+
+func TestMockReset(t *testing.T) {
+	m := NewMock(t)
+	m.Logger().Info().Msg("before")
+	m.Reset()
+	m.ExpectInfo().WithMessage("before").Never()
+	m.Logger().Info().Msg("after")
+	if evs := m.Events(); len(evs) != 1 || evs[0].Message() != "after" {
+		t.Errorf("events after Reset = %v", evs)
+	}
+}
+
+func TestMockReportsUnmetExpectations(t *testing.T) {
+	f := &fakeTB{T: t}
+	m := NewMock(f)
+	m.ExpectError().WithField("order_id", "42")
+	m.ExpectInfo().Never()
+	m.Logger().Info().Msg("unexpected")
+	f.runCleanups()
+
+	errs := f.failures()
+	if len(errs) != 2 {
+		t.Fatalf("got %d failures, want 2: %q", len(errs), errs)
+	}
+	if !strings.Contains(errs[0], `order_id="42"`) || !strings.Contains(errs[1], "unexpected") {
+		t.Errorf("failures do not describe the expectation and the events: %q", errs)
+	}
+}
+
+// TestMockLeavesThePipelineInPlace checks that a Mock never touches the
+// installed pipeline: events outside its context, and those of slogging.New
+// Loggers, keep going to Init's writers, during the test and after its
+// cleanup, while the pipeline's level does not filter the mock's events.
+func TestMockLeavesThePipelineInPlace(t *testing.T) {
+	var out bytes.Buffer
+	var mu sync.Mutex
+	slogging.Init(slogging.Options{
+		Service:     "svc",
+		Level:       "warn",
+		FilePath:    filepath.Join(t.TempDir(), "app.log"),
+		ExtraWriter: writerFunc(func(p []byte) (int, error) { mu.Lock(); defer mu.Unlock(); return out.Write(p) }),
+	})
+	t.Cleanup(func() { slogging.Close(context.Background()) })
+
+	var mock *Mock
+	t.Run("mock", func(t *testing.T) {
+		mock = NewMock(t)
+		ctx := mock.Context(context.Background())
+		slogging.From(ctx).Info().Msg("to mock")
+		slogging.From(ctx).Trace().Msg("trace to mock")
+		slogging.New(ctx).Warn().Msg("new during")
+		slogging.From(context.Background()).Warn().Msg("during")
+	})
+	slogging.From(context.Background()).Warn().Msg("after")
+
+	mu.Lock()
+	got := out.String()
+	mu.Unlock()
+	if strings.Contains(got, "to mock") || !strings.Contains(got, "new during") ||
+		!strings.Contains(got, `"during"`) || !strings.Contains(got, "after") {
+		t.Errorf("pipeline output:\n%s", got)
+	}
+	if evs := mock.Events(); len(evs) != 2 || evs[0].Message() != "to mock" || evs[1].Message() != "trace to mock" {
+		t.Errorf("mock events = %v", evs)
+	}
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
@@ -0,0 +1,142 @@
+package sloggingtest
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"github.com/rs/zerolog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var update = flag.Bool("sloggingtest.update", false, "rewrite sloggingtest golden files")
+
+// Snapshot runs run with a context carrying a capturing logger and compares
+// the emitted events, byte for byte and in field order, with the golden file
+// testdata/<test name>.golden.jsonl. Timestamps become "<time>", seq becomes
+// "<seq>", event IDs become "<event_id:N>" numbered by first appearance (so
+// parent_event_id links stay checkable) and caller paths are cut to the
+// file's base name, so the golden file is stable across runs and checkouts.
+//
+// Run the test with -sloggingtest.update (or SLOGGINGTEST_UPDATE=1) to write
+// the golden file from the current output.
+func Snapshot(t testing.TB, run func(ctx context.Context)) {
+	t.Helper()
+	var c capture
+	l := zerolog.New(&c).Level(zerolog.TraceLevel).With().Timestamp().Logger()
+	run(l.WithContext(context.Background()))
+	got := c.bytes()
+
+	path := filepath.Join("testdata", goldenName(t.Name())+".golden.jsonl")
+	if *update || os.Getenv("SLOGGINGTEST_UPDATE") != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("sloggingtest: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("sloggingtest: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("sloggingtest: %v (run with -sloggingtest.update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("sloggingtest: events differ from %s%s", path, lineDiff(want, got))
+	}
+}
+
+// capture collects normalized event lines.
+type capture struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+	ids map[string]int // event ID -> order of first appearance
+}
+
+func (c *capture) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	line := c.normalizeIDs(normalizeLine(bytes.TrimRight(p, "\n")))
+	c.buf.Write(line)
+	c.buf.WriteByte('\n')
+	c.mu.Unlock()
+	return len(p), nil
+}
+
+func (c *capture) bytes() []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return bytes.Clone(c.buf.Bytes())
+}
+
+var (
+	timeRe    = regexp.MustCompile(`"` + regexp.QuoteMeta(zerolog.TimestampFieldName) + `":("[^"]*"|[0-9.eE+-]+)`)
+	callerRe  = regexp.MustCompile(`("` + regexp.QuoteMeta(zerolog.CallerFieldName) + `":")([^"]*/)?([^"/]*")`)
+	seqRe     = regexp.MustCompile(`"` + slogging.FieldSeq + `":[0-9]+`)
+	eventIDRe = regexp.MustCompile(`"(` + slogging.FieldEventID + `|` + slogging.FieldParentEventID + `)":"([^"]*)"`)
+)
+
+// normalizeIDs replaces event IDs with their order of first appearance, so a
+// parent_event_id still points at the event that carries the same number.
+// c.mu must be held.
+func (c *capture) normalizeIDs(p []byte) []byte {
+	return eventIDRe.ReplaceAllFunc(p, func(m []byte) []byte {
+		sub := eventIDRe.FindSubmatch(m)
+		n, ok := c.ids[string(sub[2])]
+		if !ok {
+			if c.ids == nil {
+				c.ids = make(map[string]int)
+			}
+			n = len(c.ids) + 1
+			c.ids[string(sub[2])] = n
+		}
+		return fmt.Appendf(nil, `"%s":"<event_id:%d>"`, sub[1], n)
+	})
+}
+
+// normalizeLine masks the timestamp and seq and strips directories from the
+// caller.
+func normalizeLine(p []byte) []byte {
+	p = timeRe.ReplaceAll(p, []byte(`"`+zerolog.TimestampFieldName+`":"<time>"`))
+	p = seqRe.ReplaceAll(p, []byte(`"`+slogging.FieldSeq+`":"<seq>"`))
+	return callerRe.ReplaceAllFunc(p, func(m []byte) []byte {
+		sub := callerRe.FindSubmatch(m)
+		// "pkg.Func /abs/dir/file.go:42" keeps the function: drop only the directory.
+		pre := sub[1]
+		if dir := sub[2]; len(dir) > 0 {
+			if i := bytes.LastIndexByte(dir, ' '); i >= 0 {
+				pre = append(bytes.Clone(pre), dir[:i+1]...)
+			}
+		}
+		return append(bytes.Clone(pre), sub[3]...)
+	})
+}
+
+// goldenName maps a (sub)test name to a file name.
+func goldenName(name string) string {
+	return strings.NewReplacer("/", "__", " ", "_", ":", "_").Replace(name)
+}
+
+// lineDiff describes the first differing line between want and got.
+func lineDiff(want, got []byte) string {
+	w := strings.Split(strings.TrimSuffix(string(want), "\n"), "\n")
+	g := strings.Split(strings.TrimSuffix(string(got), "\n"), "\n")
+	for i := 0; i < max(len(w), len(g)); i++ {
+		var wl, gl string
+		if i < len(w) {
+			wl = w[i]
+		}
+		if i < len(g) {
+			gl = g[i]
+		}
+		if wl != gl {
+			return fmt.Sprintf(" at event %d (want %d events, got %d):\n\twant: %s\n\t got: %s", i+1, len(w), len(g), wl, gl)
+		}
+	}
+	return ""
+}
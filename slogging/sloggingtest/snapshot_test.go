@@ -0,0 +1,69 @@
+package sloggingtest
+
+import (
+	"context"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// logChain logs an event and a child linked to it, with the volatile fields
+// a real pipeline stamps.
+func logChain(parent, child string, seq int64, msg string) func(context.Context) {
+	return func(ctx context.Context) {
+		l := slogging.From(ctx)
+		l.Info().Int64(slogging.FieldSeq, seq).Str(slogging.FieldEventID, parent).Msg("import started")
+		l.Info().Int64(slogging.FieldSeq, seq+1).Str(slogging.FieldEventID, child).
+			Str(slogging.FieldParentEventID, parent).Msg(msg)
+	}
+}
+
+func TestSnapshotUpdateThenCompare(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	t.Setenv("SLOGGINGTEST_UPDATE", "1")
+	Snapshot(t, logChain("a1", "b2", 7, "row imported"))
+	golden, err := os.ReadFile(filepath.Join("testdata", "TestSnapshotUpdateThenCompare.golden.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"level":"info","seq":"<seq>","event_id":"<event_id:1>","time":"<time>","message":"import started"}
+{"level":"info","seq":"<seq>","event_id":"<event_id:2>","parent_event_id":"<event_id:1>","time":"<time>","message":"row imported"}
+`
+	if string(golden) != want {
+		t.Fatalf("golden file:\n%s\nwant:\n%s", golden, want)
+	}
+
+	t.Setenv("SLOGGINGTEST_UPDATE", "")
+	// Different IDs, seq and time normalize to the same lines.
+	Snapshot(t, logChain("c3", "d4", 90, "row imported"))
+
+	f := &fakeTB{T: t}
+	Snapshot(f, logChain("a1", "b2", 7, "row skipped"))
+	if errs := f.failures(); len(errs) != 1 || !strings.Contains(errs[0], "at event 2") || !strings.Contains(errs[0], "row skipped") {
+		t.Errorf("changed output reported as %q", errs)
+	}
+}
+
+func TestSnapshotMissingGolden(t *testing.T) {
+	t.Chdir(t.TempDir())
+	f := &fakeTB{T: t}
+	Snapshot(f, logChain("a1", "b2", 1, "row imported"))
+	if errs := f.failures(); len(errs) == 0 || !strings.Contains(errs[0], "-sloggingtest.update") {
+		t.Errorf("missing golden file reported as %q", errs)
+	}
+}
+
+func TestNormalizeLineCaller(t *testing.T) {
+	for in, want := range map[string]string{
+		`{"caller":"/home/ci/src/app/orders.go:42"}`:             `{"caller":"orders.go:42"}`,
+		`{"caller":"app.(*Svc).Charge /home/ci/app/pay.go:7"}`:   `{"caller":"app.(*Svc).Charge pay.go:7"}`,
+		`{"time":1700000000,"caller":"main.go:1","message":"x"}`: `{"time":"<time>","caller":"main.go:1","message":"x"}`,
+	} {
+		if got := string(normalizeLine([]byte(in))); got != want {
+			t.Errorf("normalizeLine(%s) = %s, want %s", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+// Package sloglint is a go/analysis analyzer that keeps log field names from
+// drifting. It inspects string-literal keys passed to zerolog event and
+// context methods (Str, Int, Dur, ...) and to slogging.With/IntoContext, and
+// reports:
+//
+//   - literals equal to a canonical key: use the slogging.Field* constant;
+//   - literals that misspell a canonical key ("requestId", "request-id",
+//     "reqest_id"): use the constant instead.
+//
+// The package also provides Unterminated, which catches event chains that
+// are never sent. Run both with cmd/sloglint or add them to a multichecker.
+package sloglint
+
+import (
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"strings"
+)
+
+const (
+	zerologPkg  = "github.com/rs/zerolog"
+	sloggingPkg = "github.com/dinhtatuanlinh/source_logging/slogging"
+)
+
+// Analyzer reports non-canonical log field keys.
+var Analyzer = &analysis.Analyzer{
+	Name:     "sloglint",
+	Doc:      "report log field keys that duplicate or misspell slogging's canonical Field* constants",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+var literals bool
+
+func init() {
+	Analyzer.Flags.BoolVar(&literals, "literals", true, "also report literals equal to a canonical key")
+}
+
+// constName maps a canonical key to its constant name.
+var constName = func() map[string]string {
+	names := map[string]string{}
+	for _, k := range slogging.CanonicalFields {
+		names[k] = "slogging.Field" + camel(k)
+	}
+	return names
+}()
+
+func camel(k string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(k, "_") {
+		switch part {
+		case "id":
+			b.WriteString("ID")
+		case "api":
+			b.WriteString("API")
+		case "ip":
+			b.WriteString("IP")
+		default:
+			if part != "" {
+				b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+			}
+		}
+	}
+	return b.String()
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	if pass.Pkg.Path() == sloggingPkg {
+		return nil, nil // the constants' own package
+	}
+	ins := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	ins.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		fn, ok := callee(pass, call).(*types.Func)
+		if !ok {
+			return
+		}
+		for _, arg := range keyArgs(fn, call) {
+			check(pass, arg)
+		}
+	})
+	return nil, nil
+}
+
+// callee resolves the called function or method.
+func callee(pass *analysis.Pass, call *ast.CallExpr) types.Object {
+	switch f := ast.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		return pass.TypesInfo.Uses[f]
+	case *ast.SelectorExpr:
+		if sel, ok := pass.TypesInfo.Selections[f]; ok {
+			return sel.Obj()
+		}
+		return pass.TypesInfo.Uses[f.Sel]
+	}
+	return nil
+}
+
+// keyArgs returns the arguments of call that are field keys.
+func keyArgs(fn *types.Func, call *ast.CallExpr) []ast.Expr {
+	if fn.Pkg() == nil {
+		return nil
+	}
+	sig := fn.Type().(*types.Signature)
+	switch fn.Pkg().Path() {
+	case zerologPkg:
+		recv := sig.Recv()
+		if recv == nil || len(call.Args) == 0 || sig.Params().Len() == 0 {
+			return nil
+		}
+		if !isNamed(recv.Type(), "Event") && !isNamed(recv.Type(), "Context") {
+			return nil
+		}
+		if p := sig.Params().At(0); p.Name() == "key" && types.Identical(p.Type(), types.Typ[types.String]) {
+			return call.Args[:1]
+		}
+	case sloggingPkg:
+		var kv []ast.Expr
+		switch fn.Name() {
+		case "With":
+			kv = call.Args
+		case "IntoContext":
+			if len(call.Args) > 1 {
+				kv = call.Args[1:]
+			}
+		}
+		if call.Ellipsis.IsValid() {
+			return nil
+		}
+		var keys []ast.Expr
+		for i := 0; i < len(kv); i += 2 {
+			keys = append(keys, kv[i])
+		}
+		return keys
+	}
+	return nil
+}
+
+func isNamed(t types.Type, name string) bool {
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	n, ok := t.(*types.Named)
+	return ok && n.Obj().Name() == name
+}
+
+func check(pass *analysis.Pass, arg ast.Expr) {
+	lit, ok := ast.Unparen(arg).(*ast.BasicLit)
+	if !ok {
+		return // constants and variables are fine
+	}
+	tv, ok := pass.TypesInfo.Types[lit]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return
+	}
+	key := constant.StringVal(tv.Value)
+	if name, ok := constName[key]; ok {
+		if literals {
+			pass.Report(analysis.Diagnostic{
+				Pos:     lit.Pos(),
+				End:     lit.End(),
+				Message: "field key " + lit.Value + " is canonical: use " + name,
+				SuggestedFixes: []analysis.SuggestedFix{{
+					Message:   "use " + name,
+					TextEdits: []analysis.TextEdit{{Pos: lit.Pos(), End: lit.End(), NewText: []byte(name)}},
+				}},
+			})
+		}
+		return
+	}
+	if canon, ok := misspelling(key); ok {
+		pass.Reportf(lit.Pos(), "field key %s looks like a misspelling of %q: use %s", lit.Value, canon, constName[canon])
+	}
+}
+
+// misspelling reports the canonical key that key most likely means: same
+// letters ignoring case and separators, or one edit away. One key extending
+// the other, as a plural does, is a different key rather than a typo.
+func misspelling(key string) (string, bool) {
+	nk := normalize(key)
+	if nk == "" {
+		return "", false
+	}
+	for _, canon := range slogging.CanonicalFields {
+		nc := normalize(canon)
+		if nk == nc || strings.TrimPrefix(nk, "x") == nc {
+			return canon, true
+		}
+		if len(nc) >= 6 && editDistance(nk, nc) == 1 && !strings.HasPrefix(nk, nc) && !strings.HasPrefix(nc, nk) {
+			return canon, true
+		}
+	}
+	return "", false
+}
+
+func normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '_', '-', ' ', '.':
+			return -1
+		}
+		return r
+	}, strings.ToLower(s))
+}
+
+// editDistance is the optimal string alignment distance between a and b:
+// insertions, deletions, substitutions and adjacent transpositions cost 1.
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
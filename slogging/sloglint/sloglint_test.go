@@ -0,0 +1,36 @@
+package sloglint
+
+import (
+	"golang.org/x/tools/go/analysis/analysistest"
+	"testing"
+)
+
+func TestMisspelling(t *testing.T) {
+	for _, tc := range []struct {
+		key   string
+		canon string // "" when the key must not be flagged
+	}{
+		{"requestID", "request_id"},
+		{"request-id", "request_id"},
+		{"reqest_id", "request_id"},
+		{"requset_id", "request_id"},
+		{"request_ids", ""},
+		{"workers", ""},
+		{"order_id", ""},
+	} {
+		canon, ok := misspelling(tc.key)
+		if tc.canon == "" {
+			if ok {
+				t.Errorf("misspelling(%q) = %q, want no match", tc.key, canon)
+			}
+			continue
+		}
+		if !ok || canon != tc.canon {
+			t.Errorf("misspelling(%q) = %q, %v; want %q", tc.key, canon, ok, tc.canon)
+		}
+	}
+}
+
+func TestUnterminated(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), Unterminated, "unterminated")
+}
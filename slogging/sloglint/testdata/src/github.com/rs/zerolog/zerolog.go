@@ -0,0 +1,14 @@
+// Package zerolog is the part of github.com/rs/zerolog the analyzer tests use.
+package zerolog
+
+type Logger struct{}
+
+type Event struct{}
+
+func (l *Logger) Info() *Event  { return &Event{} }
+func (l *Logger) Error() *Event { return &Event{} }
+
+func (e *Event) Str(key, val string) *Event { return e }
+func (e *Event) Msg(msg string)             {}
+func (e *Event) Send()                      {}
+func (e *Event) Discard() *Event            { return e }
@@ -0,0 +1,26 @@
+package unterminated
+
+import (
+	"context"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"github.com/rs/zerolog"
+)
+
+func handler(ctx context.Context, l *zerolog.Logger) {
+	l.Info().Str("order", "42")                       // want "zerolog event is never sent"
+	_ = slogging.From(ctx).Error().Str("order", "42") // want "zerolog event is never sent"
+	slogging.Debug(ctx).Str("order", "42")            // want "zerolog event is never sent"
+
+	l.Info().Str("order", "42").Msg("ok")
+	slogging.Debug(ctx).Send()
+	l.Info().Str("order", "42").Discard()
+	e := l.Info()
+	e.Str("order", "42").Msg("kept in a variable")
+}
+
+type order struct{ id string }
+
+// Adding fields to an event owned by the caller is fine.
+func (o order) MarshalZerologObject(e *zerolog.Event) {
+	e.Str("order", o.id)
+}
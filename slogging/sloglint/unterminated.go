@@ -0,0 +1,106 @@
+package sloglint
+
+import (
+	"go/ast"
+	"go/types"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// Unterminated reports zerolog events that are built and then dropped
+// without Msg, Msgf, MsgFunc or Send, so they are never written:
+//
+//	slogging.From(ctx).Info().Str("order", id)   // nothing is logged
+//
+// An expression statement (or "_ =" assignment) whose value is a
+// *zerolog.Event is such a chain. Chains ending in Discard are intentional.
+var Unterminated = &analysis.Analyzer{
+	Name:     "slogunterminated",
+	Doc:      "report zerolog event chains that never call Msg/Msgf/MsgFunc/Send",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      runUnterminated,
+}
+
+func runUnterminated(pass *analysis.Pass) (any, error) {
+	ins := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	ins.Preorder([]ast.Node{(*ast.ExprStmt)(nil), (*ast.AssignStmt)(nil)}, func(n ast.Node) {
+		switch s := n.(type) {
+		case *ast.ExprStmt:
+			checkDropped(pass, s.X)
+		case *ast.AssignStmt:
+			for i, lhs := range s.Lhs {
+				if id, ok := lhs.(*ast.Ident); ok && id.Name == "_" && len(s.Rhs) == len(s.Lhs) {
+					checkDropped(pass, s.Rhs[i])
+				}
+			}
+		}
+	})
+	return nil, nil
+}
+
+func checkDropped(pass *analysis.Pass, x ast.Expr) {
+	call, ok := ast.Unparen(x).(*ast.CallExpr)
+	if !ok || !isEvent(pass.TypesInfo.TypeOf(call)) {
+		return
+	}
+	if sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr); ok && sel.Sel.Name == "Discard" {
+		return
+	}
+	if !startsEvent(pass, chainRoot(pass, call)) {
+		return // adds to an event owned elsewhere, e.g. inside MarshalZerologObject
+	}
+	pass.Report(analysis.Diagnostic{
+		Pos:     call.Pos(),
+		End:     call.End(),
+		Message: "zerolog event is never sent: end the chain with Msg, Msgf or Send",
+		SuggestedFixes: []analysis.SuggestedFix{{
+			Message:   "append .Send()",
+			TextEdits: []analysis.TextEdit{{Pos: call.End(), End: call.End(), NewText: []byte(".Send()")}},
+		}},
+	})
+}
+
+// chainRoot follows a chain of *zerolog.Event method calls down to its
+// first call.
+func chainRoot(pass *analysis.Pass, call *ast.CallExpr) *ast.CallExpr {
+	for {
+		sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+		if !ok {
+			return call
+		}
+		inner, ok := ast.Unparen(sel.X).(*ast.CallExpr)
+		if !ok || !isEvent(pass.TypesInfo.TypeOf(inner)) {
+			return call
+		}
+		call = inner
+	}
+}
+
+// startsEvent reports whether call creates a new event: a zerolog.Logger
+// level method (Info, WithLevel, Err, ...) or a slogging function returning
+// one (Debug, Trace).
+func startsEvent(pass *analysis.Pass, call *ast.CallExpr) bool {
+	fn, ok := callee(pass, call).(*types.Func)
+	if !ok || fn.Pkg() == nil {
+		return false
+	}
+	sig := fn.Type().(*types.Signature)
+	switch fn.Pkg().Path() {
+	case zerologPkg:
+		return sig.Recv() != nil && isNamed(sig.Recv().Type(), "Logger")
+	case sloggingPkg:
+		return sig.Recv() == nil || isNamed(sig.Recv().Type(), "Logger")
+	}
+	return false
+}
+
+// isEvent reports whether t is *zerolog.Event.
+func isEvent(t types.Type) bool {
+	p, ok := t.(*types.Pointer)
+	if !ok {
+		return false
+	}
+	n, ok := p.Elem().(*types.Named)
+	return ok && n.Obj().Name() == "Event" && n.Obj().Pkg() != nil && n.Obj().Pkg().Path() == zerologPkg
+}
@@ -0,0 +1,103 @@
+package slogging
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+)
+
+// ContextSnapshot is the logging state of a context, taken by Snapshot so
+// work that outlives a request keeps its fields without its cancellation.
+type ContextSnapshot struct {
+	logger    *zerolog.Logger
+	component string
+	values    []ctxValue
+
+	traceID, spanID string
+	span            bool
+}
+
+type ctxValue struct {
+	key ctxKey
+	val any
+}
+
+// snapshotKeys are the context values Snapshot carries over: the IDs of the
+// With* helpers and LinkTo's parent. Request levels, debug tails and request
+// stats belong to the request and are left behind.
+var snapshotKeys = []ctxKey{
+	ctxReqIDKey, ctxTraceIDKey, ctxApiIDKey, ctxOperatorNameKey, ctxRoleKey, ctxIPAddressKey, ctxLinkKey,
+}
+
+// Snapshot captures the logger and correlation IDs of ctx, and with
+// OTELCorrelation the IDs of its active span, so a goroutine that must
+// outlive the request can log with them after the request's context is
+// cancelled:
+//
+//	snap := slogging.Snapshot(ctx)
+//	go func() {
+//		ctx := snap.Attach(context.Background())
+//		slogging.From(ctx).Info().Msg("sending receipt") // same request_id
+//	}()
+func Snapshot(ctx context.Context) ContextSnapshot {
+	s := ContextSnapshot{logger: ctxLogger(ctx)}
+	if s.logger != nil {
+		s.component, _ = boundCtx(s.logger).Value(ctxComponentKey).(string)
+	}
+	for _, k := range snapshotKeys {
+		if v := ctx.Value(k); v != nil {
+			s.values = append(s.values, ctxValue{k, v})
+		}
+	}
+	s.traceID, s.spanID, s.span = spanIDs(current.Load(), ctx)
+	return s
+}
+
+// Attach returns ctx with the snapshot's IDs and logger, replacing any logger
+// ctx had. Events logged through it carry the request's fields and span but
+// go to the installed pipeline and follow its level and sampling (or those
+// of the logger's component), not WithRequestLevel's or WithDebugTail's, and
+// hooks and enrichers see ctx rather than the request's context. A span
+// active in ctx replaces the snapshot's.
+func (s ContextSnapshot) Attach(ctx context.Context) context.Context {
+	for _, v := range s.values {
+		ctx = context.WithValue(ctx, v.key, v.val)
+	}
+	p := current.Load()
+	_, _, ownSpan := spanIDs(p, ctx)
+	stamp := s.span && !ownSpan
+	if s.logger == nil && !stamp {
+		return ctx
+	}
+	ll := *global()
+	if s.logger != nil {
+		ll = *s.logger
+		if p != nil {
+			ll = ll.Output(p.out).Sample(levelGate{p: p, component: s.component, next: p.sampler})
+		}
+	}
+	bound := ctx
+	if s.component != "" {
+		bound = context.WithValue(ctx, ctxComponentKey, s.component)
+	}
+	c := ll.With().Ctx(bound)
+	if stamp {
+		if GetTraceID(ctx) == "" {
+			c = c.Str(FieldTraceID, s.traceID)
+		}
+		c = c.Str(FieldSpanID, s.spanID)
+	}
+	ll = c.Logger()
+	return ll.WithContext(ctx)
+}
+
+// boundCtx returns the context bound to l's events, read from an event that
+// is discarded unwritten, so l's sampler and hooks do not see it.
+func boundCtx(l *zerolog.Logger) context.Context {
+	ctx := context.Background()
+	probe := l.Sample(nil).Level(zerolog.TraceLevel)
+	if e := probe.Log(); e != nil {
+		ctx = e.GetCtx()
+		e.Discard()
+	}
+	return ctx
+}
@@ -0,0 +1,85 @@
+package slogging
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+	"strings"
+	"testing"
+)
+
+type testSpanKey struct{}
+
+func TestSnapshotKeepsSpan(t *testing.T) {
+	RegisterSpanContext(func(ctx context.Context) (string, string, bool) {
+		ids, ok := ctx.Value(testSpanKey{}).([2]string)
+		return ids[0], ids[1], ok
+	})
+	t.Cleanup(func() {
+		RegisterSpanContext(func(context.Context) (string, string, bool) { return "", "", false })
+	})
+	c := initCapture(t, Options{Service: "svc", OTELCorrelation: true})
+	ctx, cancel := context.WithCancel(WithRequestID(context.Background(), "r1"))
+	ctx = context.WithValue(ctx, testSpanKey{}, [2]string{"t1", "s1"})
+	snap := Snapshot(ctx)
+	cancel()
+
+	From(snap.Attach(context.Background())).Info().Msg("after the request")
+	own := context.WithValue(context.Background(), testSpanKey{}, [2]string{"t2", "s2"})
+	From(snap.Attach(own)).Info().Msg("in a new span")
+
+	ev := c.withMessage(t, "after the request")[0]
+	if ev[FieldRequestID] != "r1" || ev[FieldTraceID] != "t1" || ev[FieldSpanID] != "s1" {
+		t.Errorf("event = %v, want request r1 in span t1/s1", ev)
+	}
+	c.mu.Lock()
+	last := string(c.lines[len(c.lines)-1])
+	c.mu.Unlock()
+	if ev := c.withMessage(t, "in a new span")[0]; ev[FieldSpanID] != "s2" || strings.Count(last, `"`+FieldSpanID+`"`) != 1 {
+		t.Errorf("event in a new span = %s", last)
+	}
+}
+
+func TestSnapshotFollowsCurrentPipeline(t *testing.T) {
+	initCapture(t, Options{Service: "svc", Level: "info"})
+	ctx, cancel := context.WithCancel(WithRequestID(context.Background(), "r1"))
+	defer cancel()
+	debugSnap := Snapshot(WithRequestLevel(ctx, zerolog.DebugLevel))
+	tailSnap := Snapshot(WithDebugTail(ctx, 10))
+
+	// Another component at debug lowers zerolog's global level, leaving
+	// the gates to keep the snapshots' debug events out.
+	c := initCapture(t, Options{Service: "svc", Level: "info", ComponentLevels: map[string]string{"other": "debug"}})
+	for _, snap := range []ContextSnapshot{debugSnap, tailSnap} {
+		l := From(snap.Attach(context.Background()))
+		l.Debug().Msg("debug after reload")
+		l.Info().Msg("info after reload")
+		l.Error().Msg("error after reload")
+	}
+	if evs := c.withMessage(t, "debug after reload"); len(evs) != 0 {
+		t.Errorf("debug events kept the request's level: %v", evs)
+	}
+	for _, msg := range []string{"info after reload", "error after reload"} {
+		evs := c.withMessage(t, msg)
+		if len(evs) != 2 || evs[0][FieldRequestID] != "r1" {
+			t.Errorf("%q: got %v, want two events of r1 on the new pipeline", msg, evs)
+		}
+	}
+}
+
+func TestSnapshotKeepsComponent(t *testing.T) {
+	c := initCapture(t, Options{Service: "svc", Level: "info", ComponentLevels: map[string]string{"repo": "debug"}})
+	ctx := WithRequestID(context.Background(), "r1")
+	ctx = Component("repo").From(ctx).WithContext(ctx)
+	snap := Snapshot(ctx)
+
+	From(snap.Attach(context.Background())).Debug().Msg("repo debug")
+	evs := c.withMessage(t, "repo debug")
+	if len(evs) != 1 || evs[0][FieldComponent] != "repo" || evs[0][FieldRequestID] != "r1" {
+		t.Fatalf("got %v, want one repo event at the component's level", evs)
+	}
+	// The component survives a second snapshot of the attached context.
+	From(Snapshot(snap.Attach(context.Background())).Attach(context.Background())).Debug().Msg("repo debug again")
+	if evs := c.withMessage(t, "repo debug again"); len(evs) != 1 {
+		t.Errorf("got %d events after a second snapshot, want 1", len(evs))
+	}
+}
@@ -0,0 +1,38 @@
+package slogging
+
+import (
+	"github.com/rs/zerolog"
+	"io"
+	"os"
+)
+
+// levelSplitWriter sends events at or above at to hi and the rest to lo.
+// Events written without a level (Write, Pretty output) go to lo.
+type levelSplitWriter struct {
+	lo, hi io.Writer
+	at     zerolog.Level
+}
+
+func (s levelSplitWriter) Write(p []byte) (int, error) {
+	return s.lo.Write(p)
+}
+
+func (s levelSplitWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if level >= s.at && level != zerolog.NoLevel && level != zerolog.Disabled {
+		return s.hi.Write(p)
+	}
+	return s.lo.Write(p)
+}
+
+// stdoutSink is the console sink: os.Stdout, or with SplitStdErr a writer that
+// sends warn and above to os.Stderr, behind a lineGuard with StdoutMaxLine.
+func stdoutSink(opt Options) io.Writer {
+	var w io.Writer = os.Stdout
+	if opt.SplitStdErr {
+		w = levelSplitWriter{lo: os.Stdout, hi: os.Stderr, at: zerolog.WarnLevel}
+	}
+	if opt.StdoutMaxLine > 0 {
+		w = newLineGuard(w, opt.StdoutMaxLine, opt.StdoutOversize)
+	}
+	return w
+}
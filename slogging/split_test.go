@@ -0,0 +1,96 @@
+package slogging
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// pipeStd replaces *f (os.Stdout or os.Stderr) with a pipe for the rest of the
+// test; the returned func closes it and returns what was written.
+func pipeStd(t *testing.T, f **os.File) func() string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := *f
+	*f = w
+	t.Cleanup(func() { *f = saved })
+	out := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		out <- string(b)
+	}()
+	return func() string {
+		w.Close()
+		return <-out
+	}
+}
+
+func TestSplitStdErr(t *testing.T) {
+	stdout, stderr := pipeStd(t, &os.Stdout), pipeStd(t, &os.Stderr)
+	initCapture(t, Options{Level: "debug", AlsoStdout: true, SplitStdErr: true})
+	ctx := context.Background()
+	From(ctx).Debug().Msg("d")
+	From(ctx).Info().Msg("i")
+	From(ctx).Warn().Msg("w")
+	From(ctx).Error().Msg("e")
+	Close(ctx)
+
+	for name, tc := range map[string]struct {
+		got        string
+		want, miss []string
+	}{
+		"stdout": {stdout(), []string{`"message":"d"`, `"message":"i"`}, []string{`"message":"w"`, `"message":"e"`}},
+		"stderr": {stderr(), []string{`"message":"w"`, `"message":"e"`}, []string{`"message":"d"`, `"message":"i"`}},
+	} {
+		for _, s := range tc.want {
+			if !strings.Contains(tc.got, s) {
+				t.Errorf("%s is missing %s: %s", name, s, tc.got)
+			}
+		}
+		for _, s := range tc.miss {
+			if strings.Contains(tc.got, s) {
+				t.Errorf("%s has %s: %s", name, s, tc.got)
+			}
+		}
+	}
+}
+
+func TestRouteMinLevel(t *testing.T) {
+	dir := t.TempDir()
+	errs := &eventCapture{}
+	c := initCapture(t, Options{Level: "debug", Routes: []Route{
+		{MinLevel: "warn", Writer: errs, Exclusive: true},
+		{MinLevel: "debug", FilePath: filepath.Join(dir, "{value}.log")},
+	}})
+	ctx := context.Background()
+	From(ctx).Debug().Msg("d")
+	From(ctx).Info().Msg("i")
+	From(ctx).Error().Msg("e")
+	Close(ctx)
+
+	if len(errs.withMessage(t, "e")) != 1 || len(errs.withMessage(t, "i")) != 0 {
+		t.Errorf("warn+ route got %v, want only the error", errs.events(t))
+	}
+	if len(c.withMessage(t, "e")) != 0 || len(c.withMessage(t, "i")) != 1 {
+		t.Errorf("default sinks got %v, want the info but not the error", c.events(t))
+	}
+	// The exclusive route took the error before the per-level files saw it.
+	for name, want := range map[string]string{"debug": `"message":"d"`, "info": `"message":"i"`} {
+		b, err := os.ReadFile(filepath.Join(dir, name+".log"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Count(string(b), "\n"); got != 1 || !strings.Contains(string(b), want) {
+			t.Errorf("%s.log = %q, want only %s", name, b, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "error.log")); err == nil {
+		t.Error("error.log written despite the exclusive warn+ route")
+	}
+}
@@ -0,0 +1,421 @@
+// Package sqllog logs database/sql queries through the context logger: the
+// statement, its arguments (redacted), rows affected, duration and error,
+// under the same field names in every service. It wraps the driver, so
+// every query made through the *sql.DB is logged, and records each one with
+// slogging.RecordDependency for the access line:
+//
+//	db, err := sqllog.Open("postgres", dsn, sqllog.Options{
+//		Name:          "orders-db",
+//		SlowThreshold: 200 * time.Millisecond,
+//	})
+//
+// A driver registered under another name can be wrapped with Wrap or
+// WrapConnector instead. Queries are logged when the driver returns, so for
+// Query the duration is the time to the first response, not to the last
+// row read.
+package sqllog
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"github.com/rs/zerolog"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// What Options.Args logs of a statement's arguments.
+const (
+	ArgsTypes  = ""       // default: their Go types, e.g. ["string","int64"]
+	ArgsValues = "values" // their values, strings cut at 64 bytes and named args such as sql.Named("password", ...) redacted per slogging.DenyParams
+	ArgsNone   = "none"
+)
+
+const (
+	defaultName        = "sql"
+	defaultMaxQueryLen = 2048
+	maxArgLen          = 64
+)
+
+// Options configures the wrappers.
+type Options struct {
+	// Name identifies the database in "db" and as the dependency host
+	// (default "sql").
+	Name string
+	// Level of successful statements (default debug). Failed ones log at
+	// error; ones taking SlowThreshold or longer, when set, at warn with
+	// "slow_query": true.
+	Level         zerolog.Level
+	SlowThreshold time.Duration
+	// Args is ArgsTypes (default), ArgsValues or ArgsNone.
+	Args string
+	// MaxQueryLen cuts the logged statement, whitespace collapsed (default
+	// 2048 bytes).
+	MaxQueryLen int
+}
+
+// Open opens a *sql.DB on the driver registered as driverName, with its
+// statements logged.
+func Open(driverName, dsn string, opt Options) (*sql.DB, error) {
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	d := db.Driver()
+	db.Close()
+	if dc, ok := d.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+		return sql.OpenDB(WrapConnector(c, opt)), nil
+	}
+	return sql.OpenDB(dsnConnector{dsn: dsn, d: Wrap(d, opt)}), nil
+}
+
+// Wrap returns d with the statements of its connections logged, for
+// sql.Register.
+func Wrap(d driver.Driver, opt Options) driver.Driver {
+	return &wrappedDriver{d: d, l: newLogger(opt)}
+}
+
+// WrapConnector returns c with the statements of its connections logged,
+// for sql.OpenDB.
+func WrapConnector(c driver.Connector, opt Options) driver.Connector {
+	l := newLogger(opt)
+	return &connector{c: c, l: l, d: &wrappedDriver{d: c.Driver(), l: l}}
+}
+
+type wrappedDriver struct {
+	d driver.Driver
+	l *logger
+}
+
+func (w *wrappedDriver) Open(name string) (driver.Conn, error) {
+	c, err := w.d.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: c, l: w.l}, nil
+}
+
+type connector struct {
+	c driver.Connector
+	l *logger
+	d driver.Driver
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	cn, err := c.c.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: cn, l: c.l}, nil
+}
+
+func (c *connector) Driver() driver.Driver { return c.d }
+
+// dsnConnector is the connector of a driver without DriverContext.
+type dsnConnector struct {
+	dsn string
+	d   driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) { return c.d.Open(c.dsn) }
+func (c dsnConnector) Driver() driver.Driver                        { return c.d }
+
+// conn implements every optional interface database/sql looks for,
+// returning driver.ErrSkip or the documented default where the wrapped
+// connection does not.
+type conn struct {
+	driver.Conn
+	l *logger
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	start := time.Now()
+	var s driver.Stmt
+	var err error
+	if pc, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		s, err = pc.PrepareContext(ctx, query)
+	} else {
+		s, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		c.l.log(ctx, "prepare", query, nil, start, nil, err)
+		return nil, err
+	}
+	return &stmt{Stmt: s, conn: c, query: query}, nil
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if bt, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return bt.BeginTx(ctx, opts)
+	}
+	if opts != (driver.TxOptions{}) {
+		return nil, errors.New("sqllog: driver does not support transaction options")
+	}
+	return c.Conn.Begin()
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var res driver.Result
+	var err error
+	switch x := c.Conn.(type) {
+	case driver.ExecerContext:
+		res, err = x.ExecContext(ctx, query, args)
+	case driver.Execer:
+		var vals []driver.Value
+		if vals, err = values(args); err == nil {
+			res, err = x.Exec(query, vals)
+		}
+	default:
+		return nil, driver.ErrSkip
+	}
+	if err == driver.ErrSkip {
+		return nil, err
+	}
+	c.l.log(ctx, "exec", query, args, start, res, err)
+	return res, err
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var rows driver.Rows
+	var err error
+	switch x := c.Conn.(type) {
+	case driver.QueryerContext:
+		rows, err = x.QueryContext(ctx, query, args)
+	case driver.Queryer:
+		var vals []driver.Value
+		if vals, err = values(args); err == nil {
+			rows, err = x.Query(query, vals)
+		}
+	default:
+		return nil, driver.ErrSkip
+	}
+	if err == driver.ErrSkip {
+		return nil, err
+	}
+	c.l.log(ctx, "query", query, args, start, nil, err)
+	return rows, err
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type stmt struct {
+	driver.Stmt
+	conn  *conn
+	query string
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), named(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), named(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var res driver.Result
+	var err error
+	if ec, ok := s.Stmt.(driver.StmtExecContext); ok {
+		res, err = ec.ExecContext(ctx, args)
+	} else {
+		var vals []driver.Value
+		if vals, err = values(args); err == nil {
+			res, err = s.Stmt.Exec(vals)
+		}
+	}
+	s.conn.l.log(ctx, "exec", s.query, args, start, res, err)
+	return res, err
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var rows driver.Rows
+	var err error
+	if qc, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = qc.QueryContext(ctx, args)
+	} else {
+		var vals []driver.Value
+		if vals, err = values(args); err == nil {
+			rows, err = s.Stmt.Query(vals)
+		}
+	}
+	s.conn.l.log(ctx, "query", s.query, args, start, nil, err)
+	return rows, err
+}
+
+// CheckNamedValue defers to the statement's checker, then the connection's,
+// as database/sql would without the wrapper.
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return s.conn.CheckNamedValue(nv)
+}
+
+func values(args []driver.NamedValue) ([]driver.Value, error) {
+	vals := make([]driver.Value, len(args))
+	for i, a := range args {
+		if a.Name != "" {
+			return nil, errors.New("sqllog: driver does not support named arguments")
+		}
+		vals[i] = a.Value
+	}
+	return vals, nil
+}
+
+func named(vals []driver.Value) []driver.NamedValue {
+	args := make([]driver.NamedValue, len(vals))
+	for i, v := range vals {
+		args[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return args
+}
+
+type logger struct {
+	opt Options
+}
+
+func newLogger(opt Options) *logger {
+	if opt.Name == "" {
+		opt.Name = defaultName
+	}
+	if opt.MaxQueryLen <= 0 {
+		opt.MaxQueryLen = defaultMaxQueryLen
+	}
+	return &logger{opt: opt}
+}
+
+// log writes one statement's event and records it as a dependency.
+func (l *logger) log(ctx context.Context, op, query string, args []driver.NamedValue, start time.Time, res driver.Result, err error) {
+	latency := time.Since(start)
+	failed := err != nil && !errors.Is(err, context.Canceled)
+	slogging.RecordDependency(ctx, l.opt.Name, "", latency, failed)
+
+	level := l.opt.Level
+	slow := l.opt.SlowThreshold > 0 && latency >= l.opt.SlowThreshold
+	switch {
+	case failed:
+		level = zerolog.ErrorLevel
+	case slow:
+		level = max(level, zerolog.WarnLevel)
+	}
+	e := slogging.From(ctx).WithLevel(level)
+	if e == nil {
+		return
+	}
+	e.Str(slogging.FieldDB, l.opt.Name).
+		Str(slogging.FieldSQL, l.query(query))
+	l.args(e, args)
+	if res != nil && err == nil {
+		if n, rerr := res.RowsAffected(); rerr == nil {
+			e.Int64(slogging.FieldRowsAffected, n)
+		}
+	}
+	if slow {
+		e.Bool(slogging.FieldSlowQuery, true)
+	}
+	e.Float64(slogging.FieldDurationMs, float64(latency.Microseconds())/1000).
+		Err(err).
+		Msg("sql " + op)
+}
+
+func (l *logger) query(q string) string {
+	q = strings.Join(strings.Fields(q), " ")
+	return cut(q, l.opt.MaxQueryLen)
+}
+
+func (l *logger) args(e *zerolog.Event, args []driver.NamedValue) {
+	if len(args) == 0 || l.opt.Args == ArgsNone {
+		return
+	}
+	arr := zerolog.Arr()
+	for _, a := range args {
+		if l.opt.Args != ArgsValues {
+			if a.Value == nil {
+				arr.Str("nil")
+			} else {
+				arr.Str(fmt.Sprintf("%T", a.Value))
+			}
+			continue
+		}
+		if a.Name != "" && denied(a.Name) {
+			arr.Str("[REDACTED]")
+			continue
+		}
+		switch v := a.Value.(type) {
+		case nil:
+			arr.Interface(nil)
+		case string:
+			arr.Str(cut(v, maxArgLen))
+		case []byte:
+			arr.Str(fmt.Sprintf("[]byte(%d)", len(v)))
+		case time.Time:
+			arr.Time(v)
+		default:
+			arr.Interface(v)
+		}
+	}
+	e.Array(slogging.FieldSQLArgs, arr)
+}
+
+func denied(name string) bool {
+	name = strings.ToLower(name)
+	for _, d := range slogging.DenyParams {
+		if strings.Contains(name, d) {
+			return true
+		}
+	}
+	return false
+}
+
+// cut shortens s to at most n bytes on a rune boundary, marking the cut.
+func cut(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "…"
+}
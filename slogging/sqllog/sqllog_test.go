@@ -0,0 +1,319 @@
+package sqllog_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"github.com/dinhtatuanlinh/source_logging/slogging"
+	"github.com/dinhtatuanlinh/source_logging/slogging/sloggingtest"
+	"github.com/dinhtatuanlinh/source_logging/slogging/sqllog"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var errFailed = errors.New("relation does not exist")
+
+// calls records which methods of the fake driver database/sql reached.
+type calls struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (c *calls) add(name string) {
+	c.mu.Lock()
+	c.names = append(c.names, name)
+	c.mu.Unlock()
+}
+
+func (c *calls) list() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.names)
+}
+
+// run is what every fake statement does: it waits delay, and fails when the
+// query mentions "missing".
+func run(query string, delay time.Duration) error {
+	time.Sleep(delay)
+	if strings.Contains(query, "missing") {
+		return errFailed
+	}
+	return nil
+}
+
+// baseConn implements only driver.Conn, so database/sql prepares every
+// statement.
+type baseConn struct {
+	calls *calls
+	delay time.Duration
+}
+
+func (c *baseConn) Prepare(query string) (driver.Stmt, error) {
+	c.calls.add("Prepare")
+	return &fakeStmt{c: c, query: query}, nil
+}
+
+func (c *baseConn) Close() error              { return nil }
+func (c *baseConn) Begin() (driver.Tx, error) { return nil, errors.New("no transactions") }
+
+// plainConn adds the Execer and Queryer interfaces.
+type plainConn struct{ baseConn }
+
+func (c *plainConn) Exec(query string, args []driver.Value) (driver.Result, error) {
+	c.calls.add("Exec")
+	return driver.RowsAffected(3), run(query, c.delay)
+}
+
+func (c *plainConn) Query(query string, args []driver.Value) (driver.Rows, error) {
+	c.calls.add("Query")
+	return &fakeRows{}, run(query, c.delay)
+}
+
+// ctxConn adds the context interfaces.
+type ctxConn struct{ baseConn }
+
+func (c *ctxConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	c.calls.add("PrepareContext")
+	return &fakeStmt{c: &c.baseConn, query: query}, nil
+}
+
+func (c *ctxConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.calls.add("ExecContext")
+	return driver.RowsAffected(3), run(query, c.delay)
+}
+
+func (c *ctxConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.calls.add("QueryContext")
+	return &fakeRows{}, run(query, c.delay)
+}
+
+// skipConn has the context interfaces but declines them, as some drivers do
+// for statements with arguments.
+type skipConn struct{ baseConn }
+
+func (c *skipConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	c.calls.add("ExecContext")
+	return nil, driver.ErrSkip
+}
+
+func (c *skipConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	c.calls.add("QueryContext")
+	return nil, driver.ErrSkip
+}
+
+type fakeStmt struct {
+	c     *baseConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.c.calls.add("Stmt.Exec")
+	return driver.RowsAffected(3), run(s.query, s.c.delay)
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.c.calls.add("Stmt.Query")
+	return &fakeRows{}, run(s.query, s.c.delay)
+}
+
+type fakeRows struct{}
+
+func (fakeRows) Columns() []string              { return []string{"id"} }
+func (fakeRows) Close() error                   { return nil }
+func (fakeRows) Next(dest []driver.Value) error { return io.EOF }
+
+// fakeConnector hands out one kind of connection.
+type fakeConnector struct{ conn func() driver.Conn }
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return c.conn(), nil }
+func (c fakeConnector) Driver() driver.Driver                        { return fakeDriver(c) }
+
+type fakeDriver fakeConnector
+
+func (d fakeDriver) Open(string) (driver.Conn, error) { return d.conn(), nil }
+
+// open returns a database whose connections are made by conn, wrapped with
+// opt.
+func open(t *testing.T, conn func() driver.Conn, opt sqllog.Options) *sql.DB {
+	t.Helper()
+	db := sql.OpenDB(sqllog.WrapConnector(fakeConnector{conn}, opt))
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestStatementsReachTheDriver(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		conn func(*calls) driver.Conn
+		want []string
+	}{
+		{"context", func(c *calls) driver.Conn { return &ctxConn{baseConn{calls: c}} },
+			[]string{"ExecContext", "QueryContext", "PrepareContext", "Stmt.Exec"}},
+		{"plain", func(c *calls) driver.Conn { return &plainConn{baseConn{calls: c}} },
+			[]string{"Exec", "Query", "Prepare", "Stmt.Exec"}},
+		{"prepare only", func(c *calls) driver.Conn { return &baseConn{calls: c} },
+			[]string{"Prepare", "Stmt.Exec", "Prepare", "Stmt.Query", "Prepare", "Stmt.Exec"}},
+		{"skip", func(c *calls) driver.Conn { return &skipConn{baseConn{calls: c}} },
+			[]string{"ExecContext", "Prepare", "Stmt.Exec", "QueryContext", "Prepare", "Stmt.Query", "Prepare", "Stmt.Exec"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := sloggingtest.NewMock(t)
+			ctx := m.Context(context.Background())
+			c := &calls{}
+			db := open(t, func() driver.Conn { return tc.conn(c) }, sqllog.Options{Name: "orders-db"})
+			db.SetMaxOpenConns(1)
+
+			if _, err := db.ExecContext(ctx, "UPDATE orders SET paid = true WHERE id = $1", 7); err != nil {
+				t.Fatal(err)
+			}
+			rows, err := db.QueryContext(ctx, "SELECT id FROM orders")
+			if err != nil {
+				t.Fatal(err)
+			}
+			rows.Close()
+			s, err := db.PrepareContext(ctx, "DELETE FROM orders WHERE id = $1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.ExecContext(ctx, 7); err != nil {
+				t.Fatal(err)
+			}
+			s.Close()
+
+			if got := c.list(); !slices.Equal(got, tc.want) {
+				t.Errorf("driver calls = %q, want %q", got, tc.want)
+			}
+			// One event per statement run, whichever way it reached the
+			// driver; none for the declined attempts.
+			m.ExpectDebug().WithMessage("sql exec").
+				WithField(slogging.FieldDB, "orders-db").
+				WithField(slogging.FieldRowsAffected, 3).
+				Times(2)
+			m.ExpectDebug().WithMessage("sql query").
+				WithField(slogging.FieldSQL, "SELECT id FROM orders").
+				Times(1)
+			if n := len(m.Events()); n != 3 {
+				t.Errorf("got %d events, want 3", n)
+			}
+		})
+	}
+}
+
+func TestWithoutContext(t *testing.T) {
+	m := sloggingtest.NewMock(t)
+	saved := log.Logger
+	log.Logger = *m.Logger()
+	t.Cleanup(func() { log.Logger = saved })
+
+	c := &calls{}
+	d := sqllog.Wrap(fakeDriver{func() driver.Conn { return &plainConn{baseConn{calls: c}} }}, sqllog.Options{})
+	cn, err := d.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := cn.Prepare("INSERT INTO orders VALUES ($1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Exec([]driver.Value{int64(1)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Query([]driver.Value{int64(1)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"Prepare", "Stmt.Exec", "Stmt.Query"}; !slices.Equal(c.list(), want) {
+		t.Errorf("driver calls = %q, want %q", c.list(), want)
+	}
+	m.ExpectDebug().WithMessage("sql exec").WithField(slogging.FieldDB, "sql").Times(1)
+	m.ExpectDebug().WithMessage("sql query").Times(1)
+}
+
+func TestArgs(t *testing.T) {
+	const query = "UPDATE users SET name = $1, password = $2 WHERE id = $3"
+	for _, tc := range []struct {
+		name string
+		args string
+		want []any
+	}{
+		{"default", sqllog.ArgsTypes, []any{"string", "string", "int64"}},
+		{"values", sqllog.ArgsValues, []any{"ann", "[REDACTED]", float64(7)}},
+		{"none", sqllog.ArgsNone, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := sloggingtest.NewMock(t)
+			db := open(t, func() driver.Conn { return &ctxConn{baseConn{calls: &calls{}}} }, sqllog.Options{Args: tc.args})
+			if _, err := db.ExecContext(m.Context(context.Background()), query,
+				"ann", sql.Named("password", "hunter2"), 7); err != nil {
+				t.Fatal(err)
+			}
+
+			events := m.Events()
+			if len(events) != 1 {
+				t.Fatalf("got %d events, want 1", len(events))
+			}
+			got, _ := events[0][slogging.FieldSQLArgs].([]any)
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("%s = %v, want %v", slogging.FieldSQLArgs, got, tc.want)
+			}
+			if sql, _ := events[0][slogging.FieldSQL].(string); sql != query {
+				t.Errorf("%s = %q, want %q", slogging.FieldSQL, sql, query)
+			}
+		})
+	}
+}
+
+func TestLevels(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		query string
+		delay time.Duration
+		level string
+		slow  bool
+	}{
+		{"fast", "SELECT 1", 0, "info", false},
+		{"slow", "SELECT pg_sleep(1)", 30 * time.Millisecond, "warn", true},
+		{"failed", "SELECT * FROM missing", 0, "error", false},
+		{"slow and failed", "SELECT * FROM missing", 30 * time.Millisecond, "error", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := sloggingtest.NewMock(t)
+			db := open(t, func() driver.Conn { return &ctxConn{baseConn{calls: &calls{}, delay: tc.delay}} }, sqllog.Options{
+				Level:         zerolog.InfoLevel,
+				SlowThreshold: 20 * time.Millisecond,
+			})
+			_, err := db.ExecContext(m.Context(context.Background()), tc.query)
+			if (err != nil) != strings.Contains(tc.query, "missing") {
+				t.Fatalf("err = %v", err)
+			}
+
+			events := m.Events()
+			if len(events) != 1 {
+				t.Fatalf("got %d events, want 1", len(events))
+			}
+			e := events[0]
+			if e["level"] != tc.level {
+				t.Errorf("level = %v, want %s", e["level"], tc.level)
+			}
+			if slow, _ := e[slogging.FieldSlowQuery].(bool); slow != tc.slow {
+				t.Errorf("%s = %v, want %v", slogging.FieldSlowQuery, slow, tc.slow)
+			}
+			if _, ok := e[slogging.FieldRowsAffected]; ok == (err != nil) {
+				t.Errorf("%s present = %v with err = %v", slogging.FieldRowsAffected, ok, err)
+			}
+			if err != nil && e["error"] != errFailed.Error() {
+				t.Errorf("error = %v, want %q", e["error"], errFailed)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package slogging
+
+import (
+	"errors"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// maxStackFrames caps the frames recorded for one error.
+const maxStackFrames = 32
+
+// stackSkipPrefixes are the logging frames dropped from the top of a stack
+// captured at the call site. The trailing dot keeps subpackages such as
+// grpcmw, whose interceptors are the interesting caller.
+var stackSkipPrefixes = []string{"github.com/rs/zerolog.", "github.com/dinhtatuanlinh/source_logging/slogging."}
+
+// marshalStack is installed as zerolog.ErrorStackMarshaler, so Stack() on an
+// event (or Options.WithStack) renders "stack" in zerolog/pkgerrors' shape:
+//
+//	"stack":[{"func":"billing.(*Svc).Charge","source":"billing/pay.go","line":"42"}, ...]
+//
+// An error that carries its own stack (github.com/pkg/errors and anything else
+// with a StackTrace() method returning program counters) is used as is; the
+// innermost such error in the Unwrap chain wins, since it is closest to where
+// things went wrong. Otherwise the stack is the logging call site.
+func marshalStack(err error) any {
+	if pcs := errorPCs(err); pcs != nil {
+		return stackFramesOf(pcs, false)
+	}
+	pcs := make([]uintptr, maxStackFrames+16)
+	n := runtime.Callers(2, pcs)
+	if n == 0 {
+		return nil
+	}
+	return stackFramesOf(pcs[:n], true)
+}
+
+// stackFramesOf resolves pcs into pkgerrors-style frame objects. trimLogging
+// drops the zerolog and slogging frames at the top, for call-site stacks.
+// Frames are resolved before trimming because one pc can expand into several
+// inlined functions.
+func stackFramesOf(pcs []uintptr, trimLogging bool) []map[string]string {
+	out := make([]map[string]string, 0, len(pcs))
+	frames := runtime.CallersFrames(pcs)
+	for {
+		f, more := frames.Next()
+		if trimLogging && hasAnyPrefix(f.Function, stackSkipPrefixes) {
+			if !more {
+				return out
+			}
+			continue
+		}
+		trimLogging = false
+		if f.Function != "" || f.File != "" {
+			out = append(out, map[string]string{
+				"func":   funcName(f.Function),
+				"source": trimSourcePath(f.File),
+				"line":   itoa(f.Line),
+			})
+		}
+		if !more || len(out) == maxStackFrames {
+			return out
+		}
+	}
+}
+
+// errorPCs returns the program counters recorded by the innermost error in
+// err's chain that has a StackTrace method, or nil.
+func errorPCs(err error) []uintptr {
+	var pcs []uintptr
+	for _, e := range unwrapChain(err) {
+		if p := stackTraceOf(e); p != nil {
+			pcs = p
+		}
+	}
+	return pcs
+}
+
+// stackTraceOf calls e.StackTrace() by reflection, so pkg/errors is supported
+// without being a dependency. Its errors.StackTrace is a []Frame, and a Frame
+// is a return address as recorded by runtime.Callers.
+func stackTraceOf(e error) []uintptr {
+	m := reflect.ValueOf(e).MethodByName("StackTrace")
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+		return nil
+	}
+	t := m.Type().Out(0)
+	if t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Uintptr {
+		return nil
+	}
+	v := m.Call(nil)[0]
+	if v.Len() == 0 {
+		return nil
+	}
+	pcs := make([]uintptr, v.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(v.Index(i).Uint())
+	}
+	return pcs
+}
+
+// ErrorChain returns the message of err and of every error it wraps,
+// outermost first. errors.Join and other multi-errors are walked depth first.
+// Logger.Error records it as "error_chain" alongside a stack:
+//
+//	"error_chain":["charge: card declined: insufficient funds","card declined: insufficient funds","insufficient funds"]
+func ErrorChain(err error) []string {
+	chain := unwrapChain(err)
+	out := make([]string, 0, len(chain))
+	for _, e := range chain {
+		out = append(out, safeErrorString(e))
+	}
+	return out
+}
+
+// unwrapChain flattens err's Unwrap tree, outermost first.
+func unwrapChain(err error) []error {
+	var out []error
+	var walk func(error)
+	walk = func(e error) {
+		for e != nil && len(out) < maxStackFrames {
+			out = append(out, e)
+			if multi, ok := e.(interface{ Unwrap() []error }); ok {
+				for _, inner := range multi.Unwrap() {
+					walk(inner)
+				}
+				return
+			}
+			e = errors.Unwrap(e)
+		}
+	}
+	walk(err)
+	return out
+}
+
+// safeErrorString is e.Error() that survives an Error method panicking.
+func safeErrorString(e error) (s string) {
+	defer func() {
+		if r := recover(); r != nil {
+			s = "!PANIC in Error()"
+		}
+	}()
+	return e.Error()
+}
+
+// funcName strips the import path from a runtime function name, keeping the
+// package: "github.com/acme/billing.(*Svc).Charge" -> "billing.(*Svc).Charge".
+func funcName(fn string) string {
+	if i := strings.LastIndexByte(fn, '/'); i >= 0 {
+		fn = fn[i+1:]
+	}
+	return fn
+}
+
+// trimSourcePath keeps the file and its directory: "billing/pay.go".
+func trimSourcePath(file string) string {
+	i := strings.LastIndexByte(file, '/')
+	if i < 0 {
+		return file
+	}
+	if j := strings.LastIndexByte(file[:i], '/'); j >= 0 {
+		return file[j+1:]
+	}
+	return file
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
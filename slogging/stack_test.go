@@ -0,0 +1,94 @@
+package slogging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// stackErr carries its own stack, the way github.com/pkg/errors does.
+type stackErr struct{ pcs []uintptr }
+
+type frame uintptr
+
+func (e *stackErr) Error() string { return "insufficient funds" }
+
+func (e *stackErr) StackTrace() []frame {
+	out := make([]frame, len(e.pcs))
+	for i, pc := range e.pcs {
+		out[i] = frame(pc)
+	}
+	return out
+}
+
+func newStackErr() error {
+	pcs := make([]uintptr, 16)
+	return &stackErr{pcs[:runtime.Callers(1, pcs)]}
+}
+
+// stackFuncs returns the func of every frame of an event's stack.
+func stackFuncs(t *testing.T, ev map[string]any) []string {
+	t.Helper()
+	frames, _ := ev["stack"].([]any)
+	var out []string
+	for _, f := range frames {
+		out = append(out, f.(map[string]any)["func"].(string))
+	}
+	return out
+}
+
+func TestErrorStackAndChain(t *testing.T) {
+	ctx := context.Background()
+	wrapped := fmt.Errorf("charge: %w", fmt.Errorf("card declined: %w", errors.New("insufficient funds")))
+	wantChain := []any{"charge: card declined: insufficient funds", "card declined: insufficient funds", "insufficient funds"}
+
+	c := initCapture(t, Options{Service: "svc"})
+	New(ctx).Error(wrapped).Msg("plain")
+	New(ctx).Stack().Error(wrapped).Msg("per call")
+	New(ctx).Stack().Error(fmt.Errorf("charge: %w", newStackErr())).Msg("own stack")
+	New(ctx).Stack().Error(errors.Join(wrapped, errors.New("rollback failed"))).Msg("joined")
+
+	if ev := c.withMessage(t, "plain")[0]; ev["stack"] != nil || ev[FieldErrorChain] != nil {
+		t.Errorf("without Stack: %v", ev)
+	}
+	ev := c.withMessage(t, "per call")[0]
+	if !reflect.DeepEqual(ev[FieldErrorChain], wantChain) {
+		t.Errorf("%s = %v, want %v", FieldErrorChain, ev[FieldErrorChain], wantChain)
+	}
+	funcs := stackFuncs(t, ev)
+	if len(funcs) == 0 {
+		t.Fatal("no stack")
+	}
+	for _, f := range funcs {
+		if strings.HasPrefix(f, "zerolog.") || strings.HasPrefix(f, "slogging.") {
+			t.Errorf("call-site stack keeps logging frame %s: %v", f, funcs)
+		}
+	}
+	// An error's own stack is used as recorded.
+	if funcs := stackFuncs(t, c.withMessage(t, "own stack")[0]); len(funcs) == 0 || funcs[0] != "slogging.newStackErr" {
+		t.Errorf("stack = %v, want the error's own, from newStackErr", funcs)
+	}
+	// Joined errors are walked depth first.
+	want := append([]any{"charge: card declined: insufficient funds\nrollback failed"}, wantChain...)
+	if got := c.withMessage(t, "joined")[0][FieldErrorChain]; !reflect.DeepEqual(got, append(want, "rollback failed")) {
+		t.Errorf("%s = %q", FieldErrorChain, got)
+	}
+
+	c = initCapture(t, Options{Service: "svc", WithStack: true})
+	From(ctx).Error().Err(wrapped).Msg("any Err")
+	New(ctx).Error(wrapped).Msg("option")
+	New(ctx).Error(errors.New("timeout")).Msg("single")
+	if ev := c.withMessage(t, "any Err")[0]; ev["stack"] == nil || ev[FieldErrorChain] != nil {
+		t.Errorf("WithStack, Event.Err: %v, want a stack only", ev)
+	}
+	if ev := c.withMessage(t, "option")[0]; ev["stack"] == nil || !reflect.DeepEqual(ev[FieldErrorChain], wantChain) {
+		t.Errorf("WithStack, Logger.Error: %v", ev)
+	}
+	if ev := c.withMessage(t, "single")[0]; ev["stack"] == nil || ev[FieldErrorChain] != nil {
+		t.Errorf("an error wrapping nothing got %s: %v", FieldErrorChain, ev)
+	}
+}
@@ -0,0 +1,198 @@
+package slogging
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/rs/zerolog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Syslog facilities for Options.SyslogFacility (RFC 5424 section 6.2.1).
+const (
+	FacilityUser   = 1
+	FacilityDaemon = 3
+	FacilityLocal0 = 16
+	FacilityLocal7 = 23
+)
+
+// redialInterval is the least time between two connection attempts of a
+// network sink, so a down collector costs one dial per second rather than
+// one per event.
+const redialInterval = time.Second
+
+// netConn is a network sink's connection, dialed on first use and redialed
+// after a failure. Callers serialize access.
+type netConn struct {
+	network, addr string
+	conn          net.Conn
+	lastDial      time.Time
+}
+
+// get returns the connection, dialing it if needed.
+func (c *netConn) get() (net.Conn, error) {
+	if c.conn != nil {
+		return c.conn, nil
+	}
+	if time.Since(c.lastDial) < redialInterval {
+		return nil, fmt.Errorf("slogging: %s %s: not connected", c.network, c.addr)
+	}
+	c.lastDial = time.Now()
+	conn, err := net.DialTimeout(c.network, c.addr, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	c.conn = conn
+	return conn, nil
+}
+
+// drop closes the connection so the next get redials.
+func (c *netConn) drop() error {
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// datagram reports whether the network preserves message boundaries.
+func (c *netConn) datagram() bool {
+	switch c.network {
+	case "udp", "udp4", "udp6", "unixgram":
+		return true
+	}
+	return false
+}
+
+// syslogSeverity maps zerolog levels to RFC 5424 severities.
+func syslogSeverity(level zerolog.Level) int {
+	switch level {
+	case zerolog.PanicLevel:
+		return 1 // alert
+	case zerolog.FatalLevel:
+		return 2 // critical
+	case zerolog.ErrorLevel:
+		return 3
+	case zerolog.WarnLevel:
+		return 4
+	case zerolog.InfoLevel:
+		return 6
+	case zerolog.DebugLevel, zerolog.TraceLevel:
+		return 7
+	}
+	return 5 // notice: events without a level
+}
+
+// syslogWriter sends each event as an RFC 5424 message whose MSG is the JSON
+// line. Stream transports use octet-counting framing (RFC 6587), so events
+// may contain newlines. A failed write drops the connection; the next event
+// redials.
+type syslogWriter struct {
+	facility int
+	header   string // " HOSTNAME APP-NAME PROCID MSGID SD " after the timestamp
+
+	mu     sync.Mutex
+	nc     netConn
+	closed bool
+}
+
+// newSyslogWriter prepares a writer for addr; network defaults to "udp", or
+// "unixgram" for a socket path such as /dev/log. It does not dial until the
+// first event.
+func newSyslogWriter(network, addr string, facility int, appName string) *syslogWriter {
+	if network == "" {
+		network = "udp"
+		if strings.HasPrefix(addr, "/") {
+			network = "unixgram"
+		}
+	}
+	if facility <= 0 || facility > 23 { // 0 is kern, not for applications
+		facility = FacilityUser
+	}
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "-"
+	}
+	return &syslogWriter{
+		nc:       netConn{network: network, addr: addr},
+		facility: facility,
+		header:   " " + syslogName(host, 255) + " " + syslogName(appName, 48) + " " + strconv.Itoa(os.Getpid()) + " - - ",
+	}
+}
+
+// syslogName makes s a valid header field: printable ASCII without spaces,
+// at most n bytes, "-" when empty.
+func syslogName(s string, n int) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c <= ' ' || c > '~' {
+			b[i] = '_'
+		}
+	}
+	if len(b) > n {
+		b = b[:n]
+	}
+	if len(b) == 0 {
+		return "-"
+	}
+	return string(b)
+}
+
+func (s *syslogWriter) Write(p []byte) (int, error) {
+	return s.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (s *syslogWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	msg := s.format(level, bytes.TrimRight(p, "\n"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0, net.ErrClosed
+	}
+	if err := s.send(msg); err != nil {
+		// One retry on a fresh connection covers a collector restart.
+		s.nc.drop()
+		if err := s.send(msg); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (s *syslogWriter) format(level zerolog.Level, p []byte) []byte {
+	var b bytes.Buffer
+	b.Grow(len(p) + 96)
+	fmt.Fprintf(&b, "<%d>1 ", s.facility*8+syslogSeverity(level))
+	b.WriteString(time.Now().UTC().Format("2006-01-02T15:04:05.000000Z07:00"))
+	b.WriteString(s.header)
+	b.Write(p)
+	return b.Bytes()
+}
+
+// send writes msg, dialing first if needed. Callers hold s.mu.
+func (s *syslogWriter) send(msg []byte) error {
+	conn, err := s.nc.get()
+	if err != nil {
+		return err
+	}
+	if !s.nc.datagram() {
+		if _, err := conn.Write([]byte(strconv.Itoa(len(msg)) + " ")); err != nil {
+			return err
+		}
+	}
+	_, err = conn.Write(msg)
+	return err
+}
+
+func (s *syslogWriter) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return s.nc.drop()
+}
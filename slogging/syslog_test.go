@@ -0,0 +1,91 @@
+package slogging
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/rs/zerolog"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogSink(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	initCapture(t, Options{Service: "billing", SyslogAddr: pc.LocalAddr().String(), SyslogFacility: FacilityLocal0})
+	ctx := context.Background()
+	From(ctx).Info().Msg("paid")
+	From(ctx).Error().Msg("refund failed")
+
+	got := map[string]string{}
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for buf := make([]byte, 64<<10); len(got) < 2; {
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("got %q before %v", got, err)
+		}
+		msg := string(buf[:n])
+		for _, m := range []string{"paid", "refund failed"} {
+			if strings.Contains(msg, `"message":"`+m+`"`) {
+				got[m] = msg
+			}
+		}
+	}
+	// local0 is facility 16: info is <134>, error <131>.
+	header := fmt.Sprintf(" billing %d - - {", os.Getpid())
+	for m, pri := range map[string]string{"paid": "<134>1 ", "refund failed": "<131>1 "} {
+		if !strings.HasPrefix(got[m], pri) || !strings.Contains(got[m], header) {
+			t.Errorf("%q = %q, want %q and %q", m, got[m], pri, header)
+		}
+	}
+}
+
+func TestSyslogStreamFraming(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	w := newSyslogWriter("tcp", ln.Addr().String(), 0, "my app")
+	defer w.Close()
+
+	events := []string{`{"message":"one"}`, "{\"stack\":\"a\nb\"}"}
+	for _, ev := range events {
+		if _, err := w.WriteLevel(zerolog.WarnLevel, []byte(ev+"\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(conn)
+	for _, ev := range events {
+		size, err := r.ReadString(' ')
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(size))
+		if err != nil {
+			t.Fatalf("frame length %q: %v", size, err)
+		}
+		msg := make([]byte, n)
+		if _, err := io.ReadFull(r, msg); err != nil {
+			t.Fatal(err)
+		}
+		// user is facility 1, warn severity 4; the app name loses its space.
+		if !strings.HasPrefix(string(msg), "<12>1 ") || !strings.Contains(string(msg), " my_app ") || !strings.HasSuffix(string(msg), " "+ev) {
+			t.Errorf("frame = %q, want the warn event %q", msg, ev)
+		}
+	}
+}
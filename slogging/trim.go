@@ -0,0 +1,175 @@
+package slogging
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/rs/zerolog"
+	"io"
+	"net/netip"
+	"strings"
+)
+
+// DefaultVerboseFields are trimmed by a TrimRule that lists no Fields.
+var DefaultVerboseFields = []string{"request_body", "response_body", "body", "user_agent", "headers"}
+
+// TrimRule drops verbose fields from events whose client IP is in one of CIDRs,
+// so health checkers and mesh probes do not pay for bodies and user agents
+// that are kept for public traffic:
+//
+//	{CIDRs: []string{"10.0.0.0/8", "fd00::/8"}}                    // DefaultVerboseFields
+//	{CIDRs: []string{"10.1.2.0/24"}, Fields: []string{"headers"}}  // just headers
+//
+// The IP is read from the event's IPField (default "ip_address", as set by
+// WithIPAddress). A bare address is treated as a single-host prefix. All
+// matching rules apply. JSON output only.
+type TrimRule struct {
+	CIDRs   []string
+	Fields  []string // top-level fields to drop; empty = DefaultVerboseFields
+	IPField string   // default "ip_address"
+}
+
+type trimRule struct {
+	nets    []netip.Prefix
+	fields  [][]byte // quoted keys
+	ipField []byte   // quoted key
+}
+
+// trimWriter applies TrimRules to each event before passing it on.
+type trimWriter struct {
+	w     io.Writer
+	rules []trimRule
+}
+
+// newTrimWriter compiles rules. Invalid CIDRs are skipped and reported, so a
+// typo in config loses one network rather than all logging.
+func newTrimWriter(w io.Writer, rules []TrimRule) (*trimWriter, []error) {
+	t := &trimWriter{w: w}
+	var errs []error
+	for _, r := range rules {
+		var tr trimRule
+		for _, c := range r.CIDRs {
+			pfx, err := parsePrefix(c)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("slogging: TrimRule CIDR %q: %w", c, err))
+				continue
+			}
+			tr.nets = append(tr.nets, pfx)
+		}
+		if len(tr.nets) == 0 {
+			continue
+		}
+		fields := r.Fields
+		if len(fields) == 0 {
+			fields = DefaultVerboseFields
+		}
+		for _, f := range fields {
+			tr.fields = append(tr.fields, []byte(`"`+f+`"`))
+		}
+		tr.ipField = []byte(`"` + firstNonEmpty(r.IPField, FieldIPAddress) + `"`)
+		t.rules = append(t.rules, tr)
+	}
+	return t, errs
+}
+
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		return p.Masked(), err
+	}
+	a, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(a, a.BitLen()), nil
+}
+
+func (t *trimWriter) Write(p []byte) (int, error) {
+	return t.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (t *trimWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	drop := t.dropSet(p)
+	if drop == nil {
+		return writeLevel(t.w, level, p)
+	}
+	members, tail, _ := scanMembers(p)
+	buf := lineBufs.Get().(*bytes.Buffer)
+	defer lineBufs.Put(buf)
+	buf.Reset()
+	buf.WriteByte('{')
+	first := true
+	for _, m := range members {
+		if containsKey(drop, p[m.start:m.keyEnd]) {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		buf.Write(p[m.start:m.end])
+	}
+	buf.WriteByte('}')
+	buf.Write(p[tail:])
+	if _, err := writeLevel(t.w, level, buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// dropSet returns the keys to remove from p, or nil when no rule matches.
+func (t *trimWriter) dropSet(p []byte) [][]byte {
+	var members []span
+	scanned := false
+	var drop [][]byte
+	for _, r := range t.rules {
+		if !bytes.Contains(p, r.ipField) {
+			continue
+		}
+		if !scanned {
+			var ok bool
+			if members, _, ok = scanMembers(p); !ok {
+				return nil
+			}
+			scanned = true
+		}
+		addr, ok := memberAddr(p, members, r.ipField)
+		if !ok {
+			continue
+		}
+		for _, n := range r.nets {
+			if n.Contains(addr) {
+				drop = append(drop, r.fields...)
+				break
+			}
+		}
+	}
+	return drop
+}
+
+// memberAddr parses the string value of key as an IP address.
+func memberAddr(p []byte, members []span, key []byte) (netip.Addr, bool) {
+	for _, m := range members {
+		if !bytes.Equal(p[m.start:m.keyEnd], key) {
+			continue
+		}
+		v := bytes.TrimLeft(p[m.keyEnd:m.end], " :")
+		if len(v) < 2 || v[0] != '"' || v[len(v)-1] != '"' {
+			return netip.Addr{}, false
+		}
+		a, err := netip.ParseAddr(string(v[1 : len(v)-1]))
+		if err != nil {
+			return netip.Addr{}, false
+		}
+		return a.Unmap(), true
+	}
+	return netip.Addr{}, false
+}
+
+func containsKey(keys [][]byte, k []byte) bool {
+	for _, x := range keys {
+		if bytes.Equal(x, k) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package slogging
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTrimRules(t *testing.T) {
+	c := initCapture(t, Options{TrimRules: []TrimRule{
+		{CIDRs: []string{"10.0.0.0/8", "not-a-cidr"}},
+		{CIDRs: []string{"192.168.1.7"}, Fields: []string{"headers"}},
+	}})
+	for _, tc := range []struct {
+		ip        string
+		kept, cut []string
+	}{
+		{"10.1.2.3", nil, []string{"body", "user_agent", "headers"}},
+		{"::ffff:10.1.2.3", nil, []string{"body", "user_agent", "headers"}},
+		{"192.168.1.7", []string{"body", "user_agent"}, []string{"headers"}},
+		{"192.168.1.8", []string{"body", "user_agent", "headers"}, nil},
+		{"203.0.113.9", []string{"body", "user_agent", "headers"}, nil},
+	} {
+		ctx := WithIPAddress(context.Background(), tc.ip)
+		From(ctx).Info().Str("body", "{}").Str("user_agent", "probe/1").Str("headers", "h").Msg(tc.ip)
+		evs := c.withMessage(t, tc.ip)
+		if len(evs) != 1 {
+			t.Fatalf("%s: got %d events", tc.ip, len(evs))
+		}
+		for _, k := range tc.kept {
+			if _, ok := evs[0][k]; !ok {
+				t.Errorf("%s: %s dropped", tc.ip, k)
+			}
+		}
+		for _, k := range tc.cut {
+			if _, ok := evs[0][k]; ok {
+				t.Errorf("%s: %s kept", tc.ip, k)
+			}
+		}
+	}
+
+	var warned bool
+	for _, ev := range c.events(t) {
+		warned = warned || ev[FieldSloggingEvent] == "invalid_option"
+	}
+	if !warned {
+		t.Error("invalid CIDR not reported")
+	}
+}
@@ -0,0 +1,214 @@
+package slogging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxWorkerLine bounds one event from a worker; a longer line closes the
+// connection, and the worker reconnects.
+const maxWorkerLine = 1 << 20
+
+// workerHello is the first line a worker sends on each connection.
+type workerHello struct {
+	Worker string `json:"worker"`
+	PID    int    `json:"pid"`
+}
+
+// WorkerEnv returns the environment a parent adds to a child's, so the
+// child's OptionsFromEnv(prefix) logs to the parent's WorkerSocket as name:
+//
+//	cmd := exec.Command(os.Args[0], "work")
+//	cmd.Env = append(os.Environ(), slogging.WorkerEnv("", "/run/billing/log.sock", "worker-1")...)
+func WorkerEnv(prefix, socket, name string) []string {
+	if prefix == "" {
+		prefix = "LOG"
+	}
+	prefix = strings.TrimSuffix(prefix, "_") + "_"
+	return []string{prefix + "PARENT_SOCKET=" + socket, prefix + "WORKER_NAME=" + name}
+}
+
+// workerSink sends a worker's events to its parent, one line each. Until the
+// parent is reachable, and between redials, they go to stderr.
+type workerSink struct {
+	hello    []byte
+	fallback io.Writer
+
+	mu     sync.Mutex
+	nc     netConn
+	closed bool
+}
+
+func newWorkerSink(path, name string) *workerSink {
+	pid := os.Getpid()
+	if name == "" {
+		name = "pid-" + strconv.Itoa(pid)
+	}
+	hello, _ := json.Marshal(workerHello{Worker: name, PID: pid})
+	return &workerSink{
+		hello:    append(hello, '\n'),
+		fallback: os.Stderr,
+		nc:       netConn{network: "unix", addr: path},
+	}
+}
+
+func (s *workerSink) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0, net.ErrClosed
+	}
+	if err := s.send(p); err != nil {
+		// One retry on a fresh connection covers a parent restart.
+		s.nc.drop()
+		if err := s.send(p); err != nil {
+			s.nc.drop()
+			return s.fallback.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// send writes p, dialing and greeting first if needed. Callers hold s.mu.
+func (s *workerSink) send(p []byte) error {
+	fresh := s.nc.conn == nil
+	conn, err := s.nc.get()
+	if err != nil {
+		return err
+	}
+	if fresh {
+		if _, err := conn.Write(s.hello); err != nil {
+			return err
+		}
+	}
+	_, err = conn.Write(p)
+	return err
+}
+
+func (s *workerSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return s.nc.drop()
+}
+
+// workerServer accepts worker connections and writes their events to out.
+type workerServer struct {
+	path string
+	ln   net.Listener
+	out  io.Writer
+	log  *zerolog.Logger
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+	wg    sync.WaitGroup
+}
+
+// listenWorkers listens on path, replacing a socket left by a previous run.
+func listenWorkers(path string, out io.Writer, log *zerolog.Logger) (*workerServer, error) {
+	if fi, err := os.Lstat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("slogging: WorkerSocket: %w", err)
+	}
+	s := &workerServer{path: path, ln: ln, out: out, log: log, conns: make(map[net.Conn]struct{})}
+	s.wg.Add(1)
+	go s.accept()
+	return s, nil
+}
+
+func (s *workerServer) accept() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return // closed
+		}
+		s.mu.Lock()
+		if s.conns == nil {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go s.serve(conn)
+	}
+}
+
+// serve reads one worker's hello and then its events, each written whole.
+func (s *workerServer) serve(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+	sc := bufio.NewScanner(conn)
+	sc.Buffer(make([]byte, 0, 64<<10), maxWorkerLine)
+	if !sc.Scan() {
+		return
+	}
+	var hello workerHello
+	if err := json.Unmarshal(sc.Bytes(), &hello); err != nil || hello.Worker == "" {
+		selfEventOn(s.log, zerolog.WarnLevel, "worker_rejected").
+			Msg("worker connection without a valid hello; closing it")
+		return
+	}
+	tags, _ := json.Marshal(map[string]any{FieldWorker: hello.Worker, FieldWorkerPID: hello.PID})
+	tags = tags[:len(tags)-1] // {"worker":...,"worker_pid":N
+	var line []byte
+	for sc.Scan() {
+		ev := bytes.TrimSpace(sc.Bytes())
+		if len(ev) < 2 || ev[0] != '{' {
+			continue
+		}
+		line = append(line[:0], tags...)
+		if ev[1] != '}' {
+			line = append(line, ',')
+		}
+		line = append(line, ev[1:]...)
+		line = append(line, '\n')
+		level := zerolog.NoLevel
+		if v, ok := jsonField(ev, zerolog.LevelFieldName); ok {
+			if l, err := zerolog.ParseLevel(v); err == nil {
+				level = l
+			}
+		}
+		writeLevel(s.out, level, line)
+	}
+	if err := sc.Err(); err != nil && !errors.Is(err, net.ErrClosed) {
+		selfEventOn(s.log, zerolog.WarnLevel, "worker_stream_error").
+			Str(FieldWorker, hello.Worker).Int(FieldWorkerPID, hello.PID).Err(err).
+			Msg("dropping worker connection")
+	}
+}
+
+// Close stops accepting, disconnects the workers (which fall back to stderr
+// until a parent listens again) and removes the socket.
+func (s *workerServer) Close() error {
+	err := s.ln.Close()
+	s.mu.Lock()
+	for c := range s.conns {
+		c.Close()
+	}
+	s.conns = nil
+	s.mu.Unlock()
+	s.wg.Wait()
+	os.Remove(s.path)
+	return err
+}
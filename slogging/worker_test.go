@@ -0,0 +1,87 @@
+package slogging
+
+import (
+	"bytes"
+	"context"
+	"github.com/rs/zerolog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// socketPath returns a socket path short enough for sun_path.
+func socketPath(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "slog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "log.sock")
+}
+
+func TestWorkerSocket(t *testing.T) {
+	path := socketPath(t)
+	c := initCapture(t, Options{Service: "parent", WorkerSocket: path})
+
+	// Two workers log concurrently; every event arrives whole and tagged.
+	var wg sync.WaitGroup
+	for _, name := range []string{"worker-1", "worker-2"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ws := newWorkerSink(path, name)
+			defer ws.Close()
+			l := zerolog.New(ws)
+			for i := range 50 {
+				l.Warn().Str("pad", strings.Repeat("x", 4096)).Int("n", i).Msg(name)
+			}
+			l.Log().Msg("no level")
+		}()
+	}
+	wg.Wait()
+	eventually(t, "all worker events", func() bool {
+		return len(c.withMessage(t, "worker-1"))+len(c.withMessage(t, "worker-2")) == 100
+	})
+	for _, name := range []string{"worker-1", "worker-2"} {
+		for i, ev := range c.withMessage(t, name) {
+			if ev[FieldWorker] != name || ev[FieldWorkerPID] != float64(os.Getpid()) || ev["n"] != float64(i) || ev["level"] != "warn" {
+				t.Fatalf("event %d from %s = %.200v", i, name, ev)
+			}
+		}
+	}
+	eventually(t, "the events without a level", func() bool { return len(c.withMessage(t, "no level")) == 2 })
+
+	// A connection that does not introduce itself is dropped.
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Write([]byte(`{"level":"info","message":"who am I"}` + "\n"))
+	eventually(t, "worker_rejected", func() bool { return len(c.selfEvents(t, "worker_rejected")) == 1 })
+	conn.Close()
+
+	// Close removes the socket.
+	Close(context.Background())
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("socket still there after Close: %v", err)
+	}
+}
+
+func TestWorkerSinkFallback(t *testing.T) {
+	ws := newWorkerSink(socketPath(t), "worker-1")
+	var fallback bytes.Buffer
+	ws.fallback = &fallback
+	defer ws.Close()
+
+	// No parent listens: the event goes to the fallback, not lost.
+	if _, err := ws.Write([]byte(`{"message":"orphan"}` + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := fallback.String(); got != `{"message":"orphan"}`+"\n" {
+		t.Errorf("fallback got %q", got)
+	}
+}